@@ -19,10 +19,35 @@ const (
 	ChatCompletionChoiceFinishReasonToolCalls     ChatCompletionChoiceFinishReason = "tool_calls"
 )
 
+// Defines values for ChatCompletionChunkChoiceFinishReason.
+const (
+	ChatCompletionChunkChoiceFinishReasonContentFilter ChatCompletionChunkChoiceFinishReason = "content_filter"
+	ChatCompletionChunkChoiceFinishReasonFunctionCall  ChatCompletionChunkChoiceFinishReason = "function_call"
+	ChatCompletionChunkChoiceFinishReasonLength        ChatCompletionChunkChoiceFinishReason = "length"
+	ChatCompletionChunkChoiceFinishReasonStop          ChatCompletionChunkChoiceFinishReason = "stop"
+	ChatCompletionChunkChoiceFinishReasonToolCalls     ChatCompletionChunkChoiceFinishReason = "tool_calls"
+)
+
+// Defines values for ChatCompletionDeltaRole.
+const (
+	ChatCompletionDeltaRoleAssistant ChatCompletionDeltaRole = "assistant"
+	ChatCompletionDeltaRoleFunction  ChatCompletionDeltaRole = "function"
+	ChatCompletionDeltaRoleSystem    ChatCompletionDeltaRole = "system"
+	ChatCompletionDeltaRoleTool      ChatCompletionDeltaRole = "tool"
+	ChatCompletionDeltaRoleUser      ChatCompletionDeltaRole = "user"
+)
+
 // Defines values for ChatCompletionRequestFunctionCall0.
 const (
-	Auto ChatCompletionRequestFunctionCall0 = "auto"
-	None ChatCompletionRequestFunctionCall0 = "none"
+	ChatCompletionRequestFunctionCall0Auto ChatCompletionRequestFunctionCall0 = "auto"
+	ChatCompletionRequestFunctionCall0None ChatCompletionRequestFunctionCall0 = "none"
+)
+
+// Defines values for ChatCompletionRequestToolChoice0.
+const (
+	ChatCompletionRequestToolChoice0Auto     ChatCompletionRequestToolChoice0 = "auto"
+	ChatCompletionRequestToolChoice0None     ChatCompletionRequestToolChoice0 = "none"
+	ChatCompletionRequestToolChoice0Required ChatCompletionRequestToolChoice0 = "required"
 )
 
 // Defines values for ChatMessageRole.
@@ -34,10 +59,38 @@ const (
 	ChatMessageRoleUser      ChatMessageRole = "user"
 )
 
+// Defines values for EmbeddingsRequestEncodingFormat.
+const (
+	EmbeddingsRequestEncodingFormatFloat EmbeddingsRequestEncodingFormat = "float"
+)
+
+// Defines values for MessageContentPartImageUrlDetail.
+const (
+	MessageContentPartImageUrlDetailAuto MessageContentPartImageUrlDetail = "auto"
+	MessageContentPartImageUrlDetailHigh MessageContentPartImageUrlDetail = "high"
+	MessageContentPartImageUrlDetailLow  MessageContentPartImageUrlDetail = "low"
+)
+
 // Defines values for MessageContentPartType.
 const (
-	ImageUrl MessageContentPartType = "image_url"
-	Text     MessageContentPartType = "text"
+	MessageContentPartTypeImageUrl MessageContentPartType = "image_url"
+	MessageContentPartTypeText     MessageContentPartType = "text"
+)
+
+// Defines values for NamedToolChoiceType.
+const (
+	NamedToolChoiceTypeFunction NamedToolChoiceType = "function"
+)
+
+// Defines values for ResponseFormatType.
+const (
+	ResponseFormatTypeJsonObject ResponseFormatType = "json_object"
+	ResponseFormatTypeText       ResponseFormatType = "text"
+)
+
+// Defines values for ToolType.
+const (
+	ToolTypeFunction ToolType = "function"
 )
 
 // Defines values for ToolCallType.
@@ -55,6 +108,36 @@ type ChatCompletionChoice struct {
 // ChatCompletionChoiceFinishReason defines model for ChatCompletionChoice.FinishReason.
 type ChatCompletionChoiceFinishReason string
 
+// ChatCompletionChunk defines model for ChatCompletionChunk.
+type ChatCompletionChunk struct {
+	Choices []ChatCompletionChunkChoice `json:"choices"`
+	Created int                         `json:"created"`
+	Id      string                      `json:"id"`
+	Model   string                      `json:"model"`
+	Object  string                      `json:"object"`
+	Usage   *Usage                      `json:"usage,omitempty"`
+}
+
+// ChatCompletionChunkChoice defines model for ChatCompletionChunkChoice.
+type ChatCompletionChunkChoice struct {
+	Delta        ChatCompletionDelta                    `json:"delta"`
+	FinishReason *ChatCompletionChunkChoiceFinishReason `json:"finish_reason"`
+	Index        int                                    `json:"index"`
+}
+
+// ChatCompletionChunkChoiceFinishReason defines model for ChatCompletionChunkChoice.FinishReason.
+type ChatCompletionChunkChoiceFinishReason string
+
+// ChatCompletionDelta defines model for ChatCompletionDelta.
+type ChatCompletionDelta struct {
+	Content   *string                  `json:"content,omitempty"`
+	Role      *ChatCompletionDeltaRole `json:"role,omitempty"`
+	ToolCalls *[]ToolCall              `json:"tool_calls,omitempty"`
+}
+
+// ChatCompletionDeltaRole defines model for ChatCompletionDelta.Role.
+type ChatCompletionDeltaRole string
+
 // ChatCompletionRequest defines model for ChatCompletionRequest.
 type ChatCompletionRequest struct {
 	// FrequencyPenalty Penalize frequent tokens.
@@ -69,6 +152,9 @@ type ChatCompletionRequest struct {
 	// LogitBias Modify probability of specific tokens.
 	LogitBias *map[string]int `json:"logit_bias,omitempty"`
 
+	// Logprobs Whether to return the log probabilities of the output tokens.
+	Logprobs *bool `json:"logprobs,omitempty"`
+
 	// MaxTokens Maximum number of tokens to generate.
 	MaxTokens *int `json:"max_tokens,omitempty"`
 
@@ -82,7 +168,11 @@ type ChatCompletionRequest struct {
 	N *int `json:"n,omitempty"`
 
 	// PresencePenalty Penalize new topic tokens.
-	PresencePenalty *float32 `json:"presence_penalty,omitempty"`
+	PresencePenalty *float32        `json:"presence_penalty,omitempty"`
+	ResponseFormat  *ResponseFormat `json:"response_format,omitempty"`
+
+	// Seed Seed for deterministic sampling, on a best-effort basis.
+	Seed *int `json:"seed,omitempty"`
 
 	// Stop Sequences where the API will stop generating further tokens.
 	Stop *ChatCompletionRequest_Stop `json:"stop,omitempty"`
@@ -90,9 +180,21 @@ type ChatCompletionRequest struct {
 	// Stream Whether to stream back results.
 	Stream *bool `json:"stream,omitempty"`
 
+	// StreamOptions Options for streaming responses. Only used when stream is true.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
 	// Temperature Sampling temperature to use.
 	Temperature *float32 `json:"temperature,omitempty"`
 
+	// ToolChoice Controls which tool, if any, the model calls.
+	ToolChoice *ChatCompletionRequest_ToolChoice `json:"tool_choice,omitempty"`
+
+	// Tools Tools the model may call.
+	Tools *[]Tool `json:"tools,omitempty"`
+
+	// TopLogprobs Number of most likely tokens to return at each position, with their log probabilities. Requires logprobs.
+	TopLogprobs *int `json:"top_logprobs,omitempty"`
+
 	// TopP Nucleus sampling probability.
 	TopP *float32 `json:"top_p,omitempty"`
 
@@ -124,6 +226,14 @@ type ChatCompletionRequest_Stop struct {
 	union json.RawMessage
 }
 
+// ChatCompletionRequestToolChoice0 defines model for ChatCompletionRequest.ToolChoice.0.
+type ChatCompletionRequestToolChoice0 string
+
+// ChatCompletionRequest_ToolChoice Controls which tool, if any, the model calls.
+type ChatCompletionRequest_ToolChoice struct {
+	union json.RawMessage
+}
+
 // ChatCompletionResponse defines model for ChatCompletionResponse.
 type ChatCompletionResponse struct {
 	Choices []ChatCompletionChoice `json:"choices"`
@@ -131,7 +241,10 @@ type ChatCompletionResponse struct {
 	Id      string                 `json:"id"`
 	Model   string                 `json:"model"`
 	Object  string                 `json:"object"`
-	Usage   *Usage                 `json:"usage,omitempty"`
+
+	// SystemFingerprint Backend configuration of the upstream that served the completion, when it reports one.
+	SystemFingerprint *string `json:"system_fingerprint,omitempty"`
+	Usage             *Usage  `json:"usage,omitempty"`
 }
 
 // ChatMessage defines model for ChatMessage.
@@ -140,8 +253,11 @@ type ChatMessage struct {
 	FunctionCall *FunctionCall        `json:"function_call,omitempty"`
 
 	// Name Optional name for function/tool messages.
-	Name *string         `json:"name,omitempty"`
-	Role ChatMessageRole `json:"role"`
+	Name *string `json:"name,omitempty"`
+
+	// ReasoningContent Reasoning of the model preceding the answer, in non-streamed responses.
+	ReasoningContent *string         `json:"reasoning_content,omitempty"`
+	Role             ChatMessageRole `json:"role"`
 
 	// ToolCallId Tool call this message responds to (if role=tool).
 	ToolCallId *string     `json:"tool_call_id,omitempty"`
@@ -162,13 +278,66 @@ type ChatMessage_Content struct {
 // ChatMessageRole defines model for ChatMessage.Role.
 type ChatMessageRole string
 
+// Embedding defines model for Embedding.
+type Embedding struct {
+	Embedding []float32 `json:"embedding"`
+	Index     int       `json:"index"`
+	Object    string    `json:"object"`
+}
+
+// EmbeddingsRequest defines model for EmbeddingsRequest.
+type EmbeddingsRequest struct {
+	// EncodingFormat The format to return the embeddings in.
+	EncodingFormat *EmbeddingsRequestEncodingFormat `json:"encoding_format,omitempty"`
+
+	// Input Text or array of texts to embed.
+	Input EmbeddingsRequest_Input `json:"input"`
+
+	// Model ID of the model to use.
+	Model string `json:"model"`
+
+	// User A unique identifier representing your end-user.
+	User *string `json:"user,omitempty"`
+}
+
+// EmbeddingsRequestEncodingFormat The format to return the embeddings in.
+type EmbeddingsRequestEncodingFormat string
+
+// EmbeddingsRequestInput0 defines model for .
+type EmbeddingsRequestInput0 = string
+
+// EmbeddingsRequestInput1 defines model for .
+type EmbeddingsRequestInput1 = []string
+
+// EmbeddingsRequest_Input Text or array of texts to embed.
+type EmbeddingsRequest_Input struct {
+	union json.RawMessage
+}
+
+// EmbeddingsResponse defines model for EmbeddingsResponse.
+type EmbeddingsResponse struct {
+	Data   []Embedding     `json:"data"`
+	Model  string          `json:"model"`
+	Object string          `json:"object"`
+	Usage  EmbeddingsUsage `json:"usage"`
+}
+
+// EmbeddingsUsage defines model for EmbeddingsUsage.
+type EmbeddingsUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
 // ErrorResponse defines model for ErrorResponse.
 type ErrorResponse struct {
 	Error struct {
-		Code    *string `json:"code"`
-		Message string  `json:"message"`
-		Param   *string `json:"param"`
-		Type    string  `json:"type"`
+		Code *string `json:"code"`
+
+		// Details The errors of the providers tried, in order. Only sent when the gateway exposes error details.
+		Details *[]ProviderError `json:"details,omitempty"`
+		Message string           `json:"message"`
+		Param   *string          `json:"param"`
+		Type    string           `json:"type"`
 	} `json:"error"`
 }
 
@@ -191,15 +360,122 @@ type FunctionDefinition struct {
 // MessageContentPart defines model for MessageContentPart.
 type MessageContentPart struct {
 	ImageUrl *struct {
+		Detail *MessageContentPartImageUrlDetail `json:"detail,omitempty"`
+
+		// Url Image URL or base64 data URL (data:image/png;base64,...)
 		Url string `json:"url"`
 	} `json:"image_url,omitempty"`
 	Text *string                `json:"text,omitempty"`
 	Type MessageContentPartType `json:"type"`
 }
 
+// MessageContentPartImageUrlDetail defines model for MessageContentPart.ImageUrl.Detail.
+type MessageContentPartImageUrlDetail string
+
 // MessageContentPartType defines model for MessageContentPart.Type.
 type MessageContentPartType string
 
+// Model defines model for Model.
+type Model struct {
+	// Id The configured model ID.
+	Id     string `json:"id"`
+	Object string `json:"object"`
+
+	// OwnedBy The provider serving the model.
+	OwnedBy string `json:"owned_by"`
+}
+
+// ModelList defines model for ModelList.
+type ModelList struct {
+	Data   []Model `json:"data"`
+	Object string  `json:"object"`
+}
+
+// ModerationRequest defines model for ModerationRequest.
+type ModerationRequest struct {
+	// Input Text or array of texts to classify.
+	Input ModerationRequest_Input `json:"input"`
+
+	// Model ID of the model to use.
+	Model string `json:"model"`
+}
+
+// ModerationRequestInput0 defines model for .
+type ModerationRequestInput0 = string
+
+// ModerationRequestInput1 defines model for .
+type ModerationRequestInput1 = []string
+
+// ModerationRequest_Input Text or array of texts to classify.
+type ModerationRequest_Input struct {
+	union json.RawMessage
+}
+
+// ModerationResponse defines model for ModerationResponse.
+type ModerationResponse struct {
+	Id      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult defines model for ModerationResult.
+type ModerationResult struct {
+	// Categories Whether each category is flagged.
+	Categories map[string]bool `json:"categories"`
+
+	// CategoryScores The score of each category.
+	CategoryScores map[string]float32 `json:"category_scores"`
+
+	// Flagged Whether any of the categories is flagged.
+	Flagged bool `json:"flagged"`
+}
+
+// NamedToolChoice defines model for NamedToolChoice.
+type NamedToolChoice struct {
+	Function struct {
+		Name string `json:"name"`
+	} `json:"function"`
+	Type NamedToolChoiceType `json:"type"`
+}
+
+// NamedToolChoiceType defines model for NamedToolChoice.Type.
+type NamedToolChoiceType string
+
+// ProviderError defines model for ProviderError.
+type ProviderError struct {
+	Message string `json:"message"`
+
+	// Model The upstream model name.
+	Model string `json:"model"`
+
+	// Provider The provider ID.
+	Provider string `json:"provider"`
+}
+
+// ResponseFormat defines model for ResponseFormat.
+type ResponseFormat struct {
+	// Type Set to json_object to make the model produce valid JSON.
+	Type ResponseFormatType `json:"type"`
+}
+
+// ResponseFormatType Set to json_object to make the model produce valid JSON.
+type ResponseFormatType string
+
+// StreamOptions Options for streaming responses. Only used when stream is true.
+type StreamOptions struct {
+	// IncludeUsage Send an additional chunk before the [DONE] message with an empty choices array and the token usage of the whole request.
+	IncludeUsage *bool `json:"include_usage,omitempty"`
+}
+
+// Tool defines model for Tool.
+type Tool struct {
+	Function FunctionDefinition `json:"function"`
+	Type     ToolType           `json:"type"`
+}
+
+// ToolType defines model for Tool.Type.
+type ToolType string
+
 // ToolCall defines model for ToolCall.
 type ToolCall struct {
 	Function FunctionCall `json:"function"`
@@ -220,6 +496,12 @@ type Usage struct {
 // CreateChatCompletionJSONRequestBody defines body for CreateChatCompletion for application/json ContentType.
 type CreateChatCompletionJSONRequestBody = ChatCompletionRequest
 
+// CreateEmbeddingsJSONRequestBody defines body for CreateEmbeddings for application/json ContentType.
+type CreateEmbeddingsJSONRequestBody = EmbeddingsRequest
+
+// CreateModerationJSONRequestBody defines body for CreateModeration for application/json ContentType.
+type CreateModerationJSONRequestBody = ModerationRequest
+
 // AsChatCompletionRequestFunctionCall0 returns the union data inside the ChatCompletionRequest_FunctionCall as a ChatCompletionRequestFunctionCall0
 func (t ChatCompletionRequest_FunctionCall) AsChatCompletionRequestFunctionCall0() (ChatCompletionRequestFunctionCall0, error) {
 	var body ChatCompletionRequestFunctionCall0
@@ -344,6 +626,68 @@ func (t *ChatCompletionRequest_Stop) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// AsChatCompletionRequestToolChoice0 returns the union data inside the ChatCompletionRequest_ToolChoice as a ChatCompletionRequestToolChoice0
+func (t ChatCompletionRequest_ToolChoice) AsChatCompletionRequestToolChoice0() (ChatCompletionRequestToolChoice0, error) {
+	var body ChatCompletionRequestToolChoice0
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromChatCompletionRequestToolChoice0 overwrites any union data inside the ChatCompletionRequest_ToolChoice as the provided ChatCompletionRequestToolChoice0
+func (t *ChatCompletionRequest_ToolChoice) FromChatCompletionRequestToolChoice0(v ChatCompletionRequestToolChoice0) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeChatCompletionRequestToolChoice0 performs a merge with any union data inside the ChatCompletionRequest_ToolChoice, using the provided ChatCompletionRequestToolChoice0
+func (t *ChatCompletionRequest_ToolChoice) MergeChatCompletionRequestToolChoice0(v ChatCompletionRequestToolChoice0) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsNamedToolChoice returns the union data inside the ChatCompletionRequest_ToolChoice as a NamedToolChoice
+func (t ChatCompletionRequest_ToolChoice) AsNamedToolChoice() (NamedToolChoice, error) {
+	var body NamedToolChoice
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromNamedToolChoice overwrites any union data inside the ChatCompletionRequest_ToolChoice as the provided NamedToolChoice
+func (t *ChatCompletionRequest_ToolChoice) FromNamedToolChoice(v NamedToolChoice) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeNamedToolChoice performs a merge with any union data inside the ChatCompletionRequest_ToolChoice, using the provided NamedToolChoice
+func (t *ChatCompletionRequest_ToolChoice) MergeNamedToolChoice(v NamedToolChoice) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t ChatCompletionRequest_ToolChoice) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *ChatCompletionRequest_ToolChoice) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
 // AsChatMessageContent0 returns the union data inside the ChatMessage_Content as a ChatMessageContent0
 func (t ChatMessage_Content) AsChatMessageContent0() (ChatMessageContent0, error) {
 	var body ChatMessageContent0
@@ -406,11 +750,144 @@ func (t *ChatMessage_Content) UnmarshalJSON(b []byte) error {
 	return err
 }
 
+// AsEmbeddingsRequestInput0 returns the union data inside the EmbeddingsRequest_Input as a EmbeddingsRequestInput0
+func (t EmbeddingsRequest_Input) AsEmbeddingsRequestInput0() (EmbeddingsRequestInput0, error) {
+	var body EmbeddingsRequestInput0
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromEmbeddingsRequestInput0 overwrites any union data inside the EmbeddingsRequest_Input as the provided EmbeddingsRequestInput0
+func (t *EmbeddingsRequest_Input) FromEmbeddingsRequestInput0(v EmbeddingsRequestInput0) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeEmbeddingsRequestInput0 performs a merge with any union data inside the EmbeddingsRequest_Input, using the provided EmbeddingsRequestInput0
+func (t *EmbeddingsRequest_Input) MergeEmbeddingsRequestInput0(v EmbeddingsRequestInput0) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsEmbeddingsRequestInput1 returns the union data inside the EmbeddingsRequest_Input as a EmbeddingsRequestInput1
+func (t EmbeddingsRequest_Input) AsEmbeddingsRequestInput1() (EmbeddingsRequestInput1, error) {
+	var body EmbeddingsRequestInput1
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromEmbeddingsRequestInput1 overwrites any union data inside the EmbeddingsRequest_Input as the provided EmbeddingsRequestInput1
+func (t *EmbeddingsRequest_Input) FromEmbeddingsRequestInput1(v EmbeddingsRequestInput1) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeEmbeddingsRequestInput1 performs a merge with any union data inside the EmbeddingsRequest_Input, using the provided EmbeddingsRequestInput1
+func (t *EmbeddingsRequest_Input) MergeEmbeddingsRequestInput1(v EmbeddingsRequestInput1) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t EmbeddingsRequest_Input) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *EmbeddingsRequest_Input) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
+// AsModerationRequestInput0 returns the union data inside the ModerationRequest_Input as a ModerationRequestInput0
+func (t ModerationRequest_Input) AsModerationRequestInput0() (ModerationRequestInput0, error) {
+	var body ModerationRequestInput0
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromModerationRequestInput0 overwrites any union data inside the ModerationRequest_Input as the provided ModerationRequestInput0
+func (t *ModerationRequest_Input) FromModerationRequestInput0(v ModerationRequestInput0) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeModerationRequestInput0 performs a merge with any union data inside the ModerationRequest_Input, using the provided ModerationRequestInput0
+func (t *ModerationRequest_Input) MergeModerationRequestInput0(v ModerationRequestInput0) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+// AsModerationRequestInput1 returns the union data inside the ModerationRequest_Input as a ModerationRequestInput1
+func (t ModerationRequest_Input) AsModerationRequestInput1() (ModerationRequestInput1, error) {
+	var body ModerationRequestInput1
+	err := json.Unmarshal(t.union, &body)
+	return body, err
+}
+
+// FromModerationRequestInput1 overwrites any union data inside the ModerationRequest_Input as the provided ModerationRequestInput1
+func (t *ModerationRequest_Input) FromModerationRequestInput1(v ModerationRequestInput1) error {
+	b, err := json.Marshal(v)
+	t.union = b
+	return err
+}
+
+// MergeModerationRequestInput1 performs a merge with any union data inside the ModerationRequest_Input, using the provided ModerationRequestInput1
+func (t *ModerationRequest_Input) MergeModerationRequestInput1(v ModerationRequestInput1) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	merged, err := runtime.JSONMerge(t.union, b)
+	t.union = merged
+	return err
+}
+
+func (t ModerationRequest_Input) MarshalJSON() ([]byte, error) {
+	b, err := t.union.MarshalJSON()
+	return b, err
+}
+
+func (t *ModerationRequest_Input) UnmarshalJSON(b []byte) error {
+	err := t.union.UnmarshalJSON(b)
+	return err
+}
+
 // ServerInterface represents all server handlers.
 type ServerInterface interface {
 	// Creates a model response for the given chat conversation.
 	// (POST /chat/completions)
 	CreateChatCompletion(c *gin.Context)
+	// Creates an embedding vector representing the input text.
+	// (POST /embeddings)
+	CreateEmbeddings(c *gin.Context)
+	// Lists the models configured in the gateway.
+	// (GET /models)
+	ListModels(c *gin.Context)
+	// Classifies whether the input text is potentially harmful.
+	// (POST /moderations)
+	CreateModeration(c *gin.Context)
 }
 
 // ServerInterfaceWrapper converts contexts to parameters.
@@ -435,6 +912,45 @@ func (siw *ServerInterfaceWrapper) CreateChatCompletion(c *gin.Context) {
 	siw.Handler.CreateChatCompletion(c)
 }
 
+// CreateEmbeddings operation middleware
+func (siw *ServerInterfaceWrapper) CreateEmbeddings(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.CreateEmbeddings(c)
+}
+
+// ListModels operation middleware
+func (siw *ServerInterfaceWrapper) ListModels(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.ListModels(c)
+}
+
+// CreateModeration operation middleware
+func (siw *ServerInterfaceWrapper) CreateModeration(c *gin.Context) {
+
+	for _, middleware := range siw.HandlerMiddlewares {
+		middleware(c)
+		if c.IsAborted() {
+			return
+		}
+	}
+
+	siw.Handler.CreateModeration(c)
+}
+
 // GinServerOptions provides options for the Gin server.
 type GinServerOptions struct {
 	BaseURL      string
@@ -463,4 +979,7 @@ func RegisterHandlersWithOptions(router gin.IRouter, si ServerInterface, options
 	}
 
 	router.POST(options.BaseURL+"/chat/completions", wrapper.CreateChatCompletion)
+	router.POST(options.BaseURL+"/embeddings", wrapper.CreateEmbeddings)
+	router.GET(options.BaseURL+"/models", wrapper.ListModels)
+	router.POST(options.BaseURL+"/moderations", wrapper.CreateModeration)
 }
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// defaultSocketMode is the file mode of the Unix socket when none is configured.
+const defaultSocketMode os.FileMode = 0o660
+
+// listen opens the listener of the server: the Unix socket when one is
+// configured, else Address or, when unset, Port on all interfaces.
+func listen(cfg config.ServerConfig) (net.Listener, error) {
+	if cfg.UnixSocket.Path != "" {
+		return listenUnix(cfg.UnixSocket)
+	}
+	addr := cfg.Address
+	if addr == "" {
+		addr = ":" + cfg.Port
+	}
+	return net.Listen("tcp", addr)
+}
+
+// listenUnix listens on the socket at cfg.Path, replacing a socket left behind
+// by a gateway that didn't shut down cleanly. The socket is removed when the
+// listener is closed.
+func listenUnix(cfg config.UnixSocketConfig) (net.Listener, error) {
+	mode := defaultSocketMode
+	if cfg.Mode != "" {
+		m, err := strconv.ParseUint(cfg.Mode, 8, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid unix socket mode %q: %w", cfg.Mode, err)
+		}
+		mode = os.FileMode(m)
+	}
+
+	if err := removeStaleSocket(cfg.Path); err != nil {
+		return nil, err
+	}
+	ln, err := net.Listen("unix", cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(cfg.Path, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("failed to chmod unix socket: %w", err)
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the socket at path unless a server still accepts
+// connections on it. Files other than sockets are left alone.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("%s exists and is not a unix socket", path)
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return fmt.Errorf("unix socket %s is in use", path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return fmt.Errorf("failed to check unix socket %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
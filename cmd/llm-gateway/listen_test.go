@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAddress(t *testing.T) {
+	ln, err := listen(config.ServerConfig{Port: "8080", Address: "127.0.0.1:0"})
+	require.NoError(t, err)
+	defer ln.Close()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	assert.True(t, addr.IP.IsLoopback())
+	assert.NotZero(t, addr.Port)
+}
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+
+	// A socket left behind by a gateway that didn't shut down cleanly.
+	stale, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	ln, err := listen(config.ServerConfig{Port: "8080", UnixSocket: config.UnixSocketConfig{Path: path, Mode: "0600"}})
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.ModeSocket|0o600, info.Mode()&(os.ModeSocket|os.ModePerm))
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	conn.Close()
+
+	t.Run("in use", func(t *testing.T) {
+		_, err := listen(config.ServerConfig{UnixSocket: config.UnixSocketConfig{Path: path}})
+		assert.ErrorContains(t, err, "is in use")
+	})
+
+	require.NoError(t, ln.Close())
+	_, err = os.Stat(path)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestListenUnixSocket_NotASocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.sock")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, err := listen(config.ServerConfig{UnixSocket: config.UnixSocketConfig{Path: path}})
+	assert.ErrorContains(t, err, "is not a unix socket")
+}
@@ -1,32 +1,84 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/dmitrii/llm-gateway/internal/config"
 	"github.com/dmitrii/llm-gateway/internal/log"
 	"github.com/dmitrii/llm-gateway/internal/server"
+	"github.com/dmitrii/llm-gateway/internal/tracing"
 )
 
+// shutdownTimeout bounds how long in-flight requests may take to finish on shutdown.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(validate(os.Args[2:], os.Stdout))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(schema(os.Args[2:], os.Stdout))
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("Failed to load configuration", "error", err)
-		return
+		os.Exit(1)
 	}
 
-	logger := log.New(cfg.Logging.Level)
+	logger := log.New(cfg.Logging)
 	slog.SetDefault(logger)
 
-	slog.Info("Starting LLM Gateway", "port", cfg.Server.Port)
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		slog.Error("Failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			slog.Error("Failed to shut down tracing", "error", err)
+		}
+	}()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	ln, err := listen(cfg.Server)
+	if err != nil {
+		slog.Error("Failed to listen", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("Starting LLM Gateway", "address", ln.Addr().String())
+
+	// Serve the probes while the providers are initialized.
+	handler := server.NewStartupHandler()
+	srv := &http.Server{Handler: handler}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("Failed to start server", "error", err)
+			stop()
+		}
+	}()
 
-	r, err := server.New(cfg, logger)
+	r, err := server.New(ctx, cfg, logger)
 	if err != nil {
 		slog.Error("Failed to init server", "error", err)
-		return
+		os.Exit(1)
 	}
+	handler.SetGateway(r)
 
-	if err := r.Run(":" + cfg.Server.Port); err != nil {
-		slog.Error("Failed to start server", "error", err)
+	<-ctx.Done()
+	slog.Info("Shutting down LLM Gateway")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("Failed to shut down server", "error", err)
 	}
 }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// schema writes the JSON schema of the config, generated from the config
+// structs, to the file at args[0] or to out. It returns the exit code.
+func schema(args []string, out io.Writer) int {
+	if len(args) > 1 {
+		fmt.Fprintln(out, "usage: llm-gateway schema [output-file]")
+		return 2
+	}
+
+	data, err := config.GenerateJSONSchema()
+	if err != nil {
+		fmt.Fprintf(out, "failed to generate the config schema: %v\n", err)
+		return 1
+	}
+	if len(args) == 1 {
+		err = os.WriteFile(args[0], data, 0o644)
+	} else {
+		_, err = out.Write(data)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "failed to write the config schema: %v\n", err)
+		return 1
+	}
+	return 0
+}
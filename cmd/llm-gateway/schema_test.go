@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchema(t *testing.T) {
+	var out strings.Builder
+	assert.Equal(t, 0, schema(nil, &out))
+	assert.Equal(t, string(config.JSONSchema), out.String())
+
+	path := filepath.Join(t.TempDir(), "config.schema.json")
+	out.Reset()
+	assert.Equal(t, 0, schema([]string{path}, &out))
+	assert.Empty(t, out.String())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, config.JSONSchema, data)
+
+	out.Reset()
+	assert.Equal(t, 2, schema([]string{path, path}, &out))
+	assert.Equal(t, "usage: llm-gateway schema [output-file]\n", out.String())
+}
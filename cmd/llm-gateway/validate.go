@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+)
+
+// validate checks the config files at args, CONFIG_PATH or config.yml, as the
+// gateway would load them on startup, merged in order: schema, provider
+// settings and the references between providers, models, fallbacks and
+// aliases, and then the transforms, content filter patterns and proxy URLs
+// the proxy would reject. It writes a report to out and returns the exit code,
+// 1 when the config is invalid.
+func validate(args []string, out io.Writer) int {
+	paths := config.Paths()
+	if len(args) > 0 {
+		paths = args
+	}
+	name := strings.Join(paths, ", ")
+
+	cfg, err := config.LoadFiles(paths...)
+	if err == nil {
+		err = proxy.ValidateConfig(cfg)
+	}
+	if err != nil {
+		fmt.Fprintf(out, "%s is invalid:\n", name)
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Fprintf(out, "  %s\n", line)
+		}
+		return 1
+	}
+	fmt.Fprintf(out, "%s is valid\n", name)
+	return 0
+}
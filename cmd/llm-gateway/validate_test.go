@@ -0,0 +1,106 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+	valid := write("valid.yml", `
+providers:
+  - id: dummy
+    provider: dummy
+    config: {}
+models:
+  - id: chat
+    name: chat
+    provider: dummy
+`)
+	invalid := write("invalid.yml", `
+providers:
+  - id: dummy
+    provider: dummy
+    config: {}
+models:
+  - id: chat
+    name: chat
+    provider: missing
+    fallback: [backup]
+`)
+	unbuildable := write("unbuildable.yml", `
+providers:
+  - id: dummy
+    provider: dummy
+    config: {}
+    transforms: [missing]
+  - id: hf
+    provider: huggingface
+    proxy_url: http://proxy:8080
+    config:
+      api_key: key
+models:
+  - id: chat
+    name: chat
+    provider: dummy
+`)
+
+	tests := []struct {
+		name     string
+		args     []string
+		code     int
+		expected []string
+	}{
+		{name: "valid", args: []string{valid}, code: 0, expected: []string{valid + " is valid"}},
+		{
+			name: "invalid references",
+			args: []string{invalid},
+			code: 1,
+			expected: []string{
+				invalid + " is invalid:",
+				`  router config validation error: model "chat" references unknown provider "missing"`,
+				`  model "chat" references unknown fallback model "backup"`,
+			},
+		},
+		{
+			name: "proxy settings",
+			args: []string{unbuildable},
+			code: 1,
+			expected: []string{
+				unbuildable + " is invalid:",
+				`  provider "dummy" references unknown transform "missing"`,
+				"  proxy_url is not supported by the huggingface provider hf, which uses an HTTP client of its own",
+			},
+		},
+		{name: "missing file", args: []string{filepath.Join(dir, "missing.yml")}, code: 1, expected: []string{filepath.Join(dir, "missing.yml") + " is invalid:"}},
+		{
+			name: "merged files",
+			args: []string{valid, invalid},
+			code: 1,
+			expected: []string{
+				valid + ", " + invalid + " is invalid:",
+				`  router config validation error: model "chat" references unknown provider "missing"`,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			assert.Equal(t, tt.code, validate(tt.args, &out))
+			lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			require.GreaterOrEqual(t, len(lines), len(tt.expected))
+			assert.Equal(t, tt.expected, lines[:len(tt.expected)])
+		})
+	}
+}
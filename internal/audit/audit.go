@@ -0,0 +1,105 @@
+// Package audit records the chat completions served by the gateway as JSON lines,
+// independently of the operational logs.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+)
+
+// Record is a line of the audit log.
+type Record struct {
+	Timestamp time.Time  `json:"timestamp"`
+	RequestID string     `json:"request_id,omitempty"`
+	Model     string     `json:"model"`
+	Provider  string     `json:"provider"`
+	Usage     *api.Usage `json:"usage,omitempty"`
+	// Messages and Choices are only recorded with include_content.
+	Messages []api.ChatMessage          `json:"messages,omitempty"`
+	Choices  []api.ChatCompletionChoice `json:"choices,omitempty"`
+}
+
+// Logger writes audit records. It is safe for concurrent use.
+type Logger struct {
+	includeContent bool
+	now            func() time.Time
+
+	mu  sync.Mutex
+	enc *json.Encoder
+	// file is the destination file, closed by Close. nil for stdout.
+	file *os.File
+}
+
+// New creates the Logger configured by cfg, writing to stdout or appending to
+// the destination file. It returns nil when the audit log is disabled.
+func New(cfg config.AuditConfig) (*Logger, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if cfg.Destination == "" || cfg.Destination == "stdout" {
+		return NewLogger(os.Stdout, cfg.IncludeContent), nil
+	}
+	f, err := os.OpenFile(cfg.Destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	l := NewLogger(f, cfg.IncludeContent)
+	l.file = f
+	return l, nil
+}
+
+// Close closes the destination file of the Logger, if it has one. Nothing is
+// recorded after Close.
+func (l *Logger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	l.enc = json.NewEncoder(io.Discard)
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// NewLogger creates a Logger writing to w.
+func NewLogger(w io.Writer, includeContent bool) *Logger {
+	return &Logger{
+		includeContent: includeContent,
+		now:            time.Now,
+		enc:            json.NewEncoder(w),
+	}
+}
+
+// Log records the completion resp of req, served by model of provider.
+// The request ID is taken from ctx.
+func (l *Logger) Log(ctx context.Context, model, provider string, req *api.ChatCompletionRequest, resp *api.ChatCompletionResponse) error {
+	record := Record{
+		Timestamp: l.now().UTC(),
+		RequestID: requestid.FromContext(ctx),
+		Model:     model,
+		Provider:  provider,
+		Usage:     resp.Usage,
+	}
+	if l.includeContent {
+		record.Messages = req.Messages
+		record.Choices = resp.Choices
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.enc.Encode(record); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func textContent(t *testing.T, text string) *api.ChatMessage_Content {
+	t.Helper()
+	content := &api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0(text))
+	return content
+}
+
+func TestLoggerLog(t *testing.T) {
+	req := &api.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: textContent(t, "What is my password?")}},
+	}
+	resp := &api.ChatCompletionResponse{
+		Model: "gpt-4o",
+		Choices: []api.ChatCompletionChoice{
+			{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: textContent(t, "I can't know that.")}},
+		},
+		Usage: &api.Usage{PromptTokens: 5, CompletionTokens: 6, TotalTokens: 11},
+	}
+	ctx := requestid.NewContext(context.Background(), "req-1")
+
+	tests := []struct {
+		name           string
+		includeContent bool
+	}{
+		{name: "metadata only", includeContent: false},
+		{name: "with content", includeContent: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(&buf, tt.includeContent)
+			logger.now = func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+			require.NoError(t, logger.Log(ctx, "smart", "openai", req, resp))
+
+			var record Record
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+			assert.Equal(t, "req-1", record.RequestID)
+			assert.Equal(t, "smart", record.Model)
+			assert.Equal(t, "openai", record.Provider)
+			assert.Equal(t, resp.Usage, record.Usage)
+			assert.Equal(t, time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC), record.Timestamp)
+			if tt.includeContent {
+				assert.Contains(t, buf.String(), "What is my password?")
+				assert.Contains(t, buf.String(), "I can't know that.")
+			} else {
+				assert.Empty(t, record.Messages)
+				assert.Empty(t, record.Choices)
+				assert.NotContains(t, buf.String(), "password")
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	logger, err := New(config.AuditConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, logger)
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err = New(config.AuditConfig{Enabled: true, Destination: path})
+	require.NoError(t, err)
+	require.NotNil(t, logger)
+
+	require.NoError(t, logger.Log(context.Background(), "smart", "openai", &api.ChatCompletionRequest{}, &api.ChatCompletionResponse{}))
+	require.NoError(t, logger.Log(context.Background(), "smart", "openai", &api.ChatCompletionRequest{}, &api.ChatCompletionResponse{}))
+
+	require.NoError(t, logger.Close())
+	require.NoError(t, logger.Log(context.Background(), "smart", "openai", &api.ChatCompletionRequest{}, &api.ChatCompletionResponse{}))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Len(t, bytes.Split(bytes.TrimSpace(data), []byte("\n")), 2)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+)
+
+type omittedFieldsKey struct{}
+
+// WithOmittedFields returns a copy of ctx removing the given top-level fields
+// from the JSON bodies of the upstream requests made with it, for the fields
+// that provider clients always send, such as the temperature of the OpenAI
+// client, that some models reject.
+func WithOmittedFields(ctx context.Context, fields ...string) context.Context {
+	return context.WithValue(ctx, omittedFieldsKey{}, fields)
+}
+
+// WithRenamedFields renames the top-level fields of the JSON bodies of the
+// requests, for the upstream APIs that take the requests of a provider client
+// but for the names of a few fields. A field renamed to "" is removed.
+func WithRenamedFields(renames map[string]string) Option {
+	return func(c *Client) {
+		c.renamedFields = renames
+	}
+}
+
+// rewriteFields renames the fields of the JSON body of req set with
+// WithRenamedFields and removes the ones requested by its context, returning a
+// copy of req. Requests without such fields, or whose body isn't a JSON object,
+// are returned as is.
+func (c *Client) rewriteFields(req *http.Request) (*http.Request, error) {
+	fields, _ := req.Context().Value(omittedFieldsKey{}).([]string)
+	if (len(fields) == 0 && len(c.renamedFields) == 0) || req.Body == nil || req.Body == http.NoBody {
+		return req, nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type")); mediaType != "application/json" {
+		return req, nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]json.RawMessage
+	if json.Unmarshal(body, &payload) == nil {
+		for from, to := range c.renamedFields {
+			if value, ok := payload[from]; ok {
+				delete(payload, from)
+				if to != "" {
+					payload[to] = value
+				}
+			}
+		}
+		for _, field := range fields {
+			delete(payload, field)
+		}
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, err
+		}
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	return req, nil
+}
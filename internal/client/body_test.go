@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDo_OmittedFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{name: "JSON", contentType: "application/json", body: `{"model":"o1","temperature":0}`, want: `{"model":"o1"}`},
+		{name: "JSON without the field", contentType: "application/json", body: `{"model":"o1"}`, want: `{"model":"o1"}`},
+		{name: "not JSON", contentType: "text/plain", body: `{"temperature":0}`, want: `{"temperature":0}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, int64(len(body)), r.ContentLength)
+				attempts = append(attempts, string(body))
+				if len(attempts) == 1 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx := WithOmittedFields(context.Background(), "temperature")
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", tt.contentType)
+			resp, err := New(srv.Client(), WithRetry(RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond})).Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			// Retries replay the body without the field.
+			require.Len(t, attempts, 2)
+			for _, body := range attempts {
+				assert.JSONEq(t, tt.want, body)
+			}
+		})
+	}
+}
+
+func TestClientDo_RenamedFields(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got = string(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	ctx := WithOmittedFields(context.Background(), "temperature")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, strings.NewReader(`{"model":"m","seed":1,"stream_options":{},"temperature":0}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	c := New(srv.Client(), WithRenamedFields(map[string]string{"seed": "random_seed", "stream_options": "", "max_tokens": "max_output_tokens"}))
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.JSONEq(t, `{"model":"m","random_seed":1}`, got)
+}
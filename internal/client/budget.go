@@ -0,0 +1,20 @@
+package client
+
+import "context"
+
+type retryBudgetKey struct{}
+
+// WithRetryBudget returns a copy of ctx making the retries of the upstream
+// requests made with it, by WithRetry and WithConnectRetry, ask allow first.
+// When allow returns false, the outcome of the last attempt is returned at
+// once instead of waiting for the backoff.
+func WithRetryBudget(ctx context.Context, allow func() bool) context.Context {
+	return context.WithValue(ctx, retryBudgetKey{}, allow)
+}
+
+// retryAllowed reports whether the retry budget carried by ctx, if any,
+// allows one more retry.
+func retryAllowed(ctx context.Context) bool {
+	allow, _ := ctx.Value(retryBudgetKey{}).(func() bool)
+	return allow == nil || allow()
+}
@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDoRequestWithRetry_RetryBudget(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, 500, 500, 500, 500)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	// The budget allows a single retry.
+	tokens := 1
+	ctx := WithRetryBudget(context.Background(), func() bool {
+		tokens--
+		return tokens >= 0
+	})
+	clk := &fakeClock{}
+	resp, err := doRequestWithRetry(ctx, srv.Client(), req, RetryConfig{MaxAttempts: 4, InitialBackoff: time.Second}, clk)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&attempts))
+	// The denied retry isn't waited for.
+	assert.Equal(t, []time.Duration{time.Second}, clk.waits)
+}
+
+func TestDoRequestWithConnectRetry_RetryBudget(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "http://127.0.0.1:1", nil)
+	require.NoError(t, err)
+
+	asked := 0
+	ctx := WithRetryBudget(context.Background(), func() bool {
+		asked++
+		return false
+	})
+	clk := &fakeClock{}
+	_, err = doRequestWithConnectRetry(ctx, http.DefaultClient, req, ConnectRetryConfig{Count: 3}, clk)
+
+	require.Error(t, err)
+	assert.Equal(t, 1, asked)
+	assert.Empty(t, clk.waits)
+}
@@ -0,0 +1,394 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// RetryConfig describes how failed upstream requests are retried.
+// Requests are retried on network errors and on 429/5xx responses.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts" description:"Total number of attempts, including the first one" jsonschema:"minimum=1"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" description:"Wait before the first retry" jsonschema:"default=500ms"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" description:"Maximum wait between two attempts" jsonschema:"default=30s"`
+	Multiplier     float64       `yaml:"multiplier" description:"Backoff growth factor between attempts" jsonschema:"default=2,minimum=1"`
+}
+
+const (
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMultiplier     = 2
+)
+
+// withDefaults fills unset backoff parameters with their default values.
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.MaxAttempts < 1 {
+		r.MaxAttempts = 1
+	}
+	if r.InitialBackoff <= 0 {
+		r.InitialBackoff = defaultInitialBackoff
+	}
+	if r.MaxBackoff <= 0 {
+		r.MaxBackoff = defaultMaxBackoff
+	}
+	if r.Multiplier < 1 {
+		r.Multiplier = defaultMultiplier
+	}
+	return r
+}
+
+// backoff returns the wait before the given retry (1 for the first retry).
+func (r RetryConfig) backoff(retry int) time.Duration {
+	d := float64(r.InitialBackoff) * math.Pow(r.Multiplier, float64(retry-1))
+	if d > float64(r.MaxBackoff) {
+		return r.MaxBackoff
+	}
+	return time.Duration(d)
+}
+
+// ConnectRetryConfig describes how requests whose connection to the upstream
+// couldn't be established are retried. Unlike RetryConfig, it never retries a
+// request the upstream has received, so a generation is never paid for twice.
+type ConnectRetryConfig struct {
+	Count   int           `yaml:"count" description:"Number of retries after the first attempt" jsonschema:"minimum=0"`
+	Backoff time.Duration `yaml:"backoff" description:"Wait between two attempts" jsonschema:"default=100ms"`
+}
+
+const defaultConnectBackoff = 100 * time.Millisecond
+
+// clock abstracts waiting so that backoff timing can be tested.
+type clock interface {
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+var defaultClock clock = realClock{}
+
+// DoRequest sends req with httpClient in a single attempt.
+// The request ID carried by ctx is forwarded in the X-Request-ID header and
+// its trace context in the W3C trace context headers. Forwarded client headers
+// carried by ctx are added unless req already has them.
+func DoRequest(ctx context.Context, httpClient *http.Client, req *http.Request) (*http.Response, error) {
+	req = req.Clone(ctx)
+	if id := requestid.FromContext(ctx); id != "" && req.Header.Get(requestid.Header) == "" {
+		req.Header.Set(requestid.Header, id)
+	}
+	for name, values := range ForwardedHeaders(ctx) {
+		if req.Header.Get(name) == "" {
+			req.Header[name] = values
+		}
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return httpClient.Do(req)
+}
+
+// DoRequestWithConnectRetry sends req with httpClient like DoRequest, retrying
+// according to retry when the connection to the upstream fails to be
+// established (DNS resolution, refused connection). Requests that reached the
+// upstream, whatever their outcome, are never retried. Retries are subject to
+// the retry budget of ctx, see WithRetryBudget.
+func DoRequestWithConnectRetry(ctx context.Context, httpClient *http.Client, req *http.Request, retry ConnectRetryConfig) (*http.Response, error) {
+	return doRequestWithConnectRetry(ctx, httpClient, req, retry, defaultClock)
+}
+
+func doRequestWithConnectRetry(ctx context.Context, httpClient *http.Client, req *http.Request, retry ConnectRetryConfig, clk clock) (*http.Response, error) {
+	if retry.Backoff <= 0 {
+		retry.Backoff = defaultConnectBackoff
+	}
+	// A request body can only be replayed if it can be recreated.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retry.Count = 0
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.WithContext(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := DoRequest(ctx, httpClient, attemptReq)
+		if err == nil || attempt >= retry.Count || !isConnectError(err) || ctx.Err() != nil || !retryAllowed(ctx) {
+			return resp, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-clk.After(retry.Backoff):
+		}
+	}
+}
+
+// isConnectError reports whether err is a failure to establish the connection,
+// before anything was sent to the upstream.
+func isConnectError(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// DoRequestWithRetry sends req with httpClient, retrying according to retry
+// and the retry budget of ctx, see WithRetryBudget. The Retry-After header of
+// a 429/503 response overrides the computed backoff. The response of the last
+// attempt is returned as is.
+func DoRequestWithRetry(ctx context.Context, httpClient *http.Client, req *http.Request, retry RetryConfig) (*http.Response, error) {
+	return doRequestWithRetry(ctx, httpClient, req, retry, defaultClock)
+}
+
+func doRequestWithRetry(ctx context.Context, httpClient *http.Client, req *http.Request, retry RetryConfig, clk clock) (*http.Response, error) {
+	retry = retry.withDefaults()
+	// A request body can only be replayed if it can be recreated.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		retry.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.WithContext(ctx)
+			attemptReq.Body = body
+		}
+
+		resp, err := DoRequest(ctx, httpClient, attemptReq)
+		if attempt >= retry.MaxAttempts || !shouldRetry(resp, err) || ctx.Err() != nil || !retryAllowed(ctx) {
+			return resp, err
+		}
+
+		wait := retry.backoff(attempt)
+		if resp != nil {
+			if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+				wait = retryAfter
+			}
+			// Drain the body so the connection can be reused.
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-clk.After(wait):
+		}
+	}
+}
+
+// shouldRetry reports whether the outcome of an attempt is worth retrying.
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// maxErrorBodySize caps how much of an error response body is kept in a StatusError.
+const maxErrorBodySize = 4 << 10
+
+// StatusError is returned by Client for upstream responses with a 4xx or 5xx status.
+type StatusError struct {
+	StatusCode int
+	Body       []byte
+	// RetryAfter is the wait requested by the upstream, zero if none was given.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if len(e.Body) == 0 {
+		return fmt.Sprintf("upstream returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("upstream returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// checkStatus converts an error response into a StatusError, consuming its body.
+func checkStatus(resp *http.Response) error {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	statusErr := &StatusError{StatusCode: resp.StatusCode, Body: body}
+	if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		statusErr.RetryAfter = retryAfter
+	}
+	return statusErr
+}
+
+// Client sends upstream requests through DoRequest, DoRequestWithRetry or
+// DoRequestWithConnectRetry.
+// It satisfies the Doer interface accepted by the langchaingo HTTP clients.
+type Client struct {
+	httpClient   *http.Client
+	retry        *RetryConfig
+	connectRetry *ConnectRetryConfig
+	keys         *KeyPool
+	headers      map[string]string
+	// renamedFields are the fields of the JSON request bodies renamed by
+	// WithRenamedFields.
+	renamedFields map[string]string
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithRetry enables retries with the given policy.
+func WithRetry(retry RetryConfig) Option {
+	return func(c *Client) {
+		c.retry = &retry
+	}
+}
+
+// WithConnectRetry retries the requests that fail to connect to the upstream
+// with the given policy. It's superseded by WithRetry, which retries network
+// errors as well.
+func WithConnectRetry(retry ConnectRetryConfig) Option {
+	return func(c *Client) {
+		c.connectRetry = &retry
+	}
+}
+
+// WithKeyPool authenticates every request with a bearer token taken from keys.
+// A key rejected with a 401 is marked unhealthy and the request is sent again
+// with the next key.
+func WithKeyPool(keys *KeyPool) Option {
+	return func(c *Client) {
+		c.keys = keys
+	}
+}
+
+// New creates a Client sending requests with httpClient.
+func New(httpClient *http.Client, opts ...Option) *Client {
+	c := &Client{
+		httpClient: httpClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Do sends the request. Responses with a 4xx or 5xx status are returned
+// as a *StatusError so that callers can classify the failure.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	req, err := c.rewriteFields(req)
+	if err != nil {
+		return nil, err
+	}
+	if c.keys != nil {
+		return c.doWithKeys(req)
+	}
+	return c.do(req)
+}
+
+// doWithKeys sends req with the keys of the pool until one isn't rejected with
+// a 401, trying every key at most once.
+func (c *Client) doWithKeys(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		keyReq := req.Clone(req.Context())
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			keyReq.Body = body
+		}
+		key := c.keys.Next()
+		keyReq.Header.Set("Authorization", "Bearer "+key)
+
+		resp, err := c.do(keyReq)
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusUnauthorized {
+			return resp, err
+		}
+		c.keys.MarkUnhealthy(key)
+
+		// A request body can only be replayed if it can be recreated.
+		replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+		if attempt >= c.keys.Len() || !replayable || req.Context().Err() != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *Client) do(req *http.Request) (*http.Response, error) {
+	if len(c.headers) > 0 {
+		req = req.Clone(req.Context())
+		for name, value := range c.headers {
+			req.Header.Set(name, value)
+		}
+	}
+
+	var resp *http.Response
+	var err error
+	switch {
+	case c.retry != nil:
+		resp, err = DoRequestWithRetry(req.Context(), c.httpClient, req, *c.retry)
+	case c.connectRetry != nil:
+		resp, err = DoRequestWithConnectRetry(req.Context(), c.httpClient, req, *c.connectRetry)
+	default:
+		resp, err = DoRequest(req.Context(), c.httpClient, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStatus(resp); err != nil {
+		return nil, err
+	}
+	if err := recordSystemFingerprint(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// StandardClient returns an *http.Client sending its requests through c,
+// for libraries that only accept a standard client.
+func (c *Client) StandardClient() *http.Client {
+	return &http.Client{Transport: roundTripperFunc(c.Do)}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
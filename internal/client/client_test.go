@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// fakeClock records the requested waits and fires immediately.
+type fakeClock struct {
+	waits []time.Duration
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	ch <- time.Now()
+	return ch
+}
+
+// newStatusServer returns a server replying with the given status codes in order,
+// then with 200 once they are exhausted.
+func newStatusServer(t *testing.T, attempts *int32, statuses ...int) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(attempts, 1)
+		if int(n) <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestDoRequestWithRetry_RetriesUntilSuccess(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, http.StatusBadGateway, http.StatusServiceUnavailable)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"model":"test"}`))
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithRetry(context.Background(), srv.Client(), req, RetryConfig{
+		MaxAttempts:    5,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}, clk)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+	assert.Equal(t, []time.Duration{100 * time.Millisecond, 200 * time.Millisecond}, clk.waits)
+}
+
+func TestDoRequestWithRetry_StopsAtMaxAttempts(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, 500, 500, 500, 500, 500)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithRetry(context.Background(), srv.Client(), req, RetryConfig{
+		MaxAttempts:    4,
+		InitialBackoff: time.Second,
+		MaxBackoff:     3 * time.Second,
+		Multiplier:     2,
+	}, clk)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&attempts))
+	// The third wait is capped by MaxBackoff.
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, clk.waits)
+}
+
+func TestDoRequestWithRetry_NoRetryOnClientError(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, http.StatusBadRequest)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithRetry(context.Background(), srv.Client(), req, RetryConfig{MaxAttempts: 3}, clk)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	assert.Empty(t, clk.waits)
+}
+
+func TestDoRequestWithRetry_HonorsRetryAfter(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithRetry(context.Background(), srv.Client(), req, RetryConfig{
+		MaxAttempts:    2,
+		InitialBackoff: time.Millisecond,
+	}, clk)
+
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{7 * time.Second}, clk.waits)
+}
+
+func TestDoRequestWithRetry_ContextCancelled(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, 503, 503, 503)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	resp, err := DoRequestWithRetry(ctx, srv.Client(), req, RetryConfig{MaxAttempts: 3})
+
+	assert.Nil(t, resp)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.LessOrEqual(t, atomic.LoadInt32(&attempts), int32(1))
+}
+
+// refusingClient returns a client whose first dials target a closed port, so
+// that the connection is refused, and the following ones srv.
+func refusingClient(t *testing.T, srv *httptest.Server, refusals int32) (*http.Client, *int32) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	var dials int32
+	var dialer net.Dialer
+	transport := &http.Transport{DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if atomic.AddInt32(&dials, 1) <= refusals {
+			addr = closedAddr
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}}
+	t.Cleanup(transport.CloseIdleConnections)
+	return &http.Client{Transport: transport}, &dials
+}
+
+func TestDoRequestWithConnectRetry_RetriesRefusedConnection(t *testing.T) {
+	var body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+	}))
+	t.Cleanup(srv.Close)
+	httpClient, dials := refusingClient(t, srv, 1)
+
+	req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader(`{"model":"test"}`))
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithConnectRetry(context.Background(), httpClient, req, ConnectRetryConfig{Count: 2}, clk)
+
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(dials))
+	assert.Equal(t, `{"model":"test"}`, body)
+	assert.Equal(t, []time.Duration{defaultConnectBackoff}, clk.waits)
+}
+
+func TestDoRequestWithConnectRetry_StopsAtCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(srv.Close)
+	httpClient, dials := refusingClient(t, srv, 5)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	_, err = doRequestWithConnectRetry(context.Background(), httpClient, req, ConnectRetryConfig{Count: 2, Backoff: time.Second}, clk)
+
+	require.Error(t, err)
+	assert.True(t, isConnectError(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(dials))
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, clk.waits)
+}
+
+func TestDoRequestWithConnectRetry_NoRetryOnStatus(t *testing.T) {
+	var attempts int32
+	srv := newStatusServer(t, &attempts, http.StatusBadGateway)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	clk := &fakeClock{}
+	resp, err := doRequestWithConnectRetry(context.Background(), srv.Client(), req, ConnectRetryConfig{Count: 3}, clk)
+
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+	assert.Empty(t, clk.waits)
+}
+
+func TestClientDo_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"error":"slow down"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := New(srv.Client()).Do(req)
+
+	assert.Nil(t, resp)
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusTooManyRequests, statusErr.StatusCode)
+	assert.Equal(t, `{"error":"slow down"}`, string(statusErr.Body))
+	assert.Equal(t, 7*time.Second, statusErr.RetryAfter)
+}
+
+func TestDoRequest_ForwardsRequestID(t *testing.T) {
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get(requestid.Header)
+	}))
+	t.Cleanup(srv.Close)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := DoRequest(requestid.NewContext(context.Background(), "test-request-id"), srv.Client(), req)
+
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "test-request-id", received)
+}
+
+func TestDoRequest_PropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() { otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator()) })
+
+	var received string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Get("traceparent")
+	}))
+	t.Cleanup(srv.Close)
+
+	spanCtx := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), spanCtx)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := DoRequest(ctx, srv.Client(), req)
+
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "00-01000000000000000000000000000000-0200000000000000-01", received)
+}
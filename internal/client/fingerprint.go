@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"sync"
+)
+
+type systemFingerprintKey struct{}
+
+// systemFingerprint is the system_fingerprint of the last JSON response of
+// the upstream requests made with a context.
+type systemFingerprint struct {
+	mu    sync.Mutex
+	value string
+}
+
+// WithSystemFingerprint returns a copy of ctx recording the system_fingerprint
+// of the JSON responses of the upstream requests made with it, which provider
+// clients such as the langchain ones drop, and a function returning the last
+// one recorded, or "" when the upstream sent none.
+func WithSystemFingerprint(ctx context.Context) (context.Context, func() string) {
+	fingerprint := &systemFingerprint{}
+	return context.WithValue(ctx, systemFingerprintKey{}, fingerprint), func() string {
+		fingerprint.mu.Lock()
+		defer fingerprint.mu.Unlock()
+		return fingerprint.value
+	}
+}
+
+// recordSystemFingerprint records the system_fingerprint of resp when the
+// context of its request asks for it. Only JSON bodies are read, and replaced
+// by a copy, leaving event streams alone.
+func recordSystemFingerprint(resp *http.Response) error {
+	if resp.Request == nil {
+		return nil
+	}
+	fingerprint, _ := resp.Request.Context().Value(systemFingerprintKey{}).(*systemFingerprint)
+	if fingerprint == nil {
+		return nil
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "application/json" {
+		return nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var payload struct {
+		SystemFingerprint string `json:"system_fingerprint"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.SystemFingerprint != "" {
+		fingerprint.mu.Lock()
+		fingerprint.value = payload.SystemFingerprint
+		fingerprint.mu.Unlock()
+	}
+	return nil
+}
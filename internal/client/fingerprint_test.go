@@ -0,0 +1,48 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDo_SystemFingerprint(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{name: "JSON", contentType: "application/json; charset=utf-8", body: `{"system_fingerprint":"fp_1"}`, want: "fp_1"},
+		{name: "JSON without fingerprint", contentType: "application/json", body: `{"id":"1"}`},
+		{name: "event stream", contentType: "text/event-stream", body: "data: {\"system_fingerprint\":\"fp_1\"}\n\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				fmt.Fprint(w, tt.body)
+			}))
+			t.Cleanup(srv.Close)
+
+			ctx, fingerprint := WithSystemFingerprint(context.Background())
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+			require.NoError(t, err)
+			resp, err := New(srv.Client()).Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.body, string(body))
+			assert.Equal(t, tt.want, fingerprint())
+		})
+	}
+}
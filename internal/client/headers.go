@@ -0,0 +1,34 @@
+package client
+
+import (
+	"context"
+	"maps"
+	"net/http"
+)
+
+type forwardedHeadersKey struct{}
+
+// WithForwardedHeaders returns a copy of ctx carrying client headers to forward
+// upstream. They are only set on upstream requests that don't already have them,
+// so they never replace the headers of the provider, such as Authorization.
+func WithForwardedHeaders(ctx context.Context, headers http.Header) context.Context {
+	return context.WithValue(ctx, forwardedHeadersKey{}, headers)
+}
+
+// ForwardedHeaders returns the headers carried by ctx, or nil.
+func ForwardedHeaders(ctx context.Context) http.Header {
+	headers, _ := ctx.Value(forwardedHeadersKey{}).(http.Header)
+	return headers
+}
+
+// WithHeaders sets headers on every request, replacing the ones set by the
+// provider client, including Authorization. Headers given by a later option
+// replace the ones of the same name given by an earlier one.
+func WithHeaders(headers map[string]string) Option {
+	return func(c *Client) {
+		if c.headers == nil {
+			c.headers = make(map[string]string, len(headers))
+		}
+		maps.Copy(c.headers, headers)
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientDo_Headers(t *testing.T) {
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		name       string
+		configured map[string]string
+		forwarded  http.Header
+		want       map[string]string
+	}{
+		{
+			name:       "configured headers are added",
+			configured: map[string]string{"X-Api-Org": "acme"},
+			want:       map[string]string{"X-Api-Org": "acme", "Authorization": "Bearer provider-key"},
+		},
+		{
+			name:       "configured authorization replaces the provider one",
+			configured: map[string]string{"Authorization": "Bearer configured"},
+			want:       map[string]string{"Authorization": "Bearer configured"},
+		},
+		{
+			name:      "forwarded headers are added",
+			forwarded: http.Header{"X-Tenant-Id": {"tenant-1"}},
+			want:      map[string]string{"X-Tenant-Id": "tenant-1", "Authorization": "Bearer provider-key"},
+		},
+		{
+			name:      "forwarded headers don't replace the provider ones",
+			forwarded: http.Header{"Authorization": {"Bearer gateway-key"}},
+			want:      map[string]string{"Authorization": "Bearer provider-key"},
+		},
+		{
+			name:       "forwarded headers don't replace configured ones",
+			configured: map[string]string{"X-Tenant-Id": "configured"},
+			forwarded:  http.Header{"X-Tenant-Id": {"tenant-1"}},
+			want:       map[string]string{"X-Tenant-Id": "configured"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.forwarded != nil {
+				ctx = WithForwardedHeaders(ctx, tt.forwarded)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer provider-key")
+
+			resp, err := New(srv.Client(), WithHeaders(tt.configured)).Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			for name, value := range tt.want {
+				assert.Equal(t, value, received.Get(name), name)
+			}
+		})
+	}
+}
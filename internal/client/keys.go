@@ -0,0 +1,75 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultKeyCooldown is how long a key rejected with a 401 is skipped by a KeyPool
+// created without a cooldown.
+const DefaultKeyCooldown = time.Minute
+
+// KeyPool hands out API keys round-robin. Keys marked unhealthy are skipped
+// until their cooldown has passed.
+type KeyPool struct {
+	keys     []string
+	cooldown time.Duration
+	now      func() time.Time
+
+	mu             sync.Mutex
+	next           int
+	unhealthyUntil []time.Time
+}
+
+// NewKeyPool creates a KeyPool rotating over keys, which must not be empty.
+func NewKeyPool(keys []string, cooldown time.Duration) *KeyPool {
+	if cooldown <= 0 {
+		cooldown = DefaultKeyCooldown
+	}
+	return &KeyPool{
+		keys:           keys,
+		cooldown:       cooldown,
+		now:            time.Now,
+		unhealthyUntil: make([]time.Time, len(keys)),
+	}
+}
+
+// Len returns the number of keys in the pool.
+func (p *KeyPool) Len() int {
+	return len(p.keys)
+}
+
+// Next returns the next healthy key. When every key is cooling down, the key
+// that recovers first is returned.
+func (p *KeyPool) Next() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	pick := -1
+	for i := range p.keys {
+		idx := (p.next + i) % len(p.keys)
+		if !now.Before(p.unhealthyUntil[idx]) {
+			pick = idx
+			break
+		}
+		if pick == -1 || p.unhealthyUntil[idx].Before(p.unhealthyUntil[pick]) {
+			pick = idx
+		}
+	}
+	p.next = (pick + 1) % len(p.keys)
+	return p.keys[pick]
+}
+
+// MarkUnhealthy skips key for the cooldown of the pool.
+func (p *KeyPool) MarkUnhealthy(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	until := p.now().Add(p.cooldown)
+	for i, k := range p.keys {
+		if k == key {
+			p.unhealthyUntil[i] = until
+		}
+	}
+}
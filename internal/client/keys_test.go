@@ -0,0 +1,81 @@
+package client
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyPool(t *testing.T) {
+	now := time.Unix(0, 0)
+	pool := NewKeyPool([]string{"a", "b", "c"}, time.Minute)
+	pool.now = func() time.Time { return now }
+
+	assert.Equal(t, []string{"a", "b", "c", "a"}, []string{pool.Next(), pool.Next(), pool.Next(), pool.Next()})
+
+	pool.MarkUnhealthy("c")
+	assert.Equal(t, []string{"b", "a", "b"}, []string{pool.Next(), pool.Next(), pool.Next()})
+
+	// With every key cooling down, the one recovering first is used.
+	now = now.Add(10 * time.Second)
+	pool.MarkUnhealthy("a")
+	pool.MarkUnhealthy("b")
+	assert.Equal(t, "c", pool.Next())
+
+	now = now.Add(time.Minute)
+	assert.Equal(t, []string{"a", "b", "c"}, []string{pool.Next(), pool.Next(), pool.Next()})
+}
+
+func TestClientDo_KeyPool(t *testing.T) {
+	var received []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		received = append(received, auth)
+		if auth == "Bearer revoked" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	pool := NewKeyPool([]string{"revoked", "valid"}, time.Minute)
+	c := New(srv.Client(), WithKeyPool(pool))
+
+	for range 2 {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, strings.NewReader("{}"))
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer revoked")
+
+		resp, err := c.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	// The revoked key is retried with the next one and then skipped.
+	assert.Equal(t, []string{"Bearer revoked", "Bearer valid", "Bearer valid"}, received)
+}
+
+func TestClientDo_KeyPoolAllRejected(t *testing.T) {
+	var calls int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := New(srv.Client(), WithKeyPool(NewKeyPool([]string{"a", "b"}, time.Minute)))
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+
+	_, err = c.Do(req)
+
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+	assert.Equal(t, 2, calls)
+}
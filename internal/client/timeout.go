@@ -0,0 +1,74 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// WithIdleTimeout returns a transport sending requests with base that fails
+// them once the upstream has sent nothing for timeout: neither the response
+// headers nor data of the response body. Unlike the timeout of http.Client, it
+// doesn't bound the whole response, so that streamed responses last as long as
+// the upstream keeps sending them. The errors wrap context.DeadlineExceeded.
+func WithIdleTimeout(base http.RoundTripper, timeout time.Duration) http.RoundTripper {
+	return &idleTimeoutTransport{base: base, timeout: timeout}
+}
+
+type idleTimeoutTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t *idleTimeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	errTimeout := fmt.Errorf("upstream sent nothing for %s: %w", t.timeout, context.DeadlineExceeded)
+	ctx, cancel := context.WithCancelCause(req.Context())
+	timer := time.AfterFunc(t.timeout, func() { cancel(errTimeout) })
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		if context.Cause(ctx) == errTimeout {
+			err = errTimeout
+		}
+		cancel(nil)
+		return nil, err
+	}
+	timer.Reset(t.timeout)
+	resp.Body = &idleTimeoutBody{
+		body:       resp.Body,
+		ctx:        ctx,
+		cancel:     cancel,
+		timer:      timer,
+		timeout:    t.timeout,
+		errTimeout: errTimeout,
+	}
+	return resp, nil
+}
+
+// idleTimeoutBody restarts the idle timer of its request on every read.
+type idleTimeoutBody struct {
+	body       io.ReadCloser
+	ctx        context.Context
+	cancel     context.CancelCauseFunc
+	timer      *time.Timer
+	timeout    time.Duration
+	errTimeout error
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil && context.Cause(b.ctx) == b.errTimeout {
+		return n, b.errTimeout
+	}
+	b.timer.Reset(b.timeout)
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.timer.Stop()
+	b.cancel(nil)
+	return b.body.Close()
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithIdleTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow-headers":
+			time.Sleep(2 * timeout)
+		case "/stream":
+			// Longer than the timeout in total, but never silent for that long.
+			for i := range 5 {
+				fmt.Fprintf(w, "data: %d\n\n", i)
+				w.(http.Flusher).Flush()
+				time.Sleep(timeout / 2)
+			}
+		case "/stalled-body":
+			w.(http.Flusher).Flush()
+			time.Sleep(2 * timeout)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	httpClient := &http.Client{Transport: WithIdleTimeout(srv.Client().Transport, timeout)}
+
+	t.Run("headers", func(t *testing.T) {
+		_, err := httpClient.Get(srv.URL + "/slow-headers")
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		resp, err := httpClient.Get(srv.URL + "/stream")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "data: 4")
+	})
+
+	t.Run("body", func(t *testing.T) {
+		resp, err := httpClient.Get(srv.URL + "/stalled-body")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSConfig represents the TLS settings of the connections to a provider.
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file,omitempty" description:"PEM file of CA certificates trusted in addition to the system ones"`
+	CertFile           string `yaml:"cert_file,omitempty" description:"PEM client certificate for mutual TLS, requires key_file" jsonschema:"requires=key_file"`
+	KeyFile            string `yaml:"key_file,omitempty" description:"PEM key of the client certificate" jsonschema:"requires=cert_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify,omitempty" description:"Accept any server certificate, for testing only" jsonschema:"default=false"`
+}
+
+// NewTLSConfig creates the client TLS config of cfg, loading its files.
+func NewTLSConfig(cfg TLSConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, errors.New("cert_file and key_file must be set together")
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// WithTLS returns a copy of transport, with its pool settings, connecting with
+// tlsCfg. The copy has its own connection pool.
+func WithTLS(transport *http.Transport, tlsCfg *tls.Config) *http.Transport {
+	t := transport.Clone()
+	t.TLSClientConfig = tlsCfg
+	return t
+}
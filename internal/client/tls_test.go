@@ -0,0 +1,120 @@
+package client
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeCertificate writes a self-signed certificate and its key as PEM files in dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "llm-gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return certFile, keyFile, cert
+}
+
+func TestNewTLSConfig_MutualTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, clientCert := writeCertificate(t, dir)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	srv.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	srv.StartTLS()
+	defer srv.Close()
+
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	t.Run("with client certificate", func(t *testing.T) {
+		tlsCfg, err := NewTLSConfig(TLSConfig{CAFile: caFile, CertFile: certFile, KeyFile: keyFile})
+		require.NoError(t, err)
+
+		resp, err := (&http.Client{Transport: WithTLS(NewTransport(TransportConfig{}), tlsCfg)}).Get(srv.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("without client certificate", func(t *testing.T) {
+		tlsCfg, err := NewTLSConfig(TLSConfig{CAFile: caFile})
+		require.NoError(t, err)
+
+		_, err = (&http.Client{Transport: WithTLS(NewTransport(TransportConfig{}), tlsCfg)}).Get(srv.URL)
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTLSConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeCertificate(t, dir)
+	notPEM := filepath.Join(dir, "ca.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a certificate"), 0o600))
+
+	tests := []struct {
+		name string
+		cfg  TLSConfig
+	}{
+		{name: "missing CA file", cfg: TLSConfig{CAFile: filepath.Join(dir, "missing.crt")}},
+		{name: "CA file without certificates", cfg: TLSConfig{CAFile: notPEM}},
+		{name: "certificate without key", cfg: TLSConfig{CertFile: certFile}},
+		{name: "key without certificate", cfg: TLSConfig{KeyFile: keyFile}},
+		{name: "mismatched key pair", cfg: TLSConfig{CertFile: certFile, KeyFile: certFile}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTLSConfig(tt.cfg)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestWithTLS(t *testing.T) {
+	shared := NewTransport(TransportConfig{MaxIdleConns: 50})
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	transport := WithTLS(shared, tlsCfg)
+
+	assert.NotSame(t, shared, transport)
+	assert.Same(t, tlsCfg, transport.TLSClientConfig)
+	assert.Equal(t, 50, transport.MaxIdleConns)
+	assert.NotSame(t, tlsCfg, shared.TLSClientConfig)
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig tunes the connection pool of the transport shared by the
+// upstream clients.
+type TransportConfig struct {
+	MaxIdleConns        int           `yaml:"max_idle_conns" env:"MAX_IDLE_CONNS" description:"Maximum idle connections across all hosts" jsonschema:"default=100,minimum=0"`
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host" env:"MAX_IDLE_CONNS_PER_HOST" description:"Maximum idle connections per host" jsonschema:"default=20,minimum=0"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout" env:"IDLE_CONN_TIMEOUT" description:"How long an idle connection is kept" jsonschema:"default=90s"`
+}
+
+const (
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 20
+	defaultIdleConnTimeout     = 90 * time.Second
+)
+
+// withDefaults fills unset pool parameters with their default values.
+func (t TransportConfig) withDefaults() TransportConfig {
+	if t.MaxIdleConns <= 0 {
+		t.MaxIdleConns = defaultMaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost <= 0 {
+		t.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if t.IdleConnTimeout <= 0 {
+		t.IdleConnTimeout = defaultIdleConnTimeout
+	}
+	return t
+}
+
+// NewTransport creates a transport with the pool settings of cfg. The other
+// settings, such as the proxy and dial timeouts, are those of http.DefaultTransport.
+func NewTransport(cfg TransportConfig) *http.Transport {
+	cfg = cfg.withDefaults()
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = cfg.MaxIdleConns
+	t.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	t.IdleConnTimeout = cfg.IdleConnTimeout
+	return t
+}
+
+// WithProxy returns a copy of transport, with its pool settings, sending the
+// requests through the forward proxy at proxyURL instead of the one of the
+// environment. The copy has its own connection pool.
+func WithProxy(transport *http.Transport, proxyURL *url.URL) *http.Transport {
+	t := transport.Clone()
+	t.Proxy = http.ProxyURL(proxyURL)
+	return t
+}
@@ -0,0 +1,31 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTransport(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		transport := NewTransport(TransportConfig{})
+
+		assert.Equal(t, 100, transport.MaxIdleConns)
+		assert.Equal(t, 20, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+		assert.NotNil(t, transport.Proxy)
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		transport := NewTransport(TransportConfig{
+			MaxIdleConns:        50,
+			MaxIdleConnsPerHost: 50,
+			IdleConnTimeout:     time.Minute,
+		})
+
+		assert.Equal(t, 50, transport.MaxIdleConns)
+		assert.Equal(t, 50, transport.MaxIdleConnsPerHost)
+		assert.Equal(t, time.Minute, transport.IdleConnTimeout)
+	})
+}
@@ -1,10 +1,16 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v11"
+	"github.com/dmitrii/llm-gateway/internal/client"
 	"github.com/tmc/langchaingo/llms/openai"
 	"gopkg.in/yaml.v3"
 )
@@ -14,36 +20,261 @@ import (
 // `yaml` tags are used for mapping from the config file.
 // `env` tags are used for mapping from environment variables.
 // `envDefault` provides default values.
+// `description` tags document the fields, in the JSON schema as well.
 type Config struct {
-	Server    ServerConfig      `yaml:"server" envPrefix:"SERVER_"`
-	Logging   LoggingConfig     `yaml:"logging" envPrefix:"LOG_"`
-	Providers []*ProviderConfig `yaml:"providers"`
-	Models    []*ModelConfig    `yaml:"models"`
-	OpenAPI   OpenApiConfig     `yaml:"openapi" envPrefix:"OPENAPI_"`
+	Server        ServerConfig        `yaml:"server" envPrefix:"SERVER_" description:"Server configuration"`
+	Logging       LoggingConfig       `yaml:"logging" envPrefix:"LOG_" description:"Logging configuration"`
+	Tracing       TracingConfig       `yaml:"tracing" envPrefix:"TRACING_" description:"OpenTelemetry tracing configuration"`
+	Audit         AuditConfig         `yaml:"audit" envPrefix:"AUDIT_" description:"Audit log of the served chat completions, separate from the operational logs"`
+	Metrics       MetricsConfig       `yaml:"metrics" envPrefix:"METRICS_" description:"Metrics reported besides the /metrics endpoint"`
+	Providers     []*ProviderConfig   `yaml:"providers" description:"List of LLM providers"`
+	Models        []*ModelConfig      `yaml:"models" description:"List of model configurations"`
+	Aliases       map[string]string   `yaml:"aliases,omitempty" description:"Model IDs by alias, resolved before the model lookup; aliases can't shadow model IDs"`
+	DefaultModel  string              `yaml:"default_model,omitempty" env:"DEFAULT_MODEL" description:"Model ID or alias of chat completions sent without a model"`
+	Fallback      FallbackConfig      `yaml:"fallback" description:"Fallback configuration"`
+	OpenAPI       OpenApiConfig       `yaml:"openapi" envPrefix:"OPENAPI_" description:"OpenAPI configuration"`
+	Startup       StartupConfig       `yaml:"startup" envPrefix:"STARTUP_" description:"Provider initialization configuration"`
+	ContentFilter ContentFilterConfig `yaml:"content_filter" description:"Redaction of the chat completion content returned to clients"`
+}
+
+// ContentFilterConfig represents the redaction of the chat completion content
+// returned to clients. It is disabled when Patterns is empty.
+type ContentFilterConfig struct {
+	Patterns       []ContentFilterPattern `yaml:"patterns" description:"Regular expressions of the content to redact"`
+	Replacement    string                 `yaml:"replacement" description:"Text replacing the redacted content, defaults to [REDACTED]"`
+	MaxMatchLength int                    `yaml:"max_match_length" description:"Length in bytes of the longest content matched across streamed chunks, held back from the client until the next chunk; defaults to 64" jsonschema:"minimum=0"`
+}
+
+// ContentFilterPattern is a regular expression of the content to redact.
+type ContentFilterPattern struct {
+	Name  string `yaml:"name" description:"Name of the pattern in the metrics" jsonschema:"required,minLength=1"`
+	Regex string `yaml:"regex" description:"Go regular expression of the content to redact" jsonschema:"required,format=regex,minLength=1"`
+}
+
+// StartupConfig controls the initialization of the providers, which runs concurrently.
+type StartupConfig struct {
+	ProviderTimeout time.Duration `yaml:"provider_timeout" env:"PROVIDER_TIMEOUT" description:"Timeout for the initialization of each provider" jsonschema:"default=30s"`
+	AllowPartial    bool          `yaml:"allow_partial" env:"ALLOW_PARTIAL" description:"Start without the providers that failed to initialize" jsonschema:"default=false"`
+}
+
+// FallbackConfig controls which upstream errors make the proxy try the next model.
+// Errors without a status (network errors, timeouts) always trigger a fallback.
+type FallbackConfig struct {
+	OnStatusCodes       []int             `yaml:"on_status_codes,omitempty" description:"Upstream status codes that make the proxy try the next model, defaults to 408, 429 and all 5xx codes" jsonschema:"minimum=100,maximum=599"`
+	PerAttemptTimeout   time.Duration     `yaml:"per_attempt_timeout,omitempty" description:"Timeout of every model attempt, after which the next model is tried; streams are only bounded until their first chunk, unbounded when unset"`
+	RetryBudget         RetryBudgetConfig `yaml:"retry_budget,omitempty" description:"Token bucket of the attempts made after a failed one, shared by all requests"`
+	MaxFallbackAttempts int               `yaml:"max_fallback_attempts,omitempty" description:"Maximum number of models attempted for a request, the requested one included, unlimited when 0" jsonschema:"minimum=0"`
+	MaxRetryAfter       time.Duration     `yaml:"max_retry_after,omitempty" description:"Longest Retry-After of an upstream 429 waited for before trying the same model again instead of falling back, disabled when unset"`
+	MaxThrottleRetries  int               `yaml:"max_throttle_retries,omitempty" description:"Maximum number of retries of a throttled model within a request, defaults to 1" jsonschema:"minimum=0"`
+}
+
+// RetryBudgetConfig is a token bucket refilled at RetriesPerSecond and holding up
+// to Burst retries. It is disabled when RetriesPerSecond is 0.
+type RetryBudgetConfig struct {
+	RetriesPerSecond float64 `yaml:"retries_per_second" description:"Rate at which the budget is refilled, disabled when 0" jsonschema:"minimum=0"`
+	Burst            int     `yaml:"burst" description:"Maximum number of retries in the budget, defaults to one second worth of retries" jsonschema:"minimum=0"`
 }
 
 type OpenApiConfig struct {
-	SpecPath string `yaml:"spec_path" env:"SPEC_PATH" envDefault:"./api/openapi.yaml"`
-	UiPath   string `yaml:"ui_path" env:"UI_PATH" envDefault:"./api/swagger-ui"`
+	SpecPath string `yaml:"spec_path" env:"SPEC_PATH" envDefault:"./api/openapi.yaml" description:"Path to OpenAPI specification file"`
+	UiPath   string `yaml:"ui_path" env:"UI_PATH" envDefault:"./api/swagger-ui" description:"Path to Swagger UI files"`
 }
 
 // ServerConfig represents the server configuration.
 type ServerConfig struct {
-	Port    string `yaml:"port" env:"PORT" envDefault:"8080"`
-	BaseURL string `yaml:"base_url" env:"BASE_URL" envDefault:"http://localhost:8080"`
+	Port                    string                 `yaml:"port" env:"PORT" envDefault:"8080" description:"Port to listen on"`
+	Address                 string                 `yaml:"address" env:"ADDRESS" description:"Host and port to listen on, e.g. 127.0.0.1:8080, overriding port"`
+	UnixSocket              UnixSocketConfig       `yaml:"unix_socket" envPrefix:"UNIX_SOCKET_" description:"Unix domain socket to listen on instead of TCP"`
+	BaseURL                 string                 `yaml:"base_url" env:"BASE_URL" envDefault:"http://localhost:8080" description:"Base URL for the server"`
+	Readiness               ReadinessConfig        `yaml:"readiness" envPrefix:"READINESS_" description:"Readiness endpoint configuration"`
+	HealthCheck             HealthCheckConfig      `yaml:"health_check" envPrefix:"HEALTH_CHECK_" description:"Background provider health checks reported by the /status endpoint"`
+	APIKeys                 []string               `yaml:"api_keys" env:"API_KEYS" envSeparator:"," secret:"true" description:"API keys accepted by the /v1 endpoints, authentication is disabled when empty"`
+	RateLimit               RateLimitConfig        `yaml:"rate_limit" envPrefix:"RATE_LIMIT_" description:"Rate limits of the /v1 endpoints"`
+	CORS                    CORSConfig             `yaml:"cors" envPrefix:"CORS_" description:"CORS policy of the /v1 endpoints, disabled without allowed origins"`
+	MaxRequestBytes         int64                  `yaml:"max_request_bytes" env:"MAX_REQUEST_BYTES" description:"Maximum size of /v1 request bodies, unlimited when 0" jsonschema:"default=0,minimum=0"`
+	MaxMessages             int                    `yaml:"max_messages" env:"MAX_MESSAGES" description:"Maximum number of messages of a chat completion, unlimited when 0" jsonschema:"default=0,minimum=0"`
+	MaxMessageChars         int                    `yaml:"max_message_chars" env:"MAX_MESSAGE_CHARS" description:"Maximum total characters of the messages of a chat completion, unlimited when 0" jsonschema:"default=0,minimum=0"`
+	MaxConcurrency          int                    `yaml:"max_concurrency" env:"MAX_CONCURRENCY" description:"Maximum concurrent upstream calls of all the providers, streamed chat completions holding their slot until they end; unlimited when 0" jsonschema:"default=0,minimum=0"`
+	ConcurrencyTimeout      time.Duration          `yaml:"concurrency_timeout" env:"CONCURRENCY_TIMEOUT" description:"Maximum wait for a free slot once max_concurrency is reached before failing with a 503; only bounded by the request when unset"`
+	Priority                PriorityConfig         `yaml:"priority" envPrefix:"PRIORITY_" description:"Priority tiers of the requests waiting for a max_concurrency slot, served in arrival order when unset"`
+	HTTPClient              client.TransportConfig `yaml:"http_client" envPrefix:"HTTP_CLIENT_" description:"Connection pool shared by the upstream provider clients"`
+	AllowProviderOverride   bool                   `yaml:"allow_provider_override" env:"ALLOW_PROVIDER_OVERRIDE" description:"Let chat completions pick the provider with the X-Provider-Override header, skipping fallbacks" jsonschema:"default=false"`
+	ExposeUpstreamModel     bool                   `yaml:"expose_upstream_model" env:"EXPOSE_UPSTREAM_MODEL" description:"Answer chat completions with the upstream name of the model that served them instead of the requested model ID" jsonschema:"default=false"`
+	ExposeServedModel       bool                   `yaml:"expose_served_model" env:"EXPOSE_SERVED_MODEL" description:"Report the provider ID and upstream model name that served a chat completion in the X-Served-Model header" jsonschema:"default=false"`
+	CoalesceRequests        bool                   `yaml:"coalesce_requests" env:"COALESCE_REQUESTS" description:"Share one upstream call between identical non-streamed chat completions in flight with a temperature of 0 or a seed" jsonschema:"default=false"`
+	DropReasoning           bool                   `yaml:"drop_reasoning" env:"DROP_REASONING" description:"Remove the reasoning of the models from non-streamed chat completions, the only ones carrying it" jsonschema:"default=false"`
+	ExposeErrorDetails      bool                   `yaml:"expose_error_details" env:"EXPOSE_ERROR_DETAILS" description:"Add the errors of the providers tried to the error responses of requests no provider could serve; they are always logged" jsonschema:"default=false"`
+	ForwardHeaders          []string               `yaml:"forward_headers" env:"FORWARD_HEADERS" envSeparator:"," description:"Client request headers forwarded to the upstream providers, never replacing the headers set by the provider"`
+	RequestTimeout          time.Duration          `yaml:"request_timeout" env:"REQUEST_TIMEOUT" description:"Maximum duration of a /v1 request, fallbacks included; unlimited when unset"`
+	StreamRequestTimeout    time.Duration          `yaml:"stream_request_timeout" env:"STREAM_REQUEST_TIMEOUT" description:"Maximum duration of a streamed chat completion, which request_timeout doesn't apply to; unlimited when unset"`
+	StreamKeepaliveInterval time.Duration          `yaml:"stream_keepalive_interval" env:"STREAM_KEEPALIVE_INTERVAL" description:"Idle time of a started chat completion stream after which a ': ping' SSE comment is sent to keep the connection open; disabled when unset"`
+	Compression             CompressionConfig      `yaml:"compression" envPrefix:"COMPRESSION_" description:"Gzip compression of the responses of clients accepting it"`
+}
+
+// PriorityConfig represents the priority tiers of the requests waiting for a
+// slot of ServerConfig.MaxConcurrency. The requests wait in arrival order when
+// Tiers is empty.
+type PriorityConfig struct {
+	Tiers       []string            `yaml:"tiers" env:"TIERS" envSeparator:"," description:"Names of the priority tiers, highest first, picked by requests with the X-Priority header; disabled when empty" jsonschema:"minLength=1"`
+	DefaultTier string              `yaml:"default_tier" env:"DEFAULT_TIER" description:"Tier of the requests without one, defaults to the last tier"`
+	APIKeys     map[string][]string `yaml:"api_keys,omitempty" secret:"true" description:"Gateway API keys by tier, the tier of a key taking precedence over the X-Priority header"`
+}
+
+// Tier returns the tier of a request with the given API key and X-Priority
+// header, and false when the header names an unknown tier.
+func (c PriorityConfig) Tier(apiKey, header string) (string, bool) {
+	if apiKey != "" {
+		for _, tier := range c.Tiers {
+			if slices.Contains(c.APIKeys[tier], apiKey) {
+				return tier, true
+			}
+		}
+	}
+	if header != "" {
+		return header, slices.Contains(c.Tiers, header)
+	}
+	return c.Default(), true
+}
+
+// Default returns the tier of the requests without one, DefaultTier or else
+// the last tier.
+func (c PriorityConfig) Default() string {
+	if c.DefaultTier != "" || len(c.Tiers) == 0 {
+		return c.DefaultTier
+	}
+	return c.Tiers[len(c.Tiers)-1]
+}
+
+// UnixSocketConfig represents the Unix domain socket the server listens on.
+type UnixSocketConfig struct {
+	Path string `yaml:"path" env:"PATH" description:"Path of the socket"`
+	Mode string `yaml:"mode" env:"MODE" description:"Octal file mode of the socket" jsonschema:"default=0660,pattern=^(0?[0-7]{3})?$"`
+}
+
+// CompressionConfig represents the gzip compression of the responses.
+type CompressionConfig struct {
+	Enabled   bool `yaml:"enabled" env:"ENABLED" jsonschema:"default=false"`
+	MinLength int  `yaml:"min_length" env:"MIN_LENGTH" description:"Size in bytes from which responses are compressed, defaults to 1024; streamed responses are always compressed" jsonschema:"minimum=0"`
+}
+
+// CORSConfig represents the CORS policy of the /v1 endpoints.
+// CORS is disabled when AllowedOrigins is empty.
+type CORSConfig struct {
+	AllowedOrigins   []string      `yaml:"allowed_origins" env:"ALLOWED_ORIGINS" envSeparator:"," description:"Origins allowed to call the API, \"*\" allows any origin"`
+	AllowedMethods   []string      `yaml:"allowed_methods" env:"ALLOWED_METHODS" envSeparator:"," description:"Methods allowed in cross-origin requests, defaults to GET, POST and OPTIONS"`
+	AllowedHeaders   []string      `yaml:"allowed_headers" env:"ALLOWED_HEADERS" envSeparator:"," description:"Headers allowed in cross-origin requests, defaults to Authorization, Content-Type and X-Request-ID"`
+	AllowCredentials bool          `yaml:"allow_credentials" env:"ALLOW_CREDENTIALS" description:"Allow cookies and authorization headers in cross-origin requests" jsonschema:"default=false"`
+	MaxAge           time.Duration `yaml:"max_age" env:"MAX_AGE" description:"How long browsers may cache a preflight response, not sent when unset"`
+}
+
+// RateLimitConfig represents the rate limits of the /v1 endpoints.
+type RateLimitConfig struct {
+	Global    RateLimit `yaml:"global" envPrefix:"GLOBAL_" description:"Limit shared by all clients"`
+	PerAPIKey RateLimit `yaml:"per_api_key" envPrefix:"PER_API_KEY_" description:"Limit applied to each gateway API key"`
+}
+
+// RateLimit is a token bucket refilled at RequestsPerSecond and holding up to Burst requests.
+// It is disabled when RequestsPerSecond is 0.
+type RateLimit struct {
+	RequestsPerSecond float64 `yaml:"requests_per_second" env:"REQUESTS_PER_SECOND" description:"Token bucket refill rate, the limit is disabled when 0" jsonschema:"default=0,minimum=0"`
+	Burst             int     `yaml:"burst" env:"BURST" description:"Token bucket size, defaults to one second worth of requests" jsonschema:"minimum=0"`
+}
+
+// ReadinessConfig represents the configuration of the /readyz endpoint.
+type ReadinessConfig struct {
+	ProbeProviders bool          `yaml:"probe_providers" env:"PROBE_PROVIDERS" envDefault:"false" description:"Probe the provider base URLs when checking readiness"`
+	CacheTTL       time.Duration `yaml:"cache_ttl" env:"CACHE_TTL" envDefault:"5s" description:"How long a readiness result is cached"`
+}
+
+// HealthCheckConfig represents the background health checks of the providers.
+type HealthCheckConfig struct {
+	Interval time.Duration `yaml:"interval" env:"INTERVAL" description:"Interval between two checks of every provider, disabled when unset"`
 }
 
 // LoggingConfig represents the logging configuration.
 type LoggingConfig struct {
-	Level string `yaml:"level" env:"LEVEL" envDefault:"info"`
+	Level      string  `yaml:"level" env:"LEVEL" envDefault:"info" description:"Log level" jsonschema:"enum=trace|debug|info|warn|error|fatal"`
+	Format     string  `yaml:"format,omitempty" env:"FORMAT" description:"Log format" jsonschema:"default=json,enum=json|text"`
+	Output     string  `yaml:"output,omitempty" env:"OUTPUT" description:"Log output: stdout, stderr or a file path" jsonschema:"default=stdout"`
+	SampleRate float64 `yaml:"sample_rate" env:"SAMPLE_RATE" description:"Fraction of successful requests written to the access log, chosen by request ID; other responses are always logged" jsonschema:"default=1,minimum=0,maximum=1"`
+}
+
+// TracingConfig represents the OpenTelemetry tracing configuration.
+type TracingConfig struct {
+	Endpoint   string  `yaml:"endpoint" env:"ENDPOINT" description:"URL of the OTLP/HTTP collector, spans are not exported when empty"`
+	SampleRate float64 `yaml:"sample_rate" env:"SAMPLE_RATE" description:"Fraction of new traces that are sampled; traces started upstream follow the decision of their parent" jsonschema:"default=1,minimum=0,maximum=1"`
+}
+
+// AuditConfig represents the audit log of the served chat completions.
+type AuditConfig struct {
+	Enabled        bool   `yaml:"enabled" env:"ENABLED" jsonschema:"default=false"`
+	Destination    string `yaml:"destination,omitempty" env:"DESTINATION" description:"stdout or a file path" jsonschema:"default=stdout"`
+	IncludeContent bool   `yaml:"include_content" env:"INCLUDE_CONTENT" description:"Record the request messages and the response choices, otherwise only metadata is recorded" jsonschema:"default=false"`
+}
+
+// MetricsConfig represents the metrics reported besides the /metrics endpoint.
+type MetricsConfig struct {
+	SummaryInterval time.Duration `yaml:"summary_interval" env:"SUMMARY_INTERVAL" description:"Period of a log line summing up the requests, tokens, errors and fallbacks since the previous one; disabled when unset"`
 }
 
 // ModelConfig represents the configuration for a specific model.
 type ModelConfig struct {
-	ID       string   `yaml:"id"`
-	Name     string   `yaml:"name"`
-	Provider string   `yaml:"provider"`
-	Fallback []string `yaml:"fallback"`
+	ID                         string           `yaml:"id" description:"Unique identifier for the model" jsonschema:"required"`
+	Name                       string           `yaml:"name" description:"Name of the model" jsonschema:"required"`
+	Provider                   string           `yaml:"provider" description:"Provider ID that this model uses" jsonschema:"required"`
+	Fallback                   []string         `yaml:"fallback" description:"List of fallback model IDs"`
+	Enabled                    *bool            `yaml:"enabled,omitempty" description:"Whether the model is in service; a disabled model is skipped in favor of its fallbacks" jsonschema:"default=true"`
+	FallbackStrategy           FallbackStrategy `yaml:"fallback_strategy,omitempty" description:"Order in which the model and its fallbacks are tried: as configured, random, or lowest recent error rate first" jsonschema:"default=ordered,enum=ordered|random|least_errors"`
+	MaxFallbackAttempts        int              `yaml:"max_fallback_attempts,omitempty" description:"Maximum number of models attempted for the requests of the model, overriding fallback.max_fallback_attempts when set" jsonschema:"minimum=0"`
+	PricePer1KPromptTokens     float64          `yaml:"price_per_1k_prompt_tokens,omitempty" description:"Price in USD per 1000 prompt tokens, used for the cost metric" jsonschema:"minimum=0"`
+	PricePer1KCompletionTokens float64          `yaml:"price_per_1k_completion_tokens,omitempty" description:"Price in USD per 1000 completion tokens, used for the cost metric" jsonschema:"minimum=0"`
+	Defaults                   *ModelDefaults   `yaml:"defaults,omitempty" description:"Request parameters applied when a request omits them"`
+	Limits                     *ModelLimits     `yaml:"limits,omitempty" description:"Hard bounds on the chat completion parameters, clamped or rejected with reject_on_exceed"`
+	ContextWindow              int              `yaml:"context_window,omitempty" description:"Tokens the model accepts, prompt and completion together; chat completions estimated not to fit are rejected, unchecked when 0" jsonschema:"minimum=0"`
+	Tokenizer                  string           `yaml:"tokenizer,omitempty" description:"Token estimation of the model: a tiktoken encoding such as p50k_base, or chars for a token per 4 characters; chosen from the model name when unset" jsonschema:"enum=cl100k_base|p50k_base|r50k_base|chars"`
+	SystemPrompt               string           `yaml:"system_prompt,omitempty" description:"System message prepended to chat completions that don't have one"`
+	AlwaysPrependSystemPrompt  bool             `yaml:"always_prepend_system_prompt,omitempty" description:"Prepend the system prompt even to chat completions that have a system message"`
+	Transforms                 []string         `yaml:"transforms,omitempty" description:"Names of the transforms applied, in order, to the chat completions of the model after the ones of its provider"`
+	OmitSamplingParams         bool             `yaml:"omit_sampling_params,omitempty" description:"Send chat completions without temperature, top_p, presence_penalty and frequency_penalty, for reasoning models rejecting them" jsonschema:"default=false"`
+}
+
+// IsEnabled reports whether the model is in service.
+func (m *ModelConfig) IsEnabled() bool {
+	return m.Enabled == nil || *m.Enabled
+}
+
+// FallbackStrategy controls the order in which a model and its fallbacks are tried.
+type FallbackStrategy string
+
+const (
+	// FallbackStrategyOrdered tries the model, then its fallbacks in the configured order.
+	FallbackStrategyOrdered FallbackStrategy = "ordered"
+	// FallbackStrategyRandom tries the model and its fallbacks in a random order.
+	FallbackStrategyRandom FallbackStrategy = "random"
+	// FallbackStrategyLeastErrors tries the models with the lowest recent error
+	// rate first, in the configured order among equal rates.
+	FallbackStrategyLeastErrors FallbackStrategy = "least_errors"
+)
+
+// ModelDefaults are request parameters applied when a request doesn't set them.
+// The defaults of the requested model also apply to its fallback models.
+type ModelDefaults struct {
+	MaxTokens      *int     `yaml:"max_tokens,omitempty" description:"Default maximum number of tokens to generate" jsonschema:"minimum=1"`
+	Temperature    *float32 `yaml:"temperature,omitempty" description:"Default sampling temperature" jsonschema:"minimum=0,maximum=2"`
+	TopP           *float32 `yaml:"top_p,omitempty" description:"Default nucleus sampling probability" jsonschema:"minimum=0,maximum=1"`
+	EncodingFormat *string  `yaml:"encoding_format,omitempty" description:"Default format of the returned embeddings" jsonschema:"enum=float"`
+}
+
+// ModelLimits bound the chat completion parameters sent to a model, after the
+// defaults are applied. Parameters out of bounds are clamped, or rejected with
+// RejectOnExceed. Unset bounds aren't enforced.
+type ModelLimits struct {
+	MaxTokensCeiling int      `yaml:"max_tokens_ceiling,omitempty" description:"Maximum max_tokens, also used when a request omits max_tokens" jsonschema:"minimum=1"`
+	TemperatureMin   *float32 `yaml:"temperature_min,omitempty" description:"Minimum temperature" jsonschema:"minimum=0,maximum=2"`
+	TemperatureMax   *float32 `yaml:"temperature_max,omitempty" description:"Maximum temperature" jsonschema:"minimum=0,maximum=2"`
+	TopPMin          *float32 `yaml:"top_p_min,omitempty" description:"Minimum top_p" jsonschema:"minimum=0,maximum=1"`
+	TopPMax          *float32 `yaml:"top_p_max,omitempty" description:"Maximum top_p" jsonschema:"minimum=0,maximum=1"`
+	RejectOnExceed   bool     `yaml:"reject_on_exceed,omitempty" description:"Reject requests out of bounds with a 400 instead of clamping them" jsonschema:"default=false"`
 }
 
 type ProviderName string
@@ -56,6 +287,10 @@ const (
 	ProviderOllama      ProviderName = "ollama"
 	ProviderHuggingFace ProviderName = "huggingface"
 	ProviderVertexAI    ProviderName = "vertex_ai"
+	ProviderBedrock     ProviderName = "bedrock"
+	ProviderMistral     ProviderName = "mistral"
+	ProviderCohere      ProviderName = "cohere"
+	ProviderOpenRouter  ProviderName = "openrouter"
 	ProviderDummy       ProviderName = "dummy"
 )
 
@@ -67,48 +302,147 @@ var configTypeFactories = map[ProviderName]func() ProviderConfigInterface{
 	ProviderOllama:      func() ProviderConfigInterface { return &OllamaProviderConfig{} },
 	ProviderHuggingFace: func() ProviderConfigInterface { return &HuggingFaceProviderConfig{} },
 	ProviderVertexAI:    func() ProviderConfigInterface { return &VertexAIProviderConfig{} },
+	ProviderBedrock:     func() ProviderConfigInterface { return &BedrockProviderConfig{} },
+	ProviderMistral:     func() ProviderConfigInterface { return &MistralProviderConfig{} },
+	ProviderCohere:      func() ProviderConfigInterface { return &CohereProviderConfig{} },
+	ProviderOpenRouter:  func() ProviderConfigInterface { return &OpenRouterProviderConfig{} },
 	ProviderDummy:       func() ProviderConfigInterface { return &DummyProviderConfig{} },
 }
 
 type OpenAIProviderConfig struct {
-	APIKey     string `yaml:"api_key" env:"OPENAI_API_KEY"`
-	APIUrl     string `yaml:"api_url" env:"OPENAI_API_URL" envDefault:"https://api.openai.com"`
-	OrgID      string `yaml:"org_id" env:"OPENAI_ORG_ID"`
-	ApiVersion string `yaml:"api_version" env:"OPENAI_API_VERSION" envDefault:"v1"`
+	APIKey      string        `yaml:"api_key" env:"OPENAI_API_KEY" secret:"true" description:"OpenAI API key"`
+	APIKeys     []string      `yaml:"api_keys" env:"OPENAI_API_KEYS" envSeparator:"," secret:"true" description:"OpenAI API keys rotated round-robin, takes precedence over api_key"`
+	KeyCooldown time.Duration `yaml:"key_cooldown" env:"OPENAI_KEY_COOLDOWN" description:"How long a key rejected with a 401 is skipped" jsonschema:"default=1m"`
+	APIUrl      string        `yaml:"api_url" env:"OPENAI_API_URL" envDefault:"https://api.openai.com" description:"OpenAI API URL"`
+	OrgID       string        `yaml:"org_id" env:"OPENAI_ORG_ID" description:"OpenAI organization ID"`
+	ApiVersion  string        `yaml:"api_version" env:"OPENAI_API_VERSION" envDefault:"v1" description:"OpenAI API version"`
+}
+
+// Keys returns the configured API keys, either APIKeys or the single APIKey.
+func (c *OpenAIProviderConfig) Keys() []string {
+	if len(c.APIKeys) > 0 {
+		return c.APIKeys
+	}
+	if c.APIKey != "" {
+		return []string{c.APIKey}
+	}
+	return nil
 }
 
 type AzureOpenAIProviderConfig struct {
-	APIKey     string         `yaml:"api_key" env:"AZURE_OPENAI_API_KEY"`
-	APIUrl     string         `yaml:"api_url" env:"AZURE_OPENAI_API_URL" envDefault:"https://{your-custom-endpoint}.openai.azure.com/"`
-	ApiVersion string         `yaml:"api_version" env:"AZURE_OPENAI_API_VERSION" envDefault:"v1"`
-	ApiType    openai.APIType `yaml:"api_type" env:"AZURE_OPENAI_API_TYPE" envDefault:"AZURE"`
+	APIKey      string            `yaml:"api_key" env:"AZURE_OPENAI_API_KEY" secret:"true" description:"Azure OpenAI API key"`
+	APIUrl      string            `yaml:"api_url" env:"AZURE_OPENAI_API_URL" envDefault:"https://{your-custom-endpoint}.openai.azure.com/" description:"Azure OpenAI API URL"`
+	ApiVersion  string            `yaml:"api_version" env:"AZURE_OPENAI_API_VERSION" envDefault:"2024-10-21" description:"Azure OpenAI API version"`
+	ApiType     openai.APIType    `yaml:"api_type" env:"AZURE_OPENAI_API_TYPE" envDefault:"AZURE" description:"Azure OpenAI API type"`
+	Deployments map[string]string `yaml:"deployments,omitempty" description:"Deployment names by model name; models without an entry use the deployment of the same name"`
 }
 
 type AnthropicProviderConfig struct {
-	APIKey string `yaml:"api_key" env:"ANTHROPIC_API_KEY"`
-	APIUrl string `yaml:"api_url" env:"ANTHROPIC_API_URL" envDefault:"https://api.anthropic.com/v1"`
+	APIKey string `yaml:"api_key" env:"ANTHROPIC_API_KEY" secret:"true" description:"Anthropic API key"`
+	APIUrl string `yaml:"api_url" env:"ANTHROPIC_API_URL" envDefault:"https://api.anthropic.com/v1" description:"Anthropic API URL"`
 }
 
 type GeminiProviderConfig struct {
-	APIKey        string `yaml:"api_key" env:"GEMINI_API_KEY"`
-	CloudLocation string `yaml:"cloud_location" env:"GEMINI_CLOUD_LOCATION" envDefault:"us-central1"`
+	APIKey        string `yaml:"api_key" env:"GEMINI_API_KEY" secret:"true" description:"Gemini API key"`
+	CloudLocation string `yaml:"cloud_location" env:"GEMINI_CLOUD_LOCATION" envDefault:"us-central1" description:"Gemini cloud location"`
 }
 
 type OllamaProviderConfig struct {
-	APIUrl string `yaml:"api_url" env:"OLLAMA_API_URL" envDefault:"http://localhost:11434"`
+	APIUrl    string `yaml:"api_url" env:"OLLAMA_API_URL" envDefault:"http://localhost:11434" description:"Ollama API URL"`
+	KeepAlive string `yaml:"keep_alive,omitempty" env:"OLLAMA_KEEP_ALIVE" description:"How long the model stays loaded after a request, e.g. 10m, or -1 to keep it loaded; the Ollama default when unset"`
+	NumCtx    int    `yaml:"num_ctx,omitempty" env:"OLLAMA_NUM_CTX" description:"Context window size in tokens, the model default when unset" jsonschema:"minimum=1"`
+	Format    string `yaml:"format,omitempty" env:"OLLAMA_FORMAT" description:"Response format, e.g. json, unconstrained when unset"`
 }
 
 type HuggingFaceProviderConfig struct {
-	APIKey string `yaml:"api_key" env:"HF_TOKEN"`
-	APIUrl string `yaml:"api_url" env:"HF_API_URL" envDefault:"https://api-inference.huggingface.co"`
+	APIKey      string          `yaml:"api_key" env:"HF_TOKEN" secret:"true" description:"HuggingFace API key"`
+	Mode        HuggingFaceMode `yaml:"mode,omitempty" env:"HF_MODE" description:"HuggingFace API to target: the serverless inference API or a dedicated TGI endpoint" jsonschema:"default=serverless,enum=serverless|inference_endpoints"`
+	APIUrl      string          `yaml:"api_url" env:"HF_API_URL" envDefault:"https://api-inference.huggingface.co" description:"HuggingFace API URL"`
+	EndpointURL string          `yaml:"endpoint_url,omitempty" env:"HF_ENDPOINT_URL" description:"Base URL of the dedicated TGI server, required in inference_endpoints mode"`
 }
 
-type DummyProviderConfig struct{}
+// HuggingFaceMode is the HuggingFace API a huggingface provider targets.
+type HuggingFaceMode string
+
+const (
+	// HuggingFaceModeServerless targets the serverless inference API at APIUrl.
+	HuggingFaceModeServerless HuggingFaceMode = "serverless"
+	// HuggingFaceModeInferenceEndpoints targets a dedicated Text Generation
+	// Inference (TGI) server at EndpointURL.
+	HuggingFaceModeInferenceEndpoints HuggingFaceMode = "inference_endpoints"
+)
+
+func (c HuggingFaceProviderConfig) validate() error {
+	switch c.Mode {
+	case "", HuggingFaceModeServerless:
+		if c.EndpointURL != "" {
+			return fmt.Errorf("endpoint_url requires mode %q", HuggingFaceModeInferenceEndpoints)
+		}
+	case HuggingFaceModeInferenceEndpoints:
+		if c.EndpointURL == "" {
+			return fmt.Errorf("endpoint_url must be set in mode %q", HuggingFaceModeInferenceEndpoints)
+		}
+	default:
+		return fmt.Errorf("unknown mode %q", c.Mode)
+	}
+	return nil
+}
+
+type DummyProviderConfig struct {
+	Echo bool `yaml:"echo,omitempty" env:"DUMMY_ECHO" description:"Answer with the last user message instead of a fixed response" jsonschema:"default=false"`
+}
 
 type VertexAIProviderConfig struct {
-	ProjectID       string `yaml:"project_id" env:"VERTEX_AI_PROJECT_ID"`
-	Location        string `yaml:"location" env:"VERTEX_AI_LOCATION" envDefault:"us-central1"`
-	PathToCredsFile string `yaml:"path_to_creds_file" env:"VERTEX_AI_CREDS_FILE"`
+	ProjectID       string `yaml:"project_id" env:"VERTEX_AI_PROJECT_ID" description:"Vertex AI project ID"`
+	Location        string `yaml:"location" env:"VERTEX_AI_LOCATION" envDefault:"us-central1" description:"Vertex AI location"`
+	PathToCredsFile string `yaml:"path_to_creds_file" env:"VERTEX_AI_CREDS_FILE" secret:"true" description:"Path to Vertex AI credentials file"`
+	CredentialsJSON string `yaml:"credentials_json,omitempty" env:"VERTEX_AI_CREDS_JSON" secret:"true" description:"Vertex AI credentials as inline JSON, preferred over path_to_creds_file"`
+}
+
+func (c VertexAIProviderConfig) validate() error {
+	if (c.CredentialsJSON == "") == (c.PathToCredsFile == "") {
+		return fmt.Errorf("exactly one of credentials_json and path_to_creds_file must be set")
+	}
+	return nil
+}
+
+type MistralProviderConfig struct {
+	APIKey string `yaml:"api_key" env:"MISTRAL_API_KEY" secret:"true" description:"Mistral API key"`
+	APIUrl string `yaml:"api_url" env:"MISTRAL_API_URL" envDefault:"https://api.mistral.ai" description:"Mistral API URL"`
+}
+
+type CohereProviderConfig struct {
+	APIKey string `yaml:"api_key" env:"COHERE_API_KEY" secret:"true" description:"Cohere API key"`
+	APIUrl string `yaml:"api_url" env:"COHERE_API_URL" envDefault:"https://api.cohere.ai" description:"Cohere API URL"`
+}
+
+// OpenRouterProviderConfig represents the configuration of the OpenRouter
+// provider. Model names, e.g. anthropic/claude-3.5-sonnet, are sent as is.
+type OpenRouterProviderConfig struct {
+	APIKey  string `yaml:"api_key" env:"OPENROUTER_API_KEY" secret:"true" description:"OpenRouter API key"`
+	APIUrl  string `yaml:"api_url" env:"OPENROUTER_API_URL" envDefault:"https://openrouter.ai/api/v1" description:"OpenRouter API URL"`
+	Referer string `yaml:"referer,omitempty" env:"OPENROUTER_REFERER" description:"Site URL sent in the HTTP-Referer header to identify the application, not sent when empty"`
+	Title   string `yaml:"title,omitempty" env:"OPENROUTER_TITLE" description:"Application name sent in the X-Title header"`
+}
+
+// BedrockProviderConfig represents the configuration of the AWS Bedrock provider.
+// The default AWS credentials chain is used when no access key is set.
+type BedrockProviderConfig struct {
+	Region          string `yaml:"region" env:"AWS_REGION" description:"AWS region"`
+	AccessKeyID     string `yaml:"access_key_id" env:"AWS_ACCESS_KEY_ID" secret:"true" description:"AWS access key ID, the default credentials chain is used when empty"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"AWS_SECRET_ACCESS_KEY" secret:"true" description:"AWS secret access key"`
+	SessionToken    string `yaml:"session_token" env:"AWS_SESSION_TOKEN" secret:"true" description:"AWS session token for temporary credentials"`
+	ModelARN        string `yaml:"model_arn" env:"BEDROCK_MODEL_ARN" description:"Model ARN invoked instead of the model name, e.g. for provisioned throughput"`
+}
+
+func (c BedrockProviderConfig) validate() error {
+	if c.Region == "" {
+		return fmt.Errorf("region is required")
+	}
+	if (c.AccessKeyID == "") != (c.SecretAccessKey == "") {
+		return fmt.Errorf("access_key_id and secret_access_key must be set together")
+	}
+	return nil
 }
 
 func (OpenAIProviderConfig) isProviderConfig()      {}
@@ -118,37 +452,149 @@ func (GeminiProviderConfig) isProviderConfig()      {}
 func (OllamaProviderConfig) isProviderConfig()      {}
 func (HuggingFaceProviderConfig) isProviderConfig() {}
 func (VertexAIProviderConfig) isProviderConfig()    {}
+func (BedrockProviderConfig) isProviderConfig()     {}
+func (MistralProviderConfig) isProviderConfig()     {}
+func (CohereProviderConfig) isProviderConfig()      {}
+func (OpenRouterProviderConfig) isProviderConfig()  {}
 func (DummyProviderConfig) isProviderConfig()       {}
 
 type ProviderConfigInterface interface {
 	isProviderConfig()
 }
 
+// providerConfigValidator is implemented by provider configs with checks
+// that need the environment overrides applied, which the JSON schema can't see.
+type providerConfigValidator interface {
+	validate() error
+}
+
 type ProviderConfig struct {
-	ID       string                  `yaml:"id"`
-	Provider ProviderName            `yaml:"provider"`
-	Config   ProviderConfigInterface `yaml:"-"`
-	Raw      yaml.Node               `yaml:"config"`
+	ID             string                     `yaml:"id" description:"Unique identifier for the provider" jsonschema:"required"`
+	Provider       ProviderName               `yaml:"provider" description:"Provider type" jsonschema:"required"`
+	Config         ProviderConfigInterface    `yaml:"-" json:"config"`
+	Raw            yaml.Node                  `yaml:"config" json:"-" description:"Provider-specific configuration"`
+	Enabled        *bool                      `yaml:"enabled,omitempty" description:"Whether the provider is in service; a disabled provider isn't created and its models are skipped" jsonschema:"default=true"`
+	Timeout        time.Duration              `yaml:"timeout,omitempty" description:"Maximum wait of an upstream call for the response or the next data of its body, so that streams aren't cut" jsonschema:"default=60s"`
+	Retry          *client.RetryConfig        `yaml:"retry,omitempty" description:"Retry policy for failed upstream calls (network errors, 429 and 5xx responses), disabled when unset"`
+	ConnectRetry   *client.ConnectRetryConfig `yaml:"connect_retry,omitempty" description:"Retry policy for upstream calls that fail to connect (DNS errors, refused connections) only, used when retry is unset"`
+	TLS            *client.TLSConfig          `yaml:"tls,omitempty" description:"TLS settings of the connections to the provider, which then get a connection pool of their own"`
+	ProxyURL       string                     `yaml:"proxy_url,omitempty" secret:"true" description:"Forward proxy of the upstream calls, e.g. http://proxy:3128, overriding HTTP_PROXY and HTTPS_PROXY"`
+	CircuitBreaker *CircuitBreakerConfig      `yaml:"circuit_breaker,omitempty" description:"Circuit breaker skipping the provider after repeated failures, disabled when unset"`
+	MaxConcurrency int                        `yaml:"max_concurrency,omitempty" description:"Maximum number of concurrent upstream calls, unlimited when 0" jsonschema:"minimum=0"`
+	QueueBehavior  QueueBehavior              `yaml:"queue_behavior,omitempty" description:"Whether calls over max_concurrency wait for a free slot or fall back to the next model" jsonschema:"default=wait,enum=wait|fallback"`
+	QueueTimeout   time.Duration              `yaml:"queue_timeout,omitempty" description:"Maximum wait for a free slot with the wait queue behavior; only bounded by the request when unset"`
+	Headers        map[string]string          `yaml:"headers,omitempty" secret:"true" description:"Headers set on every upstream request"`
+	DefaultParams  *ModelDefaults             `yaml:"default_params,omitempty" description:"Request parameters applied when neither the request nor the defaults of its model set them"`
+	Transforms     []string                   `yaml:"transforms,omitempty" description:"Names of the transforms applied, in order, to the chat completion requests sent to the provider and to its responses"`
+}
+
+// IsEnabled reports whether the provider is in service.
+func (p *ProviderConfig) IsEnabled() bool {
+	return p.Enabled == nil || *p.Enabled
+}
+
+// EnabledProviders returns the providers that are in service.
+func (c *Config) EnabledProviders() []*ProviderConfig {
+	var providers []*ProviderConfig
+	for _, p := range c.Providers {
+		if p.IsEnabled() {
+			providers = append(providers, p)
+		}
+	}
+	return providers
+}
+
+// QueueBehavior controls calls to a provider that reached its MaxConcurrency.
+type QueueBehavior string
+
+const (
+	// QueueBehaviorWait waits for a free slot, this is the default.
+	QueueBehaviorWait QueueBehavior = "wait"
+	// QueueBehaviorFallback skips the provider and tries the next model right away.
+	QueueBehaviorFallback QueueBehavior = "fallback"
+)
+
+// CircuitBreakerConfig represents the circuit breaker configuration of a provider.
+type CircuitBreakerConfig struct {
+	FailureThreshold int           `yaml:"failure_threshold" description:"Number of consecutive failures that opens the breaker" jsonschema:"required,minimum=1"`
+	Window           time.Duration `yaml:"window,omitempty" description:"Period the consecutive failures must happen in, unlimited when unset"`
+	Cooldown         time.Duration `yaml:"cooldown" description:"How long the breaker stays open before probing again" jsonschema:"default=30s"`
+	HalfOpenRequests int           `yaml:"half_open_requests" description:"Number of probe requests allowed while half-open" jsonschema:"default=1,minimum=1"`
 }
 
-// Load loads the configuration from a file and/or environment variables.
-// The config file path is read from the `CONFIG_PATH` environment variable.
-// If `CONFIG_PATH` is not set, it defaults to `config.yml`.
+// defaultPath is the config file loaded when `CONFIG_PATH` is not set.
+const defaultPath = "config.yml"
+
+// Load loads the configuration from files and/or environment variables.
+// The config file paths are read from the `CONFIG_PATH` environment variable,
+// a comma-separated list of files merged in order, which must all exist.
+// If `CONFIG_PATH` is not set, it defaults to `config.yml`, which may be missing.
 func Load() (*Config, error) {
-	configPath := os.Getenv("CONFIG_PATH")
-	if configPath == "" {
-		configPath = "config.yml"
+	paths := listedPaths()
+	if len(paths) == 0 {
+		if _, err := os.Stat(defaultPath); errors.Is(err, fs.ErrNotExist) {
+			return LoadFiles()
+		}
+		paths = []string{defaultPath}
 	}
+	return LoadFiles(paths...)
+}
+
+// Paths returns the paths of the config files: the comma-separated list of
+// `CONFIG_PATH`, or `config.yml`.
+func Paths() []string {
+	if paths := listedPaths(); len(paths) > 0 {
+		return paths
+	}
+	return []string{defaultPath}
+}
+
+// listedPaths returns the paths listed in `CONFIG_PATH`.
+func listedPaths() []string {
+	var paths []string
+	for _, path := range strings.Split(os.Getenv("CONFIG_PATH"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
 
-	var cfg Config
-	// Load config from file if it exists
-	if _, err := os.Stat(configPath); err == nil {
+// LoadFile loads the configuration from the file at configPath and environment
+// variables, then validates it.
+func LoadFile(configPath string) (*Config, error) {
+	return LoadFiles(configPath)
+}
+
+// LoadFiles loads the configuration from the files at configPaths, which must
+// exist, and environment variables, then validates it. Later files override
+// earlier ones: their settings are deep-merged, and their providers and models
+// are merged with the ones of the same ID or appended.
+func LoadFiles(configPaths ...string) (*Config, error) {
+	// envDefault would override the file settings, so defaults that a file
+	// can set to a zero value are applied up front.
+	cfg := Config{
+		Logging: LoggingConfig{SampleRate: 1},
+		Tracing: TracingConfig{SampleRate: 1},
+	}
+	// Load config from the files
+	var merger configMerger
+	for _, configPath := range configPaths {
 		data, err := os.ReadFile(configPath)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read config file: %w", err)
 		}
 
-		if err := yaml.Unmarshal(data, &cfg); err != nil {
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config %s: %w", configPath, err)
+		}
+		if err := merger.merge(configPath, &doc); err != nil {
+			return nil, fmt.Errorf("failed to merge config: %w", err)
+		}
+	}
+	if merger.doc != nil {
+		if err := merger.doc.Decode(&cfg); err != nil {
 			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 		}
 	}
@@ -180,6 +626,17 @@ func Load() (*Config, error) {
 		}
 	}
 
+	if err := resolveSecrets(&cfg); err != nil {
+		return nil, fmt.Errorf("failed to resolve config references: %w", err)
+	}
+	for _, providerCfg := range cfg.Providers {
+		if v, ok := providerCfg.Config.(providerConfigValidator); ok {
+			if err := v.validate(); err != nil {
+				return nil, fmt.Errorf("invalid provider config for %q: %w", providerCfg.ID, err)
+			}
+		}
+	}
+
 	configBytes, err := yaml.Marshal(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal config to yaml: %w", err)
@@ -189,5 +646,9 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("router config validation error: %w", err)
 	}
 
+	if err := cfg.validateReferences(); err != nil {
+		return nil, fmt.Errorf("router config validation error: %w", err)
+	}
+
 	return &cfg, nil
 }
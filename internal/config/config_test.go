@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -35,6 +38,9 @@ models:
     name: test-model-name
     provider: openai-test
     fallback: ["fallback-model"]
+  - id: fallback-model
+    name: fallback-model-name
+    provider: openai-test
 `)
 	assert.NoError(t, err)
 	tmpFile.Close()
@@ -56,7 +62,7 @@ models:
 	assert.True(t, ok)
 	assert.Equal(t, "test-key", openAIConfig.APIKey)
 	assert.Equal(t, "http://test.url", openAIConfig.APIUrl)
-	assert.Len(t, cfg.Models, 1)
+	assert.Len(t, cfg.Models, 2)
 	assert.Equal(t, "test-model", cfg.Models[0].ID)
 	assert.Equal(t, "test-model-name", cfg.Models[0].Name)
 	assert.Equal(t, "openai-test", cfg.Models[0].Provider)
@@ -95,13 +101,21 @@ logging:
 }
 
 func TestLoadConfigNotFound(t *testing.T) {
-	// Ensure CONFIG_PATH is not set to a valid file
-	os.Setenv("CONFIG_PATH", "nonexistent.yml")
-	defer os.Unsetenv("CONFIG_PATH")
-
+	// The default config.yml is optional.
+	t.Setenv("CONFIG_PATH", "")
 	cfg, err := Load()
 	assert.NoError(t, err)
 	assert.NotNil(t, cfg)
+
+	// The files listed in CONFIG_PATH aren't.
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	assert.NoError(t, os.WriteFile(base, []byte("server:\n  port: \"9000\"\n"), 0o600))
+	missing := filepath.Join(dir, "missing.yml")
+	t.Setenv("CONFIG_PATH", base+","+missing)
+	cfg, err = Load()
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, missing)
 }
 
 func TestLoadProviderEnvOverride(t *testing.T) {
@@ -135,3 +149,565 @@ providers:
 	assert.True(t, ok)
 	assert.Equal(t, "env-key", openAIConfig.APIKey)
 }
+
+func TestLoadProviderTimeout(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+providers:
+  - id: dummy-test
+    provider: dummy
+    timeout: 30s
+    config: {}
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	assert.Len(t, cfg.Providers, 1)
+	assert.Equal(t, 30*time.Second, cfg.Providers[0].Timeout)
+}
+
+func TestLoadEnabled(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+providers:
+  - id: dummy-test
+    provider: dummy
+    config: {}
+  - id: dummy-disabled
+    provider: dummy
+    enabled: false
+    config: {}
+models:
+  - id: dummy-model
+    name: dummy
+    provider: dummy-test
+    enabled: false
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+
+	assert.True(t, cfg.Providers[0].IsEnabled())
+	assert.False(t, cfg.Providers[1].IsEnabled())
+	assert.Equal(t, []*ProviderConfig{cfg.Providers[0]}, cfg.EnabledProviders())
+	assert.False(t, cfg.Models[0].IsEnabled())
+}
+
+func TestLoadTracing(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+tracing:
+  endpoint: http://otel-collector:4318
+  sample_rate: 0.25
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	assert.Equal(t, "http://otel-collector:4318", cfg.Tracing.Endpoint)
+	assert.Equal(t, 0.25, cfg.Tracing.SampleRate)
+}
+
+func TestLoadOpenAIKeys(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+providers:
+  - id: openai-test
+    provider: openai
+    config:
+      api_keys: [key-1, key-2]
+      key_cooldown: 5m
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	openaiCfg := cfg.Providers[0].Config.(*OpenAIProviderConfig)
+	assert.Equal(t, []string{"key-1", "key-2"}, openaiCfg.Keys())
+	assert.Equal(t, 5*time.Minute, openaiCfg.KeyCooldown)
+	assert.Equal(t, []string{"single-key"}, (&OpenAIProviderConfig{APIKey: "single-key"}).Keys())
+}
+
+func TestLoadProviderHeaders(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+server:
+  forward_headers: [X-Tenant-ID]
+providers:
+  - id: vllm
+    provider: openai
+    headers:
+      X-Api-Org: ${TEST_API_ORG}
+      X-Price: $5
+    config:
+      api_url: http://vllm:8000
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+	t.Setenv("TEST_API_ORG", "acme")
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	assert.NotNil(t, cfg)
+
+	assert.Equal(t, []string{"X-Tenant-ID"}, cfg.Server.ForwardHeaders)
+	assert.Equal(t, map[string]string{"X-Api-Org": "acme", "X-Price": "$5"}, cfg.Providers[0].Headers)
+}
+
+func TestLoadBedrockProvider(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "valid",
+			config: `
+providers:
+  - id: bedrock-test
+    provider: bedrock
+    config:
+      region: us-east-1
+      model_arn: arn:aws:bedrock:us-east-1:123456789012:provisioned-model/abc
+`,
+		},
+		{
+			name: "missing region",
+			config: `
+providers:
+  - id: bedrock-test
+    provider: bedrock
+    config: {}
+`,
+			wantErr: "region is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config-*.yml")
+			assert.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			_, err = tmpFile.WriteString(tt.config)
+			assert.NoError(t, err)
+			tmpFile.Close()
+
+			os.Setenv("CONFIG_PATH", tmpFile.Name())
+			defer os.Unsetenv("CONFIG_PATH")
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			bedrockCfg, ok := cfg.Providers[0].Config.(*BedrockProviderConfig)
+			assert.True(t, ok)
+			assert.Equal(t, "us-east-1", bedrockCfg.Region)
+		})
+	}
+}
+
+func TestLoadVertexAICredentials(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		env     string
+		wantErr string
+	}{
+		{
+			name: "credentials file",
+			config: `
+providers:
+  - id: vertex-test
+    provider: vertex_ai
+    config:
+      project_id: project
+      path_to_creds_file: /secrets/creds.json
+`,
+		},
+		{
+			name: "inline credentials from env",
+			config: `
+providers:
+  - id: vertex-test
+    provider: vertex_ai
+    config:
+      project_id: project
+`,
+			env: `{"type": "service_account"}`,
+		},
+		{
+			name: "both",
+			config: `
+providers:
+  - id: vertex-test
+    provider: vertex_ai
+    config:
+      project_id: project
+      path_to_creds_file: /secrets/creds.json
+      credentials_json: '{"type": "service_account"}'
+`,
+			wantErr: "exactly one of credentials_json and path_to_creds_file must be set",
+		},
+		{
+			name: "neither",
+			config: `
+providers:
+  - id: vertex-test
+    provider: vertex_ai
+    config:
+      project_id: project
+`,
+			wantErr: "exactly one of credentials_json and path_to_creds_file must be set",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config-*.yml")
+			assert.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			_, err = tmpFile.WriteString(tt.config)
+			assert.NoError(t, err)
+			tmpFile.Close()
+
+			t.Setenv("CONFIG_PATH", tmpFile.Name())
+			if tt.env != "" {
+				t.Setenv("VERTEX_AI_CREDS_JSON", tt.env)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			vertexCfg := cfg.Providers[0].Config.(*VertexAIProviderConfig)
+			assert.Equal(t, tt.env, vertexCfg.CredentialsJSON)
+		})
+	}
+}
+
+func TestLoadHuggingFaceMode(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		mode    HuggingFaceMode
+		wantErr string
+	}{
+		{
+			name: "serverless",
+			config: `
+providers:
+  - id: hf
+    provider: huggingface
+    config:
+      api_key: hf-key
+`,
+		},
+		{
+			name: "inference endpoint",
+			config: `
+providers:
+  - id: hf
+    provider: huggingface
+    config:
+      mode: inference_endpoints
+      endpoint_url: https://tgi.internal
+`,
+			mode: HuggingFaceModeInferenceEndpoints,
+		},
+		{
+			name: "inference endpoint without URL",
+			config: `
+providers:
+  - id: hf
+    provider: huggingface
+    config:
+      mode: inference_endpoints
+`,
+			wantErr: `endpoint_url must be set in mode "inference_endpoints"`,
+		},
+		{
+			name: "serverless with endpoint URL",
+			config: `
+providers:
+  - id: hf
+    provider: huggingface
+    config:
+      endpoint_url: https://tgi.internal
+`,
+			wantErr: `endpoint_url requires mode "inference_endpoints"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile, err := os.CreateTemp("", "config-*.yml")
+			assert.NoError(t, err)
+			defer os.Remove(tmpFile.Name())
+
+			_, err = tmpFile.WriteString(tt.config)
+			assert.NoError(t, err)
+			tmpFile.Close()
+
+			t.Setenv("CONFIG_PATH", tmpFile.Name())
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			hfCfg := cfg.Providers[0].Config.(*HuggingFaceProviderConfig)
+			assert.Equal(t, tt.mode, hfCfg.Mode)
+		})
+	}
+}
+
+func TestLoadConfigInvalidReferences(t *testing.T) {
+	tmpFile, err := os.CreateTemp("", "config-*.yml")
+	assert.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString(`
+providers:
+  - id: dummy-test
+    provider: dummy
+    config: {}
+models:
+  - id: model-a
+    name: model-a
+    provider: dummy-test
+    fallback: ["model-b"]
+  - id: model-b
+    name: model-b
+    provider: dummy-test
+    fallback: ["model-a", "missing-model"]
+  - id: model-c
+    name: model-c
+    provider: missing-provider
+  - id: model-d
+    name: model-d
+    provider: dummy-test
+    fallback: ["model-d"]
+aliases:
+  model-a: model-b
+  gpt-4o: missing-model
+default_model: gpt-4
+server:
+  priority:
+    tiers: [interactive, batch]
+    default_tier: normal
+    api_keys:
+      interactive: [key-1]
+      realtime: [key-2]
+`)
+	assert.NoError(t, err)
+	tmpFile.Close()
+
+	os.Setenv("CONFIG_PATH", tmpFile.Name())
+	defer os.Unsetenv("CONFIG_PATH")
+
+	cfg, err := Load()
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, `model "model-b" references unknown fallback model "missing-model"`)
+	assert.ErrorContains(t, err, `model "model-c" references unknown provider "missing-provider"`)
+	assert.ErrorContains(t, err, "fallback cycle: model-a -> model-b -> model-a")
+	assert.ErrorContains(t, err, "fallback cycle: model-d -> model-d")
+	assert.ErrorContains(t, err, `alias "model-a" shadows a model ID`)
+	assert.ErrorContains(t, err, `alias "gpt-4o" references unknown model "missing-model"`)
+	assert.ErrorContains(t, err, `default model "gpt-4" is neither a model nor an alias`)
+	assert.ErrorContains(t, err, `default priority tier "normal" is not a priority tier`)
+	assert.ErrorContains(t, err, `API keys reference unknown priority tier "realtime"`)
+	assert.ErrorContains(t, err, "priority tiers require max_concurrency")
+}
+
+func TestPriorityConfigTier(t *testing.T) {
+	cfg := PriorityConfig{
+		Tiers:   []string{"interactive", "normal", "batch"},
+		APIKeys: map[string][]string{"batch": {"batch-key"}},
+	}
+
+	tier, ok := cfg.Tier("", "")
+	assert.True(t, ok)
+	assert.Equal(t, "batch", tier)
+
+	tier, ok = cfg.Tier("other-key", "interactive")
+	assert.True(t, ok)
+	assert.Equal(t, "interactive", tier)
+
+	tier, ok = cfg.Tier("batch-key", "interactive")
+	assert.True(t, ok)
+	assert.Equal(t, "batch", tier)
+
+	_, ok = cfg.Tier("", "urgent")
+	assert.False(t, ok)
+
+	cfg.DefaultTier = "normal"
+	tier, _ = cfg.Tier("", "")
+	assert.Equal(t, "normal", tier)
+}
+
+func TestLoadConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	assert.NoError(t, os.WriteFile(base, []byte(`
+server:
+  port: "9000"
+logging:
+  format: text
+providers:
+  - id: openai-main
+    provider: openai
+    timeout: 30s
+    config:
+      api_key: base-key
+      org_id: base-org
+models:
+  - id: chat
+    name: gpt-4o
+    provider: openai-main
+    fallback: [backup]
+  - id: backup
+    name: gpt-4o-mini
+    provider: openai-main
+`), 0o600))
+	overlay := filepath.Join(dir, "overlay.yml")
+	assert.NoError(t, os.WriteFile(overlay, []byte(`
+logging:
+  sample_rate: 0.5
+providers:
+  - id: openai-main
+    provider: openai
+    config:
+      api_key: overlay-key
+  - id: dummy-test
+    provider: dummy
+    config: {}
+models:
+  - id: chat
+    name: gpt-4.1
+  - id: local
+    name: local
+    provider: dummy-test
+`), 0o600))
+
+	t.Setenv("CONFIG_PATH", base+", "+overlay+",")
+	t.Setenv("SERVER_PORT", "9100")
+	assert.Equal(t, []string{base, overlay}, Paths())
+
+	cfg, err := Load()
+	assert.NoError(t, err)
+	if !assert.NotNil(t, cfg) {
+		return
+	}
+
+	// Environment variables still override the merged files.
+	assert.Equal(t, "9100", cfg.Server.Port)
+	assert.Equal(t, "text", cfg.Logging.Format)
+	assert.Equal(t, 0.5, cfg.Logging.SampleRate)
+
+	// The provider of both files is merged, keeping what the overlay doesn't set.
+	assert.Len(t, cfg.Providers, 2)
+	assert.Equal(t, "openai-main", cfg.Providers[0].ID)
+	assert.Equal(t, 30*time.Second, cfg.Providers[0].Timeout)
+	openAIConfig, ok := cfg.Providers[0].Config.(*OpenAIProviderConfig)
+	assert.True(t, ok)
+	assert.Equal(t, "overlay-key", openAIConfig.APIKey)
+	assert.Equal(t, "base-org", openAIConfig.OrgID)
+	assert.Equal(t, "dummy-test", cfg.Providers[1].ID)
+
+	assert.Len(t, cfg.Models, 3)
+	assert.Equal(t, "chat", cfg.Models[0].ID)
+	assert.Equal(t, "gpt-4.1", cfg.Models[0].Name)
+	assert.Equal(t, "openai-main", cfg.Models[0].Provider)
+	assert.Equal(t, []string{"backup"}, cfg.Models[0].Fallback)
+	assert.Equal(t, "local", cfg.Models[2].ID)
+}
+
+func TestLoadConfigFilesConflict(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.yml")
+	assert.NoError(t, os.WriteFile(base, []byte(`
+providers:
+  - id: main
+    provider: openai
+    config:
+      api_key: test-key
+`), 0o600))
+	overlay := filepath.Join(dir, "overlay.yml")
+	assert.NoError(t, os.WriteFile(overlay, []byte(`
+providers:
+  - id: main
+    provider: anthropic
+    config:
+      api_key: test-key
+`), 0o600))
+
+	cfg, err := LoadFiles(base, overlay)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, fmt.Sprintf(`provider "main" is a openai provider in %s but a anthropic provider in %s`, base, overlay))
+
+	// An ID defined twice in the same file is still a duplicate.
+	duplicate := filepath.Join(dir, "duplicate.yml")
+	assert.NoError(t, os.WriteFile(duplicate, []byte(`
+providers:
+  - id: main
+    provider: openai
+    config:
+      api_key: other-key
+  - id: main
+    provider: openai
+    config:
+      api_key: other-key
+`), 0o600))
+	cfg, err = LoadFiles(base, duplicate)
+	assert.Nil(t, cfg)
+	assert.ErrorContains(t, err, `provider "main" is defined more than once`)
+}
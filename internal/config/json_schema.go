@@ -14,7 +14,12 @@ import (
 	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
+//go:generate go run ../../cmd/llm-gateway schema config.schema.json
+
 var (
+	// JSONSchema is the schema the config is validated against, generated
+	// from the config structs by GenerateJSONSchema.
+	//
 	//go:embed config.schema.json
 	JSONSchema []byte
 )
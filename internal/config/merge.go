@@ -0,0 +1,133 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configMerger deep-merges config files, each one overriding and extending the
+// ones before it.
+type configMerger struct {
+	doc *yaml.Node
+	// providerFiles are the files the merged providers were defined in, by ID.
+	providerFiles map[string]string
+}
+
+// merge merges the YAML document of the config file at path. The keys of its
+// mappings replace or extend the merged ones, recursively, and its providers
+// and models are merged by ID with the ones of the same ID, appended otherwise.
+// Other values, lists included, replace the merged ones.
+func (m *configMerger) merge(path string, doc *yaml.Node) error {
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a mapping", path)
+	}
+	if m.doc == nil {
+		m.doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+		m.providerFiles = make(map[string]string)
+	}
+
+	merged := m.doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		existing := mappingValue(merged, key.Value)
+		switch {
+		case existing == nil:
+			merged.Content = append(merged.Content, key, value)
+		case key.Value == "providers" || key.Value == "models":
+			if err := m.mergeByID(path, key.Value, existing, value); err != nil {
+				return err
+			}
+		default:
+			mergeNode(existing, value)
+		}
+		if key.Value == "providers" {
+			for _, item := range value.Content {
+				if id := mappingScalar(item, "id"); id != "" {
+					if _, ok := m.providerFiles[id]; !ok {
+						m.providerFiles[id] = path
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mergeByID merges the items of the list src into the ones of the list dst
+// with the same ID, and appends the others. A provider can't change its type.
+func (m *configMerger) mergeByID(path, list string, dst, src *yaml.Node) error {
+	if dst.Kind != yaml.SequenceNode || src.Kind != yaml.SequenceNode {
+		mergeNode(dst, src)
+		return nil
+	}
+	// Every item of dst is merged into once at most, so that the IDs
+	// defined twice in one file are still reported as duplicates.
+	byID := make(map[string]*yaml.Node, len(dst.Content))
+	for _, item := range dst.Content {
+		if id := mappingScalar(item, "id"); id != "" {
+			if _, ok := byID[id]; !ok {
+				byID[id] = item
+			}
+		}
+	}
+	for _, item := range src.Content {
+		id := mappingScalar(item, "id")
+		existing, ok := byID[id]
+		if !ok || id == "" {
+			dst.Content = append(dst.Content, item)
+			continue
+		}
+		delete(byID, id)
+		if list == "providers" {
+			before, after := mappingScalar(existing, "provider"), mappingScalar(item, "provider")
+			if after != "" && before != "" && after != before {
+				return fmt.Errorf("provider %q is a %s provider in %s but a %s provider in %s", id, before, m.providerFiles[id], after, path)
+			}
+		}
+		mergeNode(existing, item)
+	}
+	return nil
+}
+
+// mergeNode merges src into dst: the keys of mappings are merged recursively,
+// any other value of src replaces dst.
+func mergeNode(dst, src *yaml.Node) {
+	if dst.Kind != yaml.MappingNode || src.Kind != yaml.MappingNode {
+		*dst = *src
+		return
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if existing := mappingValue(dst, key.Value); existing != nil {
+			mergeNode(existing, value)
+			continue
+		}
+		dst.Content = append(dst.Content, key, value)
+	}
+}
+
+// mappingValue returns the value of key in the mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// mappingScalar returns the scalar value of key in the mapping node, or "".
+func mappingScalar(node *yaml.Node, key string) string {
+	if value := mappingValue(node, key); value != nil && value.Kind == yaml.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
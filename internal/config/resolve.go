@@ -0,0 +1,172 @@
+package config
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SecretResolver resolves the references of a scheme in config values. The
+// reference ${vault:secret/data/openai#api_key} is resolved by the resolver
+// registered for vault, with secret/data/openai#api_key.
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to a SecretResolver.
+type SecretResolverFunc func(ref string) (string, error)
+
+// Resolve calls f(ref).
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = map[string]SecretResolver{
+		"env":  SecretResolverFunc(resolveEnv),
+		"file": SecretResolverFunc(resolveFile),
+	}
+)
+
+// RegisterSecretResolver makes Load resolve the ${scheme:ref} references with r,
+// replacing the resolver registered for scheme, if any. It must be called before
+// the config is loaded.
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	secretResolvers[scheme] = r
+}
+
+func secretResolver(scheme string) (SecretResolver, bool) {
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+	r, ok := secretResolvers[scheme]
+	return r, ok
+}
+
+// resolveEnv returns the value of the environment variable name, empty when unset.
+func resolveEnv(name string) (string, error) {
+	return os.Getenv(name), nil
+}
+
+// resolveFile returns the content of the file at path, without the trailing
+// newline secret files usually end with.
+func resolveFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// secretReference matches the ${scheme:ref} references of a value, and the
+// ${NAME} environment variable references, short for ${env:NAME}.
+var secretReference = regexp.MustCompile(`\$\{(?:([a-z][a-z0-9]*):([^}]*)|([A-Za-z_][A-Za-z0-9_]*))\}`)
+
+// resolveReferences replaces the references of value with what their resolvers
+// return. Other dollar signs are kept as is.
+func resolveReferences(value string) (string, error) {
+	if !strings.Contains(value, "${") {
+		return value, nil
+	}
+
+	var resolveErr error
+	resolved := secretReference.ReplaceAllStringFunc(value, func(ref string) string {
+		match := secretReference.FindStringSubmatch(ref)
+		scheme, arg := match[1], match[2]
+		if scheme == "" {
+			scheme, arg = "env", match[3]
+		}
+		r, ok := secretResolver(scheme)
+		if !ok {
+			resolveErr = cmp.Or(resolveErr, fmt.Errorf("no resolver for %q references", scheme))
+			return ref
+		}
+		v, err := r.Resolve(arg)
+		if err != nil {
+			resolveErr = cmp.Or(resolveErr, fmt.Errorf("failed to resolve %s: %w", ref, err))
+			return ref
+		}
+		return v
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+var yamlNodeType = reflect.TypeFor[yaml.Node]()
+
+// resolveSecrets resolves the references of the string values reachable from v,
+// which must be a pointer, in place. Errors name the field by its yaml path.
+func resolveSecrets(v any) error {
+	return resolveValue(reflect.ValueOf(v), "")
+}
+
+func resolveValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveValue(v.Elem(), path)
+	case reflect.Struct:
+		if v.Type() == yamlNodeType {
+			return nil
+		}
+		t := v.Type()
+		for i := range t.NumField() {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			if name == "" || name == "-" {
+				name = strings.ToLower(field.Name)
+			}
+			if err := resolveValue(v.Field(i), joinPath(path, name)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			// Map values aren't addressable, so they are resolved on a copy.
+			elem := reflect.New(iter.Value().Type()).Elem()
+			elem.Set(iter.Value())
+			if err := resolveValue(elem, joinPath(path, fmt.Sprint(iter.Key()))); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), elem)
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		resolved, err := resolveReferences(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(resolved)
+	}
+	return nil
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveReferences(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("file-key\n"), 0o600))
+	t.Setenv("TEST_RESOLVE_KEY", "env-key")
+	RegisterSecretResolver("test", SecretResolverFunc(func(ref string) (string, error) {
+		if ref == "missing" {
+			return "", errors.New("not found")
+		}
+		return "test-" + ref, nil
+	}))
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+		wantErr  bool
+	}{
+		{name: "no reference", value: "plain $5", expected: "plain $5"},
+		{name: "env shorthand", value: "${TEST_RESOLVE_KEY}", expected: "env-key"},
+		{name: "env", value: "Bearer ${env:TEST_RESOLVE_KEY}", expected: "Bearer env-key"},
+		{name: "unset env", value: "${env:TEST_RESOLVE_UNSET}", expected: ""},
+		{name: "file", value: "${file:" + keyFile + "}", expected: "file-key"},
+		{name: "missing file", value: "${file:" + filepath.Join(dir, "missing") + "}", wantErr: true},
+		{name: "registered resolver", value: "${test:secret/openai}", expected: "test-secret/openai"},
+		{name: "resolver error", value: "${test:missing}", wantErr: true},
+		{name: "unknown scheme", value: "${vault:secret/openai}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resolved, err := resolveReferences(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, resolved)
+		})
+	}
+}
+
+func TestLoadFileSecrets(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "openai-key")
+	require.NoError(t, os.WriteFile(keyFile, []byte("sk-from-file\n"), 0o600))
+	configFile := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+server:
+  api_keys: ["${file:`+keyFile+`}"]
+providers:
+  - id: openai
+    provider: openai
+    headers:
+      X-Api-Key: ${file:`+keyFile+`}
+    config:
+      api_key: ${file:`+keyFile+`}
+`), 0o600))
+	t.Setenv("CONFIG_PATH", configFile)
+
+	cfg, err := Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"sk-from-file"}, cfg.Server.APIKeys)
+	assert.Equal(t, map[string]string{"X-Api-Key": "sk-from-file"}, cfg.Providers[0].Headers)
+	openAIConfig, ok := cfg.Providers[0].Config.(*OpenAIProviderConfig)
+	require.True(t, ok)
+	assert.Equal(t, "sk-from-file", openAIConfig.APIKey)
+}
+
+func TestLoadMissingSecretFile(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yml")
+	require.NoError(t, os.WriteFile(configFile, []byte(`
+providers:
+  - id: openai
+    provider: openai
+    config:
+      api_key: ${file:`+filepath.Join(dir, "missing")+`}
+`), 0o600))
+	t.Setenv("CONFIG_PATH", configFile)
+
+	_, err := Load()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "providers[0].config.api_key")
+}
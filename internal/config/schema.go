@@ -0,0 +1,300 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// jsonSchema is a JSON schema of the config, with the keywords config.schema.json
+// uses. The fields are in the order they are written in.
+type jsonSchema struct {
+	Schema               string              `json:"$schema,omitempty"`
+	Title                string              `json:"title,omitempty"`
+	Type                 string              `json:"type,omitempty"`
+	Const                string              `json:"const,omitempty"`
+	Format               string              `json:"format,omitempty"`
+	Pattern              string              `json:"pattern,omitempty"`
+	Enum                 []string            `json:"enum,omitempty"`
+	MinLength            *int                `json:"minLength,omitempty"`
+	Minimum              *float64            `json:"minimum,omitempty"`
+	Maximum              *float64            `json:"maximum,omitempty"`
+	Description          string              `json:"description,omitempty"`
+	Default              any                 `json:"default,omitempty"`
+	AdditionalProperties any                 `json:"additionalProperties,omitempty"`
+	Required             []string            `json:"required,omitempty"`
+	Properties           *schemaProperties   `json:"properties,omitempty"`
+	Dependencies         map[string][]string `json:"dependencies,omitempty"`
+	Items                *jsonSchema         `json:"items,omitempty"`
+	AllOf                []*jsonSchema       `json:"allOf,omitempty"`
+	If                   *jsonSchema         `json:"if,omitempty"`
+	Then                 *jsonSchema         `json:"then,omitempty"`
+}
+
+// schemaProperties are the properties of an object schema, written in the
+// order of the fields of its struct.
+type schemaProperties struct {
+	names   []string
+	schemas map[string]*jsonSchema
+}
+
+func (p *schemaProperties) set(name string, schema *jsonSchema) {
+	if p.schemas == nil {
+		p.schemas = make(map[string]*jsonSchema)
+	}
+	if _, ok := p.schemas[name]; !ok {
+		p.names = append(p.names, name)
+	}
+	p.schemas[name] = schema
+}
+
+func (p *schemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range p.names {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := marshalSchema(name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalSchema(p.schemas[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// GenerateJSONSchema returns the JSON schema of the config, config.schema.json,
+// generated from the config structs. The fields are named after their yaml tag
+// and described by their `description` tag. Their `jsonschema` tag holds
+// comma-separated keywords:
+//
+//   - required: the field must be set.
+//   - requires=<field>: the field can only be set together with another one.
+//   - default=<value>: the default when it isn't set by the envDefault tag.
+//   - enum=<a>|<b>, format, pattern, minLength, minimum and maximum: constraints
+//     of the value, or of the items of a list.
+//
+// Durations get the go-duration format, and the provider configs one schema
+// per provider type.
+func GenerateJSONSchema() ([]byte, error) {
+	schema, err := structSchema(reflect.TypeFor[Config]())
+	if err != nil {
+		return nil, err
+	}
+	schema.Schema = "http://json-schema.org/draft-07/schema#"
+	schema.Title = "LLM Gateway Configuration"
+	schema.Description = "Configuration schema for LLM Gateway application"
+
+	data, err := marshalSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// marshalSchema encodes v as JSON, leaving the <, > and & of the descriptions
+// as is.
+func marshalSchema(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// typeSchema returns the schema of the values of t.
+func typeSchema(t reflect.Type) (*jsonSchema, error) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == durationType:
+		return &jsonSchema{Type: "string", Format: "go-duration"}, nil
+	case t == yamlNodeType:
+		return &jsonSchema{Type: "object"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchema{Type: "string"}, nil
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items}, nil
+	case reflect.Map:
+		values, err := typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "object", AdditionalProperties: values}, nil
+	case reflect.Struct:
+		schema, err := structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		if t == reflect.TypeFor[ProviderConfig]() {
+			if err := addProviderConfigs(schema); err != nil {
+				return nil, err
+			}
+		}
+		return schema, nil
+	}
+	return nil, fmt.Errorf("no JSON schema for the %s type", t)
+}
+
+// structSchema returns the schema of the struct t, an object of its fields.
+func structSchema(t reflect.Type) (*jsonSchema, error) {
+	schema := &jsonSchema{Type: "object", AdditionalProperties: false, Properties: &schemaProperties{}}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		fieldSchema, err := typeSchema(field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		fieldSchema.Description = field.Tag.Get("description")
+		if err := applySchemaTag(schema, name, fieldSchema, field); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		schema.Properties.set(name, fieldSchema)
+	}
+	return schema, nil
+}
+
+// applySchemaTag applies the keywords of the jsonschema and envDefault tags of
+// field to its schema, and to the one of the struct holding it.
+func applySchemaTag(parent *jsonSchema, name string, schema *jsonSchema, field reflect.StructField) error {
+	// The constraints of a list are the ones of its items.
+	constrained := schema
+	if schema.Type == "array" {
+		constrained = schema.Items
+	}
+
+	if value, ok := field.Tag.Lookup("envDefault"); ok {
+		def, err := schemaDefault(schema, value)
+		if err != nil {
+			return err
+		}
+		schema.Default = def
+	}
+
+	tag := field.Tag.Get("jsonschema")
+	if tag == "" {
+		return nil
+	}
+	for _, keyword := range strings.Split(tag, ",") {
+		key, value, _ := strings.Cut(keyword, "=")
+		var err error
+		switch key {
+		case "required":
+			parent.Required = append(parent.Required, name)
+		case "requires":
+			if parent.Dependencies == nil {
+				parent.Dependencies = make(map[string][]string)
+			}
+			parent.Dependencies[name] = append(parent.Dependencies[name], value)
+		case "default":
+			schema.Default, err = schemaDefault(schema, value)
+		case "enum":
+			constrained.Enum = strings.Split(value, "|")
+		case "format":
+			constrained.Format = value
+		case "pattern":
+			constrained.Pattern = value
+		case "minLength":
+			var n int
+			n, err = strconv.Atoi(value)
+			constrained.MinLength = &n
+		case "minimum":
+			constrained.Minimum, err = parseSchemaNumber(value)
+		case "maximum":
+			constrained.Maximum, err = parseSchemaNumber(value)
+		default:
+			err = fmt.Errorf("unknown jsonschema keyword %q", key)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// schemaDefault returns the default value of schema written as value.
+func schemaDefault(schema *jsonSchema, value string) (any, error) {
+	switch schema.Type {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer":
+		return strconv.ParseInt(value, 10, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	case "string":
+		return value, nil
+	}
+	return nil, fmt.Errorf("no default value for the %s type", schema.Type)
+}
+
+func parseSchemaNumber(value string) (*float64, error) {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// addProviderConfigs restricts the provider types of the provider schema to
+// the known ones, and its config to the one of its provider type.
+func addProviderConfigs(schema *jsonSchema) error {
+	names := slices.Sorted(maps.Keys(configTypeFactories))
+	provider := schema.Properties.schemas["provider"]
+	for _, name := range names {
+		provider.Enum = append(provider.Enum, string(name))
+
+		config, err := typeSchema(reflect.TypeOf(configTypeFactories[name]()))
+		if err != nil {
+			return err
+		}
+		match := &jsonSchema{Properties: &schemaProperties{}}
+		match.Properties.set("provider", &jsonSchema{Const: string(name)})
+		then := &jsonSchema{Properties: &schemaProperties{}}
+		then.Properties.set("config", config)
+		schema.AllOf = append(schema.AllOf, &jsonSchema{If: match, Then: then})
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	data, err := GenerateJSONSchema()
+	require.NoError(t, err)
+	assert.Equal(t, string(JSONSchema), string(data), "config.schema.json is out of date, run go generate ./internal/config")
+}
+
+func TestStructSchema(t *testing.T) {
+	type limits struct {
+		Codes []int `yaml:"codes" description:"Status codes" jsonschema:"minimum=100,maximum=599"`
+	}
+	type example struct {
+		Name     string            `yaml:"name" jsonschema:"required,minLength=1"`
+		Level    string            `yaml:"level,omitempty" env:"LEVEL" envDefault:"info" jsonschema:"enum=debug|info"`
+		Rate     float64           `yaml:"rate" jsonschema:"default=1,minimum=0,maximum=1"`
+		Timeout  time.Duration     `yaml:"timeout"`
+		Cert     string            `yaml:"cert" jsonschema:"requires=key"`
+		Key      string            `yaml:"key" jsonschema:"requires=cert"`
+		Limits   *limits           `yaml:"limits"`
+		Headers  map[string]string `yaml:"headers"`
+		Internal string            `yaml:"-"`
+	}
+
+	schema, err := structSchema(reflect.TypeFor[example]())
+	require.NoError(t, err)
+	data, err := json.Marshal(schema)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["name"],
+		"dependencies": {"cert": ["key"], "key": ["cert"]},
+		"properties": {
+			"name": {"type": "string", "minLength": 1},
+			"level": {"type": "string", "enum": ["debug", "info"], "default": "info"},
+			"rate": {"type": "number", "minimum": 0, "maximum": 1, "default": 1},
+			"timeout": {"type": "string", "format": "go-duration"},
+			"cert": {"type": "string"},
+			"key": {"type": "string"},
+			"limits": {
+				"type": "object",
+				"additionalProperties": false,
+				"properties": {
+					"codes": {"type": "array", "description": "Status codes", "items": {"type": "integer", "minimum": 100, "maximum": 599}}
+				}
+			},
+			"headers": {"type": "object", "additionalProperties": {"type": "string"}}
+		}
+	}`, string(data))
+
+	_, err = structSchema(reflect.TypeFor[struct {
+		Name string `yaml:"name" jsonschema:"minLen=1"`
+	}]())
+	assert.ErrorContains(t, err, `unknown jsonschema keyword "minLen"`)
+}
@@ -0,0 +1,96 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+// secretMask replaces the values of secret fields.
+const secretMask = "********"
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// Redacted returns v, typically the Config, as maps and slices ready to be encoded
+// as JSON. Fields are named after their json tag, or their yaml tag otherwise,
+// and fields tagged `secret:"true"` have their non-empty values masked.
+// Durations are rendered in the Go duration format.
+func Redacted(v any) any {
+	return redact(reflect.ValueOf(v), false)
+}
+
+func redact(v reflect.Value, secret bool) any {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return redact(v.Elem(), secret)
+	case reflect.Struct:
+		return redactStruct(v)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		items := make([]any, v.Len())
+		for i := range items {
+			items[i] = redact(v.Index(i), secret)
+		}
+		return items
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		// The keys of a secret map, e.g. header names, stay visible.
+		entries := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			entries[iter.Key().String()] = redact(iter.Value(), secret)
+		}
+		return entries
+	}
+
+	if secret {
+		if v.IsZero() {
+			return v.Interface()
+		}
+		return secretMask
+	}
+	if v.Type() == durationType {
+		return time.Duration(v.Int()).String()
+	}
+	return v.Interface()
+}
+
+func redactStruct(v reflect.Value) map[string]any {
+	fields := make(map[string]any, v.NumField())
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := fieldName(field)
+		if name == "-" {
+			continue
+		}
+		fields[name] = redact(v.Field(i), field.Tag.Get("secret") == "true")
+	}
+	return fields
+}
+
+// fieldName returns the name of field in the redacted config.
+func fieldName(field reflect.StructField) string {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		tag, ok = field.Tag.Lookup("yaml")
+	}
+	if !ok {
+		return field.Name
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedacted(t *testing.T) {
+	cfg := &Config{
+		Server: ServerConfig{Port: "8080", APIKeys: []string{"gateway-key-1", "gateway-key-2"}},
+		Providers: []*ProviderConfig{
+			{
+				ID:       "openai",
+				Provider: ProviderOpenAI,
+				Timeout:  30 * time.Second,
+				Headers:  map[string]string{"X-Api-Org": "org-secret"},
+				Config:   &OpenAIProviderConfig{APIKey: "sk-secret", APIUrl: "https://api.openai.com"},
+			},
+			{
+				ID:       "vertex",
+				Provider: ProviderVertexAI,
+				Config:   &VertexAIProviderConfig{ProjectID: "project", PathToCredsFile: "/secrets/creds.json"},
+			},
+		},
+	}
+
+	data, err := json.Marshal(Redacted(cfg))
+	require.NoError(t, err)
+	out := string(data)
+
+	for _, secret := range []string{"gateway-key-1", "sk-secret", "org-secret", "/secrets/creds.json"} {
+		assert.NotContains(t, out, secret)
+	}
+
+	var redacted struct {
+		Server struct {
+			Port    string   `json:"port"`
+			APIKeys []string `json:"api_keys"`
+		} `json:"server"`
+		Providers []struct {
+			ID      string            `json:"id"`
+			Timeout string            `json:"timeout"`
+			Headers map[string]string `json:"headers"`
+			Config  map[string]any    `json:"config"`
+		} `json:"providers"`
+	}
+	require.NoError(t, json.Unmarshal(data, &redacted))
+	assert.Equal(t, "8080", redacted.Server.Port)
+	assert.Equal(t, []string{secretMask, secretMask}, redacted.Server.APIKeys)
+	assert.Equal(t, "30s", redacted.Providers[0].Timeout)
+	assert.Equal(t, map[string]string{"X-Api-Org": secretMask}, redacted.Providers[0].Headers)
+	assert.Equal(t, secretMask, redacted.Providers[0].Config["api_key"])
+	assert.Equal(t, "https://api.openai.com", redacted.Providers[0].Config["api_url"])
+	// Empty secrets are left empty, so missing keys stay visible.
+	assert.Nil(t, redacted.Providers[0].Config["api_keys"])
+	assert.Equal(t, "project", redacted.Providers[1].Config["project_id"])
+	assert.Equal(t, secretMask, redacted.Providers[1].Config["path_to_creds_file"])
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// validateReferences checks what the JSON schema can't: that IDs are unique,
+// that models reference existing providers and fallback models, that aliases
+// reference existing models without shadowing one, that the default model
+// exists, that fallback chains don't loop, and that the priority tiers of the
+// server exist. All problems are reported together.
+func (c *Config) validateReferences() error {
+	var errs []error
+
+	providers := make(map[string]struct{}, len(c.Providers))
+	for _, p := range c.Providers {
+		if _, ok := providers[p.ID]; ok {
+			errs = append(errs, fmt.Errorf("provider %q is defined more than once", p.ID))
+		}
+		providers[p.ID] = struct{}{}
+	}
+
+	models := make(map[string]*ModelConfig, len(c.Models))
+	for _, m := range c.Models {
+		if _, ok := models[m.ID]; ok {
+			errs = append(errs, fmt.Errorf("model %q is defined more than once", m.ID))
+		}
+		models[m.ID] = m
+	}
+
+	for _, m := range c.Models {
+		if _, ok := providers[m.Provider]; !ok {
+			errs = append(errs, fmt.Errorf("model %q references unknown provider %q", m.ID, m.Provider))
+		}
+		for _, fallback := range m.Fallback {
+			if _, ok := models[fallback]; !ok {
+				errs = append(errs, fmt.Errorf("model %q references unknown fallback model %q", m.ID, fallback))
+			}
+		}
+	}
+
+	for _, alias := range slices.Sorted(maps.Keys(c.Aliases)) {
+		if _, ok := models[alias]; ok {
+			errs = append(errs, fmt.Errorf("alias %q shadows a model ID", alias))
+		}
+		if target := c.Aliases[alias]; models[target] == nil {
+			errs = append(errs, fmt.Errorf("alias %q references unknown model %q", alias, target))
+		}
+	}
+
+	if c.DefaultModel != "" {
+		if _, ok := c.Aliases[c.DefaultModel]; !ok && models[c.DefaultModel] == nil {
+			errs = append(errs, fmt.Errorf("default model %q is neither a model nor an alias", c.DefaultModel))
+		}
+	}
+
+	priority := c.Server.Priority
+	if len(priority.Tiers) > 0 && c.Server.MaxConcurrency <= 0 {
+		errs = append(errs, errors.New("priority tiers require max_concurrency, whose slots they order"))
+	}
+	if priority.DefaultTier != "" && !slices.Contains(priority.Tiers, priority.DefaultTier) {
+		errs = append(errs, fmt.Errorf("default priority tier %q is not a priority tier", priority.DefaultTier))
+	}
+	for _, tier := range slices.Sorted(maps.Keys(priority.APIKeys)) {
+		if !slices.Contains(priority.Tiers, tier) {
+			errs = append(errs, fmt.Errorf("API keys reference unknown priority tier %q", tier))
+		}
+	}
+
+	for _, cycle := range fallbackCycles(c.Models, models) {
+		errs = append(errs, fmt.Errorf("fallback cycle: %s", strings.Join(cycle, " -> ")))
+	}
+
+	return errors.Join(errs...)
+}
+
+// fallbackCycles returns the cycles of the fallback graph, each one starting
+// and ending with the same model ID. Unknown fallback models are ignored.
+func fallbackCycles(order []*ModelConfig, models map[string]*ModelConfig) [][]string {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[string]int, len(models))
+	var path []string
+	var cycles [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		state[id] = visiting
+		path = append(path, id)
+		for _, next := range models[id].Fallback {
+			if _, ok := models[next]; !ok {
+				continue
+			}
+			switch state[next] {
+			case visiting:
+				start := 0
+				for path[start] != next {
+					start++
+				}
+				cycle := append([]string{}, path[start:]...)
+				cycles = append(cycles, append(cycle, next))
+			case unvisited:
+				visit(next)
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+	}
+
+	for _, m := range order {
+		if state[m.ID] == unvisited {
+			visit(m.ID)
+		}
+	}
+	return cycles
+}
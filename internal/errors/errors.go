@@ -1,11 +1,18 @@
 package errors
 
-import "net/http"
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
 
 type Error struct {
 	Message string `json:"message"`
 	Status  int    `json:"code"`
 	Details error  `json:"details,omitempty"`
+	// Unsupported marks the requests rejected by the model they were sent to
+	// only, such as ones using a feature it lacks, which its fallbacks may serve.
+	Unsupported bool `json:"-"`
 }
 
 func (e Error) Error() string {
@@ -23,6 +30,43 @@ func (e Error) WithMessage(message string) Error {
 }
 
 var (
-	ErrNotFound = Error{Message: "Resource not found", Status: http.StatusNotFound}
-	ErrInternal = Error{Message: "Internal server error", Status: http.StatusInternalServerError}
+	ErrBadRequest      = Error{Message: "Bad request", Status: http.StatusBadRequest}
+	ErrNotFound        = Error{Message: "Resource not found", Status: http.StatusNotFound}
+	ErrUnauthorized    = Error{Message: "Invalid API key", Status: http.StatusUnauthorized}
+	ErrRateLimited     = Error{Message: "Rate limit exceeded", Status: http.StatusTooManyRequests}
+	ErrInternal        = Error{Message: "Internal server error", Status: http.StatusInternalServerError}
+	ErrRequestTooLarge = Error{Message: "Request body too large", Status: http.StatusRequestEntityTooLarge}
+	ErrGatewayTimeout  = Error{Message: "Upstream request timed out", Status: http.StatusGatewayTimeout}
+	ErrUnavailable     = Error{Message: "Service unavailable", Status: http.StatusServiceUnavailable}
+	// ErrUnsupported is returned for requests the model can't serve, see Error.Unsupported.
+	ErrUnsupported = Error{Message: "Unsupported by the model", Status: http.StatusBadRequest, Unsupported: true}
+	// ErrUnsupportedMediaType is returned for request bodies that aren't JSON.
+	ErrUnsupportedMediaType = Error{Message: "Unsupported media type", Status: http.StatusUnsupportedMediaType}
 )
+
+// ProviderError is the error of a provider call made for a request.
+type ProviderError struct {
+	Provider string
+	Model    string
+	Err      error
+}
+
+// ProviderErrors are the errors of the provider calls made for a request, in
+// the order they were made.
+type ProviderErrors []ProviderError
+
+func (e ProviderErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, pe := range e {
+		msgs[i] = fmt.Sprintf("%s (%s): %v", pe.Provider, pe.Model, pe.Err)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e ProviderErrors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, pe := range e {
+		errs[i] = pe.Err
+	}
+	return errs
+}
@@ -1,14 +1,27 @@
 package log
 
 import (
+	"context"
+	"io"
 	"log/slog"
 	"os"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+)
+
+// Logging formats.
+const (
+	FormatJSON = "json"
+	FormatText = "text"
 )
 
 // New creates a new slog.Logger based on the provided configuration.
-func New(lvl string) *slog.Logger {
+// Records logged with a context carrying a request ID include it as `request_id`.
+// If the output file can't be opened, the logger writes to stdout and logs a warning.
+func New(cfg config.LoggingConfig) *slog.Logger {
 	var level slog.Level
-	switch lvl {
+	switch cfg.Level {
 	case "debug":
 		level = slog.LevelDebug
 	case "info":
@@ -21,7 +34,57 @@ func New(lvl string) *slog.Logger {
 		level = slog.LevelInfo
 	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: level}))
+	out, openErr := openOutput(cfg.Output)
+	if openErr != nil {
+		out = os.Stdout
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatText:
+		handler = slog.NewTextHandler(out, opts)
+	default:
+		handler = slog.NewJSONHandler(out, opts)
+	}
+
+	logger := slog.New(&contextHandler{handler})
+	if openErr != nil {
+		logger.Warn("Failed to open log output, logging to stdout", "output", cfg.Output, "error", openErr)
+	}
 
 	return logger
 }
+
+// openOutput returns the writer for a logging output: stdout, stderr or a file
+// path, which is created if needed and appended to.
+func openOutput(output string) (io.Writer, error) {
+	switch output {
+	case "", "stdout":
+		return os.Stdout, nil
+	case "stderr":
+		return os.Stderr, nil
+	default:
+		return os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	}
+}
+
+// contextHandler adds the request ID of the record context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := requestid.FromContext(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,54 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	t.Run("json file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "gateway.log")
+
+		logger := New(config.LoggingConfig{Level: "info", Format: FormatJSON, Output: path})
+		logger.InfoContext(requestid.NewContext(context.Background(), "req-1"), "hello")
+		logger.Debug("dropped")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		require.Len(t, lines, 1)
+
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal(t, "hello", record["msg"])
+		assert.Equal(t, "req-1", record["request_id"])
+	})
+
+	t.Run("text file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "gateway.log")
+
+		New(config.LoggingConfig{Format: FormatText, Output: path}).Info("hello", "key", "value")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "msg=hello key=value")
+	})
+
+	t.Run("unwritable file falls back to stdout", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "missing", "gateway.log")
+
+		logger := New(config.LoggingConfig{Output: path})
+
+		require.NotNil(t, logger)
+		assert.NoFileExists(t, path)
+	})
+}
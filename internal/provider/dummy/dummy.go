@@ -3,29 +3,67 @@ package dummy
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/provider"
 )
 
+const dummyResponse = "Hello! This is a dummy response."
+
+// dummyEmbeddingSize matches the size of OpenAI's text-embedding-3-small vectors.
+const dummyEmbeddingSize = 1536
+
 // DummyProvider is a dummy implementation of the Provider interface.
-type DummyProvider struct{}
+type DummyProvider struct {
+	echo bool
+}
 
 func NewDummyProvider() *DummyProvider {
 	return &DummyProvider{}
 }
 
+// NewEchoProvider creates a DummyProvider that answers with the last user message
+// and counts one token per word, so tests can assert the round trip of messages.
+func NewEchoProvider() *DummyProvider {
+	return &DummyProvider{echo: true}
+}
+
 // ChatCompletion creates a dummy completion for the given chat conversation.
 func (dp *DummyProvider) ChatCompletion(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
-	// Simulate some work and token usage
-	time.Sleep(100 * time.Millisecond)
+	var text string
+	var promptTokens, completionTokens int
+	if dp.echo {
+		var err error
+		text, err = lastUserMessage(req.Messages)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range req.Messages {
+			promptTokens += countWords(msg.Content)
+		}
+		completionTokens = len(strings.Fields(text))
+	} else {
+		// Simulate some work and token usage
+		time.Sleep(100 * time.Millisecond)
 
-	promptTokens := len(req.Messages) * 5 // Arbitrary token count for dummy
-	completionTokens := 10
+		text = dummyResponse
+		promptTokens = len(req.Messages) * 5 // Arbitrary token count for dummy
+		completionTokens = 10
+	}
+	stop, err := provider.StopSequences(req)
+	if err != nil {
+		return nil, err
+	}
+	if cut, ok := cutAtStop(text, stop); ok {
+		text = cut
+		completionTokens = len(strings.Fields(text))
+	}
 	totalTokens := promptTokens + completionTokens
 
 	content := &api.ChatMessage_Content{}
-	content.FromChatMessageContent0("Hello! This is a dummy response.")
+	content.FromChatMessageContent0(text)
 	resp := &api.ChatCompletionResponse{
 		Id:      fmt.Sprintf("dummy-cmpl-%d", time.Now().UnixNano()),
 		Object:  "chat.completion",
@@ -47,3 +85,148 @@ func (dp *DummyProvider) ChatCompletion(ctx context.Context, req *api.ChatComple
 
 	return resp, nil
 }
+
+// cutAtStop cuts text before the first occurrence of any of the stop
+// sequences, as a model stops generating there.
+func cutAtStop(text string, stop []string) (string, bool) {
+	end := -1
+	for _, s := range stop {
+		if i := strings.Index(text, s); s != "" && i >= 0 && (end < 0 || i < end) {
+			end = i
+		}
+	}
+	if end < 0 {
+		return text, false
+	}
+	return text[:end], true
+}
+
+// lastUserMessage returns the content of the last user message. Text content is
+// returned as is and content parts as their JSON encoding.
+func lastUserMessage(messages []api.ChatMessage) (string, error) {
+	for i := len(messages) - 1; i >= 0; i-- {
+		msg := messages[i]
+		if msg.Role != api.ChatMessageRoleUser || msg.Content == nil {
+			continue
+		}
+		if text, err := msg.Content.AsChatMessageContent0(); err == nil {
+			return text, nil
+		}
+		data, err := msg.Content.MarshalJSON()
+		if err != nil {
+			return "", fmt.Errorf("failed to serialize message content: %w", err)
+		}
+		return string(data), nil
+	}
+	return "", nil
+}
+
+// countWords counts the words of the text in content.
+func countWords(content *api.ChatMessage_Content) int {
+	if content == nil {
+		return 0
+	}
+	if text, err := content.AsChatMessageContent0(); err == nil {
+		return len(strings.Fields(text))
+	}
+	parts, _ := content.AsChatMessageContent1()
+	words := 0
+	for _, part := range parts {
+		if part.Text != nil {
+			words += len(strings.Fields(*part.Text))
+		}
+	}
+	return words
+}
+
+// ChatCompletionStream streams the dummy completion word by word.
+func (dp *DummyProvider) ChatCompletionStream(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+	resp, err := dp.ChatCompletion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	text, _ := resp.Choices[0].Message.Content.AsChatMessageContent0()
+
+	role := api.ChatCompletionDeltaRoleAssistant
+	words := strings.SplitAfter(text, " ")
+	for i, word := range words {
+		delta := api.ChatCompletionDelta{Content: &word}
+		if i == 0 {
+			delta.Role = &role
+		}
+		chunk := &api.ChatCompletionChunk{
+			Id:      resp.Id,
+			Object:  "chat.completion.chunk",
+			Created: resp.Created,
+			Model:   resp.Model,
+			Choices: []api.ChatCompletionChunkChoice{{Index: 0, Delta: delta}},
+		}
+		if i == len(words)-1 {
+			finishReason := api.ChatCompletionChunkChoiceFinishReasonStop
+			chunk.Choices[0].FinishReason = &finishReason
+		}
+		if err := send(ctx, chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// Embeddings returns a zero vector for every input.
+func (dp *DummyProvider) Embeddings(ctx context.Context, req *api.EmbeddingsRequest) (*api.EmbeddingsResponse, error) {
+	inputs, err := req.Input.AsEmbeddingsRequestInput1()
+	if err != nil {
+		input, err := req.Input.AsEmbeddingsRequestInput0()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert embeddings input: %w", err)
+		}
+		inputs = []string{input}
+	}
+
+	resp := &api.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]api.Embedding, len(inputs)),
+	}
+	for i := range inputs {
+		resp.Data[i] = api.Embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: make([]float32, dummyEmbeddingSize),
+		}
+	}
+	promptTokens := len(inputs) * 5 // Arbitrary token count for dummy
+	resp.Usage = api.EmbeddingsUsage{
+		PromptTokens: promptTokens,
+		TotalTokens:  promptTokens,
+	}
+
+	return resp, nil
+}
+
+// Moderations flags nothing.
+func (dp *DummyProvider) Moderations(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error) {
+	inputs, err := req.Input.AsModerationRequestInput1()
+	if err != nil {
+		input, err := req.Input.AsModerationRequestInput0()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert moderation input: %w", err)
+		}
+		inputs = []string{input}
+	}
+
+	resp := &api.ModerationResponse{
+		Id:      "modr-dummy",
+		Model:   req.Model,
+		Results: make([]api.ModerationResult, len(inputs)),
+	}
+	for i := range inputs {
+		resp.Results[i] = api.ModerationResult{
+			Flagged:        false,
+			Categories:     map[string]bool{},
+			CategoryScores: map[string]float32{},
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,102 @@
+package dummy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func textContent(t *testing.T, text string) *api.ChatMessage_Content {
+	content := &api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0(text))
+	return content
+}
+
+func TestEchoProvider(t *testing.T) {
+	req := &api.ChatCompletionRequest{
+		Model: "echo",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleSystem, Content: textContent(t, "Be brief.")},
+			{Role: api.ChatMessageRoleUser, Content: textContent(t, "Hello there, gateway")},
+			{Role: api.ChatMessageRoleAssistant, Content: textContent(t, "Hi")},
+			{Role: api.ChatMessageRoleUser, Content: textContent(t, "Echo this back")},
+		},
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		resp, err := NewEchoProvider().ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, resp.Choices, 1)
+		text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+		require.NoError(t, err)
+		assert.Equal(t, "Echo this back", text)
+		assert.Equal(t, api.ChatCompletionChoiceFinishReasonStop, resp.Choices[0].FinishReason)
+		assert.Equal(t, &api.Usage{PromptTokens: 9, CompletionTokens: 3, TotalTokens: 12}, resp.Usage)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var streamed string
+		_, err := NewEchoProvider().ChatCompletionStream(context.Background(), req, func(_ context.Context, chunk *api.ChatCompletionChunk) error {
+			streamed += *chunk.Choices[0].Delta.Content
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Echo this back", streamed)
+	})
+
+	t.Run("content parts", func(t *testing.T) {
+		text := "What is this?"
+		content := &api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent1([]api.MessageContentPart{{Type: "text", Text: &text}}))
+
+		resp, err := NewEchoProvider().ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: content}},
+		})
+		require.NoError(t, err)
+
+		echoed, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+		require.NoError(t, err)
+		assert.JSONEq(t, `[{"type":"text","text":"What is this?"}]`, echoed)
+	})
+}
+
+func TestStopSequences(t *testing.T) {
+	tests := []struct {
+		name     string
+		stop     string
+		expected string
+	}{
+		{name: "string", stop: `"this"`, expected: "Echo "},
+		{name: "array", stop: `["back", "this"]`, expected: "Echo "},
+		{name: "no match", stop: `["nothing"]`, expected: "Echo this back"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := &api.ChatCompletionRequest_Stop{}
+			require.NoError(t, stop.UnmarshalJSON([]byte(tt.stop)))
+			req := &api.ChatCompletionRequest{
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: textContent(t, "Echo this back")}},
+				Stop:     stop,
+			}
+
+			resp, err := NewEchoProvider().ChatCompletion(context.Background(), req)
+			require.NoError(t, err)
+			text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, text)
+
+			var streamed string
+			_, err = NewEchoProvider().ChatCompletionStream(context.Background(), req, func(_ context.Context, chunk *api.ChatCompletionChunk) error {
+				streamed += *chunk.Choices[0].Delta.Content
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, streamed)
+		})
+	}
+}
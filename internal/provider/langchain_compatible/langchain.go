@@ -2,25 +2,196 @@ package langchaincompatible
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log/slog"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
 	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
 	"github.com/tmc/langchaingo/llms"
 )
 
 type LangchainProvider struct {
-	model llms.Model
+	model    llms.Model
+	timeout  time.Duration
+	jsonMode bool
+	// multipleChoices enables requests for more than one choice.
+	multipleChoices bool
+	// alternatingRoles merges system messages and consecutive same-role messages.
+	alternatingRoles bool
+	// binaryImages decodes data URL images into binary parts.
+	binaryImages bool
+	// seed is set for models honoring seed, which the others drop.
+	seed bool
+	// maxStopSequences caps the stop sequences of a request, unlimited when 0.
+	maxStopSequences int
+	// upstreamModels maps model names to the names sent to the model.
+	upstreamModels map[string]string
+	// samplingFreeModels are the model names sent without sampling parameters.
+	samplingFreeModels map[string]bool
+
+	newEmbedder EmbedderFactory
+	embedders   sync.Map // model name -> Embedder
+
+	moderator Moderator
+}
+
+// Embedder creates embeddings for a list of texts.
+type Embedder interface {
+	CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// EmbedderFunc adapts a function to the Embedder interface.
+type EmbedderFunc func(ctx context.Context, texts []string) ([][]float32, error)
+
+func (f EmbedderFunc) CreateEmbedding(ctx context.Context, texts []string) ([][]float32, error) {
+	return f(ctx, texts)
 }
 
-func NewLangchainProvider(model llms.Model) *LangchainProvider {
-	return &LangchainProvider{
+// Moderator classifies inputs through a moderation API, which langchain doesn't cover.
+type Moderator interface {
+	Moderate(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error)
+}
+
+// EmbedderFactory creates an Embedder for the given model name. Langchain clients
+// bind the embedding model at construction, so one embedder is created per model.
+type EmbedderFactory func(model string) (Embedder, error)
+
+// Option configures a LangchainProvider.
+type Option func(*LangchainProvider)
+
+// WithTimeout bounds every call to the model with the given timeout. It is meant for
+// models whose underlying client can't be given a custom *http.Client.
+func WithTimeout(timeout time.Duration) Option {
+	return func(p *LangchainProvider) {
+		p.timeout = timeout
+	}
+}
+
+// WithEmbedderFactory enables embeddings for the provider.
+func WithEmbedderFactory(factory EmbedderFactory) Option {
+	return func(p *LangchainProvider) {
+		p.newEmbedder = factory
+	}
+}
+
+// WithModerator enables moderations for the provider.
+func WithModerator(moderator Moderator) Option {
+	return func(p *LangchainProvider) {
+		p.moderator = moderator
+	}
+}
+
+// WithJSONMode marks the model as honoring langchain's JSON mode, which enables
+// response_format json_object requests.
+func WithJSONMode() Option {
+	return func(p *LangchainProvider) {
+		p.jsonMode = true
+	}
+}
+
+// WithMultipleChoices marks the model as honoring n, the number of choices to
+// generate, which enables requests with n greater than 1.
+func WithMultipleChoices() Option {
+	return func(p *LangchainProvider) {
+		p.multipleChoices = true
+	}
+}
+
+// WithAlternatingRoles marks the model as taking a single system prompt and
+// requiring user and assistant turns to alternate, as Anthropic does. Messages
+// are merged accordingly before they are sent.
+func WithAlternatingRoles() Option {
+	return func(p *LangchainProvider) {
+		p.alternatingRoles = true
+	}
+}
+
+// WithBinaryImages marks the model as taking images as binary data rather than
+// URLs. Base64 data URL images are decoded into binary parts; others are passed
+// as URLs.
+func WithBinaryImages() Option {
+	return func(p *LangchainProvider) {
+		p.binaryImages = true
+	}
+}
+
+// WithSeed marks the model as honoring seed. Seeded requests to other models
+// are logged as not deterministic, since langchain drops the seed for them.
+func WithSeed() Option {
+	return func(p *LangchainProvider) {
+		p.seed = true
+	}
+}
+
+// WithMaxStopSequences marks the model as accepting at most n stop sequences.
+// Requests with more are rejected rather than truncated, since dropping some
+// would let the model generate past them.
+func WithMaxStopSequences(n int) Option {
+	return func(p *LangchainProvider) {
+		p.maxStopSequences = n
+	}
+}
+
+// WithUpstreamModels renames models before they are sent, e.g. to the Azure
+// OpenAI deployments serving them. Models without an entry keep their name.
+func WithUpstreamModels(models map[string]string) Option {
+	return func(p *LangchainProvider) {
+		p.upstreamModels = models
+	}
+}
+
+// WithoutSamplingParams sends the requests of the given models without their
+// temperature, top_p, presence_penalty and frequency_penalty, for reasoning
+// models rejecting them. The temperature, which some langchain clients always
+// send, is also removed from the body by the upstream client.
+func WithoutSamplingParams(models ...string) Option {
+	return func(p *LangchainProvider) {
+		if p.samplingFreeModels == nil {
+			p.samplingFreeModels = make(map[string]bool, len(models))
+		}
+		for _, model := range models {
+			p.samplingFreeModels[model] = true
+		}
+	}
+}
+
+func NewLangchainProvider(model llms.Model, opts ...Option) *LangchainProvider {
+	p := &LangchainProvider{
 		model: model,
 	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// withTimeout derives a context bounded by the provider timeout, if one is set.
+func (p *LangchainProvider) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, p.timeout)
+}
+
+// upstreamModel returns the name the model is sent under.
+func (p *LangchainProvider) upstreamModel(model string) string {
+	if name, ok := p.upstreamModels[model]; ok {
+		return name
+	}
+	return model
 }
 
-func openaiMsgToLangchainMsg(msg *api.ChatMessage) (llms.MessageContent, error) {
+// openaiMsgToLangchainMsg converts a message, the tool calls of assistant messages
+// to tool call parts and tool messages to a tool call response. toolNames maps the
+// IDs of the tool calls preceding msg to their function, naming the responses
+// that don't.
+func (p *LangchainProvider) openaiMsgToLangchainMsg(msg *api.ChatMessage, toolNames map[string]string) (llms.MessageContent, error) {
 	llmsMsg := llms.MessageContent{}
 	switch msg.Role {
 	case api.ChatMessageRoleUser:
@@ -34,104 +205,439 @@ func openaiMsgToLangchainMsg(msg *api.ChatMessage) (llms.MessageContent, error)
 	case api.ChatMessageRoleTool:
 		llmsMsg.Role = llms.ChatMessageTypeTool
 	default:
-		return llms.MessageContent{}, errors.ErrNotFound.WithMessage(fmt.Sprintf("unknown chat message role: %s", msg.Role))
+		return llms.MessageContent{}, errors.ErrBadRequest.WithMessage(fmt.Sprintf("unknown chat message role: %s", msg.Role))
+	}
+
+	parts, err := p.contentParts(msg.Content)
+	if err != nil {
+		return llms.MessageContent{}, err
+	}
+	llmsMsg.Parts = parts
+
+	if msg.Role == api.ChatMessageRoleAssistant && msg.ToolCalls != nil {
+		for _, call := range *msg.ToolCalls {
+			llmsMsg.Parts = append(llmsMsg.Parts, llms.ToolCall{
+				ID:           call.Id,
+				Type:         string(call.Type),
+				FunctionCall: &llms.FunctionCall{Name: call.Function.Name, Arguments: call.Function.Arguments},
+			})
+		}
+	}
+	if msg.Role == api.ChatMessageRoleTool && msg.ToolCallId != nil {
+		content, ok := textOf(llmsMsg)
+		if !ok {
+			return llms.MessageContent{}, errors.ErrBadRequest.WithMessage("tool message content must be text")
+		}
+		response := llms.ToolCallResponse{ToolCallID: *msg.ToolCallId, Name: toolNames[*msg.ToolCallId], Content: content}
+		if msg.Name != nil {
+			response.Name = *msg.Name
+		}
+		llmsMsg.Parts = []llms.ContentPart{response}
+	}
+
+	return llmsMsg, nil
+}
+
+// contentParts converts the content of a message, which tool, function and
+// tool calling assistant messages may omit and then have no text part.
+func (p *LangchainProvider) contentParts(content *api.ChatMessage_Content) ([]llms.ContentPart, error) {
+	if content == nil {
+		return nil, nil
 	}
 
 	var contentParts []api.MessageContentPart
-	contentString, err := msg.Content.AsChatMessageContent0()
+	contentString, err := content.AsChatMessageContent0()
 	if err != nil {
-		contentParts, err = msg.Content.AsChatMessageContent1()
+		contentParts, err = content.AsChatMessageContent1()
 		if err != nil {
-			return llms.MessageContent{}, fmt.Errorf("failed to convert content: %w", err)
+			return nil, fmt.Errorf("failed to convert content: %w", err)
 		}
 	}
-	if len(contentParts) > 0 {
-		llmsMsg.Parts = make([]llms.ContentPart, len(contentParts))
-		for i, part := range contentParts {
-			if part.Text != nil {
-				llmsMsg.Parts[i] = llms.TextPart(*part.Text)
-			} else if part.ImageUrl != nil {
-				llmsMsg.Parts[i] = llms.ImageURLPart(part.ImageUrl.Url)
+	if len(contentParts) == 0 {
+		return []llms.ContentPart{llms.TextPart(contentString)}, nil
+	}
+
+	parts := make([]llms.ContentPart, len(contentParts))
+	for i, part := range contentParts {
+		if part.Text != nil {
+			parts[i] = llms.TextPart(*part.Text)
+		} else if part.ImageUrl != nil {
+			imagePart, err := p.imagePart(part.ImageUrl.Url, part.ImageUrl.Detail)
+			if err != nil {
+				return nil, err
 			}
+			parts[i] = imagePart
 		}
-		return llmsMsg, nil
-	} else {
-		llmsMsg.Parts = []llms.ContentPart{
-			llms.TextPart(contentString),
+	}
+	return parts, nil
+}
+
+// imagePart converts an image URL content part. Data URLs are only decoded for
+// models taking binary images; others get the URL, detail included, as is.
+func (p *LangchainProvider) imagePart(url string, detail *api.MessageContentPartImageUrlDetail) (llms.ContentPart, error) {
+	if p.binaryImages && strings.HasPrefix(url, "data:") {
+		mimeType, data, err := decodeDataURL(url)
+		if err != nil {
+			return nil, errors.ErrBadRequest.WithMessage(fmt.Sprintf("invalid image data URL: %s", err))
 		}
+		return llms.BinaryPart(mimeType, data), nil
 	}
+	if detail != nil {
+		return llms.ImageURLWithDetailPart(url, string(*detail)), nil
+	}
+	return llms.ImageURLPart(url), nil
+}
 
-	return llmsMsg, nil
+// decodeDataURL returns the MIME type and data of a base64 data URL.
+func decodeDataURL(url string) (string, []byte, error) {
+	meta, encoded, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok {
+		return "", nil, fmt.Errorf("missing data")
+	}
+	mimeType, ok := strings.CutSuffix(meta, ";base64")
+	if !ok {
+		return "", nil, fmt.Errorf("only base64 data is supported")
+	}
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", nil, err
+	}
+	return mimeType, data, nil
 }
 
-func openaiOptionsToLangchainOptions(req *api.ChatCompletionRequest) ([]llms.CallOption, error) {
+// openaiOptionsToLangchainOptions converts the parameters of the request,
+// leaving its sampling parameters out with omitSampling.
+func openaiOptionsToLangchainOptions(req *api.ChatCompletionRequest, omitSampling bool) ([]llms.CallOption, error) {
 	options := []llms.CallOption{
 		llms.WithModel(req.Model),
 	}
 
+	if req.MaxTokens != nil {
+		options = append(options, llms.WithMaxTokens(int(*req.MaxTokens)))
+	}
+	if !omitSampling {
+		options = append(options, samplingOptions(req)...)
+	}
+	if req.N != nil {
+		options = append(options, llms.WithN(int(*req.N)))
+	}
+	stop, err := provider.StopSequences(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert stop words: %w", err)
+	}
+	if len(stop) > 0 {
+		options = append(options, llms.WithStopWords(stop))
+	}
+	if req.Seed != nil {
+		options = append(options, llms.WithSeed(*req.Seed))
+	}
+	if wantsJSON(req) {
+		options = append(options, llms.WithJSONMode())
+	}
+	options = append(options, toolOptions(req)...)
+
+	return options, nil
+}
+
+// samplingOptions converts the sampling parameters of the request.
+func samplingOptions(req *api.ChatCompletionRequest) []llms.CallOption {
+	var options []llms.CallOption
 	if req.FrequencyPenalty != nil {
 		options = append(options, llms.WithFrequencyPenalty(float64(*req.FrequencyPenalty)))
 	}
 	if req.PresencePenalty != nil {
 		options = append(options, llms.WithPresencePenalty(float64(*req.PresencePenalty)))
 	}
-	if req.MaxTokens != nil {
-		options = append(options, llms.WithMaxTokens(int(*req.MaxTokens)))
-	}
 	if req.Temperature != nil {
 		options = append(options, llms.WithTemperature(float64(*req.Temperature)))
 	}
 	if req.TopP != nil {
 		options = append(options, llms.WithTopP(float64(*req.TopP)))
 	}
-	if req.N != nil {
-		options = append(options, llms.WithN(int(*req.N)))
+	return options
+}
+
+// wantsJSON reports whether the request asks for a JSON object response.
+func wantsJSON(req *api.ChatCompletionRequest) bool {
+	return req.ResponseFormat != nil && req.ResponseFormat.Type == api.ResponseFormatTypeJsonObject
+}
+
+// checkSupported rejects request parameters the underlying model can't honor.
+func (p *LangchainProvider) checkSupported(req *api.ChatCompletionRequest) error {
+	if wantsJSON(req) && !p.jsonMode {
+		return errors.ErrUnsupported.WithMessage("response_format json_object is not supported by this provider")
 	}
-	if req.Stop != nil {
-		stopArr, err := req.Stop.AsChatCompletionRequestStop1()
-		if err != nil {
-			stopWord, err := req.Stop.AsChatCompletionRequestStop0()
-			if err != nil {
-				return nil, fmt.Errorf("failed to convert stop words: %w", err)
-			}
-			stopArr = []string{stopWord}
+	if req.N != nil && *req.N > 1 && !p.multipleChoices {
+		return errors.ErrUnsupported.WithMessage("n greater than 1 is not supported by this provider")
+	}
+	if p.maxStopSequences > 0 {
+		if stop, err := provider.StopSequences(req); err == nil && len(stop) > p.maxStopSequences {
+			return errors.ErrUnsupported.WithMessage(fmt.Sprintf("stop accepts at most %d sequences with this provider, got %d", p.maxStopSequences, len(stop)))
 		}
-		options = append(options, llms.WithStopWords(stopArr))
+	}
+	// langchaingo has no call option for logprobs and drops them from the
+	// responses, so they would be silently missing.
+	if (req.Logprobs != nil && *req.Logprobs) || req.TopLogprobs != nil {
+		return errors.ErrUnsupported.WithMessage("logprobs are not supported by this provider")
+	}
+	return nil
+}
 
+// logIgnored logs the request parameters the model ignores rather than rejects.
+func (p *LangchainProvider) logIgnored(ctx context.Context, req *api.ChatCompletionRequest) {
+	if req.Seed != nil && !p.seed {
+		slog.DebugContext(ctx, "Seed is ignored by the provider, the completion isn't guaranteed to be deterministic", "model", req.Model)
 	}
+}
 
-	return options, nil
+// omitSamplingFields asks the upstream client to drop the temperature of the
+// requests of the models sent without sampling parameters, which the OpenAI
+// and HuggingFace clients send even when unset.
+func (p *LangchainProvider) omitSamplingFields(ctx context.Context, req *api.ChatCompletionRequest) context.Context {
+	if !p.samplingFreeModels[req.Model] {
+		return ctx
+	}
+	return client.WithOmittedFields(ctx, "temperature")
 }
 
-func (p *LangchainProvider) ChatCompletion(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
-	options, err := openaiOptionsToLangchainOptions(req)
+func (p *LangchainProvider) openaiRequestToLangchain(req *api.ChatCompletionRequest) ([]llms.MessageContent, []llms.CallOption, error) {
+	options, err := openaiOptionsToLangchainOptions(req, p.samplingFreeModels[req.Model])
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert OpenAI options to Langchain options: %w", err)
+		return nil, nil, fmt.Errorf("failed to convert OpenAI options to Langchain options: %w", err)
+	}
+	if model := p.upstreamModel(req.Model); model != req.Model {
+		options = append(options, llms.WithModel(model))
 	}
 
 	messages := make([]llms.MessageContent, len(req.Messages))
+	toolNames := map[string]string{}
 	for i, msg := range req.Messages {
-		llmsMsg, err := openaiMsgToLangchainMsg(&msg)
+		llmsMsg, err := p.openaiMsgToLangchainMsg(&msg, toolNames)
 		if err != nil {
-			return nil, fmt.Errorf("failed to convert OpenAI message to Langchain message: %w", err)
+			return nil, nil, fmt.Errorf("failed to convert OpenAI message to Langchain message: %w", err)
 		}
 		messages[i] = llmsMsg
+		if msg.ToolCalls != nil {
+			for _, call := range *msg.ToolCalls {
+				toolNames[call.Id] = call.Function.Name
+			}
+		}
+	}
+	if p.alternatingRoles {
+		var err error
+		if messages, err = alternateRoles(messages); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	return messages, options, nil
+}
+
+func (p *LangchainProvider) ChatCompletion(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+	if err := p.checkSupported(req); err != nil {
+		return nil, err
+	}
+	p.logIgnored(ctx, req)
+	messages, options, err := p.openaiRequestToLangchain(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	ctx = p.omitSamplingFields(ctx, req)
+
 	// Call the Langchain model
 	langchainResp, err := p.model.GenerateContent(ctx, messages, options...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate content: %w", err)
 	}
 
-	// convert the response to the types.ChatCompletionResponse format
+	return langchainRespToOpenAI(langchainResp), nil
+}
+
+func (p *LangchainProvider) ChatCompletionStream(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+	if err := p.checkSupported(req); err != nil {
+		return nil, err
+	}
+	p.logIgnored(ctx, req)
+	// Langchain streams a single choice.
+	if req.N != nil && *req.N > 1 {
+		return nil, errors.ErrUnsupported.WithMessage("n greater than 1 is not supported for streamed chat completions")
+	}
+	messages, options, err := p.openaiRequestToLangchain(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+	ctx = p.omitSamplingFields(ctx, req)
+
+	created := int(time.Now().Unix())
+	newChunk := func(delta api.ChatCompletionDelta) *api.ChatCompletionChunk {
+		return &api.ChatCompletionChunk{
+			Object:  "chat.completion.chunk",
+			Created: created,
+			Model:   req.Model,
+			Choices: []api.ChatCompletionChunkChoice{{Index: 0, Delta: delta}},
+		}
+	}
+
+	// The first chunk carries the role, following the OpenAI streaming format.
+	sentRole := false
+	options = append(options, llms.WithStreamingFunc(func(ctx context.Context, b []byte) error {
+		delta := api.ChatCompletionDelta{Content: ptr(string(b))}
+		if !sentRole {
+			delta.Role = ptr(api.ChatCompletionDeltaRoleAssistant)
+			sentRole = true
+		}
+		return send(ctx, newChunk(delta))
+	}))
+
+	langchainResp, err := p.model.GenerateContent(ctx, messages, options...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate content: %w", err)
+	}
+
+	res := langchainRespToOpenAI(langchainResp)
+	final := newChunk(api.ChatCompletionDelta{})
+	if len(res.Choices) > 0 {
+		choice := res.Choices[0]
+		if !sentRole {
+			final.Choices[0].Delta.Role = ptr(api.ChatCompletionDeltaRoleAssistant)
+		}
+		if choice.Message.ToolCalls != nil {
+			final.Choices[0].Delta.ToolCalls = choice.Message.ToolCalls
+		}
+		if choice.FinishReason != "" {
+			final.Choices[0].FinishReason = ptr(api.ChatCompletionChunkChoiceFinishReason(choice.FinishReason))
+		}
+	}
+	if err := send(ctx, final); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (p *LangchainProvider) Embeddings(ctx context.Context, req *api.EmbeddingsRequest) (*api.EmbeddingsResponse, error) {
+	embedder, err := p.embedder(p.upstreamModel(req.Model))
+	if err != nil {
+		return nil, err
+	}
+
+	inputs, err := req.Input.AsEmbeddingsRequestInput1()
+	if err != nil {
+		input, err := req.Input.AsEmbeddingsRequestInput0()
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert embeddings input: %w", err)
+		}
+		inputs = []string{input}
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	vectors, err := embedder.CreateEmbedding(ctx, inputs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embeddings: %w", err)
+	}
+
+	res := &api.EmbeddingsResponse{
+		Object: "list",
+		Model:  req.Model,
+		Data:   make([]api.Embedding, len(vectors)),
+	}
+	for i, vector := range vectors {
+		res.Data[i] = api.Embedding{
+			Object:    "embedding",
+			Index:     i,
+			Embedding: vector,
+		}
+	}
+	return res, nil
+}
+
+func (p *LangchainProvider) Moderations(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error) {
+	if p.moderator == nil {
+		return nil, errors.ErrNotFound.WithMessage("provider does not support moderations")
+	}
+
+	ctx, cancel := p.withTimeout(ctx)
+	defer cancel()
+
+	res, err := p.moderator.Moderate(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation: %w", err)
+	}
+	return res, nil
+}
+
+// embedder returns the cached embedder for the model, creating it on first use.
+func (p *LangchainProvider) embedder(model string) (Embedder, error) {
+	if p.newEmbedder == nil {
+		return nil, errors.ErrUnsupported.WithMessage("provider does not support embeddings")
+	}
+	if embedder, ok := p.embedders.Load(model); ok {
+		return embedder.(Embedder), nil
+	}
+	embedder, err := p.newEmbedder(model)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedder for model %s: %w", model, err)
+	}
+	actual, _ := p.embedders.LoadOrStore(model, embedder)
+	return actual.(Embedder), nil
+}
+
+// finishReasons maps the stop reasons reported by the providers, lowercased,
+// to the OpenAI finish reasons.
+var finishReasons = map[string]api.ChatCompletionChoiceFinishReason{
+	"stop":           api.ChatCompletionChoiceFinishReasonStop,
+	"length":         api.ChatCompletionChoiceFinishReasonLength,
+	"tool_calls":     api.ChatCompletionChoiceFinishReasonToolCalls,
+	"function_call":  api.ChatCompletionChoiceFinishReasonFunctionCall,
+	"content_filter": api.ChatCompletionChoiceFinishReasonContentFilter,
+	// Anthropic
+	"end_turn":      api.ChatCompletionChoiceFinishReasonStop,
+	"stop_sequence": api.ChatCompletionChoiceFinishReasonStop,
+	"max_tokens":    api.ChatCompletionChoiceFinishReasonLength,
+	"tool_use":      api.ChatCompletionChoiceFinishReasonToolCalls,
+	// Cohere
+	"complete":    api.ChatCompletionChoiceFinishReasonStop,
+	"error_toxic": api.ChatCompletionChoiceFinishReasonContentFilter,
+	"error_limit": api.ChatCompletionChoiceFinishReasonLength,
+	"user_cancel": api.ChatCompletionChoiceFinishReasonStop,
+	"tool_call":   api.ChatCompletionChoiceFinishReasonToolCalls,
+	// Gemini
+	"safety":     api.ChatCompletionChoiceFinishReasonContentFilter,
+	"recitation": api.ChatCompletionChoiceFinishReasonContentFilter,
+}
+
+// finishReason converts a provider stop reason to an OpenAI finish reason.
+// Unknown and missing reasons fall back to "tool_calls" when the choice has
+// tool calls and to "stop" otherwise, so clients never see raw provider values.
+func finishReason(stopReason string, hasToolCalls bool) api.ChatCompletionChoiceFinishReason {
+	if reason, ok := finishReasons[strings.ToLower(stopReason)]; ok {
+		return reason
+	}
+	if hasToolCalls {
+		return api.ChatCompletionChoiceFinishReasonToolCalls
+	}
+	return api.ChatCompletionChoiceFinishReasonStop
+}
+
+// langchainRespToOpenAI converts a langchain response to the api.ChatCompletionResponse format.
+func langchainRespToOpenAI(langchainResp *llms.ContentResponse) *api.ChatCompletionResponse {
 	res := api.ChatCompletionResponse{
 		Choices: make([]api.ChatCompletionChoice, len(langchainResp.Choices)),
+		Usage:   &api.Usage{},
 	}
+	usageRead := false
 	for i, choice := range langchainResp.Choices {
 		converted := api.ChatCompletionChoice{
 			Index:        i,
-			FinishReason: api.ChatCompletionChoiceFinishReason(choice.StopReason),
+			FinishReason: finishReason(choice.StopReason, len(choice.ToolCalls) > 0),
 		}
 
 		if choice.FuncCall != nil {
@@ -162,9 +668,17 @@ func (p *LangchainProvider) ChatCompletion(ctx context.Context, req *api.ChatCom
 			content.FromChatMessageContent0(choice.Content)
 			converted.Message.Content = content
 		}
+		if choice.ReasoningContent != "" {
+			reasoning := choice.ReasoningContent
+			converted.Message.ReasoningContent = &reasoning
+		}
 
 		res.Choices[i] = converted
-		if choice.GenerationInfo != nil {
+		// Langchain clients repeat the usage of the whole response, which covers
+		// all the choices, in the generation info of every choice, so it's read
+		// from the first one only rather than summed.
+		if choice.GenerationInfo != nil && !usageRead {
+			usageRead = true
 			complTokens, ok := choice.GenerationInfo["CompletionTokens"].(int)
 			if !ok {
 				slog.Warn("invalid type for CompletionTokens", "type", fmt.Sprintf("%T", choice.GenerationInfo["CompletionTokens"]))
@@ -187,5 +701,9 @@ func (p *LangchainProvider) ChatCompletion(ctx context.Context, req *api.ChatCom
 			}
 		}
 	}
-	return &res, nil
+	return &res
+}
+
+func ptr[T any](v T) *T {
+	return &v
 }
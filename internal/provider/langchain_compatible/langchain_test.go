@@ -0,0 +1,611 @@
+package langchaincompatible
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/openai"
+)
+
+func TestFinishReason(t *testing.T) {
+	tests := []struct {
+		stopReason   string
+		hasToolCalls bool
+		expected     api.ChatCompletionChoiceFinishReason
+	}{
+		{stopReason: "stop", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{stopReason: "length", expected: api.ChatCompletionChoiceFinishReasonLength},
+		{stopReason: "end_turn", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{stopReason: "tool_use", hasToolCalls: true, expected: api.ChatCompletionChoiceFinishReasonToolCalls},
+		{stopReason: "COMPLETE", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{stopReason: "MAX_TOKENS", expected: api.ChatCompletionChoiceFinishReasonLength},
+		{stopReason: "ERROR_TOXIC", expected: api.ChatCompletionChoiceFinishReasonContentFilter},
+		{stopReason: "SOMETHING_NEW", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{stopReason: "", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{stopReason: "", hasToolCalls: true, expected: api.ChatCompletionChoiceFinishReasonToolCalls},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.stopReason, func(t *testing.T) {
+			assert.Equal(t, tt.expected, finishReason(tt.stopReason, tt.hasToolCalls))
+		})
+	}
+}
+
+// captureModel records the messages and call options of the last request.
+type captureModel struct {
+	messages []llms.MessageContent
+	opts     llms.CallOptions
+}
+
+func (m *captureModel) GenerateContent(_ context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	m.messages = messages
+	for _, opt := range options {
+		opt(&m.opts)
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{Content: "{}"}}}, nil
+}
+
+func (m *captureModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+func TestChatCompletionResponseFormat(t *testing.T) {
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0("hello"))
+	seed := 42
+	req := &api.ChatCompletionRequest{
+		Model:          "model",
+		Messages:       []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+		ResponseFormat: &api.ResponseFormat{Type: api.ResponseFormatTypeJsonObject},
+		Seed:           &seed,
+	}
+
+	t.Run("supported", func(t *testing.T) {
+		model := &captureModel{}
+		_, err := NewLangchainProvider(model, WithJSONMode()).ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+		assert.True(t, model.opts.JSONMode)
+		assert.Equal(t, 42, model.opts.Seed)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewLangchainProvider(&captureModel{}).ChatCompletion(context.Background(), req)
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.Status)
+		// The fallbacks may support it.
+		assert.True(t, apiErr.Unsupported)
+	})
+
+	t.Run("text", func(t *testing.T) {
+		model := &captureModel{}
+		textReq := *req
+		textReq.ResponseFormat = &api.ResponseFormat{Type: api.ResponseFormatTypeText}
+		_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), &textReq)
+		require.NoError(t, err)
+		assert.False(t, model.opts.JSONMode)
+	})
+}
+
+func TestChatCompletionStop(t *testing.T) {
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0("hello"))
+
+	tests := []struct {
+		name     string
+		stop     string
+		expected []string
+	}{
+		{name: "string", stop: `"\n"`, expected: []string{"\n"}},
+		{name: "array", stop: `["END", "STOP"]`, expected: []string{"END", "STOP"}},
+		{name: "empty strings dropped", stop: `["", "END"]`, expected: []string{"END"}},
+		{name: "empty array", stop: `[]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stop := &api.ChatCompletionRequest_Stop{}
+			require.NoError(t, stop.UnmarshalJSON([]byte(tt.stop)))
+			model := &captureModel{}
+			_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+				Model:    "model",
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+				Stop:     stop,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, model.opts.StopWords)
+		})
+	}
+
+	t.Run("over the cap", func(t *testing.T) {
+		stop := &api.ChatCompletionRequest_Stop{}
+		require.NoError(t, stop.UnmarshalJSON([]byte(`["A", "B", "C"]`)))
+		model := &captureModel{}
+		_, err := NewLangchainProvider(model, WithMaxStopSequences(2)).ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Model:    "model",
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+			Stop:     stop,
+		})
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.Status)
+		assert.Equal(t, "stop accepts at most 2 sequences with this provider, got 3", apiErr.Message)
+		assert.Nil(t, model.messages)
+	})
+}
+
+func TestOpenAIOptionsToLangchainOptionsOmitSampling(t *testing.T) {
+	temperature, topP, penalty := float32(0.7), float32(0.9), float32(0.5)
+	maxTokens := 100
+	req := &api.ChatCompletionRequest{
+		Model:            "o1",
+		MaxTokens:        &maxTokens,
+		Temperature:      &temperature,
+		TopP:             &topP,
+		PresencePenalty:  &penalty,
+		FrequencyPenalty: &penalty,
+	}
+
+	apply := func(options []llms.CallOption) llms.CallOptions {
+		var opts llms.CallOptions
+		for _, opt := range options {
+			opt(&opts)
+		}
+		return opts
+	}
+
+	options, err := openaiOptionsToLangchainOptions(req, false)
+	require.NoError(t, err)
+	assert.Len(t, options, 6)
+	opts := apply(options)
+	assert.InDelta(t, 0.7, opts.Temperature, 1e-6)
+	assert.InDelta(t, 0.9, opts.TopP, 1e-6)
+
+	options, err = openaiOptionsToLangchainOptions(req, true)
+	require.NoError(t, err)
+	assert.Len(t, options, 2)
+	opts = apply(options)
+	assert.Equal(t, "o1", opts.Model)
+	assert.Equal(t, 100, opts.MaxTokens)
+	assert.Zero(t, opts.Temperature)
+	assert.Zero(t, opts.TopP)
+	assert.Zero(t, opts.PresencePenalty)
+	assert.Zero(t, opts.FrequencyPenalty)
+
+	t.Run("per model", func(t *testing.T) {
+		content := api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent0("hello"))
+		p := NewLangchainProvider(&captureModel{}, WithoutSamplingParams("o1"))
+		for model, expected := range map[string]float64{"o1": 0, "gpt-4o": 0.7} {
+			captured := &captureModel{}
+			p.model = captured
+			modelReq := *req
+			modelReq.Model = model
+			modelReq.Messages = []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}}
+			_, err := p.ChatCompletion(context.Background(), &modelReq)
+			require.NoError(t, err)
+			assert.InDelta(t, expected, captured.opts.Temperature, 1e-6, model)
+		}
+	})
+
+	t.Run("openai body", func(t *testing.T) {
+		content := api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent0("hello"))
+		var bodies []map[string]json.RawMessage
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var body map[string]json.RawMessage
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			bodies = append(bodies, body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`))
+		}))
+		defer srv.Close()
+
+		llm, err := openai.New(openai.WithToken("test"), openai.WithBaseURL(srv.URL), openai.WithHTTPClient(client.New(srv.Client())))
+		require.NoError(t, err)
+		p := NewLangchainProvider(llm, WithoutSamplingParams("o1"))
+		for _, model := range []string{"o1", "gpt-4o"} {
+			modelReq := *req
+			modelReq.Model = model
+			modelReq.Messages = []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}}
+			_, err := p.ChatCompletion(context.Background(), &modelReq)
+			require.NoError(t, err)
+		}
+
+		// The OpenAI client sends the temperature even when it's unset.
+		require.Len(t, bodies, 2)
+		assert.NotContains(t, bodies[0], "temperature")
+		assert.NotContains(t, bodies[0], "top_p")
+		assert.Contains(t, bodies[0], "messages")
+		assert.Contains(t, bodies[1], "temperature")
+	})
+}
+
+type moderatorFunc func(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error)
+
+func (f moderatorFunc) Moderate(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error) {
+	return f(ctx, req)
+}
+
+func TestModerations(t *testing.T) {
+	req := &api.ModerationRequest{Model: "omni-moderation-latest"}
+
+	t.Run("supported", func(t *testing.T) {
+		expected := &api.ModerationResponse{Id: "modr-1", Model: req.Model}
+		p := NewLangchainProvider(&captureModel{}, WithModerator(moderatorFunc(func(ctx context.Context, got *api.ModerationRequest) (*api.ModerationResponse, error) {
+			assert.Equal(t, req, got)
+			return expected, nil
+		})))
+
+		resp, err := p.Moderations(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, expected, resp)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewLangchainProvider(&captureModel{}).Moderations(context.Background(), req)
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 404, apiErr.Status)
+	})
+}
+
+func TestChatCompletionImageParts(t *testing.T) {
+	const dataURL = "data:image/png;base64,iVBORw0KGgo="
+	const remoteURL = "https://example.com/cat.png"
+	text := "What is in these images?"
+	detail := api.MessageContentPartImageUrlDetailLow
+
+	parts := []api.MessageContentPart{
+		{Type: api.MessageContentPartTypeText, Text: &text},
+		{Type: api.MessageContentPartTypeImageUrl, ImageUrl: &struct {
+			Detail *api.MessageContentPartImageUrlDetail `json:"detail,omitempty"`
+			Url    string                                `json:"url"`
+		}{Url: dataURL, Detail: &detail}},
+		{Type: api.MessageContentPartTypeImageUrl, ImageUrl: &struct {
+			Detail *api.MessageContentPartImageUrlDetail `json:"detail,omitempty"`
+			Url    string                                `json:"url"`
+		}{Url: remoteURL}},
+	}
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent1(parts))
+	req := &api.ChatCompletionRequest{
+		Model:    "vision-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+	}
+
+	t.Run("urls", func(t *testing.T) {
+		model := &captureModel{}
+		_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, model.messages, 1)
+		assert.Equal(t, []llms.ContentPart{
+			llms.TextPart(text),
+			llms.ImageURLWithDetailPart(dataURL, "low"),
+			llms.ImageURLPart(remoteURL),
+		}, model.messages[0].Parts)
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		model := &captureModel{}
+		_, err := NewLangchainProvider(model, WithBinaryImages()).ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, model.messages, 1)
+		assert.Equal(t, []llms.ContentPart{
+			llms.TextPart(text),
+			llms.BinaryPart("image/png", []byte("\x89PNG\r\n\x1a\n")),
+			llms.ImageURLPart(remoteURL),
+		}, model.messages[0].Parts)
+	})
+
+	t.Run("invalid data url", func(t *testing.T) {
+		invalid := api.ChatMessage_Content{}
+		require.NoError(t, invalid.FromChatMessageContent1([]api.MessageContentPart{
+			{Type: api.MessageContentPartTypeImageUrl, ImageUrl: &struct {
+				Detail *api.MessageContentPartImageUrlDetail `json:"detail,omitempty"`
+				Url    string                                `json:"url"`
+			}{Url: "data:image/png,raw"}},
+		}))
+
+		_, err := NewLangchainProvider(&captureModel{}, WithBinaryImages()).ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &invalid}},
+		})
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.Status)
+	})
+
+	t.Run("openai forwards parts verbatim", func(t *testing.T) {
+		var body struct {
+			Messages []struct {
+				Content json.RawMessage `json:"content"`
+			} `json:"messages"`
+		}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"cats"},"finish_reason":"stop"}]}`))
+		}))
+		defer srv.Close()
+
+		llm, err := openai.New(openai.WithToken("test"), openai.WithBaseURL(srv.URL))
+		require.NoError(t, err)
+
+		_, err = NewLangchainProvider(llm, WithJSONMode()).ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+
+		require.Len(t, body.Messages, 1)
+		assert.JSONEq(t, `[
+			{"type": "text", "text": "What is in these images?"},
+			{"type": "image_url", "image_url": {"url": "`+dataURL+`", "detail": "low"}},
+			{"type": "image_url", "image_url": {"url": "`+remoteURL+`"}}
+		]`, string(body.Messages[0].Content))
+	})
+}
+
+func TestChatCompletionMessagesWithoutContent(t *testing.T) {
+	question := api.ChatMessage_Content{}
+	require.NoError(t, question.FromChatMessageContent0("What's the weather in Paris?"))
+	toolCallID := "call_1"
+	req := &api.ChatCompletionRequest{
+		Model: "tool-model",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleUser, Content: &question},
+			{Role: api.ChatMessageRoleAssistant, ToolCalls: &[]api.ToolCall{{
+				Id:       toolCallID,
+				Type:     api.ToolCallTypeFunction,
+				Function: api.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: api.ChatMessageRoleTool, ToolCallId: &toolCallID},
+		},
+	}
+
+	model := &captureModel{}
+	_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, model.messages, 3)
+	assert.Equal(t, llms.MessageContent{Role: llms.ChatMessageTypeAI, Parts: []llms.ContentPart{llms.ToolCall{
+		ID:           toolCallID,
+		Type:         "function",
+		FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+	}}}, model.messages[1])
+	assert.Equal(t, llms.MessageContent{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+		llms.ToolCallResponse{ToolCallID: toolCallID, Name: "get_weather"},
+	}}, model.messages[2])
+}
+
+func TestChatCompletionToolCallRoundTrip(t *testing.T) {
+	var body struct {
+		Messages []json.RawMessage `json:"messages"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"It's 20 degrees."},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	llm, err := openai.New(openai.WithToken("test"), openai.WithBaseURL(srv.URL))
+	require.NoError(t, err)
+
+	question := api.ChatMessage_Content{}
+	require.NoError(t, question.FromChatMessageContent0("What's the weather in Paris?"))
+	result := api.ChatMessage_Content{}
+	require.NoError(t, result.FromChatMessageContent0(`{"temperature":20}`))
+	toolCallID := "call_1"
+	resp, err := NewLangchainProvider(llm).ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model: "gpt-4o",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleUser, Content: &question},
+			{Role: api.ChatMessageRoleAssistant, ToolCalls: &[]api.ToolCall{{
+				Id:       toolCallID,
+				Type:     api.ToolCallTypeFunction,
+				Function: api.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+			}}},
+			{Role: api.ChatMessageRoleTool, ToolCallId: &toolCallID, Content: &result},
+		},
+	})
+	require.NoError(t, err)
+	answer, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "It's 20 degrees.", answer)
+
+	require.Len(t, body.Messages, 3)
+	var assistant struct {
+		Role      string `json:"role"`
+		ToolCalls []struct {
+			ID       string `json:"id"`
+			Type     string `json:"type"`
+			Function struct {
+				Name      string `json:"name"`
+				Arguments string `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	}
+	require.NoError(t, json.Unmarshal(body.Messages[1], &assistant))
+	assert.Equal(t, "assistant", assistant.Role)
+	require.Len(t, assistant.ToolCalls, 1)
+	assert.Equal(t, toolCallID, assistant.ToolCalls[0].ID)
+	assert.Equal(t, "function", assistant.ToolCalls[0].Type)
+	assert.Equal(t, "get_weather", assistant.ToolCalls[0].Function.Name)
+	assert.Equal(t, `{"city":"Paris"}`, assistant.ToolCalls[0].Function.Arguments)
+
+	var tool struct {
+		Role       string `json:"role"`
+		ToolCallID string `json:"tool_call_id"`
+		Content    string `json:"content"`
+	}
+	require.NoError(t, json.Unmarshal(body.Messages[2], &tool))
+	assert.Equal(t, "tool", tool.Role)
+	assert.Equal(t, toolCallID, tool.ToolCallID)
+	assert.Equal(t, `{"temperature":20}`, tool.Content)
+}
+
+// choicesModel answers with three choices, each repeating the usage of the
+// whole response as langchain clients do.
+type choicesModel struct {
+	captureModel
+}
+
+func (m *choicesModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if _, err := m.captureModel.GenerateContent(ctx, messages, options...); err != nil {
+		return nil, err
+	}
+	usage := map[string]any{"PromptTokens": 10, "CompletionTokens": 9, "TotalTokens": 19}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{
+		{Content: "one", StopReason: "stop", GenerationInfo: usage},
+		{Content: "two", StopReason: "stop", GenerationInfo: usage},
+		{Content: "three", StopReason: "length", GenerationInfo: usage},
+	}}, nil
+}
+
+func TestChatCompletionMultipleChoices(t *testing.T) {
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0("hello"))
+	n := 3
+	req := &api.ChatCompletionRequest{
+		Model:    "model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+		N:        &n,
+	}
+
+	t.Run("supported", func(t *testing.T) {
+		model := &choicesModel{}
+		resp, err := NewLangchainProvider(model, WithMultipleChoices()).ChatCompletion(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, 3, model.opts.N)
+		require.Len(t, resp.Choices, 3)
+		for i, choice := range resp.Choices {
+			assert.Equal(t, i, choice.Index)
+		}
+		assert.Equal(t, api.ChatCompletionChoiceFinishReasonLength, resp.Choices[2].FinishReason)
+		assert.Equal(t, &api.Usage{PromptTokens: 10, CompletionTokens: 9, TotalTokens: 19}, resp.Usage)
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewLangchainProvider(&choicesModel{}).ChatCompletion(context.Background(), req)
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.Status)
+		assert.Equal(t, "n greater than 1 is not supported by this provider", apiErr.Message)
+		assert.True(t, apiErr.Unsupported)
+	})
+
+	t.Run("streamed", func(t *testing.T) {
+		_, err := NewLangchainProvider(&choicesModel{}, WithMultipleChoices()).ChatCompletionStream(context.Background(), req, func(context.Context, *api.ChatCompletionChunk) error { return nil })
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, 400, apiErr.Status)
+		assert.True(t, apiErr.Unsupported)
+	})
+}
+
+// toolCallModel answers with a tool call and no streamed content.
+type toolCallModel struct {
+	captureModel
+}
+
+func (m *toolCallModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	if _, err := m.captureModel.GenerateContent(ctx, messages, options...); err != nil {
+		return nil, err
+	}
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		StopReason: "tool_calls",
+		ToolCalls: []llms.ToolCall{{
+			ID:           "call_1",
+			Type:         "function",
+			FunctionCall: &llms.FunctionCall{Name: "get_weather", Arguments: `{"city":"Paris"}`},
+		}},
+	}}}, nil
+}
+
+func TestChatCompletionStreamToolCalls(t *testing.T) {
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0("What's the weather in Paris?"))
+	req := &api.ChatCompletionRequest{
+		Model:    "tool-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+	}
+
+	var chunks []*api.ChatCompletionChunk
+	_, err := NewLangchainProvider(&toolCallModel{}).ChatCompletionStream(context.Background(), req, func(_ context.Context, chunk *api.ChatCompletionChunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 1)
+	delta := chunks[0].Choices[0].Delta
+	assert.Equal(t, ptr(api.ChatCompletionDeltaRoleAssistant), delta.Role)
+	require.NotNil(t, delta.ToolCalls)
+	assert.Equal(t, "get_weather", (*delta.ToolCalls)[0].Function.Name)
+}
+
+func TestChatCompletionLogprobs(t *testing.T) {
+	content := api.ChatMessage_Content{}
+	require.NoError(t, content.FromChatMessageContent0("hello"))
+	logprobs := true
+	topLogprobs := 5
+
+	tests := []struct {
+		name string
+		req  *api.ChatCompletionRequest
+	}{
+		{name: "logprobs", req: &api.ChatCompletionRequest{Logprobs: &logprobs}},
+		{name: "top logprobs", req: &api.ChatCompletionRequest{Logprobs: &logprobs, TopLogprobs: &topLogprobs}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Model = "model"
+			tt.req.Messages = []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}}
+			model := &captureModel{}
+
+			_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), tt.req)
+			var apiErr errors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, 400, apiErr.Status)
+			assert.Equal(t, "logprobs are not supported by this provider", apiErr.Message)
+			// The model is never called without them.
+			assert.Nil(t, model.messages)
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		disabled := false
+		_, err := NewLangchainProvider(&captureModel{}).ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Model:    "model",
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}},
+			Logprobs: &disabled,
+		})
+		require.NoError(t, err)
+	})
+}
+
+func TestLangchainRespToOpenAI_Reasoning(t *testing.T) {
+	resp := langchainRespToOpenAI(&llms.ContentResponse{Choices: []*llms.ContentChoice{
+		{Content: "42", ReasoningContent: "6 times 7 is 42.", StopReason: "stop"},
+		{Content: "43", StopReason: "stop"},
+	}})
+
+	require.Len(t, resp.Choices, 2)
+	require.NotNil(t, resp.Choices[0].Message.ReasoningContent)
+	assert.Equal(t, "6 times 7 is 42.", *resp.Choices[0].Message.ReasoningContent)
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "42", text)
+	assert.Nil(t, resp.Choices[1].Message.ReasoningContent)
+}
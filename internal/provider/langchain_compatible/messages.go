@@ -0,0 +1,71 @@
+package langchaincompatible
+
+import (
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// alternateRoles prepares messages for models that take a single system prompt and
+// require user and assistant turns to alternate, such as Anthropic. All system
+// messages are joined into one leading system message and consecutive messages of
+// the same role are merged into one, the results of the tool calls of a turn
+// included, which these models take in a single message. System messages with
+// other parts than text can't be joined and are unsupported.
+func alternateRoles(messages []llms.MessageContent) ([]llms.MessageContent, error) {
+	var system []string
+	merged := make([]llms.MessageContent, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == llms.ChatMessageTypeSystem {
+			text, ok := textOf(msg)
+			if !ok {
+				return nil, errors.ErrUnsupported.WithMessage("system messages with other content than text are not supported by this provider")
+			}
+			system = append(system, text)
+			continue
+		}
+
+		last := len(merged) - 1
+		if last >= 0 && merged[last].Role == msg.Role {
+			merged[last] = mergeMessages(merged[last], msg)
+			continue
+		}
+		merged = append(merged, msg)
+	}
+
+	if len(system) == 0 {
+		return merged, nil
+	}
+	systemMsg := llms.TextParts(llms.ChatMessageTypeSystem, strings.Join(system, "\n\n"))
+	return append([]llms.MessageContent{systemMsg}, merged...), nil
+}
+
+// mergeMessages appends the parts of b to a. Text-only messages are joined into a
+// single text part, since langchain clients may only read the first part, but
+// for the tool results, which answer distinct tool calls.
+func mergeMessages(a, b llms.MessageContent) llms.MessageContent {
+	aText, aOK := textOf(a)
+	bText, bOK := textOf(b)
+	if aOK && bOK && a.Role != llms.ChatMessageTypeTool {
+		return llms.TextParts(a.Role, aText+"\n\n"+bText)
+	}
+
+	parts := make([]llms.ContentPart, 0, len(a.Parts)+len(b.Parts))
+	parts = append(parts, a.Parts...)
+	parts = append(parts, b.Parts...)
+	return llms.MessageContent{Role: a.Role, Parts: parts}
+}
+
+// textOf returns the text of a message made only of text parts.
+func textOf(msg llms.MessageContent) (string, bool) {
+	texts := make([]string, 0, len(msg.Parts))
+	for _, part := range msg.Parts {
+		text, ok := part.(llms.TextContent)
+		if !ok {
+			return "", false
+		}
+		texts = append(texts, text.Text)
+	}
+	return strings.Join(texts, "\n"), true
+}
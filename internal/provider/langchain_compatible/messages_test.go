@@ -0,0 +1,138 @@
+package langchaincompatible
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestAlternateRoles(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages []llms.MessageContent
+		expected []llms.MessageContent
+	}{
+		{
+			name: "two system messages",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, "You are helpful."),
+				llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+				llms.TextParts(llms.ChatMessageTypeSystem, "Answer briefly."),
+			},
+			expected: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeSystem, "You are helpful.\n\nAnswer briefly."),
+				llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+			},
+		},
+		{
+			name: "interleave",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "Are you there?"),
+				llms.TextParts(llms.ChatMessageTypeAI, "Yes."),
+				llms.TextParts(llms.ChatMessageTypeAI, "How can I help?"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "Tell me a joke"),
+			},
+			expected: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeHuman, "Hi\n\nAre you there?"),
+				llms.TextParts(llms.ChatMessageTypeAI, "Yes.\n\nHow can I help?"),
+				llms.TextParts(llms.ChatMessageTypeHuman, "Tell me a joke"),
+			},
+		},
+		{
+			name: "non-text parts are appended",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeHuman, "What is this?"),
+				{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{llms.ImageURLPart("https://example.com/cat.png")}},
+			},
+			expected: []llms.MessageContent{
+				{Role: llms.ChatMessageTypeHuman, Parts: []llms.ContentPart{
+					llms.TextPart("What is this?"),
+					llms.ImageURLPart("https://example.com/cat.png"),
+				}},
+			},
+		},
+		{
+			name: "tool results of a turn are merged",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeAI, "Checking both."),
+				{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "1", Content: "a"}}},
+				{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{llms.ToolCallResponse{ToolCallID: "2", Content: "b"}}},
+			},
+			expected: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeAI, "Checking both."),
+				{Role: llms.ChatMessageTypeTool, Parts: []llms.ContentPart{
+					llms.ToolCallResponse{ToolCallID: "1", Content: "a"},
+					llms.ToolCallResponse{ToolCallID: "2", Content: "b"},
+				}},
+			},
+		},
+		{
+			name: "text tool results keep their parts",
+			messages: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeTool, `{"temperature":20}`),
+				llms.TextParts(llms.ChatMessageTypeTool, `{"temperature":25}`),
+			},
+			expected: []llms.MessageContent{
+				llms.TextParts(llms.ChatMessageTypeTool, `{"temperature":20}`, `{"temperature":25}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := alternateRoles(tt.messages)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, messages)
+		})
+	}
+
+	t.Run("system message with an image", func(t *testing.T) {
+		_, err := alternateRoles([]llms.MessageContent{
+			{Role: llms.ChatMessageTypeSystem, Parts: []llms.ContentPart{llms.TextPart("Describe"), llms.ImageURLPart("https://example.com/cat.png")}},
+			llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+		})
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.True(t, apiErr.Unsupported)
+	})
+}
+
+func TestChatCompletionAlternatingRoles(t *testing.T) {
+	message := func(role api.ChatMessageRole, text string) api.ChatMessage {
+		content := api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent0(text))
+		return api.ChatMessage{Role: role, Content: &content}
+	}
+	req := &api.ChatCompletionRequest{
+		Model: "claude-3-haiku",
+		Messages: []api.ChatMessage{
+			message(api.ChatMessageRoleSystem, "You are helpful."),
+			message(api.ChatMessageRoleSystem, "Answer briefly."),
+			message(api.ChatMessageRoleUser, "Hi"),
+			message(api.ChatMessageRoleAssistant, "Hello!"),
+			message(api.ChatMessageRoleUser, "Tell me a joke"),
+			message(api.ChatMessageRoleUser, "About cats"),
+		},
+	}
+
+	model := &captureModel{}
+	_, err := NewLangchainProvider(model, WithAlternatingRoles()).ChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, "You are helpful.\n\nAnswer briefly."),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Hi"),
+		llms.TextParts(llms.ChatMessageTypeAI, "Hello!"),
+		llms.TextParts(llms.ChatMessageTypeHuman, "Tell me a joke\n\nAbout cats"),
+	}, model.messages)
+
+	model = &captureModel{}
+	_, err = NewLangchainProvider(model).ChatCompletion(context.Background(), req)
+	require.NoError(t, err)
+	assert.Len(t, model.messages, 6)
+}
@@ -0,0 +1,72 @@
+package langchaincompatible
+
+import (
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// toolOptions converts the tools and the tool choice of req to langchaingo options.
+// The legacy functions and function_call fields are mapped to function tools and
+// a tool choice, tools and tool_choice taking precedence.
+func toolOptions(req *api.ChatCompletionRequest) []llms.CallOption {
+	var tools []llms.Tool
+	if req.Tools != nil {
+		for _, tool := range *req.Tools {
+			tools = append(tools, llms.Tool{Type: string(tool.Type), Function: functionDefinition(tool.Function)})
+		}
+	}
+	if req.Functions != nil {
+		for _, fn := range *req.Functions {
+			tools = append(tools, llms.Tool{Type: string(api.ToolTypeFunction), Function: functionDefinition(fn)})
+		}
+	}
+
+	var options []llms.CallOption
+	if len(tools) > 0 {
+		options = append(options, llms.WithTools(tools))
+	}
+	if choice := toolChoice(req); choice != nil {
+		options = append(options, llms.WithToolChoice(choice))
+	}
+	return options
+}
+
+func functionDefinition(fn api.FunctionDefinition) *llms.FunctionDefinition {
+	def := &llms.FunctionDefinition{
+		Name:       fn.Name,
+		Parameters: fn.Parameters,
+	}
+	if fn.Description != nil {
+		def.Description = *fn.Description
+	}
+	return def
+}
+
+// toolChoice returns "none", "auto", "required" or the llms.ToolChoice of a named
+// function, nil when the request leaves the choice to the model.
+func toolChoice(req *api.ChatCompletionRequest) any {
+	if req.ToolChoice != nil {
+		if mode, err := req.ToolChoice.AsChatCompletionRequestToolChoice0(); err == nil {
+			return string(mode)
+		}
+		if named, err := req.ToolChoice.AsNamedToolChoice(); err == nil {
+			return namedFunction(named.Function.Name)
+		}
+	}
+	if req.FunctionCall != nil {
+		if mode, err := req.FunctionCall.AsChatCompletionRequestFunctionCall0(); err == nil {
+			return string(mode)
+		}
+		if named, err := req.FunctionCall.AsChatCompletionRequestFunctionCall1(); err == nil {
+			return namedFunction(named.Name)
+		}
+	}
+	return nil
+}
+
+func namedFunction(name string) llms.ToolChoice {
+	return llms.ToolChoice{
+		Type:     string(api.NamedToolChoiceTypeFunction),
+		Function: &llms.FunctionReference{Name: name},
+	}
+}
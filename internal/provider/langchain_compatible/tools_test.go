@@ -0,0 +1,66 @@
+package langchaincompatible
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms"
+)
+
+func TestChatCompletionTools(t *testing.T) {
+	weather := &llms.FunctionDefinition{
+		Name:        "get_weather",
+		Description: "Get the current weather",
+		Parameters: map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"city": map[string]any{"type": "string"}},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		wantChoice any
+	}{
+		{
+			name: "tool without choice",
+			body: `{"tools": [{"type": "function", "function": {"name": "get_weather", "description": "Get the current weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}}]}`,
+		},
+		{
+			name:       "named tool choice",
+			body:       `{"tools": [{"type": "function", "function": {"name": "get_weather", "description": "Get the current weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}}], "tool_choice": {"type": "function", "function": {"name": "get_weather"}}}`,
+			wantChoice: llms.ToolChoice{Type: "function", Function: &llms.FunctionReference{Name: "get_weather"}},
+		},
+		{
+			name:       "required tool choice",
+			body:       `{"tools": [{"type": "function", "function": {"name": "get_weather", "description": "Get the current weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}}], "tool_choice": "required"}`,
+			wantChoice: "required",
+		},
+		{
+			name:       "legacy functions",
+			body:       `{"functions": [{"name": "get_weather", "description": "Get the current weather", "parameters": {"type": "object", "properties": {"city": {"type": "string"}}}}], "function_call": "auto"}`,
+			wantChoice: "auto",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req api.ChatCompletionRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+			content := api.ChatMessage_Content{}
+			require.NoError(t, content.FromChatMessageContent0("What's the weather in Paris?"))
+			req.Model = "model"
+			req.Messages = []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: &content}}
+
+			model := &captureModel{}
+			_, err := NewLangchainProvider(model).ChatCompletion(context.Background(), &req)
+			require.NoError(t, err)
+
+			assert.Equal(t, []llms.Tool{{Type: "function", Function: weather}}, model.opts.Tools)
+			assert.Equal(t, tt.wantChoice, model.opts.ToolChoice)
+		})
+	}
+}
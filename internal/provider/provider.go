@@ -8,8 +8,21 @@ import (
 	"github.com/dmitrii/llm-gateway/api"
 )
 
+// StreamFunc is called for every chunk produced by a streaming chat completion.
+// Returning an error aborts the stream.
+type StreamFunc func(ctx context.Context, chunk *api.ChatCompletionChunk) error
+
 // Provider is the interface that all LLM providers must implement.
 type Provider interface {
 	// ChatCompletion creates a completion for the given chat conversation.
 	ChatCompletion(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error)
+	// ChatCompletionStream creates a completion for the given chat conversation and
+	// delivers it incrementally through send. The returned response holds the
+	// aggregated result, including token usage when the provider reports it.
+	ChatCompletionStream(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) (*api.ChatCompletionResponse, error)
+	// Embeddings creates embedding vectors for the given input.
+	Embeddings(ctx context.Context, req *api.EmbeddingsRequest) (*api.EmbeddingsResponse, error)
+	// Moderations classifies whether the given input is potentially harmful.
+	// Providers without a moderation API return a not found error.
+	Moderations(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error)
 }
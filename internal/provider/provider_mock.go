@@ -25,6 +25,27 @@ type ProviderMock struct {
 	afterChatCompletionCounter  uint64
 	beforeChatCompletionCounter uint64
 	ChatCompletionMock          mProviderMockChatCompletion
+
+	funcChatCompletionStream          func(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) (cp1 *api.ChatCompletionResponse, err error)
+	funcChatCompletionStreamOrigin    string
+	inspectFuncChatCompletionStream   func(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc)
+	afterChatCompletionStreamCounter  uint64
+	beforeChatCompletionStreamCounter uint64
+	ChatCompletionStreamMock          mProviderMockChatCompletionStream
+
+	funcEmbeddings          func(ctx context.Context, req *api.EmbeddingsRequest) (ep1 *api.EmbeddingsResponse, err error)
+	funcEmbeddingsOrigin    string
+	inspectFuncEmbeddings   func(ctx context.Context, req *api.EmbeddingsRequest)
+	afterEmbeddingsCounter  uint64
+	beforeEmbeddingsCounter uint64
+	EmbeddingsMock          mProviderMockEmbeddings
+
+	funcModerations          func(ctx context.Context, req *api.ModerationRequest) (mp1 *api.ModerationResponse, err error)
+	funcModerationsOrigin    string
+	inspectFuncModerations   func(ctx context.Context, req *api.ModerationRequest)
+	afterModerationsCounter  uint64
+	beforeModerationsCounter uint64
+	ModerationsMock          mProviderMockModerations
 }
 
 // NewProviderMock returns a mock for Provider
@@ -38,6 +59,15 @@ func NewProviderMock(t minimock.Tester) *ProviderMock {
 	m.ChatCompletionMock = mProviderMockChatCompletion{mock: m}
 	m.ChatCompletionMock.callArgs = []*ProviderMockChatCompletionParams{}
 
+	m.ChatCompletionStreamMock = mProviderMockChatCompletionStream{mock: m}
+	m.ChatCompletionStreamMock.callArgs = []*ProviderMockChatCompletionStreamParams{}
+
+	m.EmbeddingsMock = mProviderMockEmbeddings{mock: m}
+	m.EmbeddingsMock.callArgs = []*ProviderMockEmbeddingsParams{}
+
+	m.ModerationsMock = mProviderMockModerations{mock: m}
+	m.ModerationsMock.callArgs = []*ProviderMockModerationsParams{}
+
 	t.Cleanup(m.MinimockFinish)
 
 	return m
@@ -386,11 +416,1077 @@ func (m *ProviderMock) MinimockChatCompletionInspect() {
 	}
 }
 
-// MinimockFinish checks that all mocked methods have been called the expected number of times
-func (m *ProviderMock) MinimockFinish() {
-	m.finishOnce.Do(func() {
-		if !m.minimockDone() {
-			m.MinimockChatCompletionInspect()
+type mProviderMockChatCompletionStream struct {
+	optional           bool
+	mock               *ProviderMock
+	defaultExpectation *ProviderMockChatCompletionStreamExpectation
+	expectations       []*ProviderMockChatCompletionStreamExpectation
+
+	callArgs []*ProviderMockChatCompletionStreamParams
+	mutex    sync.RWMutex
+
+	expectedInvocations       uint64
+	expectedInvocationsOrigin string
+}
+
+// ProviderMockChatCompletionStreamExpectation specifies expectation struct of the Provider.ChatCompletionStream
+type ProviderMockChatCompletionStreamExpectation struct {
+	mock               *ProviderMock
+	params             *ProviderMockChatCompletionStreamParams
+	paramPtrs          *ProviderMockChatCompletionStreamParamPtrs
+	expectationOrigins ProviderMockChatCompletionStreamExpectationOrigins
+	results            *ProviderMockChatCompletionStreamResults
+	returnOrigin       string
+	Counter            uint64
+}
+
+// ProviderMockChatCompletionStreamParams contains parameters of the Provider.ChatCompletionStream
+type ProviderMockChatCompletionStreamParams struct {
+	ctx  context.Context
+	req  *api.ChatCompletionRequest
+	send StreamFunc
+}
+
+// ProviderMockChatCompletionStreamParamPtrs contains pointers to parameters of the Provider.ChatCompletionStream
+type ProviderMockChatCompletionStreamParamPtrs struct {
+	ctx  *context.Context
+	req  **api.ChatCompletionRequest
+	send *StreamFunc
+}
+
+// ProviderMockChatCompletionStreamResults contains results of the Provider.ChatCompletionStream
+type ProviderMockChatCompletionStreamResults struct {
+	cp1 *api.ChatCompletionResponse
+	err error
+}
+
+// ProviderMockChatCompletionStreamOrigins contains origins of expectations of the Provider.ChatCompletionStream
+type ProviderMockChatCompletionStreamExpectationOrigins struct {
+	origin     string
+	originCtx  string
+	originReq  string
+	originSend string
+}
+
+// Marks this method to be optional. The default behavior of any method with Return() is '1 or more', meaning
+// the test will fail minimock's automatic final call check if the mocked method was not called at least once.
+// Optional() makes method check to work in '0 or more' mode.
+// It is NOT RECOMMENDED to use this option unless you really need it, as default behaviour helps to
+// catch the problems when the expected method call is totally skipped during test run.
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Optional() *mProviderMockChatCompletionStream {
+	mmChatCompletionStream.optional = true
+	return mmChatCompletionStream
+}
+
+// Expect sets up expected params for Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Expect(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) *mProviderMockChatCompletionStream {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	if mmChatCompletionStream.defaultExpectation == nil {
+		mmChatCompletionStream.defaultExpectation = &ProviderMockChatCompletionStreamExpectation{}
+	}
+
+	if mmChatCompletionStream.defaultExpectation.paramPtrs != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by ExpectParams functions")
+	}
+
+	mmChatCompletionStream.defaultExpectation.params = &ProviderMockChatCompletionStreamParams{ctx, req, send}
+	mmChatCompletionStream.defaultExpectation.expectationOrigins.origin = minimock.CallerInfo(1)
+	for _, e := range mmChatCompletionStream.expectations {
+		if minimock.Equal(e.params, mmChatCompletionStream.defaultExpectation.params) {
+			mmChatCompletionStream.mock.t.Fatalf("Expectation set by When has same params: %#v", *mmChatCompletionStream.defaultExpectation.params)
+		}
+	}
+
+	return mmChatCompletionStream
+}
+
+// ExpectCtxParam1 sets up expected param ctx for Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) ExpectCtxParam1(ctx context.Context) *mProviderMockChatCompletionStream {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	if mmChatCompletionStream.defaultExpectation == nil {
+		mmChatCompletionStream.defaultExpectation = &ProviderMockChatCompletionStreamExpectation{}
+	}
+
+	if mmChatCompletionStream.defaultExpectation.params != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Expect")
+	}
+
+	if mmChatCompletionStream.defaultExpectation.paramPtrs == nil {
+		mmChatCompletionStream.defaultExpectation.paramPtrs = &ProviderMockChatCompletionStreamParamPtrs{}
+	}
+	mmChatCompletionStream.defaultExpectation.paramPtrs.ctx = &ctx
+	mmChatCompletionStream.defaultExpectation.expectationOrigins.originCtx = minimock.CallerInfo(1)
+
+	return mmChatCompletionStream
+}
+
+// ExpectReqParam2 sets up expected param req for Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) ExpectReqParam2(req *api.ChatCompletionRequest) *mProviderMockChatCompletionStream {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	if mmChatCompletionStream.defaultExpectation == nil {
+		mmChatCompletionStream.defaultExpectation = &ProviderMockChatCompletionStreamExpectation{}
+	}
+
+	if mmChatCompletionStream.defaultExpectation.params != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Expect")
+	}
+
+	if mmChatCompletionStream.defaultExpectation.paramPtrs == nil {
+		mmChatCompletionStream.defaultExpectation.paramPtrs = &ProviderMockChatCompletionStreamParamPtrs{}
+	}
+	mmChatCompletionStream.defaultExpectation.paramPtrs.req = &req
+	mmChatCompletionStream.defaultExpectation.expectationOrigins.originReq = minimock.CallerInfo(1)
+
+	return mmChatCompletionStream
+}
+
+// ExpectSendParam3 sets up expected param send for Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) ExpectSendParam3(send StreamFunc) *mProviderMockChatCompletionStream {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	if mmChatCompletionStream.defaultExpectation == nil {
+		mmChatCompletionStream.defaultExpectation = &ProviderMockChatCompletionStreamExpectation{}
+	}
+
+	if mmChatCompletionStream.defaultExpectation.params != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Expect")
+	}
+
+	if mmChatCompletionStream.defaultExpectation.paramPtrs == nil {
+		mmChatCompletionStream.defaultExpectation.paramPtrs = &ProviderMockChatCompletionStreamParamPtrs{}
+	}
+	mmChatCompletionStream.defaultExpectation.paramPtrs.send = &send
+	mmChatCompletionStream.defaultExpectation.expectationOrigins.originSend = minimock.CallerInfo(1)
+
+	return mmChatCompletionStream
+}
+
+// Inspect accepts an inspector function that has same arguments as the Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Inspect(f func(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc)) *mProviderMockChatCompletionStream {
+	if mmChatCompletionStream.mock.inspectFuncChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("Inspect function is already set for ProviderMock.ChatCompletionStream")
+	}
+
+	mmChatCompletionStream.mock.inspectFuncChatCompletionStream = f
+
+	return mmChatCompletionStream
+}
+
+// Return sets up results that will be returned by Provider.ChatCompletionStream
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Return(cp1 *api.ChatCompletionResponse, err error) *ProviderMock {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	if mmChatCompletionStream.defaultExpectation == nil {
+		mmChatCompletionStream.defaultExpectation = &ProviderMockChatCompletionStreamExpectation{mock: mmChatCompletionStream.mock}
+	}
+	mmChatCompletionStream.defaultExpectation.results = &ProviderMockChatCompletionStreamResults{cp1, err}
+	mmChatCompletionStream.defaultExpectation.returnOrigin = minimock.CallerInfo(1)
+	return mmChatCompletionStream.mock
+}
+
+// Set uses given function f to mock the Provider.ChatCompletionStream method
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Set(f func(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) (cp1 *api.ChatCompletionResponse, err error)) *ProviderMock {
+	if mmChatCompletionStream.defaultExpectation != nil {
+		mmChatCompletionStream.mock.t.Fatalf("Default expectation is already set for the Provider.ChatCompletionStream method")
+	}
+
+	if len(mmChatCompletionStream.expectations) > 0 {
+		mmChatCompletionStream.mock.t.Fatalf("Some expectations are already set for the Provider.ChatCompletionStream method")
+	}
+
+	mmChatCompletionStream.mock.funcChatCompletionStream = f
+	mmChatCompletionStream.mock.funcChatCompletionStreamOrigin = minimock.CallerInfo(1)
+	return mmChatCompletionStream.mock
+}
+
+// When sets expectation for the Provider.ChatCompletionStream which will trigger the result defined by the following
+// Then helper
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) When(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) *ProviderMockChatCompletionStreamExpectation {
+	if mmChatCompletionStream.mock.funcChatCompletionStream != nil {
+		mmChatCompletionStream.mock.t.Fatalf("ProviderMock.ChatCompletionStream mock is already set by Set")
+	}
+
+	expectation := &ProviderMockChatCompletionStreamExpectation{
+		mock:               mmChatCompletionStream.mock,
+		params:             &ProviderMockChatCompletionStreamParams{ctx, req, send},
+		expectationOrigins: ProviderMockChatCompletionStreamExpectationOrigins{origin: minimock.CallerInfo(1)},
+	}
+	mmChatCompletionStream.expectations = append(mmChatCompletionStream.expectations, expectation)
+	return expectation
+}
+
+// Then sets up Provider.ChatCompletionStream return parameters for the expectation previously defined by the When method
+func (e *ProviderMockChatCompletionStreamExpectation) Then(cp1 *api.ChatCompletionResponse, err error) *ProviderMock {
+	e.results = &ProviderMockChatCompletionStreamResults{cp1, err}
+	return e.mock
+}
+
+// Times sets number of times Provider.ChatCompletionStream should be invoked
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Times(n uint64) *mProviderMockChatCompletionStream {
+	if n == 0 {
+		mmChatCompletionStream.mock.t.Fatalf("Times of ProviderMock.ChatCompletionStream mock can not be zero")
+	}
+	mm_atomic.StoreUint64(&mmChatCompletionStream.expectedInvocations, n)
+	mmChatCompletionStream.expectedInvocationsOrigin = minimock.CallerInfo(1)
+	return mmChatCompletionStream
+}
+
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) invocationsDone() bool {
+	if len(mmChatCompletionStream.expectations) == 0 && mmChatCompletionStream.defaultExpectation == nil && mmChatCompletionStream.mock.funcChatCompletionStream == nil {
+		return true
+	}
+
+	totalInvocations := mm_atomic.LoadUint64(&mmChatCompletionStream.mock.afterChatCompletionStreamCounter)
+	expectedInvocations := mm_atomic.LoadUint64(&mmChatCompletionStream.expectedInvocations)
+
+	return totalInvocations > 0 && (expectedInvocations == 0 || expectedInvocations == totalInvocations)
+}
+
+// ChatCompletionStream implements Provider
+func (mmChatCompletionStream *ProviderMock) ChatCompletionStream(ctx context.Context, req *api.ChatCompletionRequest, send StreamFunc) (cp1 *api.ChatCompletionResponse, err error) {
+	mm_atomic.AddUint64(&mmChatCompletionStream.beforeChatCompletionStreamCounter, 1)
+	defer mm_atomic.AddUint64(&mmChatCompletionStream.afterChatCompletionStreamCounter, 1)
+
+	mmChatCompletionStream.t.Helper()
+
+	if mmChatCompletionStream.inspectFuncChatCompletionStream != nil {
+		mmChatCompletionStream.inspectFuncChatCompletionStream(ctx, req, send)
+	}
+
+	mm_params := ProviderMockChatCompletionStreamParams{ctx, req, send}
+
+	// Record call args
+	mmChatCompletionStream.ChatCompletionStreamMock.mutex.Lock()
+	mmChatCompletionStream.ChatCompletionStreamMock.callArgs = append(mmChatCompletionStream.ChatCompletionStreamMock.callArgs, &mm_params)
+	mmChatCompletionStream.ChatCompletionStreamMock.mutex.Unlock()
+
+	for _, e := range mmChatCompletionStream.ChatCompletionStreamMock.expectations {
+		if minimock.Equal(*e.params, mm_params) {
+			mm_atomic.AddUint64(&e.Counter, 1)
+			return e.results.cp1, e.results.err
+		}
+	}
+
+	if mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation != nil {
+		mm_atomic.AddUint64(&mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.Counter, 1)
+		mm_want := mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.params
+		mm_want_ptrs := mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.paramPtrs
+
+		mm_got := ProviderMockChatCompletionStreamParams{ctx, req, send}
+
+		if mm_want_ptrs != nil {
+
+			if mm_want_ptrs.ctx != nil && !minimock.Equal(*mm_want_ptrs.ctx, mm_got.ctx) {
+				mmChatCompletionStream.t.Errorf("ProviderMock.ChatCompletionStream got unexpected parameter ctx, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.expectationOrigins.originCtx, *mm_want_ptrs.ctx, mm_got.ctx, minimock.Diff(*mm_want_ptrs.ctx, mm_got.ctx))
+			}
+
+			if mm_want_ptrs.req != nil && !minimock.Equal(*mm_want_ptrs.req, mm_got.req) {
+				mmChatCompletionStream.t.Errorf("ProviderMock.ChatCompletionStream got unexpected parameter req, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.expectationOrigins.originReq, *mm_want_ptrs.req, mm_got.req, minimock.Diff(*mm_want_ptrs.req, mm_got.req))
+			}
+
+			if mm_want_ptrs.send != nil && !minimock.Equal(*mm_want_ptrs.send, mm_got.send) {
+				mmChatCompletionStream.t.Errorf("ProviderMock.ChatCompletionStream got unexpected parameter send, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.expectationOrigins.originSend, *mm_want_ptrs.send, mm_got.send, minimock.Diff(*mm_want_ptrs.send, mm_got.send))
+			}
+
+		} else if mm_want != nil && !minimock.Equal(*mm_want, mm_got) {
+			mmChatCompletionStream.t.Errorf("ProviderMock.ChatCompletionStream got unexpected parameters, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+				mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.expectationOrigins.origin, *mm_want, mm_got, minimock.Diff(*mm_want, mm_got))
+		}
+
+		mm_results := mmChatCompletionStream.ChatCompletionStreamMock.defaultExpectation.results
+		if mm_results == nil {
+			mmChatCompletionStream.t.Fatal("No results are set for the ProviderMock.ChatCompletionStream")
+		}
+		return (*mm_results).cp1, (*mm_results).err
+	}
+	if mmChatCompletionStream.funcChatCompletionStream != nil {
+		return mmChatCompletionStream.funcChatCompletionStream(ctx, req, send)
+	}
+	mmChatCompletionStream.t.Fatalf("Unexpected call to ProviderMock.ChatCompletionStream. %v %v %v", ctx, req, send)
+	return
+}
+
+// ChatCompletionStreamAfterCounter returns a count of finished ProviderMock.ChatCompletionStream invocations
+func (mmChatCompletionStream *ProviderMock) ChatCompletionStreamAfterCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmChatCompletionStream.afterChatCompletionStreamCounter)
+}
+
+// ChatCompletionStreamBeforeCounter returns a count of ProviderMock.ChatCompletionStream invocations
+func (mmChatCompletionStream *ProviderMock) ChatCompletionStreamBeforeCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmChatCompletionStream.beforeChatCompletionStreamCounter)
+}
+
+// Calls returns a list of arguments used in each call to ProviderMock.ChatCompletionStream.
+// The list is in the same order as the calls were made (i.e. recent calls have a higher index)
+func (mmChatCompletionStream *mProviderMockChatCompletionStream) Calls() []*ProviderMockChatCompletionStreamParams {
+	mmChatCompletionStream.mutex.RLock()
+
+	argCopy := make([]*ProviderMockChatCompletionStreamParams, len(mmChatCompletionStream.callArgs))
+	copy(argCopy, mmChatCompletionStream.callArgs)
+
+	mmChatCompletionStream.mutex.RUnlock()
+
+	return argCopy
+}
+
+// MinimockChatCompletionStreamDone returns true if the count of the ChatCompletionStream invocations corresponds
+// the number of defined expectations
+func (m *ProviderMock) MinimockChatCompletionStreamDone() bool {
+	if m.ChatCompletionStreamMock.optional {
+		// Optional methods provide '0 or more' call count restriction.
+		return true
+	}
+
+	for _, e := range m.ChatCompletionStreamMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			return false
+		}
+	}
+
+	return m.ChatCompletionStreamMock.invocationsDone()
+}
+
+// MinimockChatCompletionStreamInspect logs each unmet expectation
+func (m *ProviderMock) MinimockChatCompletionStreamInspect() {
+	for _, e := range m.ChatCompletionStreamMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			m.t.Errorf("Expected call to ProviderMock.ChatCompletionStream at\n%s with params: %#v", e.expectationOrigins.origin, *e.params)
+		}
+	}
+
+	afterChatCompletionStreamCounter := mm_atomic.LoadUint64(&m.afterChatCompletionStreamCounter)
+	// if default expectation was set then invocations count should be greater than zero
+	if m.ChatCompletionStreamMock.defaultExpectation != nil && afterChatCompletionStreamCounter < 1 {
+		if m.ChatCompletionStreamMock.defaultExpectation.params == nil {
+			m.t.Errorf("Expected call to ProviderMock.ChatCompletionStream at\n%s", m.ChatCompletionStreamMock.defaultExpectation.returnOrigin)
+		} else {
+			m.t.Errorf("Expected call to ProviderMock.ChatCompletionStream at\n%s with params: %#v", m.ChatCompletionStreamMock.defaultExpectation.expectationOrigins.origin, *m.ChatCompletionStreamMock.defaultExpectation.params)
+		}
+	}
+	// if func was set then invocations count should be greater than zero
+	if m.funcChatCompletionStream != nil && afterChatCompletionStreamCounter < 1 {
+		m.t.Errorf("Expected call to ProviderMock.ChatCompletionStream at\n%s", m.funcChatCompletionStreamOrigin)
+	}
+
+	if !m.ChatCompletionStreamMock.invocationsDone() && afterChatCompletionStreamCounter > 0 {
+		m.t.Errorf("Expected %d calls to ProviderMock.ChatCompletionStream at\n%s but found %d calls",
+			mm_atomic.LoadUint64(&m.ChatCompletionStreamMock.expectedInvocations), m.ChatCompletionStreamMock.expectedInvocationsOrigin, afterChatCompletionStreamCounter)
+	}
+}
+
+type mProviderMockEmbeddings struct {
+	optional           bool
+	mock               *ProviderMock
+	defaultExpectation *ProviderMockEmbeddingsExpectation
+	expectations       []*ProviderMockEmbeddingsExpectation
+
+	callArgs []*ProviderMockEmbeddingsParams
+	mutex    sync.RWMutex
+
+	expectedInvocations       uint64
+	expectedInvocationsOrigin string
+}
+
+// ProviderMockEmbeddingsExpectation specifies expectation struct of the Provider.Embeddings
+type ProviderMockEmbeddingsExpectation struct {
+	mock               *ProviderMock
+	params             *ProviderMockEmbeddingsParams
+	paramPtrs          *ProviderMockEmbeddingsParamPtrs
+	expectationOrigins ProviderMockEmbeddingsExpectationOrigins
+	results            *ProviderMockEmbeddingsResults
+	returnOrigin       string
+	Counter            uint64
+}
+
+// ProviderMockEmbeddingsParams contains parameters of the Provider.Embeddings
+type ProviderMockEmbeddingsParams struct {
+	ctx context.Context
+	req *api.EmbeddingsRequest
+}
+
+// ProviderMockEmbeddingsParamPtrs contains pointers to parameters of the Provider.Embeddings
+type ProviderMockEmbeddingsParamPtrs struct {
+	ctx *context.Context
+	req **api.EmbeddingsRequest
+}
+
+// ProviderMockEmbeddingsResults contains results of the Provider.Embeddings
+type ProviderMockEmbeddingsResults struct {
+	ep1 *api.EmbeddingsResponse
+	err error
+}
+
+// ProviderMockEmbeddingsOrigins contains origins of expectations of the Provider.Embeddings
+type ProviderMockEmbeddingsExpectationOrigins struct {
+	origin    string
+	originCtx string
+	originReq string
+}
+
+// Marks this method to be optional. The default behavior of any method with Return() is '1 or more', meaning
+// the test will fail minimock's automatic final call check if the mocked method was not called at least once.
+// Optional() makes method check to work in '0 or more' mode.
+// It is NOT RECOMMENDED to use this option unless you really need it, as default behaviour helps to
+// catch the problems when the expected method call is totally skipped during test run.
+func (mmEmbeddings *mProviderMockEmbeddings) Optional() *mProviderMockEmbeddings {
+	mmEmbeddings.optional = true
+	return mmEmbeddings
+}
+
+// Expect sets up expected params for Provider.Embeddings
+func (mmEmbeddings *mProviderMockEmbeddings) Expect(ctx context.Context, req *api.EmbeddingsRequest) *mProviderMockEmbeddings {
+	if mmEmbeddings.mock.funcEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Set")
+	}
+
+	if mmEmbeddings.defaultExpectation == nil {
+		mmEmbeddings.defaultExpectation = &ProviderMockEmbeddingsExpectation{}
+	}
+
+	if mmEmbeddings.defaultExpectation.paramPtrs != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by ExpectParams functions")
+	}
+
+	mmEmbeddings.defaultExpectation.params = &ProviderMockEmbeddingsParams{ctx, req}
+	mmEmbeddings.defaultExpectation.expectationOrigins.origin = minimock.CallerInfo(1)
+	for _, e := range mmEmbeddings.expectations {
+		if minimock.Equal(e.params, mmEmbeddings.defaultExpectation.params) {
+			mmEmbeddings.mock.t.Fatalf("Expectation set by When has same params: %#v", *mmEmbeddings.defaultExpectation.params)
+		}
+	}
+
+	return mmEmbeddings
+}
+
+// ExpectCtxParam1 sets up expected param ctx for Provider.Embeddings
+func (mmEmbeddings *mProviderMockEmbeddings) ExpectCtxParam1(ctx context.Context) *mProviderMockEmbeddings {
+	if mmEmbeddings.mock.funcEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Set")
+	}
+
+	if mmEmbeddings.defaultExpectation == nil {
+		mmEmbeddings.defaultExpectation = &ProviderMockEmbeddingsExpectation{}
+	}
+
+	if mmEmbeddings.defaultExpectation.params != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Expect")
+	}
+
+	if mmEmbeddings.defaultExpectation.paramPtrs == nil {
+		mmEmbeddings.defaultExpectation.paramPtrs = &ProviderMockEmbeddingsParamPtrs{}
+	}
+	mmEmbeddings.defaultExpectation.paramPtrs.ctx = &ctx
+	mmEmbeddings.defaultExpectation.expectationOrigins.originCtx = minimock.CallerInfo(1)
+
+	return mmEmbeddings
+}
+
+// ExpectReqParam2 sets up expected param req for Provider.Embeddings
+func (mmEmbeddings *mProviderMockEmbeddings) ExpectReqParam2(req *api.EmbeddingsRequest) *mProviderMockEmbeddings {
+	if mmEmbeddings.mock.funcEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Set")
+	}
+
+	if mmEmbeddings.defaultExpectation == nil {
+		mmEmbeddings.defaultExpectation = &ProviderMockEmbeddingsExpectation{}
+	}
+
+	if mmEmbeddings.defaultExpectation.params != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Expect")
+	}
+
+	if mmEmbeddings.defaultExpectation.paramPtrs == nil {
+		mmEmbeddings.defaultExpectation.paramPtrs = &ProviderMockEmbeddingsParamPtrs{}
+	}
+	mmEmbeddings.defaultExpectation.paramPtrs.req = &req
+	mmEmbeddings.defaultExpectation.expectationOrigins.originReq = minimock.CallerInfo(1)
+
+	return mmEmbeddings
+}
+
+// Inspect accepts an inspector function that has same arguments as the Provider.Embeddings
+func (mmEmbeddings *mProviderMockEmbeddings) Inspect(f func(ctx context.Context, req *api.EmbeddingsRequest)) *mProviderMockEmbeddings {
+	if mmEmbeddings.mock.inspectFuncEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("Inspect function is already set for ProviderMock.Embeddings")
+	}
+
+	mmEmbeddings.mock.inspectFuncEmbeddings = f
+
+	return mmEmbeddings
+}
+
+// Return sets up results that will be returned by Provider.Embeddings
+func (mmEmbeddings *mProviderMockEmbeddings) Return(ep1 *api.EmbeddingsResponse, err error) *ProviderMock {
+	if mmEmbeddings.mock.funcEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Set")
+	}
+
+	if mmEmbeddings.defaultExpectation == nil {
+		mmEmbeddings.defaultExpectation = &ProviderMockEmbeddingsExpectation{mock: mmEmbeddings.mock}
+	}
+	mmEmbeddings.defaultExpectation.results = &ProviderMockEmbeddingsResults{ep1, err}
+	mmEmbeddings.defaultExpectation.returnOrigin = minimock.CallerInfo(1)
+	return mmEmbeddings.mock
+}
+
+// Set uses given function f to mock the Provider.Embeddings method
+func (mmEmbeddings *mProviderMockEmbeddings) Set(f func(ctx context.Context, req *api.EmbeddingsRequest) (ep1 *api.EmbeddingsResponse, err error)) *ProviderMock {
+	if mmEmbeddings.defaultExpectation != nil {
+		mmEmbeddings.mock.t.Fatalf("Default expectation is already set for the Provider.Embeddings method")
+	}
+
+	if len(mmEmbeddings.expectations) > 0 {
+		mmEmbeddings.mock.t.Fatalf("Some expectations are already set for the Provider.Embeddings method")
+	}
+
+	mmEmbeddings.mock.funcEmbeddings = f
+	mmEmbeddings.mock.funcEmbeddingsOrigin = minimock.CallerInfo(1)
+	return mmEmbeddings.mock
+}
+
+// When sets expectation for the Provider.Embeddings which will trigger the result defined by the following
+// Then helper
+func (mmEmbeddings *mProviderMockEmbeddings) When(ctx context.Context, req *api.EmbeddingsRequest) *ProviderMockEmbeddingsExpectation {
+	if mmEmbeddings.mock.funcEmbeddings != nil {
+		mmEmbeddings.mock.t.Fatalf("ProviderMock.Embeddings mock is already set by Set")
+	}
+
+	expectation := &ProviderMockEmbeddingsExpectation{
+		mock:               mmEmbeddings.mock,
+		params:             &ProviderMockEmbeddingsParams{ctx, req},
+		expectationOrigins: ProviderMockEmbeddingsExpectationOrigins{origin: minimock.CallerInfo(1)},
+	}
+	mmEmbeddings.expectations = append(mmEmbeddings.expectations, expectation)
+	return expectation
+}
+
+// Then sets up Provider.Embeddings return parameters for the expectation previously defined by the When method
+func (e *ProviderMockEmbeddingsExpectation) Then(ep1 *api.EmbeddingsResponse, err error) *ProviderMock {
+	e.results = &ProviderMockEmbeddingsResults{ep1, err}
+	return e.mock
+}
+
+// Times sets number of times Provider.Embeddings should be invoked
+func (mmEmbeddings *mProviderMockEmbeddings) Times(n uint64) *mProviderMockEmbeddings {
+	if n == 0 {
+		mmEmbeddings.mock.t.Fatalf("Times of ProviderMock.Embeddings mock can not be zero")
+	}
+	mm_atomic.StoreUint64(&mmEmbeddings.expectedInvocations, n)
+	mmEmbeddings.expectedInvocationsOrigin = minimock.CallerInfo(1)
+	return mmEmbeddings
+}
+
+func (mmEmbeddings *mProviderMockEmbeddings) invocationsDone() bool {
+	if len(mmEmbeddings.expectations) == 0 && mmEmbeddings.defaultExpectation == nil && mmEmbeddings.mock.funcEmbeddings == nil {
+		return true
+	}
+
+	totalInvocations := mm_atomic.LoadUint64(&mmEmbeddings.mock.afterEmbeddingsCounter)
+	expectedInvocations := mm_atomic.LoadUint64(&mmEmbeddings.expectedInvocations)
+
+	return totalInvocations > 0 && (expectedInvocations == 0 || expectedInvocations == totalInvocations)
+}
+
+// Embeddings implements Provider
+func (mmEmbeddings *ProviderMock) Embeddings(ctx context.Context, req *api.EmbeddingsRequest) (ep1 *api.EmbeddingsResponse, err error) {
+	mm_atomic.AddUint64(&mmEmbeddings.beforeEmbeddingsCounter, 1)
+	defer mm_atomic.AddUint64(&mmEmbeddings.afterEmbeddingsCounter, 1)
+
+	mmEmbeddings.t.Helper()
+
+	if mmEmbeddings.inspectFuncEmbeddings != nil {
+		mmEmbeddings.inspectFuncEmbeddings(ctx, req)
+	}
+
+	mm_params := ProviderMockEmbeddingsParams{ctx, req}
+
+	// Record call args
+	mmEmbeddings.EmbeddingsMock.mutex.Lock()
+	mmEmbeddings.EmbeddingsMock.callArgs = append(mmEmbeddings.EmbeddingsMock.callArgs, &mm_params)
+	mmEmbeddings.EmbeddingsMock.mutex.Unlock()
+
+	for _, e := range mmEmbeddings.EmbeddingsMock.expectations {
+		if minimock.Equal(*e.params, mm_params) {
+			mm_atomic.AddUint64(&e.Counter, 1)
+			return e.results.ep1, e.results.err
+		}
+	}
+
+	if mmEmbeddings.EmbeddingsMock.defaultExpectation != nil {
+		mm_atomic.AddUint64(&mmEmbeddings.EmbeddingsMock.defaultExpectation.Counter, 1)
+		mm_want := mmEmbeddings.EmbeddingsMock.defaultExpectation.params
+		mm_want_ptrs := mmEmbeddings.EmbeddingsMock.defaultExpectation.paramPtrs
+
+		mm_got := ProviderMockEmbeddingsParams{ctx, req}
+
+		if mm_want_ptrs != nil {
+
+			if mm_want_ptrs.ctx != nil && !minimock.Equal(*mm_want_ptrs.ctx, mm_got.ctx) {
+				mmEmbeddings.t.Errorf("ProviderMock.Embeddings got unexpected parameter ctx, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmEmbeddings.EmbeddingsMock.defaultExpectation.expectationOrigins.originCtx, *mm_want_ptrs.ctx, mm_got.ctx, minimock.Diff(*mm_want_ptrs.ctx, mm_got.ctx))
+			}
+
+			if mm_want_ptrs.req != nil && !minimock.Equal(*mm_want_ptrs.req, mm_got.req) {
+				mmEmbeddings.t.Errorf("ProviderMock.Embeddings got unexpected parameter req, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmEmbeddings.EmbeddingsMock.defaultExpectation.expectationOrigins.originReq, *mm_want_ptrs.req, mm_got.req, minimock.Diff(*mm_want_ptrs.req, mm_got.req))
+			}
+
+		} else if mm_want != nil && !minimock.Equal(*mm_want, mm_got) {
+			mmEmbeddings.t.Errorf("ProviderMock.Embeddings got unexpected parameters, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+				mmEmbeddings.EmbeddingsMock.defaultExpectation.expectationOrigins.origin, *mm_want, mm_got, minimock.Diff(*mm_want, mm_got))
+		}
+
+		mm_results := mmEmbeddings.EmbeddingsMock.defaultExpectation.results
+		if mm_results == nil {
+			mmEmbeddings.t.Fatal("No results are set for the ProviderMock.Embeddings")
+		}
+		return (*mm_results).ep1, (*mm_results).err
+	}
+	if mmEmbeddings.funcEmbeddings != nil {
+		return mmEmbeddings.funcEmbeddings(ctx, req)
+	}
+	mmEmbeddings.t.Fatalf("Unexpected call to ProviderMock.Embeddings. %v %v", ctx, req)
+	return
+}
+
+// EmbeddingsAfterCounter returns a count of finished ProviderMock.Embeddings invocations
+func (mmEmbeddings *ProviderMock) EmbeddingsAfterCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmEmbeddings.afterEmbeddingsCounter)
+}
+
+// EmbeddingsBeforeCounter returns a count of ProviderMock.Embeddings invocations
+func (mmEmbeddings *ProviderMock) EmbeddingsBeforeCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmEmbeddings.beforeEmbeddingsCounter)
+}
+
+// Calls returns a list of arguments used in each call to ProviderMock.Embeddings.
+// The list is in the same order as the calls were made (i.e. recent calls have a higher index)
+func (mmEmbeddings *mProviderMockEmbeddings) Calls() []*ProviderMockEmbeddingsParams {
+	mmEmbeddings.mutex.RLock()
+
+	argCopy := make([]*ProviderMockEmbeddingsParams, len(mmEmbeddings.callArgs))
+	copy(argCopy, mmEmbeddings.callArgs)
+
+	mmEmbeddings.mutex.RUnlock()
+
+	return argCopy
+}
+
+// MinimockEmbeddingsDone returns true if the count of the Embeddings invocations corresponds
+// the number of defined expectations
+func (m *ProviderMock) MinimockEmbeddingsDone() bool {
+	if m.EmbeddingsMock.optional {
+		// Optional methods provide '0 or more' call count restriction.
+		return true
+	}
+
+	for _, e := range m.EmbeddingsMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			return false
+		}
+	}
+
+	return m.EmbeddingsMock.invocationsDone()
+}
+
+// MinimockEmbeddingsInspect logs each unmet expectation
+func (m *ProviderMock) MinimockEmbeddingsInspect() {
+	for _, e := range m.EmbeddingsMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			m.t.Errorf("Expected call to ProviderMock.Embeddings at\n%s with params: %#v", e.expectationOrigins.origin, *e.params)
+		}
+	}
+
+	afterEmbeddingsCounter := mm_atomic.LoadUint64(&m.afterEmbeddingsCounter)
+	// if default expectation was set then invocations count should be greater than zero
+	if m.EmbeddingsMock.defaultExpectation != nil && afterEmbeddingsCounter < 1 {
+		if m.EmbeddingsMock.defaultExpectation.params == nil {
+			m.t.Errorf("Expected call to ProviderMock.Embeddings at\n%s", m.EmbeddingsMock.defaultExpectation.returnOrigin)
+		} else {
+			m.t.Errorf("Expected call to ProviderMock.Embeddings at\n%s with params: %#v", m.EmbeddingsMock.defaultExpectation.expectationOrigins.origin, *m.EmbeddingsMock.defaultExpectation.params)
+		}
+	}
+	// if func was set then invocations count should be greater than zero
+	if m.funcEmbeddings != nil && afterEmbeddingsCounter < 1 {
+		m.t.Errorf("Expected call to ProviderMock.Embeddings at\n%s", m.funcEmbeddingsOrigin)
+	}
+
+	if !m.EmbeddingsMock.invocationsDone() && afterEmbeddingsCounter > 0 {
+		m.t.Errorf("Expected %d calls to ProviderMock.Embeddings at\n%s but found %d calls",
+			mm_atomic.LoadUint64(&m.EmbeddingsMock.expectedInvocations), m.EmbeddingsMock.expectedInvocationsOrigin, afterEmbeddingsCounter)
+	}
+}
+
+type mProviderMockModerations struct {
+	optional           bool
+	mock               *ProviderMock
+	defaultExpectation *ProviderMockModerationsExpectation
+	expectations       []*ProviderMockModerationsExpectation
+
+	callArgs []*ProviderMockModerationsParams
+	mutex    sync.RWMutex
+
+	expectedInvocations       uint64
+	expectedInvocationsOrigin string
+}
+
+// ProviderMockModerationsExpectation specifies expectation struct of the Provider.Moderations
+type ProviderMockModerationsExpectation struct {
+	mock               *ProviderMock
+	params             *ProviderMockModerationsParams
+	paramPtrs          *ProviderMockModerationsParamPtrs
+	expectationOrigins ProviderMockModerationsExpectationOrigins
+	results            *ProviderMockModerationsResults
+	returnOrigin       string
+	Counter            uint64
+}
+
+// ProviderMockModerationsParams contains parameters of the Provider.Moderations
+type ProviderMockModerationsParams struct {
+	ctx context.Context
+	req *api.ModerationRequest
+}
+
+// ProviderMockModerationsParamPtrs contains pointers to parameters of the Provider.Moderations
+type ProviderMockModerationsParamPtrs struct {
+	ctx *context.Context
+	req **api.ModerationRequest
+}
+
+// ProviderMockModerationsResults contains results of the Provider.Moderations
+type ProviderMockModerationsResults struct {
+	mp1 *api.ModerationResponse
+	err error
+}
+
+// ProviderMockModerationsOrigins contains origins of expectations of the Provider.Moderations
+type ProviderMockModerationsExpectationOrigins struct {
+	origin    string
+	originCtx string
+	originReq string
+}
+
+// Marks this method to be optional. The default behavior of any method with Return() is '1 or more', meaning
+// the test will fail minimock's automatic final call check if the mocked method was not called at least once.
+// Optional() makes method check to work in '0 or more' mode.
+// It is NOT RECOMMENDED to use this option unless you really need it, as default behaviour helps to
+// catch the problems when the expected method call is totally skipped during test run.
+func (mmModerations *mProviderMockModerations) Optional() *mProviderMockModerations {
+	mmModerations.optional = true
+	return mmModerations
+}
+
+// Expect sets up expected params for Provider.Moderations
+func (mmModerations *mProviderMockModerations) Expect(ctx context.Context, req *api.ModerationRequest) *mProviderMockModerations {
+	if mmModerations.mock.funcModerations != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Set")
+	}
+
+	if mmModerations.defaultExpectation == nil {
+		mmModerations.defaultExpectation = &ProviderMockModerationsExpectation{}
+	}
+
+	if mmModerations.defaultExpectation.paramPtrs != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by ExpectParams functions")
+	}
+
+	mmModerations.defaultExpectation.params = &ProviderMockModerationsParams{ctx, req}
+	mmModerations.defaultExpectation.expectationOrigins.origin = minimock.CallerInfo(1)
+	for _, e := range mmModerations.expectations {
+		if minimock.Equal(e.params, mmModerations.defaultExpectation.params) {
+			mmModerations.mock.t.Fatalf("Expectation set by When has same params: %#v", *mmModerations.defaultExpectation.params)
+		}
+	}
+
+	return mmModerations
+}
+
+// ExpectCtxParam1 sets up expected param ctx for Provider.Moderations
+func (mmModerations *mProviderMockModerations) ExpectCtxParam1(ctx context.Context) *mProviderMockModerations {
+	if mmModerations.mock.funcModerations != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Set")
+	}
+
+	if mmModerations.defaultExpectation == nil {
+		mmModerations.defaultExpectation = &ProviderMockModerationsExpectation{}
+	}
+
+	if mmModerations.defaultExpectation.params != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Expect")
+	}
+
+	if mmModerations.defaultExpectation.paramPtrs == nil {
+		mmModerations.defaultExpectation.paramPtrs = &ProviderMockModerationsParamPtrs{}
+	}
+	mmModerations.defaultExpectation.paramPtrs.ctx = &ctx
+	mmModerations.defaultExpectation.expectationOrigins.originCtx = minimock.CallerInfo(1)
+
+	return mmModerations
+}
+
+// ExpectReqParam2 sets up expected param req for Provider.Moderations
+func (mmModerations *mProviderMockModerations) ExpectReqParam2(req *api.ModerationRequest) *mProviderMockModerations {
+	if mmModerations.mock.funcModerations != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Set")
+	}
+
+	if mmModerations.defaultExpectation == nil {
+		mmModerations.defaultExpectation = &ProviderMockModerationsExpectation{}
+	}
+
+	if mmModerations.defaultExpectation.params != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Expect")
+	}
+
+	if mmModerations.defaultExpectation.paramPtrs == nil {
+		mmModerations.defaultExpectation.paramPtrs = &ProviderMockModerationsParamPtrs{}
+	}
+	mmModerations.defaultExpectation.paramPtrs.req = &req
+	mmModerations.defaultExpectation.expectationOrigins.originReq = minimock.CallerInfo(1)
+
+	return mmModerations
+}
+
+// Inspect accepts an inspector function that has same arguments as the Provider.Moderations
+func (mmModerations *mProviderMockModerations) Inspect(f func(ctx context.Context, req *api.ModerationRequest)) *mProviderMockModerations {
+	if mmModerations.mock.inspectFuncModerations != nil {
+		mmModerations.mock.t.Fatalf("Inspect function is already set for ProviderMock.Moderations")
+	}
+
+	mmModerations.mock.inspectFuncModerations = f
+
+	return mmModerations
+}
+
+// Return sets up results that will be returned by Provider.Moderations
+func (mmModerations *mProviderMockModerations) Return(mp1 *api.ModerationResponse, err error) *ProviderMock {
+	if mmModerations.mock.funcModerations != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Set")
+	}
+
+	if mmModerations.defaultExpectation == nil {
+		mmModerations.defaultExpectation = &ProviderMockModerationsExpectation{mock: mmModerations.mock}
+	}
+	mmModerations.defaultExpectation.results = &ProviderMockModerationsResults{mp1, err}
+	mmModerations.defaultExpectation.returnOrigin = minimock.CallerInfo(1)
+	return mmModerations.mock
+}
+
+// Set uses given function f to mock the Provider.Moderations method
+func (mmModerations *mProviderMockModerations) Set(f func(ctx context.Context, req *api.ModerationRequest) (mp1 *api.ModerationResponse, err error)) *ProviderMock {
+	if mmModerations.defaultExpectation != nil {
+		mmModerations.mock.t.Fatalf("Default expectation is already set for the Provider.Moderations method")
+	}
+
+	if len(mmModerations.expectations) > 0 {
+		mmModerations.mock.t.Fatalf("Some expectations are already set for the Provider.Moderations method")
+	}
+
+	mmModerations.mock.funcModerations = f
+	mmModerations.mock.funcModerationsOrigin = minimock.CallerInfo(1)
+	return mmModerations.mock
+}
+
+// When sets expectation for the Provider.Moderations which will trigger the result defined by the following
+// Then helper
+func (mmModerations *mProviderMockModerations) When(ctx context.Context, req *api.ModerationRequest) *ProviderMockModerationsExpectation {
+	if mmModerations.mock.funcModerations != nil {
+		mmModerations.mock.t.Fatalf("ProviderMock.Moderations mock is already set by Set")
+	}
+
+	expectation := &ProviderMockModerationsExpectation{
+		mock:               mmModerations.mock,
+		params:             &ProviderMockModerationsParams{ctx, req},
+		expectationOrigins: ProviderMockModerationsExpectationOrigins{origin: minimock.CallerInfo(1)},
+	}
+	mmModerations.expectations = append(mmModerations.expectations, expectation)
+	return expectation
+}
+
+// Then sets up Provider.Moderations return parameters for the expectation previously defined by the When method
+func (e *ProviderMockModerationsExpectation) Then(mp1 *api.ModerationResponse, err error) *ProviderMock {
+	e.results = &ProviderMockModerationsResults{mp1, err}
+	return e.mock
+}
+
+// Times sets number of times Provider.Moderations should be invoked
+func (mmModerations *mProviderMockModerations) Times(n uint64) *mProviderMockModerations {
+	if n == 0 {
+		mmModerations.mock.t.Fatalf("Times of ProviderMock.Moderations mock can not be zero")
+	}
+	mm_atomic.StoreUint64(&mmModerations.expectedInvocations, n)
+	mmModerations.expectedInvocationsOrigin = minimock.CallerInfo(1)
+	return mmModerations
+}
+
+func (mmModerations *mProviderMockModerations) invocationsDone() bool {
+	if len(mmModerations.expectations) == 0 && mmModerations.defaultExpectation == nil && mmModerations.mock.funcModerations == nil {
+		return true
+	}
+
+	totalInvocations := mm_atomic.LoadUint64(&mmModerations.mock.afterModerationsCounter)
+	expectedInvocations := mm_atomic.LoadUint64(&mmModerations.expectedInvocations)
+
+	return totalInvocations > 0 && (expectedInvocations == 0 || expectedInvocations == totalInvocations)
+}
+
+// Moderations implements Provider
+func (mmModerations *ProviderMock) Moderations(ctx context.Context, req *api.ModerationRequest) (mp1 *api.ModerationResponse, err error) {
+	mm_atomic.AddUint64(&mmModerations.beforeModerationsCounter, 1)
+	defer mm_atomic.AddUint64(&mmModerations.afterModerationsCounter, 1)
+
+	mmModerations.t.Helper()
+
+	if mmModerations.inspectFuncModerations != nil {
+		mmModerations.inspectFuncModerations(ctx, req)
+	}
+
+	mm_params := ProviderMockModerationsParams{ctx, req}
+
+	// Record call args
+	mmModerations.ModerationsMock.mutex.Lock()
+	mmModerations.ModerationsMock.callArgs = append(mmModerations.ModerationsMock.callArgs, &mm_params)
+	mmModerations.ModerationsMock.mutex.Unlock()
+
+	for _, e := range mmModerations.ModerationsMock.expectations {
+		if minimock.Equal(*e.params, mm_params) {
+			mm_atomic.AddUint64(&e.Counter, 1)
+			return e.results.mp1, e.results.err
+		}
+	}
+
+	if mmModerations.ModerationsMock.defaultExpectation != nil {
+		mm_atomic.AddUint64(&mmModerations.ModerationsMock.defaultExpectation.Counter, 1)
+		mm_want := mmModerations.ModerationsMock.defaultExpectation.params
+		mm_want_ptrs := mmModerations.ModerationsMock.defaultExpectation.paramPtrs
+
+		mm_got := ProviderMockModerationsParams{ctx, req}
+
+		if mm_want_ptrs != nil {
+
+			if mm_want_ptrs.ctx != nil && !minimock.Equal(*mm_want_ptrs.ctx, mm_got.ctx) {
+				mmModerations.t.Errorf("ProviderMock.Moderations got unexpected parameter ctx, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmModerations.ModerationsMock.defaultExpectation.expectationOrigins.originCtx, *mm_want_ptrs.ctx, mm_got.ctx, minimock.Diff(*mm_want_ptrs.ctx, mm_got.ctx))
+			}
+
+			if mm_want_ptrs.req != nil && !minimock.Equal(*mm_want_ptrs.req, mm_got.req) {
+				mmModerations.t.Errorf("ProviderMock.Moderations got unexpected parameter req, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+					mmModerations.ModerationsMock.defaultExpectation.expectationOrigins.originReq, *mm_want_ptrs.req, mm_got.req, minimock.Diff(*mm_want_ptrs.req, mm_got.req))
+			}
+
+		} else if mm_want != nil && !minimock.Equal(*mm_want, mm_got) {
+			mmModerations.t.Errorf("ProviderMock.Moderations got unexpected parameters, expected at\n%s:\nwant: %#v\n got: %#v%s\n",
+				mmModerations.ModerationsMock.defaultExpectation.expectationOrigins.origin, *mm_want, mm_got, minimock.Diff(*mm_want, mm_got))
+		}
+
+		mm_results := mmModerations.ModerationsMock.defaultExpectation.results
+		if mm_results == nil {
+			mmModerations.t.Fatal("No results are set for the ProviderMock.Moderations")
+		}
+		return (*mm_results).mp1, (*mm_results).err
+	}
+	if mmModerations.funcModerations != nil {
+		return mmModerations.funcModerations(ctx, req)
+	}
+	mmModerations.t.Fatalf("Unexpected call to ProviderMock.Moderations. %v %v", ctx, req)
+	return
+}
+
+// ModerationsAfterCounter returns a count of finished ProviderMock.Moderations invocations
+func (mmModerations *ProviderMock) ModerationsAfterCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmModerations.afterModerationsCounter)
+}
+
+// ModerationsBeforeCounter returns a count of ProviderMock.Moderations invocations
+func (mmModerations *ProviderMock) ModerationsBeforeCounter() uint64 {
+	return mm_atomic.LoadUint64(&mmModerations.beforeModerationsCounter)
+}
+
+// Calls returns a list of arguments used in each call to ProviderMock.Moderations.
+// The list is in the same order as the calls were made (i.e. recent calls have a higher index)
+func (mmModerations *mProviderMockModerations) Calls() []*ProviderMockModerationsParams {
+	mmModerations.mutex.RLock()
+
+	argCopy := make([]*ProviderMockModerationsParams, len(mmModerations.callArgs))
+	copy(argCopy, mmModerations.callArgs)
+
+	mmModerations.mutex.RUnlock()
+
+	return argCopy
+}
+
+// MinimockModerationsDone returns true if the count of the Moderations invocations corresponds
+// the number of defined expectations
+func (m *ProviderMock) MinimockModerationsDone() bool {
+	if m.ModerationsMock.optional {
+		// Optional methods provide '0 or more' call count restriction.
+		return true
+	}
+
+	for _, e := range m.ModerationsMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			return false
+		}
+	}
+
+	return m.ModerationsMock.invocationsDone()
+}
+
+// MinimockModerationsInspect logs each unmet expectation
+func (m *ProviderMock) MinimockModerationsInspect() {
+	for _, e := range m.ModerationsMock.expectations {
+		if mm_atomic.LoadUint64(&e.Counter) < 1 {
+			m.t.Errorf("Expected call to ProviderMock.Moderations at\n%s with params: %#v", e.expectationOrigins.origin, *e.params)
+		}
+	}
+
+	afterModerationsCounter := mm_atomic.LoadUint64(&m.afterModerationsCounter)
+	// if default expectation was set then invocations count should be greater than zero
+	if m.ModerationsMock.defaultExpectation != nil && afterModerationsCounter < 1 {
+		if m.ModerationsMock.defaultExpectation.params == nil {
+			m.t.Errorf("Expected call to ProviderMock.Moderations at\n%s", m.ModerationsMock.defaultExpectation.returnOrigin)
+		} else {
+			m.t.Errorf("Expected call to ProviderMock.Moderations at\n%s with params: %#v", m.ModerationsMock.defaultExpectation.expectationOrigins.origin, *m.ModerationsMock.defaultExpectation.params)
+		}
+	}
+	// if func was set then invocations count should be greater than zero
+	if m.funcModerations != nil && afterModerationsCounter < 1 {
+		m.t.Errorf("Expected call to ProviderMock.Moderations at\n%s", m.funcModerationsOrigin)
+	}
+
+	if !m.ModerationsMock.invocationsDone() && afterModerationsCounter > 0 {
+		m.t.Errorf("Expected %d calls to ProviderMock.Moderations at\n%s but found %d calls",
+			mm_atomic.LoadUint64(&m.ModerationsMock.expectedInvocations), m.ModerationsMock.expectedInvocationsOrigin, afterModerationsCounter)
+	}
+}
+
+// MinimockFinish checks that all mocked methods have been called the expected number of times
+func (m *ProviderMock) MinimockFinish() {
+	m.finishOnce.Do(func() {
+		if !m.minimockDone() {
+			m.MinimockChatCompletionInspect()
+
+			m.MinimockChatCompletionStreamInspect()
+
+			m.MinimockEmbeddingsInspect()
+
+			m.MinimockModerationsInspect()
 		}
 	})
 }
@@ -414,5 +1510,8 @@ func (m *ProviderMock) MinimockWait(timeout mm_time.Duration) {
 func (m *ProviderMock) minimockDone() bool {
 	done := true
 	return done &&
-		m.MinimockChatCompletionDone()
+		m.MinimockChatCompletionDone() &&
+		m.MinimockChatCompletionStreamDone() &&
+		m.MinimockEmbeddingsDone() &&
+		m.MinimockModerationsDone()
 }
@@ -0,0 +1,28 @@
+package provider
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/dmitrii/llm-gateway/api"
+)
+
+// StopSequences returns the stop sequences of req, which clients send as a single
+// string or as an array of strings. Empty strings, which stop nothing, are
+// dropped. It returns nil when none are set.
+func StopSequences(req *api.ChatCompletionRequest) ([]string, error) {
+	if req.Stop == nil {
+		return nil, nil
+	}
+	if stop, err := req.Stop.AsChatCompletionRequestStop1(); err == nil {
+		return slices.DeleteFunc(slices.Clone(stop), func(s string) bool { return s == "" }), nil
+	}
+	stop, err := req.Stop.AsChatCompletionRequestStop0()
+	if err != nil {
+		return nil, fmt.Errorf("stop must be a string or an array of strings: %w", err)
+	}
+	if stop == "" {
+		return nil, nil
+	}
+	return []string{stop}, nil
+}
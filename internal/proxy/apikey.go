@@ -0,0 +1,17 @@
+package proxy
+
+import "context"
+
+type apiKeyKey struct{}
+
+// WithAPIKey returns a context carrying the gateway API key of the caller, so
+// that the requests of different callers are never coalesced.
+func WithAPIKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, key)
+}
+
+// apiKey returns the gateway API key carried by the context, if any.
+func apiKey(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyKey{}).(string)
+	return key
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	awshttp "github.com/aws/aws-sdk-go-v2/aws/transport/http"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/tmc/langchaingo/llms"
+	"github.com/tmc/langchaingo/llms/bedrock"
+)
+
+// newBedrockLLM creates the Bedrock client of the provider.
+// The AWS SDK decodes error responses and retries on its own, so it keeps
+// its own HTTP client and the provider retry policy is passed to the SDK.
+func newBedrockLLM(ctx context.Context, pCfg *config.ProviderConfig) (llms.Model, error) {
+	bedrockCfg := pCfg.Config.(*config.BedrockProviderConfig)
+
+	// The timeout of the buildable client would bound whole streams, so the
+	// provider timeout bounds the wait for the response headers instead.
+	timeout := providerTimeout(pCfg)
+	httpClient := awshttp.NewBuildableClient().WithTransportOptions(func(t *http.Transport) {
+		t.ResponseHeaderTimeout = timeout
+	})
+	if pCfg.ProxyURL != "" {
+		proxyURL, err := parseProxyURL(pCfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		httpClient = httpClient.WithTransportOptions(func(t *http.Transport) {
+			t.Proxy = http.ProxyURL(proxyURL)
+		})
+	}
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(bedrockCfg.Region),
+		awsconfig.WithHTTPClient(httpClient),
+	}
+	if bedrockCfg.AccessKeyID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			bedrockCfg.AccessKeyID,
+			bedrockCfg.SecretAccessKey,
+			bedrockCfg.SessionToken,
+		)))
+	}
+	if pCfg.Retry != nil {
+		opts = append(opts, awsconfig.WithRetryMaxAttempts(pCfg.Retry.MaxAttempts))
+	}
+
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	llm, err := bedrock.New(bedrock.WithClient(bedrockruntime.NewFromConfig(awsCfg)))
+	if err != nil {
+		return nil, err
+	}
+	if bedrockCfg.ModelARN != "" {
+		return &fixedModel{Model: llm, model: bedrockCfg.ModelARN}, nil
+	}
+	return llm, nil
+}
+
+// fixedModel invokes the same model whatever model the request asks for.
+type fixedModel struct {
+	llms.Model
+	model string
+}
+
+func (m *fixedModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	return m.Model.GenerateContent(ctx, messages, append(options, llms.WithModel(m.model))...)
+}
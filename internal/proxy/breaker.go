@@ -0,0 +1,143 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	circuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_gateway_circuit_breaker_state",
+			Help: "State of the provider circuit breaker (0 - closed, 1 - open, 2 - half-open)",
+		},
+		[]string{"provider"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(circuitBreakerState)
+}
+
+const (
+	defaultBreakerCooldown         = 30 * time.Second
+	defaultBreakerHalfOpenRequests = 1
+)
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreaker tracks the failures of a provider and rejects calls while it is open.
+type circuitBreaker struct {
+	provider string
+	cfg      config.CircuitBreakerConfig
+	now      func() time.Time
+
+	mu           sync.Mutex
+	state        breakerState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probes       int
+}
+
+func newCircuitBreaker(provider string, cfg config.CircuitBreakerConfig) *circuitBreaker {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultBreakerCooldown
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = defaultBreakerHalfOpenRequests
+	}
+	cb := &circuitBreaker{
+		provider: provider,
+		cfg:      cfg,
+		now:      time.Now,
+	}
+	cb.setState(breakerClosed)
+	return cb
+}
+
+// allow reports whether a call to the provider may be made.
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state {
+	case breakerOpen:
+		if cb.now().Sub(cb.openedAt) < cb.cfg.Cooldown {
+			return false
+		}
+		cb.setState(breakerHalfOpen)
+		cb.probes = 1
+		return true
+	case breakerHalfOpen:
+		if cb.probes >= cb.cfg.HalfOpenRequests {
+			return false
+		}
+		cb.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// abandon gives back the probe taken by allow for a call that isn't made.
+func (cb *circuitBreaker) abandon() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == breakerHalfOpen && cb.probes > 0 {
+		cb.probes--
+	}
+}
+
+// onSuccess records a successful call and closes the breaker.
+func (cb *circuitBreaker) onSuccess() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures = 0
+	if cb.state != breakerClosed {
+		cb.setState(breakerClosed)
+	}
+}
+
+// onFailure records a failed call, opening the breaker once the threshold is reached.
+func (cb *circuitBreaker) onFailure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := cb.now()
+	if cb.state == breakerHalfOpen {
+		cb.open(now)
+		return
+	}
+
+	if cb.failures == 0 || (cb.cfg.Window > 0 && now.Sub(cb.firstFailure) > cb.cfg.Window) {
+		cb.failures = 0
+		cb.firstFailure = now
+	}
+	cb.failures++
+	if cb.failures >= cb.cfg.FailureThreshold {
+		cb.open(now)
+	}
+}
+
+func (cb *circuitBreaker) open(now time.Time) {
+	cb.failures = 0
+	cb.openedAt = now
+	cb.setState(breakerOpen)
+}
+
+func (cb *circuitBreaker) setState(state breakerState) {
+	cb.state = state
+	circuitBreakerState.WithLabelValues(cb.provider).Set(float64(state))
+}
@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_StateTransitions(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker("test-provider", config.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		Cooldown:         time.Minute,
+		HalfOpenRequests: 1,
+	})
+	cb.now = func() time.Time { return now }
+
+	assert.True(t, cb.allow())
+	cb.onFailure()
+	assert.True(t, cb.allow(), "breaker must stay closed below the threshold")
+	cb.onFailure()
+	assert.False(t, cb.allow(), "breaker must open at the threshold")
+
+	now = now.Add(time.Minute)
+	assert.True(t, cb.allow(), "breaker must let a probe through after the cooldown")
+	assert.False(t, cb.allow(), "only one probe is allowed while half-open")
+
+	cb.onFailure()
+	assert.False(t, cb.allow(), "a failed probe must reopen the breaker")
+
+	now = now.Add(time.Minute)
+	assert.True(t, cb.allow())
+	cb.onSuccess()
+	assert.True(t, cb.allow(), "a successful probe must close the breaker")
+	assert.True(t, cb.allow())
+}
+
+func TestCircuitBreaker_Window(t *testing.T) {
+	now := time.Now()
+	cb := newCircuitBreaker("test-provider", config.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		Window:           time.Second,
+	})
+	cb.now = func() time.Time { return now }
+
+	cb.onFailure()
+	now = now.Add(2 * time.Second)
+	cb.onFailure()
+	assert.True(t, cb.allow(), "failures outside the window must not open the breaker")
+}
+
+func TestChatCompletionsHandler_OpenBreakerFallsBack(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "test-model",
+				Name:     "primary-model",
+				Provider: "provider1",
+				Fallback: []string{"fallback-model"},
+			},
+			{
+				ID:       "fallback-model",
+				Name:     "backup-model",
+				Provider: "provider2",
+			},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+		breakers: map[string]*circuitBreaker{
+			"provider1": newCircuitBreaker("provider1", config.CircuitBreakerConfig{
+				FailureThreshold: 1,
+				Cooldown:         time.Hour,
+			}),
+		},
+	}
+
+	mockProvider1.ChatCompletionMock.Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	req := api.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []api.ChatMessage{
+			{
+				Role:    api.ChatMessageRoleUser,
+				Content: createChatContent("Hello"),
+			},
+		},
+	}
+
+	for range 3 {
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "backup-model", resp.Model)
+	}
+
+	// The primary provider is only called until the breaker opens.
+	assert.Equal(t, uint64(1), mockProvider1.ChatCompletionAfterCounter())
+	assert.Equal(t, uint64(3), mockProvider2.ChatCompletionAfterCounter())
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"math"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+var (
+	retriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_retries_total",
+			Help: "Total number of upstream attempts made after a failed one, by the fallbacks and the retries",
+		},
+		[]string{"endpoint"},
+	)
+	retryBudgetExhaustedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_retry_budget_exhausted_total",
+			Help: "Total number of requests failed because the retry budget was exhausted",
+		},
+		[]string{"endpoint"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(retriesTotal)
+	prometheus.MustRegister(retryBudgetExhaustedTotal)
+}
+
+// retryBudget is a token bucket shared by all requests, which every attempt made
+// after a failed one takes a token from: fallbacks, throttle retries and the
+// retries of the upstream clients. It keeps an upstream outage from being
+// amplified by them. A nil budget allows every retry.
+type retryBudget struct {
+	limiter *rate.Limiter
+}
+
+// newRetryBudget returns the budget of cfg, or nil if it is disabled.
+func newRetryBudget(cfg config.RetryBudgetConfig) *retryBudget {
+	if cfg.RetriesPerSecond <= 0 {
+		return nil
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = max(int(math.Ceil(cfg.RetriesPerSecond)), 1)
+	}
+	return &retryBudget{limiter: rate.NewLimiter(rate.Limit(cfg.RetriesPerSecond), burst)}
+}
+
+// allow takes a token for a retry of endpoint. It returns false when the budget
+// is exhausted and the request should fail instead.
+func (b *retryBudget) allow(endpoint string) bool {
+	if b != nil && !b.limiter.Allow() {
+		retryBudgetExhaustedTotal.WithLabelValues(endpoint).Inc()
+		return false
+	}
+	retriesTotal.WithLabelValues(endpoint).Inc()
+	return true
+}
@@ -0,0 +1,94 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRetryBudget(t *testing.T) {
+	assert.Nil(t, newRetryBudget(config.RetryBudgetConfig{}))
+	assert.Equal(t, 3, newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 2.5}).limiter.Burst())
+	assert.Equal(t, 10, newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 0.1, Burst: 10}).limiter.Burst())
+}
+
+func TestChatCompletionsHandler_RetryBudget(t *testing.T) {
+	primary, backup := provider.NewProviderMock(t), provider.NewProviderMock(t)
+	primary.ChatCompletionMock.Return(nil, stderrors.New("connection refused"))
+	backup.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	p := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "primary-provider", Fallback: []string{"backup-model"}},
+				{ID: "backup-model", Name: "backup-model", Provider: "backup-provider"},
+			},
+		},
+		providers: map[string]provider.Provider{"primary-provider": primary, "backup-provider": backup},
+		// Nearly no refill, so that only the burst is available.
+		retryBudget: newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 0.001, Burst: 2}),
+	}
+	req := api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+
+	retries := testutil.ToFloat64(retriesTotal.WithLabelValues(endpointChatCompletions))
+	exhausted := testutil.ToFloat64(retryBudgetExhaustedTotal.WithLabelValues(endpointChatCompletions))
+
+	for range 2 {
+		resp, err := p.ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "backup-model", resp.Model)
+	}
+
+	_, err := p.ChatCompletionsHandler(context.Background(), req)
+	var apiErr internalerrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+	assert.Equal(t, "retry budget exhausted", apiErr.Message)
+
+	assert.Equal(t, retries+2, testutil.ToFloat64(retriesTotal.WithLabelValues(endpointChatCompletions)))
+	assert.Equal(t, exhausted+1, testutil.ToFloat64(retryBudgetExhaustedTotal.WithLabelValues(endpointChatCompletions)))
+	assert.EqualValues(t, 2, backup.ChatCompletionAfterCounter())
+}
+
+func TestChatCompletionsHandler_RetryBudgetSkippedModels(t *testing.T) {
+	primary, tripped, backup := provider.NewProviderMock(t), provider.NewProviderMock(t), provider.NewProviderMock(t)
+	primary.ChatCompletionMock.Return(nil, stderrors.New("connection refused"))
+	backup.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	breaker := newCircuitBreaker("tripped-provider", config.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour})
+	breaker.onFailure()
+	p := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "primary-provider", Fallback: []string{"tripped-model", "backup-model"}},
+				{ID: "tripped-model", Name: "tripped-model", Provider: "tripped-provider"},
+				{ID: "backup-model", Name: "backup-model", Provider: "backup-provider"},
+			},
+		},
+		providers: map[string]provider.Provider{"primary-provider": primary, "tripped-provider": tripped, "backup-provider": backup},
+		breakers:  map[string]*circuitBreaker{"tripped-provider": breaker},
+		// A single retry, which the model skipped for its open breaker must not take.
+		retryBudget: newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 0.001, Burst: 1}),
+	}
+
+	resp, err := p.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "backup-model", resp.Model)
+	assert.Zero(t, tripped.ChatCompletionAfterCounter())
+}
@@ -0,0 +1,73 @@
+package proxy
+
+import "maps"
+
+// CarryOver makes p, created from a reloaded config, keep the state of old,
+// the proxy it replaces, so that a reload doesn't reset it:
+//   - the circuit breakers and concurrency limiters of the providers whose
+//     settings are unchanged, with their failures and calls in flight,
+//   - the global limiter, unless its max_concurrency, concurrency_timeout or
+//     priority tiers changed,
+//   - the coalesced calls in flight, whose server settings take a restart,
+//   - the retry budget, unless its config changed,
+//   - the error rates of the models,
+//   - the audit logger, unless its config changed.
+//
+// It must be called before p serves any request. old keeps serving its requests
+// in flight with the same state and must be closed with Close afterwards.
+func (p *Proxy) CarryOver(old *Proxy) {
+	for id, breaker := range p.breakers {
+		if oldBreaker, ok := old.breakers[id]; ok && oldBreaker.cfg == breaker.cfg {
+			p.breakers[id] = oldBreaker
+		}
+	}
+	for id, limiter := range p.limiters {
+		if oldLimiter, ok := old.limiters[id]; ok && oldLimiter.sameConfig(limiter) {
+			p.limiters[id] = oldLimiter
+		}
+	}
+
+	if p.globalLimiter.sameConfig(old.globalLimiter) {
+		p.globalLimiter = old.globalLimiter
+	}
+	p.inflight = old.inflight
+	p.errorRates = old.errorRates
+	if p.cfg.Fallback.RetryBudget == old.cfg.Fallback.RetryBudget {
+		p.retryBudget = old.retryBudget
+	}
+	if p.cfg.Audit == old.cfg.Audit {
+		if p.audit != nil {
+			_ = p.audit.Close()
+		}
+		p.audit = old.audit
+		old.auditCarriedOver = true
+	}
+}
+
+// sameConfig reports whether l and other limit the calls the same way.
+func (l *concurrencyLimiter) sameConfig(other *concurrencyLimiter) bool {
+	return cap(l.sem) == cap(other.sem) && l.behavior == other.behavior && l.timeout == other.timeout
+}
+
+// sameConfig reports whether l and other limit the calls the same way, with the
+// same priority tiers.
+func (l *globalLimiter) sameConfig(other *globalLimiter) bool {
+	if l == nil || other == nil {
+		return l == other
+	}
+	return l.sem.size == other.sem.size && l.timeout == other.timeout &&
+		maps.Equal(l.tiers, other.tiers) && l.defaultTier == other.defaultTier
+}
+
+// Close releases the resources of the proxy once it no longer serves requests:
+// the idle connections of its providers and its audit log, unless they were
+// carried over to another proxy.
+func (p *Proxy) Close() error {
+	for _, t := range p.transports {
+		t.CloseIdleConnections()
+	}
+	if p.audit != nil && !p.auditCarriedOver {
+		return p.audit.Close()
+	}
+	return nil
+}
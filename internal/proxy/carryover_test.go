@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyCarryOver(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	newConfig := func(maxConcurrency int) *config.Config {
+		breaker := &config.CircuitBreakerConfig{FailureThreshold: 2}
+		return &config.Config{
+			Server: config.ServerConfig{MaxConcurrency: 4},
+			Audit:  config.AuditConfig{Enabled: true, Destination: auditPath},
+			Providers: []*config.ProviderConfig{
+				{ID: "kept", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}, CircuitBreaker: breaker, MaxConcurrency: 2},
+				{ID: "resized", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}, MaxConcurrency: maxConcurrency},
+			},
+		}
+	}
+
+	old, err := NewProxy(context.Background(), newConfig(2))
+	require.NoError(t, err)
+	old.breakers["kept"].onFailure()
+	old.errorRates.record("model", true)
+
+	next, err := NewProxy(context.Background(), newConfig(3))
+	require.NoError(t, err)
+	next.CarryOver(old)
+
+	assert.Same(t, old.breakers["kept"], next.breakers["kept"])
+	assert.Same(t, old.limiters["kept"], next.limiters["kept"])
+	assert.NotSame(t, old.limiters["resized"], next.limiters["resized"])
+	assert.Same(t, old.globalLimiter, next.globalLimiter)
+	assert.Same(t, old.errorRates, next.errorRates)
+	assert.Same(t, old.audit, next.audit)
+
+	// Closing the replaced proxy leaves the audit log it handed over open.
+	require.NoError(t, old.Close())
+	require.NoError(t, next.audit.Log(context.Background(), "model", "kept", &api.ChatCompletionRequest{}, &api.ChatCompletionResponse{}))
+	require.NoError(t, next.Close())
+	require.NoError(t, next.audit.Log(context.Background(), "model", "kept", &api.ChatCompletionRequest{}, &api.ChatCompletionResponse{}))
+
+	data, err := os.ReadFile(auditPath)
+	require.NoError(t, err)
+	assert.Equal(t, 1, bytes.Count(data, []byte("\n")))
+}
+
+func TestProxyCarryOver_GlobalLimiter(t *testing.T) {
+	newProxy := func(server config.ServerConfig) *Proxy {
+		p, err := NewProxy(context.Background(), &config.Config{Server: server})
+		require.NoError(t, err)
+		return p
+	}
+	old := newProxy(config.ServerConfig{MaxConcurrency: 4})
+
+	next := newProxy(config.ServerConfig{MaxConcurrency: 4})
+	next.CarryOver(old)
+	assert.Same(t, old.globalLimiter, next.globalLimiter)
+
+	// The priority tiers come from the reloaded config.
+	tiered := newProxy(config.ServerConfig{MaxConcurrency: 4, Priority: config.PriorityConfig{Tiers: []string{"interactive", "batch"}}})
+	tiered.CarryOver(next)
+	assert.NotSame(t, next.globalLimiter, tiered.globalLimiter)
+	assert.Equal(t, "batch", tiered.globalLimiter.defaultTier)
+
+	unlimited := newProxy(config.ServerConfig{})
+	unlimited.CarryOver(tiered)
+	assert.Nil(t, unlimited.globalLimiter)
+}
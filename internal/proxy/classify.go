@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"slices"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+)
+
+// httpStatusError is implemented by SDK errors carrying the upstream status, such as the AWS ones.
+type httpStatusError interface {
+	HTTPStatusCode() int
+}
+
+// errorStatus returns the HTTP status carried by err, if any.
+// Upstream statuses reported by the client take precedence over gateway errors.
+func errorStatus(err error) (int, bool) {
+	var statusErr *client.StatusError
+	if stderrors.As(err, &statusErr) {
+		return statusErr.StatusCode, true
+	}
+	var sdkErr httpStatusError
+	if stderrors.As(err, &sdkErr) {
+		return sdkErr.HTTPStatusCode(), true
+	}
+	var gatewayErr errors.Error
+	if stderrors.As(err, &gatewayErr) {
+		return gatewayErr.Status, true
+	}
+	return 0, false
+}
+
+// isRetryable reports whether a failed attempt should fall back to the next model.
+// Errors without a status, such as network errors and timeouts, are retryable.
+// Errors with a status are retryable if it is listed in onStatusCodes, or,
+// when the list is empty, if it is 408, 429 or 5xx.
+func isRetryable(err error, onStatusCodes []int) bool {
+	if stderrors.Is(err, context.Canceled) {
+		// The client went away, there is nobody left to answer.
+		return false
+	}
+	status, ok := errorStatus(err)
+	if !ok {
+		return true
+	}
+	if len(onStatusCodes) > 0 {
+		return slices.Contains(onStatusCodes, status)
+	}
+	return status == http.StatusRequestTimeout ||
+		status == http.StatusTooManyRequests ||
+		status >= http.StatusInternalServerError
+}
+
+// isUnsupported reports whether err rejects the request for the model it was
+// sent to only, see errors.Error.Unsupported.
+func isUnsupported(err error) bool {
+	var gatewayErr errors.Error
+	return stderrors.As(err, &gatewayErr) && gatewayErr.Unsupported
+}
+
+// terminalError converts the error of an attempt that isn't followed by a fallback
+// into the error returned to the caller.
+func terminalError(err error) error {
+	var gatewayErr errors.Error
+	if stderrors.As(err, &gatewayErr) {
+		return gatewayErr
+	}
+	if stderrors.Is(err, context.DeadlineExceeded) {
+		return errors.ErrGatewayTimeout.WithMessage("provider request timed out").WithDetails(err)
+	}
+	if status, ok := errorStatus(err); ok {
+		return errors.Error{Message: "provider rejected the request", Status: status, Details: err}
+	}
+	return errors.ErrInternal.WithMessage("request cancelled").WithDetails(err)
+}
+
+// Error classes of failed attempts, used as metric label values.
+const (
+	errorClassTimeout     = "timeout"
+	errorClassCanceled    = "canceled"
+	errorClassRateLimited = "rate_limited"
+	errorClassClient      = "client_error"
+	errorClassServer      = "server_error"
+	errorClassNetwork     = "network_error"
+)
+
+// errorClass returns the class of an attempt error.
+func errorClass(err error) string {
+	switch {
+	case stderrors.Is(err, context.DeadlineExceeded):
+		return errorClassTimeout
+	case stderrors.Is(err, context.Canceled):
+		return errorClassCanceled
+	}
+	status, ok := errorStatus(err)
+	switch {
+	case !ok:
+		return errorClassNetwork
+	case status == http.StatusRequestTimeout:
+		return errorClassTimeout
+	case status == http.StatusTooManyRequests:
+		return errorClassRateLimited
+	case status >= http.StatusInternalServerError:
+		return errorClassServer
+	default:
+		return errorClassClient
+	}
+}
@@ -0,0 +1,150 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/singleflight"
+)
+
+var coalescedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_gateway_coalesced_requests_total",
+		Help: "Total number of chat completions served by the upstream call of an identical request in flight",
+	},
+	[]string{"model"},
+)
+
+func init() {
+	prometheus.MustRegister(coalescedTotal)
+}
+
+// coalescedResult is what the requests sharing an upstream call get: the
+// response and the outcome of the request that made the call.
+type coalescedResult struct {
+	resp    *api.ChatCompletionResponse
+	outcome Outcome
+}
+
+// newInflight returns the group coalescing the chat completions of cfg, or nil
+// when coalescing is disabled.
+func newInflight(cfg config.ServerConfig) *singleflight.Group {
+	if !cfg.CoalesceRequests {
+		return nil
+	}
+	return &singleflight.Group{}
+}
+
+// requestKey returns the key of the chat completion request, the same for
+// identical requests of the same caller sent to the same provider with the
+// same forwarded headers.
+func requestKey(ctx context.Context, req *api.ChatCompletionRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	headers, err := json.Marshal(client.ForwardedHeaders(ctx))
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, part := range [][]byte{data, []byte(providerOverride(ctx)), []byte(apiKey(ctx)), headers} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isDeterministic reports whether identical requests are expected to get the
+// same completion: with greedy sampling or a seed.
+func isDeterministic(req *api.ChatCompletionRequest) bool {
+	return (req.Temperature != nil && *req.Temperature == 0) || req.Seed != nil
+}
+
+// defaultCoalesceTimeout bounds the shared upstream call of coalesced requests
+// when the server has no request timeout.
+const defaultCoalesceTimeout = 5 * time.Minute
+
+// coalesce returns the result of call, made once for the identical
+// deterministic requests in flight, which all get the result of the first.
+// Other requests, or all of them when coalescing is disabled, make their own call.
+// The shared call doesn't end with the request that made it, so that the others
+// still get its result, and is bounded by the request timeout instead.
+func (p *Proxy) coalesce(ctx context.Context, req *api.ChatCompletionRequest, call func(ctx context.Context) (*api.ChatCompletionResponse, error)) (*api.ChatCompletionResponse, error) {
+	if p.inflight == nil || !isDeterministic(req) {
+		return call(ctx)
+	}
+	key, err := requestKey(ctx, req)
+	if err != nil {
+		return call(ctx)
+	}
+
+	called := false
+	ch := p.inflight.DoChan(key, func() (val any, err error) {
+		called = true
+		// The shared call runs on a goroutine of its own, out of reach of the
+		// recovery of the handlers, where a panic would crash the gateway.
+		defer func() {
+			if v := recover(); v != nil {
+				slog.ErrorContext(ctx, "Coalesced chat completion panicked", "panic", v, "stack", string(debug.Stack()))
+				val, err = coalescedResult{}, errors.ErrInternal.WithDetails(fmt.Errorf("panic: %v", v))
+			}
+		}()
+		timeout := p.cfg.Server.RequestTimeout
+		if timeout <= 0 {
+			timeout = defaultCoalesceTimeout
+		}
+		callCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		resp, err := call(callCtx)
+		result := coalescedResult{resp: resp}
+		if outcome := outcomeFrom(ctx); outcome != nil {
+			result.outcome = *outcome
+		}
+		return result, err
+	})
+
+	var r singleflight.Result
+	select {
+	case <-ctx.Done():
+		return nil, terminalError(ctx.Err())
+	case r = <-ch:
+	}
+	result := r.Val.(coalescedResult)
+	if !called {
+		coalescedTotal.WithLabelValues(req.Model).Inc()
+		if outcome := outcomeFrom(ctx); outcome != nil {
+			*outcome = result.outcome
+		}
+	}
+	if r.Err != nil {
+		return nil, r.Err
+	}
+	// Every request gets a deep copy, which its handler may change.
+	return cloneResponse(result.resp)
+}
+
+// cloneResponse returns a deep copy of resp, sharing none of its slices,
+// maps and pointers.
+func cloneResponse(resp *api.ChatCompletionResponse) (*api.ChatCompletionResponse, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var clone api.ChatCompletionResponse
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
@@ -0,0 +1,199 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatCompletionsHandler_CoalescesIdenticalRequests(t *testing.T) {
+	const n = 10
+
+	tests := []struct {
+		name          string
+		temperature   float32
+		expectedCalls uint64
+	}{
+		{name: "deterministic", temperature: 0, expectedCalls: 1},
+		{name: "sampled", temperature: 1, expectedCalls: n},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := provider.NewProviderMock(t)
+			proxy := &Proxy{
+				cfg: &config.Config{
+					Models: []*config.ModelConfig{{ID: "chat-model", Name: "upstream-model", Provider: "test-provider"}},
+				},
+				providers: map[string]provider.Provider{"test-provider": mockProvider},
+				inflight:  newInflight(config.ServerConfig{CoalesceRequests: true}),
+			}
+
+			release := make(chan struct{})
+			mockProvider.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+				<-release
+				return &api.ChatCompletionResponse{
+					Model:   req.Model,
+					Choices: []api.ChatCompletionChoice{{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Hi")}}},
+				}, nil
+			})
+			coalesced := testutil.ToFloat64(coalescedTotal.WithLabelValues("chat-model"))
+
+			var wg sync.WaitGroup
+			resps := make([]*api.ChatCompletionResponse, n)
+			errs := make([]error, n)
+			for i := range n {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					ctx, outcome := WithOutcome(context.Background())
+					resps[i], errs[i] = proxy.ChatCompletionsHandler(ctx, api.ChatCompletionRequest{
+						Model:       "chat-model",
+						Temperature: ptr(tt.temperature),
+						Messages:    []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+					})
+					assert.Equal(t, "test-provider", outcome.Provider)
+				}()
+			}
+			// Let the requests reach the upstream call, or wait for the first one.
+			require.Eventually(t, func() bool { return mockProvider.ChatCompletionBeforeCounter() == tt.expectedCalls }, time.Second, time.Millisecond)
+			time.Sleep(50 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			assert.Equal(t, tt.expectedCalls, mockProvider.ChatCompletionAfterCounter())
+			for i := range n {
+				require.NoError(t, errs[i])
+				assert.Equal(t, "upstream-model", resps[i].Model)
+			}
+			// Every request gets its own deep copy of the response.
+			assert.NotSame(t, resps[0], resps[1])
+			assert.NotSame(t, &resps[0].Choices[0], &resps[1].Choices[0])
+			assert.Equal(t, coalesced+float64(n)-float64(tt.expectedCalls), testutil.ToFloat64(coalescedTotal.WithLabelValues("chat-model")))
+		})
+	}
+}
+
+func TestChatCompletionsHandler_CoalescedCallOutlivesLeader(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "chat-model", Name: "upstream-model", Provider: "test-provider"}},
+		},
+		providers: map[string]provider.Provider{"test-provider": mockProvider},
+		inflight:  newInflight(config.ServerConfig{CoalesceRequests: true}),
+	}
+
+	release := make(chan struct{})
+	mockProvider.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		select {
+		case <-release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+	req := api.ChatCompletionRequest{
+		Model:       "chat-model",
+		Temperature: ptr(float32(0)),
+		Messages:    []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := proxy.ChatCompletionsHandler(leaderCtx, req)
+		leaderErr <- err
+	}()
+	require.Eventually(t, func() bool { return mockProvider.ChatCompletionBeforeCounter() == 1 }, time.Second, time.Millisecond)
+
+	type result struct {
+		resp *api.ChatCompletionResponse
+		err  error
+	}
+	follower := make(chan result)
+	go func() {
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+		follower <- result{resp, err}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The leader going away doesn't fail the follower.
+	cancelLeader()
+	assert.Error(t, <-leaderErr)
+	close(release)
+	r := <-follower
+	require.NoError(t, r.err)
+	assert.Equal(t, "upstream-model", r.resp.Model)
+	assert.Equal(t, uint64(1), mockProvider.ChatCompletionAfterCounter())
+}
+
+func TestChatCompletionsHandler_CoalescedCallPanics(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "chat-model", Name: "upstream-model", Provider: "test-provider"}},
+		},
+		providers: map[string]provider.Provider{"test-provider": mockProvider},
+		inflight:  newInflight(config.ServerConfig{CoalesceRequests: true}),
+	}
+	mockProvider.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		panic("provider bug")
+	})
+
+	// The panic is turned into an error of the request instead of crashing the
+	// process from the goroutine of the shared call.
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:       "chat-model",
+		Temperature: ptr(float32(0)),
+		Messages:    []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	var apiErr internalerrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+	assert.ErrorContains(t, apiErr.Details, "provider bug")
+}
+
+func TestRequestKey(t *testing.T) {
+	req := &api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Seed:     ptr(42),
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+	key, err := requestKey(context.Background(), req)
+	require.NoError(t, err)
+
+	same, err := requestKey(context.Background(), &api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Seed:     ptr(42),
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	overridden, err := requestKey(WithProviderOverride(context.Background(), "other-provider"), req)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, overridden)
+
+	otherCaller, err := requestKey(WithAPIKey(context.Background(), "other-key"), req)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, otherCaller)
+
+	forwarded, err := requestKey(client.WithForwardedHeaders(context.Background(), http.Header{"X-Tenant-Id": {"tenant-1"}}), req)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, forwarded)
+
+	assert.True(t, isDeterministic(req))
+	assert.False(t, isDeterministic(&api.ChatCompletionRequest{Temperature: ptr(float32(0.7))}))
+}
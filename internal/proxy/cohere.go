@@ -0,0 +1,172 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// cohereRoles maps the langchain message types to the roles of the Cohere chat API.
+var cohereRoles = map[llms.ChatMessageType]string{
+	llms.ChatMessageTypeSystem:  "system",
+	llms.ChatMessageTypeHuman:   "user",
+	llms.ChatMessageTypeGeneric: "user",
+	llms.ChatMessageTypeAI:      "assistant",
+}
+
+// cohereModel calls the v2 chat API of Cohere with the provider client. The
+// langchaingo Cohere client is bound to a single model, sends a single prompt
+// to the legacy generate API and always uses http.DefaultClient.
+type cohereModel struct {
+	url    string
+	token  string
+	client *client.Client
+}
+
+var _ llms.Model = (*cohereModel)(nil)
+
+// Like the langchaingo client, the endpoint path is appended to the configured API URL.
+func newCohereModel(apiURL, token string, httpClient *client.Client) *cohereModel {
+	return &cohereModel{
+		url:    strings.TrimRight(apiURL, "/") + "/v2/chat",
+		token:  token,
+		client: httpClient,
+	}
+}
+
+type cohereMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type cohereChatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []cohereMessage `json:"messages"`
+	MaxTokens        int             `json:"max_tokens,omitempty"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	Temperature      float64         `json:"temperature"`
+	P                float64         `json:"p,omitempty"`
+	Seed             int             `json:"seed,omitempty"`
+	FrequencyPenalty float64         `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64         `json:"presence_penalty,omitempty"`
+}
+
+type cohereTokens struct {
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+type cohereChatResponse struct {
+	FinishReason string `json:"finish_reason"`
+	Message      struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"message"`
+	Usage struct {
+		Tokens cohereTokens `json:"tokens"`
+	} `json:"usage"`
+}
+
+func (m *cohereModel) Call(ctx context.Context, prompt string, options ...llms.CallOption) (string, error) {
+	return llms.GenerateFromSinglePrompt(ctx, m, prompt, options...)
+}
+
+// GenerateContent sends the messages with their roles. Streams get the whole
+// answer in a single chunk.
+func (m *cohereModel) GenerateContent(ctx context.Context, messages []llms.MessageContent, options ...llms.CallOption) (*llms.ContentResponse, error) {
+	var opts llms.CallOptions
+	for _, opt := range options {
+		opt(&opts)
+	}
+
+	chatReq, err := cohereRequest(messages, opts)
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal cohere request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cohere request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+m.token)
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var chatResp cohereChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return nil, fmt.Errorf("failed to decode cohere response: %w", err)
+	}
+
+	var text strings.Builder
+	for _, part := range chatResp.Message.Content {
+		if part.Type == "text" {
+			text.WriteString(part.Text)
+		}
+	}
+	if opts.StreamingFunc != nil && text.Len() > 0 {
+		if err := opts.StreamingFunc(ctx, []byte(text.String())); err != nil {
+			return nil, err
+		}
+	}
+
+	tokens := chatResp.Usage.Tokens
+	return &llms.ContentResponse{Choices: []*llms.ContentChoice{{
+		Content:    text.String(),
+		StopReason: chatResp.FinishReason,
+		GenerationInfo: map[string]any{
+			"PromptTokens":     int(tokens.InputTokens),
+			"CompletionTokens": int(tokens.OutputTokens),
+			"TotalTokens":      int(tokens.InputTokens + tokens.OutputTokens),
+		},
+	}}}, nil
+}
+
+// cohereRequest converts the messages and options of a call to a Cohere chat
+// request. Consecutive text parts of a message are joined into its content.
+// Tool results and non-text parts are unsupported.
+func cohereRequest(messages []llms.MessageContent, opts llms.CallOptions) (*cohereChatRequest, error) {
+	req := &cohereChatRequest{
+		Model:            opts.Model,
+		MaxTokens:        opts.MaxTokens,
+		StopSequences:    opts.StopWords,
+		Temperature:      opts.Temperature,
+		P:                opts.TopP,
+		Seed:             opts.Seed,
+		FrequencyPenalty: opts.FrequencyPenalty,
+		PresencePenalty:  opts.PresencePenalty,
+	}
+
+	for _, msg := range messages {
+		role, ok := cohereRoles[msg.Role]
+		if !ok {
+			return nil, errors.ErrUnsupported.WithMessage(fmt.Sprintf("cohere: %s messages are not supported", msg.Role))
+		}
+		var content []string
+		for _, part := range msg.Parts {
+			text, ok := part.(llms.TextContent)
+			if !ok {
+				return nil, errors.ErrUnsupported.WithMessage(fmt.Sprintf("cohere: unsupported message part %T", part))
+			}
+			content = append(content, text.Text)
+		}
+		req.Messages = append(req.Messages, cohereMessage{Role: role, Content: strings.Join(content, "\n\n")})
+	}
+	return req, nil
+}
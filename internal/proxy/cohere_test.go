@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCohereModel(t *testing.T) {
+	var path, auth string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		body = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		if body["model"] == "busy-model" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"message":"trial key rate limit"}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","finish_reason":"COMPLETE","message":{"role":"assistant","content":[{"type":"text","text":"Paris"}]},"usage":{"tokens":{"input_tokens":12,"output_tokens":1}}}`)
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "cohere",
+		Provider: config.ProviderCohere,
+		Config:   &config.CohereProviderConfig{APIKey: "cohere-key", APIUrl: srv.URL + "/"},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	messages := []api.ChatMessage{
+		{Role: api.ChatMessageRoleSystem, Content: createChatContent("be brief")},
+		{Role: api.ChatMessageRoleUser, Content: createChatContent("capital of Italy?")},
+		{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Rome")},
+		{Role: api.ChatMessageRoleUser, Content: createChatContent("and France?")},
+	}
+
+	t.Run("chat history", func(t *testing.T) {
+		resp, err := p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Model:       "command-r",
+			Messages:    messages,
+			Temperature: ptr(float32(0.5)),
+			Seed:        ptr(7),
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, "/v2/chat", path)
+		assert.Equal(t, "Bearer cohere-key", auth)
+		assert.Equal(t, "command-r", body["model"])
+		assert.EqualValues(t, 0.5, body["temperature"])
+		assert.EqualValues(t, 7, body["seed"])
+		assert.Equal(t, []any{
+			map[string]any{"role": "system", "content": "be brief"},
+			map[string]any{"role": "user", "content": "capital of Italy?"},
+			map[string]any{"role": "assistant", "content": "Rome"},
+			map[string]any{"role": "user", "content": "and France?"},
+		}, body["messages"])
+
+		text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+		require.NoError(t, err)
+		assert.Equal(t, "Paris", text)
+		assert.Equal(t, api.ChatCompletionChoiceFinishReasonStop, resp.Choices[0].FinishReason)
+		assert.Equal(t, 12, resp.Usage.PromptTokens)
+		assert.Equal(t, 1, resp.Usage.CompletionTokens)
+		assert.Equal(t, 13, resp.Usage.TotalTokens)
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var content string
+		_, err := p.ChatCompletionStream(context.Background(), &api.ChatCompletionRequest{Model: "command-r", Messages: messages}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+			if delta := chunk.Choices[0].Delta.Content; delta != nil {
+				content += *delta
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "Paris", content)
+	})
+
+	t.Run("tool results", func(t *testing.T) {
+		_, err := p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+			Model:    "command-r",
+			Messages: append(messages, api.ChatMessage{Role: api.ChatMessageRoleTool, Content: createChatContent(`{"temperature":20}`)}),
+		})
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.True(t, apiErr.Unsupported)
+	})
+
+	t.Run("upstream error", func(t *testing.T) {
+		_, err := p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{Model: "busy-model", Messages: messages})
+		status, ok := errorStatus(err)
+		require.True(t, ok, "%v", err)
+		assert.Equal(t, http.StatusTooManyRequests, status)
+	})
+}
@@ -0,0 +1,291 @@
+package proxy
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	providerInFlight = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_gateway_provider_in_flight_requests",
+			Help: "Number of upstream calls in flight per provider",
+		},
+		[]string{"provider"},
+	)
+	concurrencyQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "llm_gateway_concurrency_queue_depth",
+			Help: "Number of upstream calls waiting for a free slot of the gateway concurrency limit per priority tier",
+		},
+		[]string{"tier"},
+	)
+	concurrencyWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "llm_gateway_concurrency_wait_seconds",
+			Help:    "Time upstream calls waited for a free slot of the gateway concurrency limit per priority tier",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"tier"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(providerInFlight)
+	prometheus.MustRegister(concurrencyQueueDepth)
+	prometheus.MustRegister(concurrencyWaitSeconds)
+}
+
+// concurrencyLimiter bounds the number of concurrent upstream calls of a provider
+// with a buffered-channel semaphore. A nil limiter doesn't limit anything.
+type concurrencyLimiter struct {
+	sem      chan struct{}
+	behavior config.QueueBehavior
+	timeout  time.Duration
+}
+
+func newConcurrencyLimiter(pCfg *config.ProviderConfig) *concurrencyLimiter {
+	return &concurrencyLimiter{
+		sem:      make(chan struct{}, pCfg.MaxConcurrency),
+		behavior: pCfg.QueueBehavior,
+		timeout:  pCfg.QueueTimeout,
+	}
+}
+
+// acquire takes a slot, waiting for one according to the queue behavior.
+// It returns false if no slot could be taken and the next model should be tried.
+func (l *concurrencyLimiter) acquire(ctx context.Context) bool {
+	if l == nil {
+		return true
+	}
+
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	default:
+	}
+	if l.behavior == config.QueueBehaviorFallback {
+		return false
+	}
+
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	select {
+	case l.sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *concurrencyLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.sem
+}
+
+// callSlots are the slots of the provider and gateway limiters held by a
+// provider call, given back while it waits to be retried.
+type callSlots struct {
+	limiter *concurrencyLimiter
+	global  *globalLimiter
+	held    bool
+}
+
+// release frees the slots, if they are held.
+func (s *callSlots) release() {
+	if !s.held {
+		return
+	}
+	s.global.release()
+	s.limiter.release()
+	s.held = false
+}
+
+// reacquire takes the slots again after release, in the same order as the
+// first time. It returns false if either can't be taken.
+func (s *callSlots) reacquire(ctx context.Context) bool {
+	if !s.limiter.acquire(ctx) {
+		return false
+	}
+	if err := s.global.acquire(ctx); err != nil {
+		s.limiter.release()
+		return false
+	}
+	s.held = true
+	return true
+}
+
+// globalLimiter bounds the number of concurrent upstream calls of all the
+// providers with a tiered semaphore, which hands out the slots to the highest
+// priority tier first and in the order they were waited for within a tier.
+// Without priority tiers, all the calls are in the same tier. A nil limiter
+// doesn't limit anything.
+type globalLimiter struct {
+	sem     *tieredSemaphore
+	timeout time.Duration
+	// tiers are the indexes of the priority tiers of sem by name.
+	tiers       map[string]int
+	defaultTier string
+}
+
+// newGlobalLimiter creates the limiter of cfg, or returns nil when
+// MaxConcurrency is unset.
+func newGlobalLimiter(cfg config.ServerConfig) *globalLimiter {
+	if cfg.MaxConcurrency <= 0 {
+		return nil
+	}
+	tiers := make(map[string]int, len(cfg.Priority.Tiers))
+	for i, tier := range cfg.Priority.Tiers {
+		tiers[tier] = i
+	}
+	return &globalLimiter{
+		sem:         newTieredSemaphore(cfg.MaxConcurrency, max(len(tiers), 1)),
+		timeout:     cfg.ConcurrencyTimeout,
+		tiers:       tiers,
+		defaultTier: cfg.Priority.Default(),
+	}
+}
+
+// tier returns the priority tier of the calls made with ctx, the default one
+// when it has none or an unknown one.
+func (l *globalLimiter) tier(ctx context.Context) string {
+	tier := priority(ctx)
+	if _, ok := l.tiers[tier]; !ok {
+		return l.defaultTier
+	}
+	return tier
+}
+
+// acquire takes a slot, waiting for one up to the timeout. It returns a 503 when
+// the timeout is exceeded and the error of ctx when it is done first.
+func (l *globalLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	tier := l.tier(ctx)
+	if l.sem.tryAcquire() {
+		concurrencyWaitSeconds.WithLabelValues(tier).Observe(0)
+		return nil
+	}
+
+	queueDepth := concurrencyQueueDepth.WithLabelValues(tier)
+	queueDepth.Inc()
+	defer queueDepth.Dec()
+	start := time.Now()
+	waitCtx := ctx
+	if l.timeout > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, l.timeout)
+		defer cancel()
+	}
+	err := l.sem.acquire(waitCtx, l.tiers[tier])
+	concurrencyWaitSeconds.WithLabelValues(tier).Observe(time.Since(start).Seconds())
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return errors.ErrUnavailable.WithMessage("gateway concurrency limit reached")
+}
+
+// release frees a slot taken by acquire.
+func (l *globalLimiter) release() {
+	if l == nil {
+		return
+	}
+	l.sem.release()
+}
+
+// tieredSemaphore is a counting semaphore whose waiters are queued by tier. A
+// freed slot goes to the first waiter of the highest tier, so the lower tiers
+// only get the slots the higher ones don't wait for.
+type tieredSemaphore struct {
+	mu   sync.Mutex
+	size int
+	held int
+	// waiters are the channels closed to hand a slot to a waiter, by tier,
+	// highest first.
+	waiters []list.List
+}
+
+func newTieredSemaphore(size, tiers int) *tieredSemaphore {
+	return &tieredSemaphore{size: size, waiters: make([]list.List, tiers)}
+}
+
+// tryAcquire takes a slot without waiting, unless another call waits for one.
+func (s *tieredSemaphore) tryAcquire() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held < s.size && s.waiting() == 0 {
+		s.held++
+		return true
+	}
+	return false
+}
+
+// acquire takes a slot, waiting for one in the given tier until ctx is done.
+func (s *tieredSemaphore) acquire(ctx context.Context, tier int) error {
+	s.mu.Lock()
+	if s.held < s.size && s.waiting() == 0 {
+		s.held++
+		s.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	elem := s.waiters[tier].PushBack(ready)
+	s.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	s.mu.Lock()
+	select {
+	case <-ready:
+		// The slot was handed over as ctx was done, pass it on.
+		s.mu.Unlock()
+		s.release()
+	default:
+		s.waiters[tier].Remove(elem)
+		s.mu.Unlock()
+	}
+	return ctx.Err()
+}
+
+// release frees a slot, handing it over to the next waiter if any.
+func (s *tieredSemaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.waiters {
+		if next := s.waiters[i].Front(); next != nil {
+			s.waiters[i].Remove(next)
+			close(next.Value.(chan struct{}))
+			return
+		}
+	}
+	s.held--
+}
+
+// waiting returns the number of waiters of all the tiers.
+func (s *tieredSemaphore) waiting() int {
+	n := 0
+	for i := range s.waiters {
+		n += s.waiters[i].Len()
+	}
+	return n
+}
@@ -0,0 +1,188 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	t.Run("fallback behavior fails fast", func(t *testing.T) {
+		l := newConcurrencyLimiter(&config.ProviderConfig{MaxConcurrency: 1, QueueBehavior: config.QueueBehaviorFallback})
+
+		assert.True(t, l.acquire(context.Background()))
+		assert.False(t, l.acquire(context.Background()))
+		l.release()
+		assert.True(t, l.acquire(context.Background()))
+	})
+
+	t.Run("wait behavior waits up to the queue timeout", func(t *testing.T) {
+		l := newConcurrencyLimiter(&config.ProviderConfig{MaxConcurrency: 1, QueueTimeout: 10 * time.Millisecond})
+
+		assert.True(t, l.acquire(context.Background()))
+		assert.False(t, l.acquire(context.Background()))
+
+		go func() {
+			time.Sleep(5 * time.Millisecond)
+			l.release()
+		}()
+		l.timeout = time.Second
+		assert.True(t, l.acquire(context.Background()))
+	})
+
+	t.Run("nil limiter is unlimited", func(t *testing.T) {
+		var l *concurrencyLimiter
+		assert.True(t, l.acquire(context.Background()))
+		l.release()
+	})
+}
+
+func TestChatCompletionsHandler_ConcurrencyLimitFallback(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+	limiter := newConcurrencyLimiter(&config.ProviderConfig{MaxConcurrency: 1, QueueBehavior: config.QueueBehaviorFallback})
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+		limiters: map[string]*concurrencyLimiter{"provider1": limiter},
+	}
+
+	// Another request holds the only slot of provider1.
+	require.True(t, limiter.acquire(context.Background()))
+	mockProvider2.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "test-model"})
+
+	require.NoError(t, err)
+	assert.Equal(t, "backup-model", resp.Model)
+	assert.Equal(t, uint64(0), mockProvider1.ChatCompletionAfterCounter())
+}
+
+func TestGlobalLimiter(t *testing.T) {
+	t.Run("waits up to the timeout", func(t *testing.T) {
+		l := newGlobalLimiter(config.ServerConfig{MaxConcurrency: 1, ConcurrencyTimeout: 10 * time.Millisecond})
+		require.NoError(t, l.acquire(context.Background()))
+
+		err := l.acquire(context.Background())
+		var apiErr errors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+		assert.Zero(t, testutil.ToFloat64(concurrencyQueueDepth.WithLabelValues("")))
+
+		l.release()
+		assert.NoError(t, l.acquire(context.Background()))
+	})
+
+	t.Run("gives up with the request", func(t *testing.T) {
+		l := newGlobalLimiter(config.ServerConfig{MaxConcurrency: 1})
+		require.NoError(t, l.acquire(context.Background()))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, l.acquire(ctx), context.Canceled)
+	})
+
+	t.Run("serves the higher priority tiers first", func(t *testing.T) {
+		l := newGlobalLimiter(config.ServerConfig{
+			MaxConcurrency: 1,
+			Priority:       config.PriorityConfig{Tiers: []string{"interactive", "batch"}},
+		})
+		require.NoError(t, l.acquire(context.Background()))
+
+		acquired := make(chan string, 3)
+		wait := func(tier string) {
+			require.Eventually(t, func() bool {
+				return testutil.ToFloat64(concurrencyQueueDepth.WithLabelValues(tier)) == 1
+			}, time.Second, time.Millisecond)
+		}
+		go func() {
+			assert.NoError(t, l.acquire(context.Background()))
+			acquired <- "default"
+		}()
+		wait("batch")
+		go func() {
+			assert.NoError(t, l.acquire(WithPriority(context.Background(), "interactive")))
+			acquired <- "interactive"
+		}()
+		wait("interactive")
+
+		l.release()
+		assert.Equal(t, "interactive", <-acquired)
+		l.release()
+		assert.Equal(t, "default", <-acquired)
+		l.release()
+	})
+
+	t.Run("nil limiter is unlimited", func(t *testing.T) {
+		l := newGlobalLimiter(config.ServerConfig{})
+		assert.Nil(t, l)
+		assert.NoError(t, l.acquire(context.Background()))
+		l.release()
+	})
+}
+
+func TestChatCompletionsStreamHandler_GlobalConcurrencyLimit(t *testing.T) {
+	streamer := provider.NewProviderMock(t)
+	other := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "stream-model", Name: "stream-model", Provider: "provider1"},
+				{ID: "other-model", Name: "other-model", Provider: "provider2"},
+			},
+		},
+		providers:     map[string]provider.Provider{"provider1": streamer, "provider2": other},
+		globalLimiter: newGlobalLimiter(config.ServerConfig{MaxConcurrency: 1, ConcurrencyTimeout: 10 * time.Millisecond}),
+	}
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	streamer.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		content := "Hello"
+		if err := send(ctx, &api.ChatCompletionChunk{Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}}}); err != nil {
+			return nil, err
+		}
+		close(started)
+		<-finish
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+	other.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "other-model"}, nil)
+
+	streamErr := make(chan error)
+	go func() {
+		streamErr <- proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{Model: "stream-model"},
+			func(context.Context, *api.ChatCompletionChunk) error { return nil })
+	}()
+	<-started
+
+	// The stream holds the only slot, even after its first chunk.
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "other-model"})
+	var apiErr errors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+	assert.Equal(t, uint64(0), other.ChatCompletionAfterCounter())
+
+	close(finish)
+	require.NoError(t, <-streamErr)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "other-model"})
+	require.NoError(t, err)
+	assert.Equal(t, "other-model", resp.Model)
+}
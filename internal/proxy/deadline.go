@@ -0,0 +1,48 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"time"
+)
+
+// errAttemptTimeout is the cancellation cause of attempts that ran out of their
+// per-attempt timeout.
+var errAttemptTimeout = stderrors.New("attempt timed out")
+
+type attemptDeadlineKey struct{}
+
+// withAttemptTimeout returns a copy of ctx cancelled after timeout, on top of
+// the deadline ctx may already have. The returned cancel must be called once
+// the attempt is over. A timeout of zero leaves ctx unbounded.
+func withAttemptTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	timer := time.AfterFunc(timeout, func() { cancel(errAttemptTimeout) })
+	ctx = context.WithValue(ctx, attemptDeadlineKey{}, timer)
+	return ctx, func() {
+		timer.Stop()
+		cancel(context.Canceled)
+	}
+}
+
+// liftAttemptDeadline stops the per-attempt timeout of ctx, if any. Streams lift
+// it once the first chunk is sent, as there is no model left to fall back to.
+func liftAttemptDeadline(ctx context.Context) {
+	if timer, ok := ctx.Value(attemptDeadlineKey{}).(*time.Timer); ok {
+		timer.Stop()
+	}
+}
+
+// attemptTimeoutError replaces the error of an attempt cancelled by its
+// per-attempt timeout with a deadline error, so that it's classified as a
+// timeout rather than as a cancellation by the client.
+func attemptTimeoutError(ctx context.Context, timeout time.Duration, err error) error {
+	if err == nil || context.Cause(ctx) != errAttemptTimeout {
+		return err
+	}
+	return fmt.Errorf("%w after %s: %w (%v)", errAttemptTimeout, timeout, context.DeadlineExceeded, err)
+}
@@ -0,0 +1,120 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTimeoutProxy returns a proxy with a per-attempt timeout serving "test-model"
+// with slow-provider, falling back to "fallback-model" with fast-provider.
+func newTimeoutProxy(slow, fast provider.Provider) *Proxy {
+	return &Proxy{
+		cfg: &config.Config{
+			Fallback: config.FallbackConfig{PerAttemptTimeout: 20 * time.Millisecond},
+			Models: []*config.ModelConfig{
+				{ID: "test-model", Name: "slow-model", Provider: "slow-provider", Fallback: []string{"fallback-model"}},
+				{ID: "fallback-model", Name: "fast-model", Provider: "fast-provider"},
+			},
+		},
+		providers: map[string]provider.Provider{"slow-provider": slow, "fast-provider": fast},
+	}
+}
+
+// waitForCancel blocks until the attempt context is done, like a hanging upstream.
+func waitForCancel(ctx context.Context, _ *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestChatCompletionsHandler_PerAttemptTimeout(t *testing.T) {
+	req := api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+
+	t.Run("slow model falls back", func(t *testing.T) {
+		slow, fast := provider.NewProviderMock(t), provider.NewProviderMock(t)
+		slow.ChatCompletionMock.Set(waitForCancel)
+		fast.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+			require.NoError(t, ctx.Err(), "the fallback gets a fresh deadline")
+			return &api.ChatCompletionResponse{Model: req.Model}, nil
+		})
+
+		resp, err := newTimeoutProxy(slow, fast).ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "fast-model", resp.Model)
+	})
+
+	t.Run("every model times out", func(t *testing.T) {
+		slow, fast := provider.NewProviderMock(t), provider.NewProviderMock(t)
+		slow.ChatCompletionMock.Set(waitForCancel)
+		fast.ChatCompletionMock.Set(waitForCancel)
+
+		_, err := newTimeoutProxy(slow, fast).ChatCompletionsHandler(context.Background(), req)
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusGatewayTimeout, apiErr.Status)
+		assert.ErrorIs(t, apiErr.Details, context.DeadlineExceeded)
+	})
+
+	t.Run("provider errors are not timeouts", func(t *testing.T) {
+		slow, fast := provider.NewProviderMock(t), provider.NewProviderMock(t)
+		slow.ChatCompletionMock.Return(nil, stderrors.New("connection refused"))
+		fast.ChatCompletionMock.Return(nil, stderrors.New("connection refused"))
+
+		_, err := newTimeoutProxy(slow, fast).ChatCompletionsHandler(context.Background(), req)
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusInternalServerError, apiErr.Status)
+	})
+
+	t.Run("request deadline still applies", func(t *testing.T) {
+		slow, fast := provider.NewProviderMock(t), provider.NewProviderMock(t)
+		// The fast model isn't tried once the request is out of time.
+		slow.ChatCompletionMock.Set(waitForCancel)
+		p := newTimeoutProxy(slow, fast)
+		p.cfg.Fallback.PerAttemptTimeout = time.Minute
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := p.ChatCompletionsHandler(ctx, req)
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusGatewayTimeout, apiErr.Status)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestChatCompletionsStreamHandler_PerAttemptTimeoutLiftedAfterFirstChunk(t *testing.T) {
+	slow, fast := provider.NewProviderMock(t), provider.NewProviderMock(t)
+	slow.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		content := "Hello"
+		if err := send(ctx, &api.ChatCompletionChunk{Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}}}); err != nil {
+			return nil, err
+		}
+		// A long stream outlives the per-attempt timeout.
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+
+	err := newTimeoutProxy(slow, fast).ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error { return nil })
+	require.NoError(t, err)
+}
@@ -0,0 +1,46 @@
+package proxy
+
+import (
+	"context"
+	"slices"
+
+	langchaincompatible "github.com/dmitrii/llm-gateway/internal/provider/langchain_compatible"
+	"github.com/tmc/langchaingo/llms/googleai"
+	"github.com/tmc/langchaingo/llms/huggingface"
+	"github.com/tmc/langchaingo/llms/ollama"
+	llmsopenai "github.com/tmc/langchaingo/llms/openai"
+)
+
+// huggingfaceEmbeddingTask is the inference task used for HuggingFace embeddings.
+const huggingfaceEmbeddingTask = "feature-extraction"
+
+// openaiEmbedderFactory creates OpenAI clients bound to the requested embedding model.
+func openaiEmbedderFactory(opts []llmsopenai.Option) langchaincompatible.EmbedderFactory {
+	return func(model string) (langchaincompatible.Embedder, error) {
+		return llmsopenai.New(append(slices.Clip(opts), llmsopenai.WithEmbeddingModel(model))...)
+	}
+}
+
+// ollamaEmbedderFactory creates Ollama clients bound to the requested model.
+func ollamaEmbedderFactory(opts []ollama.Option) langchaincompatible.EmbedderFactory {
+	return func(model string) (langchaincompatible.Embedder, error) {
+		return ollama.New(append(slices.Clip(opts), ollama.WithModel(model))...)
+	}
+}
+
+// googleaiEmbedderFactory creates Google AI clients bound to the requested embedding model.
+func googleaiEmbedderFactory(opts []googleai.Option) langchaincompatible.EmbedderFactory {
+	return func(model string) (langchaincompatible.Embedder, error) {
+		return googleai.New(context.Background(), append(slices.Clip(opts), googleai.WithDefaultEmbeddingModel(model))...)
+	}
+}
+
+// huggingfaceEmbedderFactory passes the requested model to the HuggingFace client,
+// which accepts it on every call.
+func huggingfaceEmbedderFactory(llm *huggingface.LLM) langchaincompatible.EmbedderFactory {
+	return func(model string) (langchaincompatible.Embedder, error) {
+		return langchaincompatible.EmbedderFunc(func(ctx context.Context, texts []string) ([][]float32, error) {
+			return llm.CreateEmbedding(ctx, texts, model, huggingfaceEmbeddingTask)
+		}), nil
+	}
+}
@@ -0,0 +1,34 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"golang.org/x/oauth2"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
+)
+
+// googleScopes are the OAuth scopes of the tokens of the Google AI clients
+// authenticated with credentials.
+var googleScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/generative-language",
+}
+
+// newGoogleHTTPClient creates the HTTP client of the Google AI clients of the
+// provider: its upstream client, authenticated with auth. A custom HTTP client
+// replaces the one the Google client would authenticate itself, so the API key
+// or the tokens of the credentials are added here, the tokens being fetched
+// through transport as well. The token source keeps ctx to refresh the tokens
+// for the lifetime of the client, so it isn't cancelled with ctx.
+func newGoogleHTTPClient(ctx context.Context, pCfg *config.ProviderConfig, transport http.RoundTripper, auth ...option.ClientOption) (*http.Client, error) {
+	ctx = context.WithValue(context.WithoutCancel(ctx), oauth2.HTTPClient, newHTTPClient(pCfg, transport))
+	base := newUpstreamClient(pCfg, transport).StandardClient().Transport
+	authorized, err := htransport.NewTransport(ctx, base, append(auth, option.WithScopes(googleScopes...))...)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: authorized}, nil
+}
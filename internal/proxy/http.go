@@ -0,0 +1,103 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// defaultProviderTimeout is used for providers that don't configure a timeout.
+const defaultProviderTimeout = 60 * time.Second
+
+// providerTimeout returns the configured timeout for the provider or the default one.
+func providerTimeout(pCfg *config.ProviderConfig) time.Duration {
+	if pCfg.Timeout > 0 {
+		return pCfg.Timeout
+	}
+	return defaultProviderTimeout
+}
+
+// providerTransport returns the transport of the upstream calls of the provider:
+// shared, or a copy of it with the TLS settings and the proxy of the provider,
+// since connections with other settings can't be pooled with the shared ones.
+func providerTransport(pCfg *config.ProviderConfig, shared *http.Transport) (http.RoundTripper, error) {
+	transport := shared
+	if pCfg.TLS != nil {
+		tlsCfg, err := client.NewTLSConfig(*pCfg.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TLS config: %w", err)
+		}
+		if pCfg.TLS.InsecureSkipVerify {
+			slog.Warn("TLS certificate verification is disabled for the provider", "provider", pCfg.ID)
+		}
+		transport = client.WithTLS(transport, tlsCfg)
+	}
+	if pCfg.ProxyURL != "" {
+		proxyURL, err := parseProxyURL(pCfg.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport = client.WithProxy(transport, proxyURL)
+	}
+	return transport, nil
+}
+
+// parseProxyURL parses the proxy_url of a provider.
+func parseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		// The parse error quotes the URL, and so its password.
+		return nil, errors.New("invalid proxy_url: malformed URL")
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy_url %q: the scheme must be http, https or socks5", proxyURL.Redacted())
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy_url %q: missing host", proxyURL.Redacted())
+	}
+	return proxyURL, nil
+}
+
+// ownsHTTPClient reports whether the provider sends its requests with an HTTP
+// client of its own, which can't go through the proxy of the provider.
+func ownsHTTPClient(pCfg *config.ProviderConfig) bool {
+	switch pCfg.Provider {
+	case config.ProviderHuggingFace:
+		hfCfg, ok := pCfg.Config.(*config.HuggingFaceProviderConfig)
+		return !ok || hfCfg.Mode != config.HuggingFaceModeInferenceEndpoints
+	}
+	return false
+}
+
+// newHTTPClient creates the HTTP client used for upstream calls of the provider.
+// The clients of all providers share transport, and so its connection pool, while
+// the timeout is the provider's own. It is an idle timeout, so that streams
+// aren't cut while the upstream keeps sending them.
+func newHTTPClient(pCfg *config.ProviderConfig, transport http.RoundTripper) *http.Client {
+	return &http.Client{
+		Transport: client.WithIdleTimeout(transport, providerTimeout(pCfg)),
+	}
+}
+
+// newUpstreamClient creates the client used for upstream calls of the provider,
+// applying the provider retry policy and headers if they are configured.
+func newUpstreamClient(pCfg *config.ProviderConfig, transport http.RoundTripper, opts ...client.Option) *client.Client {
+	if pCfg.Retry != nil {
+		opts = append(opts, client.WithRetry(*pCfg.Retry))
+	}
+	if pCfg.ConnectRetry != nil {
+		opts = append(opts, client.WithConnectRetry(*pCfg.ConnectRetry))
+	}
+	if len(pCfg.Headers) > 0 {
+		opts = append(opts, client.WithHeaders(pCfg.Headers))
+	}
+	return client.New(newHTTPClient(pCfg, transport), opts...)
+}
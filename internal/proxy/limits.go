@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+)
+
+// enforceLimits keeps the parameters of req within limits, clamping them or,
+// with limits.RejectOnExceed, returning a bad request error. Parameters are
+// replaced rather than modified in place, since the request copies of the
+// attempts share them.
+func enforceLimits(req *api.ChatCompletionRequest, limits *config.ModelLimits) error {
+	if limits == nil {
+		return nil
+	}
+
+	if ceiling := limits.MaxTokensCeiling; ceiling > 0 {
+		switch {
+		case req.MaxTokens == nil:
+			req.MaxTokens = &ceiling
+		case *req.MaxTokens > ceiling:
+			if limits.RejectOnExceed {
+				return errors.ErrBadRequest.WithMessage(fmt.Sprintf("max_tokens must be at most %d for this model", ceiling))
+			}
+			req.MaxTokens = &ceiling
+		}
+	}
+
+	var err error
+	if req.Temperature, err = clampParam("temperature", req.Temperature, limits.TemperatureMin, limits.TemperatureMax, limits.RejectOnExceed); err != nil {
+		return err
+	}
+	if req.TopP, err = clampParam("top_p", req.TopP, limits.TopPMin, limits.TopPMax, limits.RejectOnExceed); err != nil {
+		return err
+	}
+	return nil
+}
+
+// clampParam returns value clamped into [lower, upper], or an error if it is
+// out of bounds and reject is set. Unset values and bounds are left alone.
+func clampParam(name string, value, lower, upper *float32, reject bool) (*float32, error) {
+	if value == nil {
+		return nil, nil
+	}
+
+	bound := value
+	if lower != nil && *value < *lower {
+		bound = lower
+	}
+	if upper != nil && *value > *upper {
+		bound = upper
+	}
+	if bound == value {
+		return value, nil
+	}
+	if reject {
+		return nil, errors.ErrBadRequest.WithMessage(fmt.Sprintf("%s must be between %s and %s for this model", name, formatBound(lower), formatBound(upper)))
+	}
+	clamped := *bound
+	return &clamped, nil
+}
+
+func formatBound(bound *float32) string {
+	if bound == nil {
+		return "unbounded"
+	}
+	return fmt.Sprintf("%g", *bound)
+}
+
+// checkContextWindow rejects req if its estimated prompt tokens plus max_tokens
+// don't fit in the context window of the model. Nothing is checked without a
+// window or a token counter.
+func checkContextWindow(counter TokenCounter, req *api.ChatCompletionRequest, window int) error {
+	if window <= 0 || counter == nil {
+		return nil
+	}
+
+	prompt := estimatePromptTokens(counter, req)
+	maxTokens := 0
+	if req.MaxTokens != nil {
+		maxTokens = *req.MaxTokens
+	}
+	if prompt+maxTokens > window {
+		return errors.ErrUnsupported.WithMessage(fmt.Sprintf(
+			"the prompt (about %d tokens) and max_tokens (%d) exceed the context window of %d tokens of this model",
+			prompt, maxTokens, window))
+	}
+	return nil
+}
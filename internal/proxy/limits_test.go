@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestEnforceLimits(t *testing.T) {
+	limits := config.ModelLimits{
+		MaxTokensCeiling: 1024,
+		TemperatureMin:   ptr(float32(0.1)),
+		TemperatureMax:   ptr(float32(1)),
+		TopPMax:          ptr(float32(0.9)),
+	}
+
+	tests := []struct {
+		name     string
+		req      api.ChatCompletionRequest
+		expected api.ChatCompletionRequest
+		rejected bool
+	}{
+		{
+			name:     "within limits",
+			req:      api.ChatCompletionRequest{MaxTokens: ptr(512), Temperature: ptr(float32(0.5)), TopP: ptr(float32(0.5))},
+			expected: api.ChatCompletionRequest{MaxTokens: ptr(512), Temperature: ptr(float32(0.5)), TopP: ptr(float32(0.5))},
+		},
+		{
+			name:     "omitted max_tokens gets the ceiling",
+			req:      api.ChatCompletionRequest{},
+			expected: api.ChatCompletionRequest{MaxTokens: ptr(1024)},
+		},
+		{
+			name:     "over the upper bounds",
+			req:      api.ChatCompletionRequest{MaxTokens: ptr(4096), Temperature: ptr(float32(1.5)), TopP: ptr(float32(1))},
+			expected: api.ChatCompletionRequest{MaxTokens: ptr(1024), Temperature: ptr(float32(1)), TopP: ptr(float32(0.9))},
+			rejected: true,
+		},
+		{
+			name:     "under the lower bound",
+			req:      api.ChatCompletionRequest{MaxTokens: ptr(512), Temperature: ptr(float32(0))},
+			expected: api.ChatCompletionRequest{MaxTokens: ptr(512), Temperature: ptr(float32(0.1))},
+			rejected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"/clamp", func(t *testing.T) {
+			req := tt.req
+			require.NoError(t, enforceLimits(&req, &limits))
+			assert.Equal(t, tt.expected, req)
+		})
+
+		t.Run(tt.name+"/reject", func(t *testing.T) {
+			rejectLimits := limits
+			rejectLimits.RejectOnExceed = true
+
+			req := tt.req
+			err := enforceLimits(&req, &rejectLimits)
+			if !tt.rejected {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expected, req)
+				return
+			}
+			var apiErr internalerrors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		})
+	}
+}
+
+func TestEnforceLimits_DoesNotModifySharedParams(t *testing.T) {
+	temperature := float32(2)
+	req := api.ChatCompletionRequest{Temperature: &temperature}
+
+	require.NoError(t, enforceLimits(&req, &config.ModelLimits{TemperatureMax: ptr(float32(1))}))
+
+	assert.Equal(t, float32(1), *req.Temperature)
+	assert.Equal(t, float32(2), temperature)
+}
+
+func TestCheckContextWindow(t *testing.T) {
+	// "Hello world" is 2 words, plus 3 tokens for the message and 3 for the reply.
+	req := api.ChatCompletionRequest{
+		Model:    "model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello world")}},
+	}
+
+	tests := []struct {
+		name      string
+		window    int
+		maxTokens *int
+		rejected  bool
+	}{
+		{name: "unchecked without window", window: 0, maxTokens: ptr(1000)},
+		{name: "prompt fits", window: 8},
+		{name: "prompt and max_tokens fit", window: 10, maxTokens: ptr(2)},
+		{name: "max_tokens overflows", window: 10, maxTokens: ptr(3), rejected: true},
+		{name: "prompt overflows", window: 7, rejected: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := req
+			req.MaxTokens = tt.maxTokens
+			err := checkContextWindow(wordCounter{}, &req, tt.window)
+			if !tt.rejected {
+				require.NoError(t, err)
+				return
+			}
+			var apiErr internalerrors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+			assert.Contains(t, apiErr.Message, "context window")
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	llmsopenai "github.com/tmc/langchaingo/llms/openai"
+)
+
+// mistralRenamedFields maps the fields of the requests of the OpenAI client to
+// the ones of the Mistral API, which rejects the fields it doesn't know.
+var mistralRenamedFields = map[string]string{
+	"max_completion_tokens": "max_tokens",
+	"seed":                  "random_seed",
+	"stream_options":        "",
+}
+
+// mistralOptions returns the options of the OpenAI client calling the Mistral
+// API, which takes the chat completions and embeddings requests of the OpenAI
+// API on /v1 once renamed with mistralRenamedFields. Unlike the Mistral client
+// of langchaingo, it sends its requests with httpClient, and so through the
+// transport, retries and headers of the provider.
+func mistralOptions(mistralCfg *config.MistralProviderConfig, httpClient *client.Client) []llmsopenai.Option {
+	return []llmsopenai.Option{
+		llmsopenai.WithToken(mistralCfg.APIKey),
+		llmsopenai.WithBaseURL(strings.TrimSuffix(mistralCfg.APIUrl, "/") + "/v1"),
+		llmsopenai.WithHTTPClient(httpClient),
+	}
+}
@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+)
+
+// openaiModerator calls the OpenAI moderation API. Like the langchaingo client,
+// it appends the endpoint path to the configured API URL.
+type openaiModerator struct {
+	url    string
+	token  string
+	orgID  string
+	client *client.Client
+}
+
+func newOpenAIModerator(apiURL, token, orgID string, httpClient *client.Client) *openaiModerator {
+	return &openaiModerator{
+		url:    strings.TrimRight(apiURL, "/") + "/moderations",
+		token:  token,
+		orgID:  orgID,
+		client: httpClient,
+	}
+}
+
+func (m *openaiModerator) Moderate(ctx context.Context, req *api.ModerationRequest) (*api.ModerationResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, m.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+m.token)
+	if m.orgID != "" {
+		httpReq.Header.Set("OpenAI-Organization", m.orgID)
+	}
+
+	resp, err := m.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res api.ModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return nil, fmt.Errorf("failed to decode moderation response: %w", err)
+	}
+	return &res, nil
+}
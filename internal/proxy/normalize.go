@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/google/uuid"
+)
+
+// newCompletionID returns an ID in the format of the OpenAI chat completion IDs.
+func newCompletionID() string {
+	return "chatcmpl-" + uuid.NewString()
+}
+
+// normalizeResponse fills in the fields of a chat completion response that the
+// provider left out, so that responses look the same whatever the backend:
+// a generated ID, the chat.completion object, the current time and the upstream
+// name of model.
+func normalizeResponse(resp *api.ChatCompletionResponse, model *config.ModelConfig) {
+	if resp.Id == "" {
+		resp.Id = newCompletionID()
+	}
+	resp.Object = "chat.completion"
+	if resp.Created == 0 {
+		resp.Created = int(time.Now().Unix())
+	}
+	if resp.Model == "" {
+		resp.Model = model.Name
+	}
+}
+
+// dropReasoning removes the reasoning of the choices of resp.
+func dropReasoning(resp *api.ChatCompletionResponse) {
+	for i := range resp.Choices {
+		resp.Choices[i].Message.ReasoningContent = nil
+	}
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLangchainProxy returns a proxy serving chat-model with an OpenAI provider
+// whose upstream answers with a fixed chat completion, streamed or not.
+func newLangchainProxy(t *testing.T) *Proxy {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), `"stream":true`) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"hi\"}}]}\n\n")
+			fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	pCfg := &config.ProviderConfig{
+		ID:       "openai",
+		Provider: config.ProviderOpenAI,
+		Config:   &config.OpenAIProviderConfig{APIKey: "test-key", APIUrl: srv.URL},
+	}
+	p, err := newProvider(context.Background(), pCfg, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	return &Proxy{
+		cfg: &config.Config{
+			Providers: []*config.ProviderConfig{pCfg},
+			Models:    []*config.ModelConfig{{ID: "chat-model", Name: "gpt-4o", Provider: "openai"}},
+		},
+		providers: map[string]provider.Provider{"openai": p},
+	}
+}
+
+func TestChatCompletionsHandler_NormalizesLangchainResponse(t *testing.T) {
+	proxy := newLangchainProxy(t)
+
+	before := time.Now().Unix()
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	})
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^chatcmpl-[0-9a-f-]{36}$`, resp.Id)
+	assert.Equal(t, "chat.completion", resp.Object)
+	assert.GreaterOrEqual(t, int64(resp.Created), before)
+	assert.Equal(t, "gpt-4o", resp.Model)
+}
+
+func TestChatCompletionsStreamHandler_NormalizesLangchainChunks(t *testing.T) {
+	proxy := newLangchainProxy(t)
+
+	var chunks []api.ChatCompletionChunk
+	err := proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		chunks = append(chunks, *chunk)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NotEmpty(t, chunks)
+	for _, chunk := range chunks {
+		assert.Regexp(t, `^chatcmpl-`, chunk.Id)
+		assert.Equal(t, chunks[0].Id, chunk.Id)
+		assert.Equal(t, "chat.completion.chunk", chunk.Object)
+		assert.Equal(t, "gpt-4o", chunk.Model)
+	}
+}
+
+func TestChatCompletionsHandler_DropReasoning(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Server: config.ServerConfig{DropReasoning: true},
+			Models: []*config.ModelConfig{{ID: "reasoning-model", Name: "deepseek-reasoner", Provider: "test-provider"}},
+		},
+		providers: map[string]provider.Provider{"test-provider": mockProvider},
+	}
+
+	reasoning := "6 times 7"
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{Choices: []api.ChatCompletionChoice{{
+		Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("42"), ReasoningContent: &reasoning},
+	}}}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "reasoning-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("6 times 7?")}},
+	})
+	require.NoError(t, err)
+
+	assert.Nil(t, resp.Choices[0].Message.ReasoningContent)
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "42", text)
+}
@@ -0,0 +1,16 @@
+package proxy
+
+import "github.com/dmitrii/llm-gateway/internal/config"
+
+// openRouterHeaders returns the headers OpenRouter identifies the calling
+// application with. The provider headers take precedence over them.
+func openRouterHeaders(cfg *config.OpenRouterProviderConfig) map[string]string {
+	headers := make(map[string]string, 2)
+	if cfg.Referer != "" {
+		headers["HTTP-Referer"] = cfg.Referer
+	}
+	if cfg.Title != "" {
+		headers["X-Title"] = cfg.Title
+	}
+	return headers
+}
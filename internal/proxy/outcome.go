@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"context"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// Outcome is what the proxy reports about a request, for the access log.
+// Fields stay empty until known: a failed request has no served model, unless
+// it is a stream that failed midway.
+type Outcome struct {
+	// Model is the requested model ID, aliases resolved.
+	Model string
+	// ServedModel and Provider are the model ID and the provider that served the request.
+	ServedModel string
+	Provider    string
+	// UpstreamModel is the name of ServedModel at the provider.
+	UpstreamModel string
+	// Fallback is set when the request was served by another model than the requested one.
+	Fallback bool
+	// Token usage of the response, estimated when the provider didn't report it.
+	PromptTokens     int
+	CompletionTokens int
+}
+
+type outcomeKey struct{}
+
+// WithOutcome returns a context collecting the outcome of the request made with
+// it into the returned Outcome.
+func WithOutcome(ctx context.Context) (context.Context, *Outcome) {
+	outcome := &Outcome{}
+	return context.WithValue(ctx, outcomeKey{}, outcome), outcome
+}
+
+// outcomeFrom returns the outcome collected by the context, or nil.
+func outcomeFrom(ctx context.Context) *Outcome {
+	outcome, _ := ctx.Value(outcomeKey{}).(*Outcome)
+	return outcome
+}
+
+// setOutcomeUsage records the token usage of the response in the outcome of ctx.
+func setOutcomeUsage(ctx context.Context, promptTokens, completionTokens int) {
+	if outcome := outcomeFrom(ctx); outcome != nil {
+		outcome.PromptTokens, outcome.CompletionTokens = promptTokens, completionTokens
+	}
+}
+
+// setOutcomeServed records model as the one serving the request in the outcome
+// of ctx.
+func setOutcomeServed(ctx context.Context, model *config.ModelConfig) {
+	if outcome := outcomeFrom(ctx); outcome != nil {
+		outcome.ServedModel = model.ID
+		outcome.Provider = model.Provider
+		outcome.UpstreamModel = model.Name
+		outcome.Fallback = model.ID != outcome.Model
+	}
+}
@@ -0,0 +1,17 @@
+package proxy
+
+import "context"
+
+type providerOverrideKey struct{}
+
+// WithProviderOverride returns a context pinning the requests made with it to the
+// provider with the given ID, without fallbacks.
+func WithProviderOverride(ctx context.Context, providerID string) context.Context {
+	return context.WithValue(ctx, providerOverrideKey{}, providerID)
+}
+
+// providerOverride returns the provider ID pinned by the context, if any.
+func providerOverride(ctx context.Context) string {
+	id, _ := ctx.Value(providerOverrideKey{}).(string)
+	return id
+}
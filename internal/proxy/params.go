@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// resolveParams fills in the parameters params omits from defaults, the first
+// one setting a parameter winning. Every endpoint resolves its parameters with
+// it, with the request parameters, then the defaults of the requested model and
+// last those of the provider of the attempt, see defaultParams.
+func resolveParams(params config.ModelDefaults, defaults ...*config.ModelDefaults) config.ModelDefaults {
+	for _, d := range defaults {
+		if d == nil {
+			continue
+		}
+		params.MaxTokens = orDefault(params.MaxTokens, d.MaxTokens)
+		params.Temperature = orDefault(params.Temperature, d.Temperature)
+		params.TopP = orDefault(params.TopP, d.TopP)
+		params.EncodingFormat = orDefault(params.EncodingFormat, d.EncodingFormat)
+	}
+	return params
+}
+
+func orDefault[T any](v, def *T) *T {
+	if v != nil {
+		return v
+	}
+	return def
+}
+
+// defaultParams returns the defaults applying to an attempt of the requested
+// model with model, by precedence. The defaults of the requested model also
+// apply to its fallback models.
+func (p *Proxy) defaultParams(requested string, model *config.ModelConfig) []*config.ModelDefaults {
+	var defaults []*config.ModelDefaults
+	if m := p.findModel(requested); m != nil {
+		defaults = append(defaults, m.Defaults)
+	}
+	if pCfg := p.findProvider(model.Provider); pCfg != nil {
+		defaults = append(defaults, pCfg.DefaultParams)
+	}
+	return defaults
+}
+
+// applyChatParams sets the resolved parameters of a chat completion attempt.
+func (p *Proxy) applyChatParams(req *api.ChatCompletionRequest, requested string, model *config.ModelConfig) {
+	params := resolveParams(config.ModelDefaults{
+		MaxTokens:   req.MaxTokens,
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+	}, p.defaultParams(requested, model)...)
+	req.MaxTokens, req.Temperature, req.TopP = params.MaxTokens, params.Temperature, params.TopP
+}
+
+// applyEmbeddingsParams sets the resolved parameters of an embeddings attempt.
+func (p *Proxy) applyEmbeddingsParams(req *api.EmbeddingsRequest, requested string, model *config.ModelConfig) {
+	params := resolveParams(config.ModelDefaults{EncodingFormat: (*string)(req.EncodingFormat)}, p.defaultParams(requested, model)...)
+	req.EncodingFormat = (*api.EmbeddingsRequestEncodingFormat)(params.EncodingFormat)
+}
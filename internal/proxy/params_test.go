@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/gojuno/minimock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveParams(t *testing.T) {
+	modelDefaults := &config.ModelDefaults{MaxTokens: ptr(256), Temperature: ptr(float32(0.2))}
+	providerDefaults := &config.ModelDefaults{MaxTokens: ptr(1024), Temperature: ptr(float32(0.7)), TopP: ptr(float32(0.9)), EncodingFormat: ptr("float")}
+
+	tests := []struct {
+		name     string
+		params   config.ModelDefaults
+		defaults []*config.ModelDefaults
+		expected config.ModelDefaults
+	}{
+		{
+			name:     "no defaults",
+			params:   config.ModelDefaults{TopP: ptr(float32(0.5))},
+			expected: config.ModelDefaults{TopP: ptr(float32(0.5))},
+		},
+		{
+			name:     "request over model and provider defaults",
+			params:   config.ModelDefaults{MaxTokens: ptr(64), Temperature: ptr(float32(1)), TopP: ptr(float32(0.5))},
+			defaults: []*config.ModelDefaults{modelDefaults, providerDefaults},
+			expected: config.ModelDefaults{MaxTokens: ptr(64), Temperature: ptr(float32(1)), TopP: ptr(float32(0.5)), EncodingFormat: ptr("float")},
+		},
+		{
+			name:     "model over provider defaults",
+			params:   config.ModelDefaults{},
+			defaults: []*config.ModelDefaults{modelDefaults, providerDefaults},
+			expected: config.ModelDefaults{MaxTokens: ptr(256), Temperature: ptr(float32(0.2)), TopP: ptr(float32(0.9)), EncodingFormat: ptr("float")},
+		},
+		{
+			name:     "provider defaults without model defaults",
+			params:   config.ModelDefaults{Temperature: ptr(float32(1))},
+			defaults: []*config.ModelDefaults{nil, providerDefaults},
+			expected: config.ModelDefaults{MaxTokens: ptr(1024), Temperature: ptr(float32(1)), TopP: ptr(float32(0.9)), EncodingFormat: ptr("float")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, resolveParams(tt.params, tt.defaults...))
+		})
+	}
+}
+
+func TestChatCompletionsHandler_ProviderDefaultParams(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Providers: []*config.ProviderConfig{
+				{ID: "provider1", DefaultParams: &config.ModelDefaults{MaxTokens: ptr(1024), TopP: ptr(float32(0.9))}},
+				{ID: "provider2", DefaultParams: &config.ModelDefaults{TopP: ptr(float32(0.5))}},
+			},
+			Models: []*config.ModelConfig{
+				{
+					ID:       "chat-model",
+					Name:     "primary-model",
+					Provider: "provider1",
+					Fallback: []string{"fallback-model"},
+					Defaults: &config.ModelDefaults{MaxTokens: ptr(256)},
+				},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+	}
+
+	// Each attempt gets the defaults of its own provider, under the defaults of
+	// the requested model and the request parameters.
+	messages := []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}}
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model:       "primary-model",
+		Messages:    messages,
+		MaxTokens:   ptr(256),
+		Temperature: ptr(float32(0.3)),
+		TopP:        ptr(float32(0.9)),
+	}).Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model:       "backup-model",
+		Messages:    messages,
+		MaxTokens:   ptr(256),
+		Temperature: ptr(float32(0.3)),
+		TopP:        ptr(float32(0.5)),
+	}).Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:       "chat-model",
+		Messages:    messages,
+		Temperature: ptr(float32(0.3)),
+	})
+	require.NoError(t, err)
+}
+
+func TestEmbeddingsHandler_ProviderDefaultParams(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Providers: []*config.ProviderConfig{
+				{ID: "provider1", DefaultParams: &config.ModelDefaults{EncodingFormat: ptr("float")}},
+			},
+			Models: []*config.ModelConfig{
+				{ID: "embed-model", Name: "text-embedding-3-small", Provider: "provider1"},
+			},
+		},
+		providers: map[string]provider.Provider{"provider1": mockProvider},
+	}
+
+	input := api.EmbeddingsRequest_Input{}
+	require.NoError(t, input.FromEmbeddingsRequestInput0("Hello, world!"))
+	format := api.EmbeddingsRequestEncodingFormatFloat
+	mockProvider.EmbeddingsMock.Expect(minimock.AnyContext, &api.EmbeddingsRequest{
+		Model:          "text-embedding-3-small",
+		Input:          input,
+		EncodingFormat: &format,
+	}).Return(&api.EmbeddingsResponse{Model: "text-embedding-3-small"}, nil)
+
+	_, err := proxy.EmbeddingsHandler(context.Background(), api.EmbeddingsRequest{Model: "embed-model", Input: input})
+	require.NoError(t, err)
+}
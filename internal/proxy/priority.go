@@ -0,0 +1,17 @@
+package proxy
+
+import "context"
+
+type priorityKey struct{}
+
+// WithPriority returns a context queuing the upstream calls made with it in the
+// given priority tier once the gateway concurrency limit is reached.
+func WithPriority(ctx context.Context, tier string) context.Context {
+	return context.WithValue(ctx, priorityKey{}, tier)
+}
+
+// priority returns the priority tier set on the context, if any.
+func priority(ctx context.Context) string {
+	tier, _ := ctx.Value(priorityKey{}).(string)
+	return tier
+}
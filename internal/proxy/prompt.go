@@ -0,0 +1,31 @@
+package proxy
+
+import (
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// injectSystemPrompt adds the system prompt of model as the leading message of
+// req, unless req already has a system message and the model isn't configured
+// to always prepend it. The messages are replaced rather than modified in place,
+// since the request copies of the attempts share them.
+func injectSystemPrompt(req *api.ChatCompletionRequest, model *config.ModelConfig) {
+	if model.SystemPrompt == "" {
+		return
+	}
+	if !model.AlwaysPrependSystemPrompt {
+		for _, msg := range req.Messages {
+			if msg.Role == api.ChatMessageRoleSystem {
+				return
+			}
+		}
+	}
+
+	content := &api.ChatMessage_Content{}
+	if err := content.FromChatMessageContent0(model.SystemPrompt); err != nil {
+		return
+	}
+	messages := make([]api.ChatMessage, 0, len(req.Messages)+1)
+	messages = append(messages, api.ChatMessage{Role: api.ChatMessageRoleSystem, Content: content})
+	req.Messages = append(messages, req.Messages...)
+}
@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/gojuno/minimock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjectSystemPrompt(t *testing.T) {
+	user := api.ChatMessage{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}
+	system := api.ChatMessage{Role: api.ChatMessageRoleSystem, Content: createChatContent("Answer in French.")}
+	prompt := api.ChatMessage{Role: api.ChatMessageRoleSystem, Content: createChatContent("You are a helpful assistant.")}
+
+	tests := []struct {
+		name     string
+		model    config.ModelConfig
+		messages []api.ChatMessage
+		expected []api.ChatMessage
+	}{
+		{
+			name:     "no system prompt",
+			model:    config.ModelConfig{},
+			messages: []api.ChatMessage{user},
+			expected: []api.ChatMessage{user},
+		},
+		{
+			name:     "without a system message",
+			model:    config.ModelConfig{SystemPrompt: "You are a helpful assistant."},
+			messages: []api.ChatMessage{user},
+			expected: []api.ChatMessage{prompt, user},
+		},
+		{
+			name:     "with a system message",
+			model:    config.ModelConfig{SystemPrompt: "You are a helpful assistant."},
+			messages: []api.ChatMessage{system, user},
+			expected: []api.ChatMessage{system, user},
+		},
+		{
+			name:     "always prepended with a system message",
+			model:    config.ModelConfig{SystemPrompt: "You are a helpful assistant.", AlwaysPrependSystemPrompt: true},
+			messages: []api.ChatMessage{system, user},
+			expected: []api.ChatMessage{prompt, system, user},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := append([]api.ChatMessage(nil), tt.messages...)
+			req := api.ChatCompletionRequest{Messages: tt.messages}
+			injectSystemPrompt(&req, &tt.model)
+			assert.Equal(t, tt.expected, req.Messages)
+			assert.Equal(t, messages, tt.messages)
+		})
+	}
+}
+
+func TestChatCompletionsHandler_SystemPromptPerFallback(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "chat-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}, SystemPrompt: "Primary prompt."},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2", SystemPrompt: "Backup prompt."},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+	}
+
+	user := api.ChatMessage{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello, world!")}
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model: "primary-model",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleSystem, Content: createChatContent("Primary prompt.")},
+			user,
+		},
+	}).Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model: "backup-model",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleSystem, Content: createChatContent("Backup prompt.")},
+			user,
+		},
+	}).Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "chat-model", Messages: []api.ChatMessage{user}})
+	require.NoError(t, err)
+	assert.Equal(t, "backup-model", resp.Model)
+}
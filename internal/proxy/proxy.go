@@ -1,11 +1,19 @@
 package proxy
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/audit"
+	"github.com/dmitrii/llm-gateway/internal/client"
 	"github.com/dmitrii/llm-gateway/internal/config"
 	"github.com/dmitrii/llm-gateway/internal/errors"
 	"github.com/dmitrii/llm-gateway/internal/provider"
@@ -19,6 +27,8 @@ import (
 	"github.com/tmc/langchaingo/llms/huggingface"
 	"github.com/tmc/langchaingo/llms/ollama"
 	llmsopenai "github.com/tmc/langchaingo/llms/openai"
+	"golang.org/x/sync/singleflight"
+	"google.golang.org/api/option"
 )
 
 var (
@@ -27,20 +37,71 @@ var (
 			Name: "llm_gateway_prompt_tokens_total",
 			Help: "Total number of prompt tokens used",
 		},
-		[]string{"model", "provider"},
+		[]string{"model", "provider", "endpoint", "estimated"},
 	)
 	completionTokensTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "llm_gateway_completion_tokens_total",
 			Help: "Total number of completion tokens used",
 		},
-		[]string{"model", "provider"},
+		[]string{"model", "provider", "endpoint", "estimated"},
 	)
 	totalTokensTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "llm_gateway_total_tokens_total",
 			Help: "Total number of tokens used (prompt + completion)",
 		},
+		[]string{"model", "provider", "endpoint", "estimated"},
+	)
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "llm_gateway_request_duration_seconds",
+			Help:    "Duration of provider calls",
+			Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60},
+		},
+		[]string{"model", "provider", "endpoint", "status"},
+	)
+	timeToFirstToken = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "llm_gateway_time_to_first_token_seconds",
+			Help:    "Time from the start of a streamed provider call to its first chunk",
+			Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 1.5, 2, 3, 5},
+		},
+		[]string{"model", "provider"},
+	)
+	clientDisconnectsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_client_disconnects_total",
+			Help: "Total number of streams cancelled because the client went away",
+		},
+		[]string{"model", "provider"},
+	)
+	fallbackTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_fallback_total",
+			Help: "Total number of responses served by a fallback model, by the reason the previous model was left",
+		},
+		[]string{"requested_model", "served_model", "reason"},
+	)
+	providerErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_provider_errors_total",
+			Help: "Total number of failed provider calls",
+		},
+		[]string{"provider", "error_class"},
+	)
+	choicesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_choices_total",
+			Help: "Total number of choices returned in chat completions",
+		},
+		[]string{"model", "provider"},
+	)
+	costTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "llm_gateway_cost_usd_total",
+			Help: "Estimated cost in USD of chat completions, based on the configured model prices",
+		},
 		[]string{"model", "provider"},
 	)
 )
@@ -49,154 +110,870 @@ func init() {
 	prometheus.MustRegister(promptTokensTotal)
 	prometheus.MustRegister(completionTokensTotal)
 	prometheus.MustRegister(totalTokensTotal)
+	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(timeToFirstToken)
+	prometheus.MustRegister(clientDisconnectsTotal)
+	prometheus.MustRegister(costTotal)
+	prometheus.MustRegister(choicesTotal)
+	prometheus.MustRegister(fallbackTotal)
+	prometheus.MustRegister(providerErrorsTotal)
 }
 
 // Proxy holds the configuration and initialized LLM providers.
 type Proxy struct {
 	cfg       *config.Config
 	providers map[string]provider.Provider
+	breakers  map[string]*circuitBreaker
+	limiters  map[string]*concurrencyLimiter
+	// globalLimiter bounds the upstream calls of all the providers. Unlimited when nil.
+	globalLimiter *globalLimiter
+	// tokenCounter estimates the usage of chat completions returned without one.
+	// Usage is not estimated when nil.
+	tokenCounter TokenCounter
+	// audit records the served chat completions. Disabled when nil.
+	audit *audit.Logger
+	// retryBudget throttles fallbacks after failed attempts. Unlimited when nil.
+	retryBudget *retryBudget
+	// errorRates orders the models with the least_errors fallback strategy.
+	errorRates *errorRates
+	// modelsByID and providersByID index the configs of cfg, which findModel
+	// and findProvider scan when they are nil.
+	modelsByID    map[string]*config.ModelConfig
+	providersByID map[string]*config.ProviderConfig
+	// contentFilter redacts the chat completion content. Disabled when nil.
+	contentFilter *contentFilter
+	// inflight coalesces the identical chat completions in flight. Disabled when nil.
+	inflight *singleflight.Group
+	// shuffle orders the models with the random fallback strategy.
+	// Defaults to rand.Shuffle when nil.
+	shuffle func(n int, swap func(i, j int))
+	// transports are the transports of the providers, whose idle connections
+	// are closed by Close.
+	transports []*http.Transport
+	// auditCarriedOver is set once audit is handed over by CarryOver, so that
+	// Close leaves it open.
+	auditCarriedOver bool
 }
 
-// NewProxy creates a new Proxy instance and initializes all configured providers.
-func NewProxy(cfg *config.Config) (*Proxy, error) {
-	providers := make(map[string]provider.Provider)
-	var err error
+// NewProxy creates a new Proxy instance and initializes all configured providers,
+// but the disabled ones. With cfg.Startup.AllowPartial, providers that fail to
+// initialize are left out instead of failing the whole proxy. The providers are
+// initialized within ctx.
+func NewProxy(ctx context.Context, cfg *config.Config) (*Proxy, error) {
+	breakers := make(map[string]*circuitBreaker)
+	limiters := make(map[string]*concurrencyLimiter)
 
+	var enabled []*config.ProviderConfig
 	for _, pCfg := range cfg.Providers {
 		id := pCfg.ID
-		if pCfg.Provider == config.ProviderDummy {
-			providers[id] = dummy.NewDummyProvider()
+		if !pCfg.IsEnabled() {
+			slog.Warn("Provider is disabled, skipping it", "provider", id)
 			continue
 		}
+		enabled = append(enabled, pCfg)
+		if pCfg.CircuitBreaker != nil {
+			breakers[id] = newCircuitBreaker(id, *pCfg.CircuitBreaker)
+		}
+		if pCfg.MaxConcurrency > 0 {
+			limiters[id] = newConcurrencyLimiter(pCfg)
+		}
+	}
 
-		var llm llms.Model
-		switch pCfg.Provider {
-		case config.ProviderAnthropic:
-			anthropicCfg := pCfg.Config.(*config.AnthropicProviderConfig)
-			llm, err = anthropic.New(
-				anthropic.WithBaseURL(anthropicCfg.APIUrl),
-				anthropic.WithToken(anthropicCfg.APIKey),
-			)
-		case config.ProviderAzureOpenAI:
-			azureCfg := pCfg.Config.(*config.AzureOpenAIProviderConfig)
-			llm, err = llmsopenai.New(
-				llmsopenai.WithToken(azureCfg.APIKey),
-				llmsopenai.WithBaseURL(azureCfg.APIUrl),
-				llmsopenai.WithAPIVersion(azureCfg.ApiVersion),
-				llmsopenai.WithAPIType(azureCfg.ApiType),
-			)
-		case config.ProviderOpenAI:
-			openaiCfg := pCfg.Config.(*config.OpenAIProviderConfig)
-			llm, err = llmsopenai.New(
-				llmsopenai.WithToken(openaiCfg.APIKey),
-				llmsopenai.WithBaseURL(openaiCfg.APIUrl),
-				llmsopenai.WithAPIVersion(openaiCfg.ApiVersion),
-				llmsopenai.WithOrganization(openaiCfg.OrgID),
-			)
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
 
-		case config.ProviderGemini:
-			geminiCfg := pCfg.Config.(*config.GeminiProviderConfig)
-			llm, err = googleai.New(
-				context.Background(),
-				googleai.WithAPIKey(geminiCfg.APIKey),
-			)
-		case config.ProviderVertexAI:
-			vertexCfg := pCfg.Config.(*config.VertexAIProviderConfig)
-			llm, err = googleai.New(
-				context.Background(),
-				googleai.WithCloudProject(vertexCfg.ProjectID),
-				googleai.WithCloudLocation(vertexCfg.Location),
-				googleai.WithCredentialsFile(vertexCfg.PathToCredsFile),
-			)
-		case config.ProviderHuggingFace:
-			hfCfg := pCfg.Config.(*config.HuggingFaceProviderConfig)
-			llm, err = huggingface.New(
-				huggingface.WithToken(hfCfg.APIKey),
-				huggingface.WithURL(hfCfg.APIUrl),
-			)
-		case config.ProviderOllama:
-			ollamaCfg := pCfg.Config.(*config.OllamaProviderConfig)
-			llm, err = ollama.New(
-				ollama.WithServerURL(ollamaCfg.APIUrl),
-			)
-		}
+	filter, err := newContentFilter(cfg.ContentFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	auditLogger, err := audit.New(cfg.Audit)
+	if err != nil {
+		return nil, err
+	}
+
+	shared := client.NewTransport(cfg.Server.HTTPClient)
+	transports := []*http.Transport{shared}
+	var transportsMu sync.Mutex
+	providers, err := initProviders(ctx, enabled, cfg.Startup, func(ctx context.Context, pCfg *config.ProviderConfig) (provider.Provider, error) {
+		transport, err := providerTransport(pCfg, shared)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create LLM model for provider %s: %w", id, err)
+			return nil, err
 		}
-		providers[id] = langchaincompatible.NewLangchainProvider(llm)
+		if t, ok := transport.(*http.Transport); ok && t != shared {
+			transportsMu.Lock()
+			transports = append(transports, t)
+			transportsMu.Unlock()
+		}
+		return newProvider(ctx, pCfg, transport, providerModelOptions(cfg.Models, pCfg.ID)...)
+	})
+	if err != nil {
+		if !cfg.Startup.AllowPartial {
+			return nil, err
+		}
+		slog.Error("Some providers failed to initialize, continuing without them", "error", err)
 	}
 
 	return &Proxy{
-		cfg:       cfg,
-		providers: providers,
+		cfg:           cfg,
+		providers:     providers,
+		breakers:      breakers,
+		limiters:      limiters,
+		globalLimiter: newGlobalLimiter(cfg.Server),
+		tokenCounter:  tiktokenCounter{},
+		audit:         auditLogger,
+		retryBudget:   newRetryBudget(cfg.Fallback.RetryBudget),
+		errorRates:    newErrorRates(),
+		modelsByID:    indexByID(cfg.Models, func(m *config.ModelConfig) string { return m.ID }),
+		providersByID: indexByID(cfg.Providers, func(p *config.ProviderConfig) string { return p.ID }),
+		contentFilter: filter,
+		inflight:      newInflight(cfg.Server),
+		transports:    transports,
 	}, nil
 }
 
+// anthropicMaxStopSequences is the most stop_sequences the Anthropic Messages
+// API takes, the cap documented for Claude.
+const anthropicMaxStopSequences = 8191
+
+// providerModelOptions returns the options of the provider with the given ID
+// set by the configs of its models.
+func providerModelOptions(models []*config.ModelConfig, providerID string) []langchaincompatible.Option {
+	var samplingFree []string
+	for _, m := range models {
+		if m.Provider == providerID && m.OmitSamplingParams {
+			samplingFree = append(samplingFree, m.Name)
+		}
+	}
+	if len(samplingFree) == 0 {
+		return nil
+	}
+	return []langchaincompatible.Option{langchaincompatible.WithoutSamplingParams(samplingFree...)}
+}
+
+// newProvider creates the provider of pCfg within ctx, sending its HTTP
+// requests through transport, with the extra options of its models.
+func newProvider(ctx context.Context, pCfg *config.ProviderConfig, transport http.RoundTripper, modelOpts ...langchaincompatible.Option) (provider.Provider, error) {
+	var err error
+	if pCfg.Provider == config.ProviderDummy {
+		if dummyCfg, ok := pCfg.Config.(*config.DummyProviderConfig); ok && dummyCfg.Echo {
+			return dummy.NewEchoProvider(), nil
+		}
+		return dummy.NewDummyProvider(), nil
+	}
+
+	if pCfg.ProxyURL != "" && ownsHTTPClient(pCfg) {
+		return nil, fmt.Errorf("proxy_url is not supported by the %s provider %s, which uses an HTTP client of its own", pCfg.Provider, pCfg.ID)
+	}
+
+	httpClient := newUpstreamClient(pCfg, transport)
+	var providerOpts []langchaincompatible.Option
+
+	var llm llms.Model
+	switch pCfg.Provider {
+	case config.ProviderAnthropic:
+		anthropicCfg := pCfg.Config.(*config.AnthropicProviderConfig)
+		llm, err = anthropic.New(
+			anthropic.WithBaseURL(anthropicCfg.APIUrl),
+			anthropic.WithToken(anthropicCfg.APIKey),
+			anthropic.WithHTTPClient(httpClient),
+		)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithAlternatingRoles(),
+			langchaincompatible.WithMaxStopSequences(anthropicMaxStopSequences),
+		)
+	case config.ProviderAzureOpenAI:
+		azureCfg := pCfg.Config.(*config.AzureOpenAIProviderConfig)
+		opts := []llmsopenai.Option{
+			llmsopenai.WithToken(azureCfg.APIKey),
+			llmsopenai.WithBaseURL(azureCfg.APIUrl),
+			llmsopenai.WithAPIVersion(azureCfg.ApiVersion),
+			llmsopenai.WithAPIType(azureCfg.ApiType),
+			llmsopenai.WithHTTPClient(httpClient),
+		}
+		llm, err = llmsopenai.New(opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithJSONMode(),
+			langchaincompatible.WithMultipleChoices(),
+			langchaincompatible.WithSeed(),
+			langchaincompatible.WithEmbedderFactory(openaiEmbedderFactory(opts)),
+			langchaincompatible.WithUpstreamModels(azureCfg.Deployments),
+		)
+	case config.ProviderOpenAI:
+		openaiCfg := pCfg.Config.(*config.OpenAIProviderConfig)
+		var token string
+		keys := openaiCfg.Keys()
+		if len(keys) > 0 {
+			token = keys[0]
+		}
+		if len(keys) > 1 {
+			// The client replaces the token with a key of the pool on every request.
+			httpClient = newUpstreamClient(pCfg, transport, client.WithKeyPool(client.NewKeyPool(keys, openaiCfg.KeyCooldown)))
+		}
+		opts := []llmsopenai.Option{
+			llmsopenai.WithToken(token),
+			llmsopenai.WithBaseURL(openaiCfg.APIUrl),
+			llmsopenai.WithAPIVersion(openaiCfg.ApiVersion),
+			llmsopenai.WithOrganization(openaiCfg.OrgID),
+			llmsopenai.WithHTTPClient(httpClient),
+		}
+		llm, err = llmsopenai.New(opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithJSONMode(),
+			langchaincompatible.WithMultipleChoices(),
+			langchaincompatible.WithSeed(),
+			langchaincompatible.WithEmbedderFactory(openaiEmbedderFactory(opts)),
+			langchaincompatible.WithModerator(newOpenAIModerator(openaiCfg.APIUrl, token, openaiCfg.OrgID, httpClient)),
+		)
+
+	case config.ProviderGemini:
+		geminiCfg := pCfg.Config.(*config.GeminiProviderConfig)
+		var googleClient *http.Client
+		googleClient, err = newGoogleHTTPClient(ctx, pCfg, transport, option.WithAPIKey(geminiCfg.APIKey))
+		if err != nil {
+			break
+		}
+		opts := []googleai.Option{
+			googleai.WithAPIKey(geminiCfg.APIKey),
+			googleai.WithHTTPClient(googleClient),
+		}
+		llm, err = googleai.New(ctx, opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithJSONMode(),
+			langchaincompatible.WithBinaryImages(),
+			langchaincompatible.WithEmbedderFactory(googleaiEmbedderFactory(opts)),
+		)
+	case config.ProviderVertexAI:
+		vertexCfg := pCfg.Config.(*config.VertexAIProviderConfig)
+		opts := []googleai.Option{
+			googleai.WithCloudProject(vertexCfg.ProjectID),
+			googleai.WithCloudLocation(vertexCfg.Location),
+		}
+		var auth option.ClientOption
+		if vertexCfg.CredentialsJSON != "" {
+			auth = option.WithCredentialsJSON([]byte(vertexCfg.CredentialsJSON))
+			opts = append(opts, googleai.WithCredentialsJSON([]byte(vertexCfg.CredentialsJSON)))
+		} else {
+			auth = option.WithCredentialsFile(vertexCfg.PathToCredsFile)
+			opts = append(opts, googleai.WithCredentialsFile(vertexCfg.PathToCredsFile))
+		}
+		var googleClient *http.Client
+		googleClient, err = newGoogleHTTPClient(ctx, pCfg, transport, auth)
+		if err != nil {
+			break
+		}
+		opts = append(opts, googleai.WithHTTPClient(googleClient))
+		llm, err = googleai.New(ctx, opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithJSONMode(),
+			langchaincompatible.WithBinaryImages(),
+			langchaincompatible.WithEmbedderFactory(googleaiEmbedderFactory(opts)),
+		)
+	case config.ProviderHuggingFace:
+		hfCfg := pCfg.Config.(*config.HuggingFaceProviderConfig)
+		// Both the TGI and the Inference API clients send the seed.
+		providerOpts = append(providerOpts, langchaincompatible.WithSeed())
+		if hfCfg.Mode == config.HuggingFaceModeInferenceEndpoints {
+			llm, err = newTGILLM(hfCfg, httpClient)
+			break
+		}
+		var hfLLM *huggingface.LLM
+		hfLLM, err = huggingface.New(
+			huggingface.WithToken(hfCfg.APIKey),
+			huggingface.WithURL(hfCfg.APIUrl),
+		)
+		llm = hfLLM
+		// The HuggingFace client always uses http.DefaultClient.
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithTimeout(providerTimeout(pCfg)),
+			langchaincompatible.WithEmbedderFactory(huggingfaceEmbedderFactory(hfLLM)),
+		)
+	case config.ProviderBedrock:
+		llm, err = newBedrockLLM(ctx, pCfg)
+	case config.ProviderMistral:
+		mistralCfg := pCfg.Config.(*config.MistralProviderConfig)
+		httpClient = newUpstreamClient(pCfg, transport, client.WithRenamedFields(mistralRenamedFields))
+		opts := mistralOptions(mistralCfg, httpClient)
+		llm, err = llmsopenai.New(opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithSeed(),
+			langchaincompatible.WithEmbedderFactory(openaiEmbedderFactory(opts)),
+		)
+	case config.ProviderOpenRouter:
+		openRouterCfg := pCfg.Config.(*config.OpenRouterProviderConfig)
+		httpClient = newUpstreamClient(pCfg, transport, client.WithHeaders(openRouterHeaders(openRouterCfg)))
+		llm, err = llmsopenai.New(
+			llmsopenai.WithToken(openRouterCfg.APIKey),
+			llmsopenai.WithBaseURL(openRouterCfg.APIUrl),
+			llmsopenai.WithHTTPClient(httpClient),
+		)
+		providerOpts = append(providerOpts, langchaincompatible.WithJSONMode(), langchaincompatible.WithSeed())
+	case config.ProviderCohere:
+		cohereCfg := pCfg.Config.(*config.CohereProviderConfig)
+		llm = newCohereModel(cohereCfg.APIUrl, cohereCfg.APIKey, httpClient)
+		providerOpts = append(providerOpts, langchaincompatible.WithSeed())
+	case config.ProviderOllama:
+		ollamaCfg := pCfg.Config.(*config.OllamaProviderConfig)
+		opts := []ollama.Option{
+			ollama.WithServerURL(ollamaCfg.APIUrl),
+			ollama.WithHTTPClient(httpClient.StandardClient()),
+		}
+		if ollamaCfg.KeepAlive != "" {
+			opts = append(opts, ollama.WithKeepAlive(ollamaCfg.KeepAlive))
+		}
+		if ollamaCfg.NumCtx > 0 {
+			opts = append(opts, ollama.WithRunnerNumCtx(ollamaCfg.NumCtx))
+		}
+		if ollamaCfg.Format != "" {
+			opts = append(opts, ollama.WithFormat(ollamaCfg.Format))
+		}
+		llm, err = ollama.New(opts...)
+		providerOpts = append(providerOpts,
+			langchaincompatible.WithJSONMode(),
+			langchaincompatible.WithBinaryImages(),
+			langchaincompatible.WithSeed(),
+			langchaincompatible.WithEmbedderFactory(ollamaEmbedderFactory(opts)),
+		)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create LLM model for provider %s: %w", pCfg.ID, err)
+	}
+	return langchaincompatible.NewLangchainProvider(llm, append(providerOpts, modelOpts...)...), nil
+}
+
+// ListModelsHandler handles requests to the /v1/models endpoint.
+func (p *Proxy) ListModelsHandler() *api.ModelList {
+	models := make([]api.Model, 0, len(p.cfg.Models))
+	for _, m := range p.cfg.Models {
+		if !m.IsEnabled() {
+			continue
+		}
+		if pCfg := p.findProvider(m.Provider); pCfg != nil && !pCfg.IsEnabled() {
+			continue
+		}
+		models = append(models, api.Model{
+			Id:      m.ID,
+			Object:  "model",
+			OwnedBy: m.Provider,
+		})
+	}
+	return &api.ModelList{
+		Object: "list",
+		Data:   models,
+	}
+}
+
+// Endpoint label values of the proxy metrics.
+const (
+	endpointChatCompletions = "chat_completions"
+	endpointEmbeddings      = "embeddings"
+	endpointModerations     = "moderations"
+)
+
+// attemptFunc performs a single attempt against the given provider and model.
+type attemptFunc[T any] func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig) (T, error)
+
 // ChatCompletionsHandler handles requests to the /v1/chat/completions endpoint.
 func (p *Proxy) ChatCompletionsHandler(ctx context.Context, req api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
-	var modelConfig *config.ModelConfig
-	for _, m := range p.cfg.Models {
-		if m.ID == req.Model {
-			modelConfig = m
-			break
+	req.Model = p.resolveChatModel(req.Model)
+	return p.coalesce(ctx, &req, func(ctx context.Context) (*api.ChatCompletionResponse, error) {
+		return withFallback(ctx, p, req.Model, endpointChatCompletions, p.chatAttempt(req, func(ctx context.Context, llmProvider provider.Provider, _ *config.ModelConfig, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+			ctx, systemFingerprint := client.WithSystemFingerprint(ctx)
+			resp, err := llmProvider.ChatCompletion(ctx, req)
+			if err == nil {
+				if fingerprint := systemFingerprint(); fingerprint != "" && resp.SystemFingerprint == nil {
+					resp.SystemFingerprint = &fingerprint
+				}
+				p.contentFilter.redactResponse(resp)
+			}
+			return resp, err
+		}), func() bool { return true })
+	})
+}
+
+// ChatCompletionsStreamHandler handles streaming requests to the /v1/chat/completions endpoint.
+// Chunks are delivered through send. Fallback models are only tried while nothing
+// has been sent to the client yet. When the client goes away mid-stream, the
+// upstream call is cancelled and the usage streamed so far is estimated. With stream_options.include_usage set, a final
+// chunk with empty choices carries the usage of the request, estimated when the
+// upstream didn't report it.
+func (p *Proxy) ChatCompletionsStreamHandler(ctx context.Context, req api.ChatCompletionRequest, send provider.StreamFunc) error {
+	req.Model = p.resolveChatModel(req.Model)
+	streamed := false
+	var last api.ChatCompletionChunk
+	// Chunks of all the attempts share the ID, as only one attempt streams.
+	id := newCompletionID()
+	redactor := p.contentFilter.newStreamRedactor()
+	resp, err := withFallback(ctx, p, req.Model, endpointChatCompletions, p.chatAttempt(req, func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		start := time.Now()
+		transforms := p.transformsFor(model)
+		var completion strings.Builder
+		sendFailed := false
+		resp, err := llmProvider.ChatCompletionStream(ctx, req, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+			if !streamed {
+				liftAttemptDeadline(ctx)
+				// Only the attempt that streams can serve the request, which
+				// the client learns from the first chunk on.
+				setOutcomeServed(ctx, model)
+				timeToFirstToken.WithLabelValues(model.Name, model.Provider).Observe(time.Since(start).Seconds())
+			}
+			streamed = true
+			if chunk.Id == "" {
+				chunk.Id = id
+			}
+			transformChunk(transforms, chunk)
+			last = *chunk
+			for _, choice := range chunk.Choices {
+				if choice.Delta.Content != nil {
+					completion.WriteString(*choice.Delta.Content)
+				}
+			}
+			redactor.redactChunk(chunk)
+			if err := send(ctx, chunk); err != nil {
+				sendFailed = true
+				return err
+			}
+			return nil
+		})
+		// A client that went away cancels the request context, or fails the
+		// writes if that isn't noticed yet.
+		if err != nil && streamed && (sendFailed || context.Cause(ctx) == context.Canceled) {
+			clientDisconnectsTotal.WithLabelValues(model.Name, model.Provider).Inc()
+			p.recordPartialUsage(model, req, completion.String())
 		}
+		if err != nil {
+			return resp, err
+		}
+		if chunk := redactor.flush(last); chunk != nil {
+			if err := send(ctx, chunk); err != nil {
+				return nil, err
+			}
+		}
+		redactor.redactResponse(resp)
+		return resp, nil
+	}), func() bool { return !streamed })
+	if err != nil {
+		return err
 	}
 
-	if modelConfig == nil {
-		return nil, errors.ErrNotFound.WithMessage("model not found in config")
+	if req.StreamOptions == nil || req.StreamOptions.IncludeUsage == nil || !*req.StreamOptions.IncludeUsage || resp.Usage == nil {
+		return nil
 	}
+	return send(ctx, &api.ChatCompletionChunk{
+		Id:      last.Id,
+		Object:  "chat.completion.chunk",
+		Created: last.Created,
+		Model:   last.Model,
+		Choices: []api.ChatCompletionChunkChoice{},
+		Usage:   resp.Usage,
+	})
+}
 
-	modelsToTry := []string{req.Model}
-	modelsToTry = append(modelsToTry, modelConfig.Fallback...)
+// tokenCounterFor returns the token counter of model: the one of its tokenizer
+// setting, or the counter of the proxy.
+func (p *Proxy) tokenCounterFor(model *config.ModelConfig) TokenCounter {
+	if model.Tokenizer != "" {
+		return encodingCounter(model.Tokenizer)
+	}
+	return p.tokenCounter
+}
 
-	var resp *api.ChatCompletionResponse
-	var err error
+// chatAttempt adapts a chat completion call to an attemptFunc, sending the request
+// with the upstream model name and the resolved parameters, within the model
+// limits, through the transforms of the model, and recording the token usage
+// of the response. Requests that don't fit in the context window of the model
+// are rejected. Responses without usage get an estimate from the token counter,
+// and the fields the provider left out are filled in. Successful completions
+// are written to the audit log.
+func (p *Proxy) chatAttempt(req api.ChatCompletionRequest, call func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error)) attemptFunc[*api.ChatCompletionResponse] {
+	return func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig) (*api.ChatCompletionResponse, error) {
+		// Create a new request object for each attempt to avoid modifying the original
+		attemptReq := req
+		attemptReq.Model = model.Name
+		p.applyChatParams(&attemptReq, req.Model, model)
+		injectSystemPrompt(&attemptReq, model)
+		transforms := p.transformsFor(model)
+		transformRequest(transforms, &attemptReq)
+		if err := enforceLimits(&attemptReq, model.Limits); err != nil {
+			return nil, err
+		}
+		counter := p.tokenCounterFor(model)
+		if err := checkContextWindow(counter, &attemptReq, model.ContextWindow); err != nil {
+			return nil, err
+		}
 
-	for _, modelID := range modelsToTry {
-		var currentModelConfig *config.ModelConfig
-		for _, m := range p.cfg.Models {
-			if m.ID == modelID {
-				currentModelConfig = m
-				break
+		resp, err := call(ctx, llmProvider, model, &attemptReq)
+		if err != nil {
+			return nil, err
+		}
+		transformResponse(transforms, resp)
+		normalizeResponse(resp, model)
+
+		estimated := false
+		if counter != nil && needsUsageEstimate(resp.Usage) {
+			resp.Usage, estimated = estimateUsage(counter, &attemptReq, resp)
+		}
+		if resp.Usage != nil {
+			recordUsage(resp.Model, model.Provider, endpointChatCompletions, estimated, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+			setUsageAttributes(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens, resp.Usage.TotalTokens)
+			setOutcomeUsage(ctx, resp.Usage.PromptTokens, resp.Usage.CompletionTokens)
+			if cost := usageCost(model, resp.Usage.PromptTokens, resp.Usage.CompletionTokens); cost > 0 {
+				costTotal.WithLabelValues(resp.Model, model.Provider).Add(cost)
 			}
 		}
+		choicesTotal.WithLabelValues(resp.Model, model.Provider).Add(float64(len(resp.Choices)))
+		if p.cfg.Server.DropReasoning {
+			dropReasoning(resp)
+		}
+		if p.audit != nil {
+			if err := p.audit.Log(ctx, model.ID, model.Provider, &attemptReq, resp); err != nil {
+				slog.ErrorContext(ctx, "Failed to write audit record", "error", err)
+			}
+		}
+		return resp, nil
+	}
+}
+
+// EmbeddingsHandler handles requests to the /v1/embeddings endpoint.
+func (p *Proxy) EmbeddingsHandler(ctx context.Context, req api.EmbeddingsRequest) (*api.EmbeddingsResponse, error) {
+	req.Model = p.resolveAlias(req.Model)
+	return withFallback(ctx, p, req.Model, endpointEmbeddings, func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig) (*api.EmbeddingsResponse, error) {
+		attemptReq := req
+		attemptReq.Model = model.Name
+		p.applyEmbeddingsParams(&attemptReq, req.Model, model)
+
+		resp, err := llmProvider.Embeddings(ctx, &attemptReq)
+		if err != nil {
+			return nil, err
+		}
+
+		recordUsage(resp.Model, model.Provider, endpointEmbeddings, false, resp.Usage.PromptTokens, 0, resp.Usage.TotalTokens)
+		setUsageAttributes(ctx, resp.Usage.PromptTokens, 0, resp.Usage.TotalTokens)
+		setOutcomeUsage(ctx, resp.Usage.PromptTokens, 0)
+		return resp, nil
+	}, func() bool { return true })
+}
+
+// ModerationsHandler handles requests to the /v1/moderations endpoint.
+func (p *Proxy) ModerationsHandler(ctx context.Context, req api.ModerationRequest) (*api.ModerationResponse, error) {
+	req.Model = p.resolveAlias(req.Model)
+	return withFallback(ctx, p, req.Model, endpointModerations, func(ctx context.Context, llmProvider provider.Provider, model *config.ModelConfig) (*api.ModerationResponse, error) {
+		attemptReq := req
+		attemptReq.Model = model.Name
+		return llmProvider.Moderations(ctx, &attemptReq)
+	}, func() bool { return true })
+}
+
+// Reasons for leaving a model for the next one, besides the error classes of failed attempts.
+const (
+	fallbackReasonModelNotFound    = "model_not_found"
+	fallbackReasonModelDisabled    = "model_disabled"
+	fallbackReasonProviderNotFound = "provider_not_found"
+	fallbackReasonProviderDisabled = "provider_disabled"
+	fallbackReasonConcurrencyLimit = "concurrency_limit"
+	fallbackReasonCircuitOpen      = "circuit_open"
+	fallbackReasonUnsupported      = "unsupported"
+)
+
+// withFallback runs attempt against the requested model and then its fallbacks,
+// in the order of its fallback strategy, until one succeeds. Only retryable
+// errors move on to the next model, see isRetryable, up to the max fallback
+// attempts of the model, as well as the requests a model can't serve, see
+// isUnsupported, which are rejected only when no model of the chain can.
+// With a provider override in ctx, only the requested model is tried, on that provider.
+// canFallback reports whether trying the next model is still allowed.
+func withFallback[T any](ctx context.Context, p *Proxy, modelID string, endpoint string, attempt attemptFunc[T], canFallback func() bool) (_ T, err error) {
+	var zero T
+
+	ctx, span := startSpan(ctx, "proxy."+endpoint, attrModel.String(modelID))
+	defer func() { endSpan(span, err) }()
+
+	outcome := outcomeFrom(ctx)
+	if outcome != nil {
+		outcome.Model = modelID
+	}
+	modelConfig := p.findModel(modelID)
+	if modelConfig == nil {
+		return zero, errors.ErrNotFound.WithMessage("model not found in config")
+	}
+
+	modelsToTry := []string{modelID}
+	// pinned serves the requested model on the provider of a provider override.
+	var pinned *config.ModelConfig
+	if providerID := providerOverride(ctx); providerID != "" {
+		if _, ok := p.providers[providerID]; !ok {
+			return zero, errors.ErrBadRequest.WithMessage(fmt.Sprintf("unknown provider override: %s", providerID))
+		}
+		m := *modelConfig
+		m.Provider = providerID
+		pinned = &m
+	} else {
+		modelsToTry = p.orderModels(modelConfig, append(modelsToTry, modelConfig.Fallback...))
+	}
 
+	// Config validation rejects fallback cycles, but a model is never tried
+	// twice within one request regardless.
+	tried := make(map[string]struct{}, len(modelsToTry))
+	attempts := 0
+	maxAttempts := cmp.Or(modelConfig.MaxFallbackAttempts, p.cfg.Fallback.MaxFallbackAttempts)
+	// fallbackReason is why the last model was left for the next one.
+	fallbackReason := ""
+	var lastErr error
+	var providerErrs errors.ProviderErrors
+	// unsupportedErr is the last rejection of a model that can't serve the
+	// request, and busy is set once a model is skipped for being unavailable,
+	// in which case it might have served it.
+	var unsupportedErr error
+	busy := false
+	// disabled is set once a model is skipped for being disabled, or for its
+	// provider being disabled.
+	disabled := false
+	for _, modelID := range modelsToTry {
+		if _, ok := tried[modelID]; ok {
+			slog.WarnContext(ctx, "Model already tried for this request, skipping", "model", modelID)
+			continue
+		}
+		tried[modelID] = struct{}{}
+
+		currentModelConfig := p.findModel(modelID)
+		if pinned != nil {
+			currentModelConfig = pinned
+		}
 		if currentModelConfig == nil {
-			slog.Error("Fallback model not found in config", "model", modelID)
+			slog.ErrorContext(ctx, "Fallback model not found in config", "model", modelID)
+			fallbackReason = fallbackReasonModelNotFound
+			continue // Try next model
+		}
+		if !currentModelConfig.IsEnabled() {
+			slog.WarnContext(ctx, "Model is disabled, skipping it", "model", modelID)
+			fallbackReason = fallbackReasonModelDisabled
+			disabled = true
 			continue // Try next model
 		}
 
 		providerName := currentModelConfig.Provider
 		llmProvider, ok := p.providers[providerName]
+		if pCfg := p.findProvider(providerName); !ok && pCfg != nil && !pCfg.IsEnabled() {
+			slog.WarnContext(ctx, "Provider of the model is disabled, skipping it", "model", modelID, "provider", providerName)
+			fallbackReason = fallbackReasonProviderDisabled
+			disabled = true
+			continue // Try next model
+		}
 		if !ok {
-			slog.Error("Provider not found for model", "model", modelID, "provider", providerName)
+			slog.ErrorContext(ctx, "Provider not found for model", "model", modelID, "provider", providerName)
+			fallbackReason = fallbackReasonProviderNotFound
 			continue // Try next model
 		}
 
-		slog.Info("Sending request to provider", "model", currentModelConfig.Name, "provider", providerName)
-		// Create a new request object for each attempt to avoid modifying the original
-		attemptReq := req
-		attemptReq.Model = currentModelConfig.Name
+		if maxAttempts > 0 && attempts >= maxAttempts {
+			slog.WarnContext(ctx, "Max fallback attempts reached, failing the request", "model", modelID, "max_fallback_attempts", maxAttempts)
+			return zero, errors.ErrUnavailable.WithMessage(fmt.Sprintf("fallback attempts exhausted: max_fallback_attempts of %d reached", maxAttempts)).WithDetails(lastErr)
+		}
+		// The concurrency slot is taken first so that a half-open breaker
+		// only hands out probes for calls that are actually made.
+		limiter := p.limiters[providerName]
+		if !limiter.acquire(ctx) {
+			slog.WarnContext(ctx, "Provider concurrency limit reached, skipping provider", "model", modelID, "provider", providerName)
+			fallbackReason = fallbackReasonConcurrencyLimit
+			busy = true
+			continue // Try next model
+		}
+		// The gateway slot is waited for once the provider one is taken, so
+		// that calls queued for a busy provider don't hold slots others could use.
+		if err := p.globalLimiter.acquire(ctx); err != nil {
+			limiter.release()
+			slog.WarnContext(ctx, "Gateway concurrency limit reached, failing the request", "model", modelID, "provider", providerName)
+			return zero, terminalError(err)
+		}
 
-		resp, err = llmProvider.ChatCompletion(ctx, &attemptReq)
-		if err != nil {
-			slog.Error("Provider chat completion failed", "error", err, "model", currentModelConfig.Name, "provider", providerName)
+		slots := &callSlots{limiter: limiter, global: p.globalLimiter, held: true}
+
+		breaker := p.breakers[providerName]
+		if breaker != nil && !breaker.allow() {
+			slots.release()
+			slog.WarnContext(ctx, "Circuit breaker is open, skipping provider", "model", modelID, "provider", providerName)
+			fallbackReason = fallbackReasonCircuitOpen
+			busy = true
 			continue // Try next model
 		}
+		// The budget is only charged once the call is sure to be made.
+		if attempts > 0 && !p.retryBudget.allow(endpoint) {
+			slots.release()
+			if breaker != nil {
+				breaker.abandon()
+			}
+			slog.WarnContext(ctx, "Retry budget exhausted, failing the request", "model", modelID, "provider", providerName)
+			return zero, errors.ErrUnavailable.WithMessage("retry budget exhausted").WithDetails(lastErr)
+		}
+
+		slog.InfoContext(ctx, "Sending request to provider", "model", currentModelConfig.Name, "provider", providerName, "endpoint", endpoint)
+
+		attempts++
+		start := time.Now()
+		resp, err := func() (_ T, err error) {
+			ctx, span := startSpan(ctx, "provider."+endpoint,
+				attrModel.String(currentModelConfig.Name),
+				attrProvider.String(providerName),
+				attrAttempt.Int(attempts),
+			)
+			defer func() { endSpan(span, err) }()
+
+			timeout := p.cfg.Fallback.PerAttemptTimeout
+			ctx, cancel := withAttemptTimeout(ctx, timeout)
+			defer cancel()
+			defer func() { err = attemptTimeoutError(ctx, timeout, err) }()
 
-		// Increment token usage metrics
-		if resp.Usage.PromptTokens > 0 {
-			promptTokensTotal.WithLabelValues(resp.Model, providerName).Add(float64(resp.Usage.PromptTokens))
+			providerInFlight.WithLabelValues(providerName).Inc()
+			defer providerInFlight.WithLabelValues(providerName).Dec()
+			defer slots.release()
+			return callWithThrottleRetry(ctx, p, providerName, endpoint, slots, canFallback, func(ctx context.Context) (T, error) {
+				return attempt(ctx, llmProvider, currentModelConfig)
+			})
+		}()
+		if err != nil && isUnsupported(err) {
+			// Rejected before reaching the upstream, which says nothing about
+			// the provider, and doesn't count as an attempt.
+			attempts--
+			if breaker != nil {
+				breaker.abandon()
+			}
+			slog.WarnContext(ctx, "Model can't serve the request, trying the next one", "model", currentModelConfig.Name, "provider", providerName, "error", err)
+			fallbackReason = fallbackReasonUnsupported
+			unsupportedErr = err
+			continue // Try next model
 		}
-		if resp.Usage.CompletionTokens > 0 {
-			completionTokensTotal.WithLabelValues(resp.Model, providerName).Add(float64(resp.Usage.CompletionTokens))
+		status := "success"
+		if err != nil {
+			status = "error"
 		}
-		if resp.Usage.TotalTokens > 0 {
-			totalTokensTotal.WithLabelValues(resp.Model, providerName).Add(float64(resp.Usage.TotalTokens))
+		requestDuration.WithLabelValues(currentModelConfig.Name, providerName, endpoint, status).Observe(time.Since(start).Seconds())
+
+		retryable := err != nil && isRetryable(err, p.cfg.Fallback.OnStatusCodes)
+		if pinned == nil {
+			p.errorRates.record(modelID, retryable)
+		}
+		if breaker != nil {
+			// Client errors say nothing about the health of the provider.
+			if retryable {
+				breaker.onFailure()
+			} else {
+				breaker.onSuccess()
+			}
+		}
+		if err != nil {
+			class := errorClass(err)
+			providerErrorsTotal.WithLabelValues(providerName, class).Inc()
+			slog.ErrorContext(ctx, "Provider request failed", "error", err, "model", currentModelConfig.Name, "provider", providerName, "endpoint", endpoint, "retryable", retryable)
+			if !canFallback() {
+				return zero, errors.ErrInternal.WithMessage("stream interrupted").WithDetails(err)
+			}
+			if !retryable || ctx.Err() != nil {
+				// A request that is cancelled or out of time can't be served by any model.
+				return zero, terminalError(err)
+			}
+			fallbackReason = class
+			lastErr = err
+			providerErrs = append(providerErrs, errors.ProviderError{Provider: providerName, Model: currentModelConfig.Name, Err: err})
+			continue // Try next model
 		}
 
+		if fallbackReason != "" {
+			fallbackTotal.WithLabelValues(modelConfig.ID, currentModelConfig.ID, fallbackReason).Inc()
+		}
+		setOutcomeServed(ctx, currentModelConfig)
 		return resp, nil
 	}
 
-	return nil, errors.ErrInternal.WithMessage("failed to get completion from any provider")
+	if unsupportedErr != nil && len(providerErrs) == 0 && !busy {
+		// No model of the chain can serve the request.
+		return zero, terminalError(unsupportedErr)
+	}
+	if disabled && len(providerErrs) == 0 && !busy {
+		// Taken out of service rather than failing.
+		return zero, errors.ErrUnavailable.WithMessage("model is disabled")
+	}
+	if fallbackReason == errorClassTimeout {
+		return zero, errors.ErrGatewayTimeout.WithMessage("provider request timed out").WithDetails(lastErr)
+	}
+	allFailed := errors.ErrInternal.WithMessage("failed to get completion from any provider")
+	if len(providerErrs) > 0 {
+		allFailed = allFailed.WithDetails(providerErrs)
+	}
+	return zero, allFailed
+}
+
+// resolveAlias returns the model ID the alias id stands for, or id if it isn't an alias.
+func (p *Proxy) resolveAlias(id string) string {
+	if target, ok := p.cfg.Aliases[id]; ok {
+		return target
+	}
+	return id
+}
+
+// resolveChatModel returns the model ID of a chat completion for the requested
+// model, which is the default model when empty.
+func (p *Proxy) resolveChatModel(id string) string {
+	if id == "" {
+		id = p.cfg.DefaultModel
+	}
+	return p.resolveAlias(id)
+}
+
+// findModel returns the model config with the given ID, or nil if there is none.
+func (p *Proxy) findModel(id string) *config.ModelConfig {
+	if p.modelsByID != nil {
+		return p.modelsByID[id]
+	}
+	for _, m := range p.cfg.Models {
+		if m.ID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// findProvider returns the provider config with the given ID, or nil if there is none.
+func (p *Proxy) findProvider(id string) *config.ProviderConfig {
+	if p.providersByID != nil {
+		return p.providersByID[id]
+	}
+	for _, pCfg := range p.cfg.Providers {
+		if pCfg.ID == id {
+			return pCfg
+		}
+	}
+	return nil
+}
+
+// indexByID maps the IDs of items to the first item with the ID.
+func indexByID[T any](items []T, id func(T) string) map[string]T {
+	index := make(map[string]T, len(items))
+	for _, item := range items {
+		if _, ok := index[id(item)]; !ok {
+			index[id(item)] = item
+		}
+	}
+	return index
+}
+
+// recordUsage increments the token usage metrics for a successful request.
+// Estimated usage is labeled apart from the usage reported by the provider.
+func recordUsage(model, providerName, endpoint string, estimated bool, promptTokens, completionTokens, totalTokens int) {
+	est := strconv.FormatBool(estimated)
+	if promptTokens > 0 {
+		promptTokensTotal.WithLabelValues(model, providerName, endpoint, est).Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		completionTokensTotal.WithLabelValues(model, providerName, endpoint, est).Add(float64(completionTokens))
+	}
+	if totalTokens > 0 {
+		totalTokensTotal.WithLabelValues(model, providerName, endpoint, est).Add(float64(totalTokens))
+	}
+}
+
+// usageCost estimates the cost in USD of the given token usage from the model prices.
+func usageCost(model *config.ModelConfig, promptTokens, completionTokens int) float64 {
+	return float64(promptTokens)/1000*model.PricePer1KPromptTokens +
+		float64(completionTokens)/1000*model.PricePer1KCompletionTokens
 }
@@ -3,6 +3,11 @@ Package proxy_test contains comprehensive unit tests for the proxy package.
 
 Test Coverage:
 - NewProxy function: Tests successful proxy creation with various configurations and error handling
+- newProvider: Tests that Azure OpenAI models reach their deployment URLs and that
+  HuggingFace inference endpoints are called through the TGI messages API, and
+  the OpenRouter model names and identification headers, the Mistral field
+  names, embedding models and error statuses, and that Anthropic
+  gets the stop sequences as stop_sequences, up to its cap
 - ChatCompletionsHandler: Tests the main request handling logic including:
   - Successful completion with proper model and provider mapping
   - Model not found scenarios
@@ -10,7 +15,25 @@ Test Coverage:
   - Fallback logic when primary provider fails
   - Error handling when all providers fail
   - Invalid fallback model configurations
+  - Disabled models and providers, and chains without an enabled model
+  - Models listed more than once in a fallback chain
+  - The max fallback attempts, global and per model
+  - Aliases resolved to model IDs, fallbacks included
+  - The default model of requests without a model
+  - Fallback gated by error class (client errors vs 429/5xx)
+  - Fallback from models lacking a requested feature, such as n greater than 1
+  - Fallback and provider error metrics
+  - The duration metric of every provider attempt, by status
   - Token metrics tracking with various usage scenarios
+  - Per-model default parameters, shared with the fallback models
+  - Estimated cost metric from configured model prices
+  - Tracing spans of the request and of every provider attempt
+  - Audit records of the served completions
+  - The seed sent to OpenAI and the system fingerprint of its response
+- ListModelsHandler: Tests the OpenAI-style listing of the enabled models
+- ChatCompletionsStreamHandler: Tests chunk delivery, fallback before the first chunk
+  and the time to first token metric
+- EmbeddingsHandler: Tests model mapping, fallback and unknown models
 
 The tests use mock providers to isolate the proxy logic and validate the behavior
 without requiring actual LLM provider connections.
@@ -19,16 +42,36 @@ without requiring actual LLM provider connections.
 package proxy
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/audit"
+	"github.com/dmitrii/llm-gateway/internal/client"
 	"github.com/dmitrii/llm-gateway/internal/config"
 	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
 	"github.com/dmitrii/llm-gateway/internal/provider"
+	langchaincompatible "github.com/dmitrii/llm-gateway/internal/provider/langchain_compatible"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gojuno/minimock/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tmc/langchaingo/llms/fake"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 // Helper function to create chat message content
@@ -100,12 +143,58 @@ func TestNewProxy_Success(t *testing.T) {
 					},
 				},
 			},
+		}, {
+			name: "openai provider with rotating keys",
+			config: &config.Config{
+				Providers: []*config.ProviderConfig{
+					{
+						ID:       "openai1",
+						Provider: config.ProviderOpenAI,
+						Config: &config.OpenAIProviderConfig{
+							APIKeys: []string{"key-1", "key-2"},
+							APIUrl:  "https://api.openai.com",
+						},
+					},
+				},
+			},
+		}, {
+			name: "bedrock provider with static credentials",
+			config: &config.Config{
+				Providers: []*config.ProviderConfig{
+					{
+						ID:       "bedrock1",
+						Provider: config.ProviderBedrock,
+						Config: &config.BedrockProviderConfig{
+							Region:          "us-east-1",
+							AccessKeyID:     "access-key",
+							SecretAccessKey: "secret-key",
+							ModelARN:        "arn:aws:bedrock:us-east-1:123456789012:provisioned-model/abc",
+						},
+					},
+				},
+			},
+		}, {
+			name: "ollama provider with runner options",
+			config: &config.Config{
+				Providers: []*config.ProviderConfig{
+					{
+						ID:       "ollama1",
+						Provider: config.ProviderOllama,
+						Config: &config.OllamaProviderConfig{
+							APIUrl:    "http://localhost:11434",
+							KeepAlive: "10m",
+							NumCtx:    8192,
+							Format:    "json",
+						},
+					},
+				},
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			proxy, err := NewProxy(tt.config)
+			proxy, err := NewProxy(context.Background(), tt.config)
 			require.NoError(t, err)
 			require.NotNil(t, proxy)
 			assert.Equal(t, tt.config, proxy.cfg)
@@ -142,7 +231,7 @@ func TestNewProxy_ErrorHandling(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			proxy, err := NewProxy(tt.config)
+			proxy, err := NewProxy(context.Background(), tt.config)
 			assert.Nil(t, proxy)
 			assert.Error(t, err)
 			assert.Contains(t, err.Error(), tt.expectedErrMsg)
@@ -150,6 +239,296 @@ func TestNewProxy_ErrorHandling(t *testing.T) {
 	}
 }
 
+func TestNewProvider_AzureDeployments(t *testing.T) {
+	var urls []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		urls = append(urls, r.URL.String())
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/openai/deployments/embeddings-prod/embeddings" {
+			fmt.Fprint(w, `{"object":"list","data":[{"object":"embedding","index":0,"embedding":[0.5]}]}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "azure",
+		Provider: config.ProviderAzureOpenAI,
+		Config: &config.AzureOpenAIProviderConfig{
+			APIKey:     "test-key",
+			APIUrl:     srv.URL,
+			ApiVersion: "2024-10-21",
+			ApiType:    "AZURE",
+			Deployments: map[string]string{
+				"gpt-4o":                 "gpt-4o-prod",
+				"text-embedding-3-small": "embeddings-prod",
+			},
+		},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	_, err = p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	})
+	require.NoError(t, err)
+	_, err = p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model:    "gpt-35-turbo",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	})
+	require.NoError(t, err)
+	input := api.EmbeddingsRequest_Input{}
+	require.NoError(t, input.FromEmbeddingsRequestInput0("hello"))
+	_, err = p.Embeddings(context.Background(), &api.EmbeddingsRequest{Model: "text-embedding-3-small", Input: input})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"/openai/deployments/gpt-4o-prod/chat/completions?api-version=2024-10-21",
+		"/openai/deployments/gpt-35-turbo/chat/completions?api-version=2024-10-21",
+		"/openai/deployments/embeddings-prod/embeddings?api-version=2024-10-21",
+	}, urls)
+}
+
+func TestNewProvider_HuggingFaceInferenceEndpoint(t *testing.T) {
+	var path, auth string
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"chat.completion","id":"","created":1733000000,"model":"meta-llama/Llama-3.1-8B-Instruct","system_fingerprint":"3.0.1-sha-bb9095a","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"logprobs":null,"finish_reason":"stop"}],"usage":{"prompt_tokens":12,"completion_tokens":3,"total_tokens":15}}`)
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "tgi",
+		Provider: config.ProviderHuggingFace,
+		Config: &config.HuggingFaceProviderConfig{
+			APIKey:      "hf-key",
+			Mode:        config.HuggingFaceModeInferenceEndpoints,
+			EndpointURL: srv.URL + "/",
+		},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	resp, err := p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model: "llama-3.1-8b",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleSystem, Content: createChatContent("be brief")},
+			{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/v1/chat/completions", path)
+	assert.Equal(t, "Bearer hf-key", auth)
+	assert.Equal(t, "tgi", body["model"])
+	assert.Len(t, body["messages"], 2)
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", text)
+	assert.Equal(t, 12, resp.Usage.PromptTokens)
+	assert.Equal(t, 3, resp.Usage.CompletionTokens)
+	assert.Equal(t, 15, resp.Usage.TotalTokens)
+}
+
+func TestNewProvider_Mistral(t *testing.T) {
+	var paths []string
+	var bodies []map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer mistral-key", r.Header.Get("Authorization"))
+		var body map[string]any
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		paths, bodies = append(paths, r.URL.Path), append(bodies, body)
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case body["model"] == "busy-model":
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"object":"error","message":"Requests rate limit exceeded","type":"rate_limited"}`)
+		case r.URL.Path == "/v1/embeddings":
+			fmt.Fprint(w, `{"id":"1","object":"list","model":"codestral-embed","data":[{"object":"embedding","index":0,"embedding":[0.1,0.2]}],"usage":{"prompt_tokens":1,"total_tokens":1}}`)
+		default:
+			fmt.Fprint(w, `{"id":"1","object":"chat.completion","model":"mistral-small-latest","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+		}
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "mistral",
+		Provider: config.ProviderMistral,
+		Config:   &config.MistralProviderConfig{APIKey: "mistral-key", APIUrl: srv.URL + "/"},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	resp, err := p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model:     "mistral-small-latest",
+		Messages:  []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+		MaxTokens: ptr(16),
+		Seed:      ptr(7),
+	})
+	require.NoError(t, err)
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "hi", text)
+	assert.Equal(t, 6, resp.Usage.TotalTokens)
+
+	// The fields of the OpenAI client are renamed to the ones of the Mistral API.
+	assert.Equal(t, "/v1/chat/completions", paths[0])
+	assert.Equal(t, "mistral-small-latest", bodies[0]["model"])
+	assert.EqualValues(t, 16, bodies[0]["max_tokens"])
+	assert.EqualValues(t, 7, bodies[0]["random_seed"])
+	assert.NotContains(t, bodies[0], "max_completion_tokens")
+	assert.NotContains(t, bodies[0], "seed")
+
+	// The embeddings are created with the requested model.
+	var input api.EmbeddingsRequest_Input
+	require.NoError(t, input.FromEmbeddingsRequestInput0("hello"))
+	embeddings, err := p.Embeddings(context.Background(), &api.EmbeddingsRequest{Model: "codestral-embed", Input: input})
+	require.NoError(t, err)
+	require.Len(t, embeddings.Data, 1)
+	assert.Equal(t, "/v1/embeddings", paths[1])
+	assert.Equal(t, "codestral-embed", bodies[1]["model"])
+
+	// Upstream errors carry their status, which decides the fallback.
+	_, err = p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model:    "busy-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	})
+	status, ok := errorStatus(err)
+	require.True(t, ok, "%v", err)
+	assert.Equal(t, http.StatusTooManyRequests, status)
+}
+
+func TestNewProvider_OpenRouter(t *testing.T) {
+	var path string
+	var headers http.Header
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, headers = r.URL.Path, r.Header.Clone()
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"gen-1","object":"chat.completion","model":"anthropic/claude-3.5-sonnet","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "openrouter",
+		Provider: config.ProviderOpenRouter,
+		Config: &config.OpenRouterProviderConfig{
+			APIKey:  "or-key",
+			APIUrl:  srv.URL + "/api/v1",
+			Referer: "https://gateway.example.com",
+			Title:   "LLM Gateway",
+		},
+		Headers: map[string]string{"X-Title": "Override"},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	_, err = p.ChatCompletion(context.Background(), &api.ChatCompletionRequest{
+		Model:    "anthropic/claude-3.5-sonnet",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v1/chat/completions", path)
+	assert.Equal(t, "anthropic/claude-3.5-sonnet", body["model"])
+	assert.Equal(t, "Bearer or-key", headers.Get("Authorization"))
+	assert.Equal(t, "https://gateway.example.com", headers.Get("HTTP-Referer"))
+	assert.Equal(t, "Override", headers.Get("X-Title"))
+}
+
+func TestNewProvider_AnthropicStopSequences(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-3-5-sonnet","content":[{"type":"text","text":"hi"}],"stop_reason":"stop_sequence","stop_sequence":"END","usage":{"input_tokens":10,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+
+	p, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "anthropic",
+		Provider: config.ProviderAnthropic,
+		Config:   &config.AnthropicProviderConfig{APIKey: "test-key", APIUrl: srv.URL},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+
+	stopRequest := func(stop []string) *api.ChatCompletionRequest {
+		req := &api.ChatCompletionRequest{
+			Model:    "claude-3-5-sonnet",
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+			Stop:     &api.ChatCompletionRequest_Stop{},
+		}
+		require.NoError(t, req.Stop.FromChatCompletionRequestStop1(stop))
+		return req
+	}
+
+	t.Run("forwarded as stop_sequences", func(t *testing.T) {
+		_, err := p.ChatCompletion(context.Background(), stopRequest([]string{"END", "", "STOP"}))
+		require.NoError(t, err)
+		assert.Equal(t, []any{"END", "STOP"}, body["stop_sequences"])
+		assert.NotContains(t, body, "stop")
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		_, err := p.ChatCompletion(context.Background(), stopRequest([]string{}))
+		require.NoError(t, err)
+		assert.NotContains(t, body, "stop_sequences")
+	})
+
+	t.Run("more than Anthropic allows", func(t *testing.T) {
+		body = nil
+		stop := make([]string, anthropicMaxStopSequences+1)
+		for i := range stop {
+			stop[i] = fmt.Sprintf("STOP-%d", i)
+		}
+		_, err := p.ChatCompletion(context.Background(), stopRequest(stop))
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		assert.Equal(t, "stop accepts at most 8191 sequences with this provider, got 8192", apiErr.Message)
+		assert.Nil(t, body, "the request must not reach Anthropic")
+	})
+}
+
+func TestChatCompletionsHandler_OpenAISeed(t *testing.T) {
+	var body map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"object":"chat.completion","id":"chatcmpl-1","created":1733000000,"model":"gpt-4o","system_fingerprint":"fp_44709d6fcb","choices":[{"index":0,"message":{"role":"assistant","content":"hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	}))
+	defer srv.Close()
+
+	openaiProvider, err := newProvider(context.Background(), &config.ProviderConfig{
+		ID:       "openai",
+		Provider: config.ProviderOpenAI,
+		Config:   &config.OpenAIProviderConfig{APIKey: "test-key", APIUrl: srv.URL},
+	}, client.NewTransport(client.TransportConfig{}))
+	require.NoError(t, err)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "gpt-4o", Name: "gpt-4o", Provider: "openai"}},
+		},
+		providers: map[string]provider.Provider{"openai": openaiProvider},
+	}
+
+	seed := 42
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("hello")}},
+		Seed:     &seed,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 42.0, body["seed"])
+	require.NotNil(t, resp.SystemFingerprint)
+	assert.Equal(t, "fp_44709d6fcb", *resp.SystemFingerprint)
+}
+
 func TestChatCompletionsHandler_Success(t *testing.T) {
 	// Create a mock provider
 	mockProvider := provider.NewProviderMock(t)
@@ -218,7 +597,7 @@ func TestChatCompletionsHandler_Success(t *testing.T) {
 	mockUserContent := createChatContent("Hello, world!")
 
 	// Setup mock expectation
-	mockProvider.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "actual-model-name",
 		Messages: []api.ChatMessage{
 			{
@@ -237,6 +616,37 @@ func TestChatCompletionsHandler_Success(t *testing.T) {
 	assert.Equal(t, expectedResp, resp)
 }
 
+func TestChatCompletionsHandler_Audit(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	var buf bytes.Buffer
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "smart", Name: "gpt-4o", Provider: "openai"}},
+		},
+		providers: map[string]provider.Provider{"openai": mockProvider},
+		audit:     audit.NewLogger(&buf, false),
+	}
+
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "gpt-4o",
+		Usage: &api.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3},
+	}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(requestid.NewContext(context.Background(), "req-1"), api.ChatCompletionRequest{
+		Model:    "smart",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	var record audit.Record
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "req-1", record.RequestID)
+	assert.Equal(t, "smart", record.Model)
+	assert.Equal(t, "openai", record.Provider)
+	assert.Equal(t, &api.Usage{PromptTokens: 1, CompletionTokens: 2, TotalTokens: 3}, record.Usage)
+	assert.Empty(t, record.Messages)
+}
+
 func TestChatCompletionsHandler_ModelNotFound(t *testing.T) {
 	cfg := &config.Config{
 		Models: []*config.ModelConfig{},
@@ -368,7 +778,7 @@ func TestChatCompletionsHandler_FallbackSuccess(t *testing.T) {
 
 	// Setup mock expectations
 	// Primary provider fails
-	mockProvider1.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "primary-model",
 		Messages: []api.ChatMessage{
 			{
@@ -379,7 +789,7 @@ func TestChatCompletionsHandler_FallbackSuccess(t *testing.T) {
 	}).Return(nil, errors.New("primary provider failed"))
 
 	// Fallback provider succeeds
-	mockProvider2.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "backup-model",
 		Messages: []api.ChatMessage{
 			{
@@ -398,6 +808,76 @@ func TestChatCompletionsHandler_FallbackSuccess(t *testing.T) {
 	assert.Equal(t, expectedResp, resp)
 }
 
+func TestChatCompletionsHandler_Alias(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "chat-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+			},
+			Aliases: map[string]string{"gpt-4o": "chat-model"},
+		},
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+	}
+
+	messages := []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello, world!")}}
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model:    "primary-model",
+		Messages: messages,
+	}).Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model:    "backup-model",
+		Messages: messages,
+	}).Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "gpt-4o", Messages: messages})
+	require.NoError(t, err)
+	assert.Equal(t, "backup-model", resp.Model)
+}
+
+func TestChatCompletionsHandler_DefaultModel(t *testing.T) {
+	messages := []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello, world!")}}
+
+	t.Run("with a default model", func(t *testing.T) {
+		mockProvider := provider.NewProviderMock(t)
+		mockProvider.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+			Model:    "actual-model-name",
+			Messages: messages,
+		}).Return(&api.ChatCompletionResponse{Model: "actual-model-name"}, nil)
+		proxy := &Proxy{
+			cfg: &config.Config{
+				Models:       []*config.ModelConfig{{ID: "test-model", Name: "actual-model-name", Provider: "test-provider"}},
+				DefaultModel: "test-model",
+			},
+			providers: map[string]provider.Provider{"test-provider": mockProvider},
+		}
+
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Messages: messages})
+		require.NoError(t, err)
+		assert.Equal(t, "actual-model-name", resp.Model)
+	})
+
+	t.Run("without a default model", func(t *testing.T) {
+		proxy := &Proxy{
+			cfg: &config.Config{
+				Models: []*config.ModelConfig{{ID: "test-model", Name: "actual-model-name", Provider: "test-provider"}},
+			},
+			providers: map[string]provider.Provider{"test-provider": provider.NewProviderMock(t)},
+		}
+
+		_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Messages: messages})
+		var apiErr internalerrors.Error
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, http.StatusNotFound, apiErr.Status)
+	})
+}
+
 func TestChatCompletionsHandler_AllProvidersFail(t *testing.T) {
 	// Create mock providers
 	mockProvider1 := provider.NewProviderMock(t)
@@ -442,7 +922,7 @@ func TestChatCompletionsHandler_AllProvidersFail(t *testing.T) {
 	}
 
 	// Setup mock expectations - both providers fail
-	mockProvider1.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "primary-model",
 		Messages: []api.ChatMessage{
 			{
@@ -452,7 +932,7 @@ func TestChatCompletionsHandler_AllProvidersFail(t *testing.T) {
 		},
 	}).Return(nil, errors.New("primary provider failed"))
 
-	mockProvider2.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "backup-model",
 		Messages: []api.ChatMessage{
 			{
@@ -469,7 +949,10 @@ func TestChatCompletionsHandler_AllProvidersFail(t *testing.T) {
 	// Verify results
 	assert.Nil(t, resp)
 	assert.Error(t, err)
-	assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider"), err)
+	assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(internalerrors.ProviderErrors{
+		{Provider: "provider1", Model: "primary-model", Err: errors.New("primary provider failed")},
+		{Provider: "provider2", Model: "backup-model", Err: errors.New("fallback provider failed")},
+	}), err)
 }
 
 func TestChatCompletionsHandler_FallbackModelNotFound(t *testing.T) {
@@ -508,7 +991,7 @@ func TestChatCompletionsHandler_FallbackModelNotFound(t *testing.T) {
 	}
 
 	// Setup mock expectation - primary provider fails
-	mockProvider1.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 		Model: "primary-model",
 		Messages: []api.ChatMessage{
 			{
@@ -525,7 +1008,316 @@ func TestChatCompletionsHandler_FallbackModelNotFound(t *testing.T) {
 	// Verify results
 	assert.Nil(t, resp)
 	assert.Error(t, err)
-	assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider"), err)
+	assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(internalerrors.ProviderErrors{
+		{Provider: "provider1", Model: "primary-model", Err: errors.New("primary provider failed")},
+	}), err)
+}
+
+func TestChatCompletionsHandler_DisabledModel(t *testing.T) {
+	primary := provider.NewProviderMock(t)
+	backup := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}, Enabled: ptr(false)},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+			},
+		},
+		providers: map[string]provider.Provider{"provider1": primary, "provider2": backup},
+	}
+	backup.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+	fallbacks := testutil.ToFloat64(fallbackTotal.WithLabelValues("test-model", "fallback-model", fallbackReasonModelDisabled))
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "backup-model", resp.Model)
+	assert.Equal(t, uint64(0), primary.ChatCompletionAfterCounter())
+	assert.Equal(t, fallbacks+1, testutil.ToFloat64(fallbackTotal.WithLabelValues("test-model", "fallback-model", fallbackReasonModelDisabled)))
+}
+
+func TestChatCompletionsHandler_NoEnabledModel(t *testing.T) {
+	tests := []struct {
+		name   string
+		models []*config.ModelConfig
+	}{
+		{
+			name: "disabled model",
+			models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}, Enabled: ptr(false)},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider1", Enabled: ptr(false)},
+			},
+		},
+		{
+			name: "disabled provider",
+			models: []*config.ModelConfig{
+				{ID: "test-model", Name: "primary-model", Provider: "offline-provider"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proxy := &Proxy{
+				cfg: &config.Config{
+					Models: tt.models,
+					Providers: []*config.ProviderConfig{
+						{ID: "provider1", Provider: config.ProviderDummy},
+						{ID: "offline-provider", Provider: config.ProviderDummy, Enabled: ptr(false)},
+					},
+				},
+				// Disabled providers aren't created.
+				providers: map[string]provider.Provider{"provider1": provider.NewProviderMock(t)},
+			}
+
+			_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+				Model:    "test-model",
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+			})
+
+			var apiErr internalerrors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+			assert.Equal(t, "model is disabled", apiErr.Message)
+		})
+	}
+}
+
+func TestChatCompletionsHandler_FallbackCycle(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{
+					ID:       "model-a",
+					Name:     "model-a",
+					Provider: "test-provider",
+					Fallback: []string{"model-b", "model-a"},
+				},
+				{
+					ID:       "model-b",
+					Name:     "model-b",
+					Provider: "test-provider",
+					Fallback: []string{"model-a"},
+				},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"test-provider": mockProvider,
+		},
+	}
+
+	mockProvider.ChatCompletionMock.Return(nil, errors.New("provider failed"))
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "model-a"})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(internalerrors.ProviderErrors{
+		{Provider: "test-provider", Model: "model-a", Err: errors.New("provider failed")},
+		{Provider: "test-provider", Model: "model-b", Err: errors.New("provider failed")},
+	}), err)
+	assert.Equal(t, uint64(2), mockProvider.ChatCompletionAfterCounter())
+}
+
+func TestChatCompletionsHandler_MaxFallbackAttempts(t *testing.T) {
+	tests := []struct {
+		name          string
+		global        int
+		perModel      int
+		expectedCalls uint64
+	}{
+		{name: "unlimited", expectedCalls: 4},
+		{name: "global cap", global: 2, expectedCalls: 2},
+		{name: "per-model cap overrides the global one", global: 2, perModel: 3, expectedCalls: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := provider.NewProviderMock(t)
+			proxy := &Proxy{
+				cfg: &config.Config{
+					Fallback: config.FallbackConfig{MaxFallbackAttempts: tt.global},
+					Models: []*config.ModelConfig{
+						{ID: "model-a", Name: "model-a", Provider: "test-provider", Fallback: []string{"model-b", "model-c", "model-d"}, MaxFallbackAttempts: tt.perModel},
+						{ID: "model-b", Name: "model-b", Provider: "test-provider"},
+						{ID: "model-c", Name: "model-c", Provider: "test-provider"},
+						{ID: "model-d", Name: "model-d", Provider: "test-provider"},
+					},
+				},
+				providers: map[string]provider.Provider{"test-provider": mockProvider},
+			}
+			upstreamErr := internalerrors.ErrUnavailable.WithMessage("overloaded")
+			mockProvider.ChatCompletionMock.Return(nil, upstreamErr)
+
+			_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{Model: "model-a"})
+
+			require.Error(t, err)
+			assert.Equal(t, tt.expectedCalls, mockProvider.ChatCompletionAfterCounter())
+			if tt.expectedCalls == 4 {
+				assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(internalerrors.ProviderErrors{
+					{Provider: "test-provider", Model: "model-a", Err: upstreamErr},
+					{Provider: "test-provider", Model: "model-b", Err: upstreamErr},
+					{Provider: "test-provider", Model: "model-c", Err: upstreamErr},
+					{Provider: "test-provider", Model: "model-d", Err: upstreamErr},
+				}), err)
+				return
+			}
+			var apiErr internalerrors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusServiceUnavailable, apiErr.Status)
+			assert.Equal(t, fmt.Sprintf("fallback attempts exhausted: max_fallback_attempts of %d reached", tt.expectedCalls), apiErr.Message)
+		})
+	}
+}
+
+func TestChatCompletionsHandler_MultipleChoicesFallback(t *testing.T) {
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "single", Name: "single-model", Provider: "single-provider", Fallback: []string{"multiple"}},
+				{ID: "multiple", Name: "multiple-model", Provider: "multiple-provider"},
+				{ID: "single-only", Name: "single-model", Provider: "single-provider"},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"single-provider":   langchaincompatible.NewLangchainProvider(fake.NewFakeLLM([]string{"single"})),
+			"multiple-provider": langchaincompatible.NewLangchainProvider(fake.NewFakeLLM([]string{"multiple"}), langchaincompatible.WithMultipleChoices()),
+		},
+	}
+	req := api.ChatCompletionRequest{
+		Model:    "single",
+		N:        ptr(2),
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+	require.NoError(t, err)
+	require.NotEmpty(t, resp.Choices)
+	content, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "multiple", content)
+
+	// Without a model supporting it, the request is rejected.
+	req.Model = "single-only"
+	_, err = proxy.ChatCompletionsHandler(context.Background(), req)
+	var apiErr internalerrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+	assert.Equal(t, "n greater than 1 is not supported by this provider", apiErr.Message)
+}
+
+func TestChatCompletionsHandler_FallbackByErrorClass(t *testing.T) {
+	models := []*config.ModelConfig{
+		{
+			ID:       "test-model",
+			Name:     "primary-model",
+			Provider: "provider1",
+			Fallback: []string{"fallback-model"},
+		},
+		{
+			ID:       "fallback-model",
+			Name:     "backup-model",
+			Provider: "provider2",
+		},
+	}
+
+	req := api.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []api.ChatMessage{
+			{
+				Role:    api.ChatMessageRoleUser,
+				Content: createChatContent("Hello"),
+			},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		err           error
+		onStatusCodes []int
+		wantFallback  bool
+		wantStatus    int
+	}{
+		{
+			name:       "upstream 400 is returned immediately",
+			err:        fmt.Errorf("failed to generate content: %w", &client.StatusError{StatusCode: http.StatusBadRequest}),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "gateway 400 is returned immediately",
+			err:        internalerrors.ErrBadRequest.WithMessage("unsupported parameter"),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:         "unsupported feature falls back",
+			err:          fmt.Errorf("failed to generate content: %w", internalerrors.ErrUnsupported.WithMessage("response_format json_object is not supported by this provider")),
+			wantFallback: true,
+		},
+		{
+			name:          "unsupported feature falls back whatever the status codes",
+			err:           internalerrors.ErrUnsupported.WithMessage("logprobs are not supported by this provider"),
+			onStatusCodes: []int{http.StatusTooManyRequests},
+			wantFallback:  true,
+		},
+		{
+			name:         "upstream 503 falls back",
+			err:          fmt.Errorf("failed to generate content: %w", &client.StatusError{StatusCode: http.StatusServiceUnavailable}),
+			wantFallback: true,
+		},
+		{
+			name:         "upstream 429 falls back",
+			err:          &client.StatusError{StatusCode: http.StatusTooManyRequests},
+			wantFallback: true,
+		},
+		{
+			name:         "timeout falls back",
+			err:          context.DeadlineExceeded,
+			wantFallback: true,
+		},
+		{
+			name:          "configured status codes override the defaults",
+			err:           &client.StatusError{StatusCode: http.StatusServiceUnavailable},
+			onStatusCodes: []int{http.StatusTooManyRequests},
+			wantStatus:    http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockProvider1 := provider.NewProviderMock(t)
+			mockProvider2 := provider.NewProviderMock(t)
+			proxy := &Proxy{
+				cfg: &config.Config{
+					Models:   models,
+					Fallback: config.FallbackConfig{OnStatusCodes: tc.onStatusCodes},
+				},
+				providers: map[string]provider.Provider{
+					"provider1": mockProvider1,
+					"provider2": mockProvider2,
+				},
+			}
+
+			mockProvider1.ChatCompletionMock.Return(nil, tc.err)
+			if tc.wantFallback {
+				mockProvider2.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+			}
+
+			resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+
+			if tc.wantFallback {
+				require.NoError(t, err)
+				assert.Equal(t, "backup-model", resp.Model)
+				return
+			}
+			assert.Nil(t, resp)
+			var gatewayErr internalerrors.Error
+			require.ErrorAs(t, err, &gatewayErr)
+			assert.Equal(t, tc.wantStatus, gatewayErr.Status)
+			assert.Equal(t, uint64(0), mockProvider2.ChatCompletionAfterCounter())
+		})
+	}
 }
 
 func TestChatCompletionsHandler_TokenMetrics(t *testing.T) {
@@ -616,7 +1408,7 @@ func TestChatCompletionsHandler_TokenMetrics(t *testing.T) {
 			}
 
 			// Setup mock expectation
-			mockProvider.ChatCompletionMock.Expect(context.Background(), &api.ChatCompletionRequest{
+			mockProvider.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
 				Model: "actual-model-name",
 				Messages: []api.ChatMessage{
 					{
@@ -637,3 +1429,794 @@ func TestChatCompletionsHandler_TokenMetrics(t *testing.T) {
 		})
 	}
 }
+
+func TestChatCompletionsHandler_FallbackMetrics(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{ID: "metrics-model", Name: "primary-model", Provider: "metrics-provider1", Fallback: []string{"metrics-fallback"}},
+			{ID: "metrics-fallback", Name: "backup-model", Provider: "metrics-provider2"},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"metrics-provider1": mockProvider1,
+			"metrics-provider2": mockProvider2,
+		},
+	}
+
+	mockProvider1.ChatCompletionMock.Return(nil, &client.StatusError{StatusCode: http.StatusServiceUnavailable})
+	mockProvider2.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "metrics-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(fallbackTotal.WithLabelValues("metrics-model", "metrics-fallback", errorClassServer)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(providerErrorsTotal.WithLabelValues("metrics-provider1", errorClassServer)))
+	assert.Equal(t, float64(0), testutil.ToFloat64(providerErrorsTotal.WithLabelValues("metrics-provider2", errorClassServer)))
+}
+
+func TestChatCompletionsHandler_RequestDuration(t *testing.T) {
+	unsupported, failing, serving := provider.NewProviderMock(t), provider.NewProviderMock(t), provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "duration-model", Name: "unsupported-model", Provider: "duration-unsupported", Fallback: []string{"duration-failing", "duration-serving"}},
+				{ID: "duration-failing", Name: "failing-model", Provider: "duration-failing"},
+				{ID: "duration-serving", Name: "serving-model", Provider: "duration-serving"},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"duration-unsupported": unsupported,
+			"duration-failing":     failing,
+			"duration-serving":     serving,
+		},
+	}
+
+	unsupported.ChatCompletionMock.Return(nil, internalerrors.ErrUnsupported.WithMessage("tools are not supported"))
+	failing.ChatCompletionMock.Return(nil, &client.StatusError{StatusCode: http.StatusBadGateway})
+	serving.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		time.Sleep(10 * time.Millisecond)
+		return &api.ChatCompletionResponse{Model: "serving-model"}, nil
+	})
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "duration-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	observed := func(model, providerName, status string) *dto.Histogram {
+		var metric dto.Metric
+		require.NoError(t, requestDuration.WithLabelValues(model, providerName, endpointChatCompletions, status).(prometheus.Histogram).Write(&metric))
+		return metric.GetHistogram()
+	}
+	// Models rejecting the request before calling the upstream aren't observed.
+	assert.Zero(t, observed("unsupported-model", "duration-unsupported", "success").GetSampleCount())
+	assert.Zero(t, observed("unsupported-model", "duration-unsupported", "error").GetSampleCount())
+	assert.EqualValues(t, 1, observed("failing-model", "duration-failing", "error").GetSampleCount())
+	assert.Zero(t, observed("failing-model", "duration-failing", "success").GetSampleCount())
+	served := observed("serving-model", "duration-serving", "success")
+	assert.EqualValues(t, 1, served.GetSampleCount())
+	assert.GreaterOrEqual(t, served.GetSampleSum(), (10 * time.Millisecond).Seconds())
+}
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected string
+	}{
+		{err: context.DeadlineExceeded, expected: errorClassTimeout},
+		{err: fmt.Errorf("wrapped: %w", context.Canceled), expected: errorClassCanceled},
+		{err: &client.StatusError{StatusCode: http.StatusRequestTimeout}, expected: errorClassTimeout},
+		{err: &client.StatusError{StatusCode: http.StatusTooManyRequests}, expected: errorClassRateLimited},
+		{err: &client.StatusError{StatusCode: http.StatusBadRequest}, expected: errorClassClient},
+		{err: &client.StatusError{StatusCode: http.StatusBadGateway}, expected: errorClassServer},
+		{err: errors.New("connection reset"), expected: errorClassNetwork},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			assert.Equal(t, tt.expected, errorClass(tt.err))
+		})
+	}
+}
+
+func TestChatCompletionsHandler_ModelDefaults(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	maxTokens := 256
+	temperature := float32(0.2)
+	requestTemperature := float32(0.9)
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "test-model",
+				Name:     "primary-model",
+				Provider: "provider1",
+				Fallback: []string{"fallback-model"},
+				Defaults: &config.ModelDefaults{MaxTokens: &maxTokens, Temperature: &temperature},
+			},
+			{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+	}
+
+	// The default max_tokens is filled in, the explicit temperature wins, and
+	// the fallback model gets the same parameters.
+	expectedReq := func(model string) *api.ChatCompletionRequest {
+		return &api.ChatCompletionRequest{
+			Model:       model,
+			Messages:    []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+			MaxTokens:   &maxTokens,
+			Temperature: &requestTemperature,
+		}
+	}
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, expectedReq("primary-model")).Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, expectedReq("backup-model")).Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:       "test-model",
+		Messages:    []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+		Temperature: &requestTemperature,
+	})
+	require.NoError(t, err)
+}
+
+func TestChatCompletionsHandler_CostMetric(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:                         "priced-model",
+				Name:                       "priced-model-name",
+				Provider:                   "test-provider",
+				PricePer1KPromptTokens:     0.5,
+				PricePer1KCompletionTokens: 1.5,
+			},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"test-provider": mockProvider,
+		},
+	}
+
+	// Completion tokens are reported without prompt tokens.
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "priced-model-name",
+		Usage: &api.Usage{
+			CompletionTokens: 200,
+			TotalTokens:      200,
+		},
+	}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "priced-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	assert.InDelta(t, 0.3, testutil.ToFloat64(costTotal.WithLabelValues("priced-model-name", "test-provider")), 1e-9)
+}
+
+func TestChatCompletionsHandler_MultipleChoices(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "choices-model", Name: "choices-model-name", Provider: "choices-provider"}},
+		},
+		providers: map[string]provider.Provider{"choices-provider": mockProvider},
+	}
+
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "choices-model-name",
+		Choices: []api.ChatCompletionChoice{
+			{Index: 0, Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("one")}},
+			{Index: 1, Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("two")}},
+			{Index: 2, Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("three")}},
+		},
+		Usage: &api.Usage{PromptTokens: 5, CompletionTokens: 9, TotalTokens: 14},
+	}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "choices-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+		N:        ptr(3),
+	})
+	require.NoError(t, err)
+
+	assert.Len(t, resp.Choices, 3)
+	assert.Equal(t, float64(3), testutil.ToFloat64(choicesTotal.WithLabelValues("choices-model-name", "choices-provider")))
+	// The usage of the response covers all of its choices.
+	assert.Equal(t, float64(9), testutil.ToFloat64(completionTokensTotal.WithLabelValues("choices-model-name", "choices-provider", endpointChatCompletions, "false")))
+}
+
+func TestUsageCost(t *testing.T) {
+	model := &config.ModelConfig{PricePer1KPromptTokens: 0.5, PricePer1KCompletionTokens: 1.5}
+
+	assert.InDelta(t, 0.3, usageCost(model, 0, 200), 1e-9)
+	assert.InDelta(t, 0.05, usageCost(model, 100, 0), 1e-9)
+	assert.InDelta(t, 0.35, usageCost(model, 100, 200), 1e-9)
+	assert.Zero(t, usageCost(&config.ModelConfig{}, 100, 200))
+}
+
+func TestChatCompletionsHandler_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{ID: "test-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}},
+			{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+	}
+
+	mockProvider1.ChatCompletionMock.Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "backup-model",
+		Usage: &api.Usage{PromptTokens: 8, CompletionTokens: 12, TotalTokens: 20},
+	}, nil)
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "test-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	primary, backup, root := spans[0], spans[1], spans[2]
+
+	assert.Equal(t, "proxy.chat_completions", root.Name())
+	assert.Contains(t, root.Attributes(), attrModel.String("test-model"))
+	assert.Equal(t, codes.Unset, root.Status().Code)
+
+	assert.Equal(t, "provider.chat_completions", primary.Name())
+	assert.Equal(t, root.SpanContext().SpanID(), primary.Parent().SpanID())
+	assert.Contains(t, primary.Attributes(), attrProvider.String("provider1"))
+	assert.Contains(t, primary.Attributes(), attrAttempt.Int(1))
+	assert.Equal(t, codes.Error, primary.Status().Code)
+
+	assert.Equal(t, root.SpanContext().SpanID(), backup.Parent().SpanID())
+	assert.Contains(t, backup.Attributes(), attrModel.String("backup-model"))
+	assert.Contains(t, backup.Attributes(), attrProvider.String("provider2"))
+	assert.Contains(t, backup.Attributes(), attrAttempt.Int(2))
+	assert.Contains(t, backup.Attributes(), attrPromptTokens.Int(8))
+	assert.Contains(t, backup.Attributes(), attrCompletionTokens.Int(12))
+	assert.Contains(t, backup.Attributes(), attrTotalTokens.Int(20))
+}
+
+func TestChatCompletionsStreamHandler_Success(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "test-model",
+				Name:     "actual-model-name",
+				Provider: "test-provider",
+			},
+		},
+	}
+
+	proxy := &Proxy{
+		cfg: cfg,
+		providers: map[string]provider.Provider{
+			"test-provider": mockProvider,
+		},
+	}
+
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		assert.Equal(t, "actual-model-name", req.Model)
+		for _, word := range []string{"Hello", " world"} {
+			if err := send(ctx, &api.ChatCompletionChunk{
+				Object:  "chat.completion.chunk",
+				Model:   req.Model,
+				Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &word}}},
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return &api.ChatCompletionResponse{Model: req.Model, Usage: &api.Usage{TotalTokens: 2}}, nil
+	})
+
+	req := api.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []api.ChatMessage{
+			{
+				Role:    api.ChatMessageRoleUser,
+				Content: createChatContent("Hello"),
+			},
+		},
+	}
+
+	var received []string
+	err := proxy.ChatCompletionsStreamHandler(context.Background(), req, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		received = append(received, *chunk.Choices[0].Delta.Content)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Hello", " world"}, received)
+}
+
+func TestChatCompletionsStreamHandler_TimeToFirstToken(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "ttft-model", Name: "ttft-model-name", Provider: "ttft-provider"}},
+		},
+		providers: map[string]provider.Provider{"ttft-provider": mockProvider},
+	}
+
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		for _, word := range []string{"Hello", " world"} {
+			if err := send(ctx, &api.ChatCompletionChunk{
+				Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &word}}},
+			}); err != nil {
+				return nil, err
+			}
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+
+	req := api.ChatCompletionRequest{
+		Model:    "ttft-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+	err := proxy.ChatCompletionsStreamHandler(context.Background(), req, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		return nil
+	})
+	require.NoError(t, err)
+
+	// Only the first chunk is observed.
+	var metric dto.Metric
+	require.NoError(t, timeToFirstToken.WithLabelValues("ttft-model-name", "ttft-provider").(prometheus.Histogram).Write(&metric))
+	assert.EqualValues(t, 1, metric.GetHistogram().GetSampleCount())
+}
+
+func TestChatCompletionsStreamHandler_IncludeUsage(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "test-model", Name: "actual-model-name", Provider: "test-provider"}},
+		},
+		providers: map[string]provider.Provider{"test-provider": mockProvider},
+	}
+
+	usage := &api.Usage{PromptTokens: 3, CompletionTokens: 2, TotalTokens: 5}
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		content := "Hello"
+		if err := send(ctx, &api.ChatCompletionChunk{
+			Id:      "chatcmpl-1",
+			Object:  "chat.completion.chunk",
+			Created: 42,
+			Model:   req.Model,
+			Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}},
+		}); err != nil {
+			return nil, err
+		}
+		return &api.ChatCompletionResponse{Model: req.Model, Usage: usage}, nil
+	})
+
+	tests := []struct {
+		name          string
+		streamOptions *api.StreamOptions
+		wantChunks    int
+	}{
+		{name: "not requested", wantChunks: 1},
+		{name: "disabled", streamOptions: &api.StreamOptions{IncludeUsage: ptr(false)}, wantChunks: 1},
+		{name: "enabled", streamOptions: &api.StreamOptions{IncludeUsage: ptr(true)}, wantChunks: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var chunks []*api.ChatCompletionChunk
+			err := proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+				Model:         "test-model",
+				Messages:      []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+				StreamOptions: tt.streamOptions,
+			}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+				chunks = append(chunks, chunk)
+				return nil
+			})
+			require.NoError(t, err)
+			require.Len(t, chunks, tt.wantChunks)
+			assert.Nil(t, chunks[0].Usage)
+			if tt.wantChunks == 1 {
+				return
+			}
+
+			final := chunks[1]
+			assert.Equal(t, "chatcmpl-1", final.Id)
+			assert.Equal(t, "chat.completion.chunk", final.Object)
+			assert.Equal(t, 42, final.Created)
+			assert.Equal(t, "actual-model-name", final.Model)
+			assert.NotNil(t, final.Choices)
+			assert.Empty(t, final.Choices)
+			assert.Equal(t, usage, final.Usage)
+		})
+	}
+}
+
+func TestChatCompletionsStreamHandler_Fallback(t *testing.T) {
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "test-model",
+				Name:     "primary-model",
+				Provider: "provider1",
+				Fallback: []string{"fallback-model"},
+			},
+			{
+				ID:       "fallback-model",
+				Name:     "backup-model",
+				Provider: "provider2",
+			},
+		},
+	}
+
+	req := api.ChatCompletionRequest{
+		Model: "test-model",
+		Messages: []api.ChatMessage{
+			{
+				Role:    api.ChatMessageRoleUser,
+				Content: createChatContent("Hello"),
+			},
+		},
+	}
+
+	content := "from fallback"
+	chunk := &api.ChatCompletionChunk{Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}}}
+
+	t.Run("falls back before the first chunk", func(t *testing.T) {
+		mockProvider1 := provider.NewProviderMock(t)
+		mockProvider2 := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg: cfg,
+			providers: map[string]provider.Provider{
+				"provider1": mockProvider1,
+				"provider2": mockProvider2,
+			},
+		}
+
+		mockProvider1.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+			return nil, errors.New("primary provider failed")
+		})
+		mockProvider2.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+			return &api.ChatCompletionResponse{Model: req.Model}, send(ctx, chunk)
+		})
+
+		var received []*api.ChatCompletionChunk
+		err := proxy.ChatCompletionsStreamHandler(context.Background(), req, func(ctx context.Context, c *api.ChatCompletionChunk) error {
+			received = append(received, c)
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, []*api.ChatCompletionChunk{chunk}, received)
+	})
+
+	t.Run("does not fall back after the first chunk", func(t *testing.T) {
+		mockProvider1 := provider.NewProviderMock(t)
+		mockProvider2 := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg: cfg,
+			providers: map[string]provider.Provider{
+				"provider1": mockProvider1,
+				"provider2": mockProvider2,
+			},
+		}
+
+		mockProvider1.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+			if err := send(ctx, chunk); err != nil {
+				return nil, err
+			}
+			return nil, errors.New("connection reset")
+		})
+
+		err := proxy.ChatCompletionsStreamHandler(context.Background(), req, func(ctx context.Context, c *api.ChatCompletionChunk) error {
+			return nil
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, uint64(0), mockProvider2.ChatCompletionStreamAfterCounter())
+	})
+}
+
+func TestListModelsHandler(t *testing.T) {
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "gpt-4.1", Name: "gpt-4.1", Provider: "openai-1"},
+				{ID: "disabled-model", Name: "disabled", Provider: "openai-1", Enabled: ptr(false)},
+				{ID: "dummy-model", Name: "dummy", Provider: "dummy-1"},
+				{ID: "offline-model", Name: "offline", Provider: "offline-1"},
+			},
+			Providers: []*config.ProviderConfig{
+				{ID: "openai-1", Provider: config.ProviderOpenAI},
+				{ID: "offline-1", Provider: config.ProviderOpenAI, Enabled: ptr(false)},
+			},
+		},
+	}
+
+	assert.Equal(t, &api.ModelList{
+		Object: "list",
+		Data: []api.Model{
+			{Id: "gpt-4.1", Object: "model", OwnedBy: "openai-1"},
+			{Id: "dummy-model", Object: "model", OwnedBy: "dummy-1"},
+		},
+	}, proxy.ListModelsHandler())
+}
+
+func TestEmbeddingsHandler(t *testing.T) {
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "embed-model",
+				Name:     "text-embedding-3-small",
+				Provider: "provider1",
+				Fallback: []string{"fallback-model"},
+			},
+			{
+				ID:       "fallback-model",
+				Name:     "nomic-embed-text",
+				Provider: "provider2",
+			},
+		},
+	}
+
+	input := api.EmbeddingsRequest_Input{}
+	require.NoError(t, input.FromEmbeddingsRequestInput0("Hello, world!"))
+	req := api.EmbeddingsRequest{Model: "embed-model", Input: input}
+
+	t.Run("success", func(t *testing.T) {
+		mockProvider := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg:       cfg,
+			providers: map[string]provider.Provider{"provider1": mockProvider},
+		}
+
+		expectedResp := &api.EmbeddingsResponse{
+			Object: "list",
+			Data:   []api.Embedding{{Object: "embedding", Index: 0, Embedding: []float32{0.1, 0.2}}},
+			Model:  "text-embedding-3-small",
+			Usage:  api.EmbeddingsUsage{PromptTokens: 4, TotalTokens: 4},
+		}
+		mockProvider.EmbeddingsMock.Expect(minimock.AnyContext, &api.EmbeddingsRequest{
+			Model: "text-embedding-3-small",
+			Input: input,
+		}).Return(expectedResp, nil)
+
+		resp, err := proxy.EmbeddingsHandler(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedResp, resp)
+	})
+
+	t.Run("fallback", func(t *testing.T) {
+		mockProvider1 := provider.NewProviderMock(t)
+		mockProvider2 := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg: cfg,
+			providers: map[string]provider.Provider{
+				"provider1": mockProvider1,
+				"provider2": mockProvider2,
+			},
+		}
+
+		mockProvider1.EmbeddingsMock.Set(func(ctx context.Context, req *api.EmbeddingsRequest) (*api.EmbeddingsResponse, error) {
+			return nil, errors.New("primary provider failed")
+		})
+		mockProvider2.EmbeddingsMock.Set(func(ctx context.Context, req *api.EmbeddingsRequest) (*api.EmbeddingsResponse, error) {
+			return &api.EmbeddingsResponse{Object: "list", Model: req.Model}, nil
+		})
+
+		resp, err := proxy.EmbeddingsHandler(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "nomic-embed-text", resp.Model)
+	})
+
+	t.Run("model not found", func(t *testing.T) {
+		proxy := &Proxy{cfg: cfg}
+
+		resp, err := proxy.EmbeddingsHandler(context.Background(), api.EmbeddingsRequest{Model: "unknown"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, internalerrors.ErrNotFound.WithMessage("model not found in config"), err)
+	})
+}
+
+func TestModerationsHandler(t *testing.T) {
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{
+				ID:       "moderation",
+				Name:     "omni-moderation-latest",
+				Provider: "provider1",
+			},
+		},
+	}
+
+	input := api.ModerationRequest_Input{}
+	require.NoError(t, input.FromModerationRequestInput0("some text"))
+	req := api.ModerationRequest{Model: "moderation", Input: input}
+
+	t.Run("success", func(t *testing.T) {
+		mockProvider := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg:       cfg,
+			providers: map[string]provider.Provider{"provider1": mockProvider},
+		}
+
+		expectedResp := &api.ModerationResponse{
+			Id:      "modr-123",
+			Model:   "omni-moderation-latest",
+			Results: []api.ModerationResult{{Flagged: true, Categories: map[string]bool{"violence": true}}},
+		}
+		mockProvider.ModerationsMock.Expect(minimock.AnyContext, &api.ModerationRequest{
+			Model: "omni-moderation-latest",
+			Input: input,
+		}).Return(expectedResp, nil)
+
+		resp, err := proxy.ModerationsHandler(context.Background(), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, expectedResp, resp)
+	})
+
+	t.Run("model not found", func(t *testing.T) {
+		proxy := &Proxy{cfg: cfg}
+
+		resp, err := proxy.ModerationsHandler(context.Background(), api.ModerationRequest{Model: "unknown"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, internalerrors.ErrNotFound.WithMessage("model not found in config"), err)
+	})
+}
+
+func TestChatCompletionsHandler_ProviderOverride(t *testing.T) {
+	cfg := &config.Config{
+		Models: []*config.ModelConfig{
+			{ID: "override-model", Name: "gpt-4o", Provider: "override-primary", Fallback: []string{"override-fallback"}},
+			{ID: "override-fallback", Name: "llama3", Provider: "override-secondary"},
+		},
+	}
+	req := api.ChatCompletionRequest{
+		Model:    "override-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}
+
+	t.Run("pins the provider", func(t *testing.T) {
+		primary := provider.NewProviderMock(t)
+		secondary := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg:       cfg,
+			providers: map[string]provider.Provider{"override-primary": primary, "override-secondary": secondary},
+		}
+
+		secondary.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+			return &api.ChatCompletionResponse{Model: req.Model}, nil
+		})
+
+		resp, err := proxy.ChatCompletionsHandler(WithProviderOverride(context.Background(), "override-secondary"), req)
+
+		require.NoError(t, err)
+		assert.Equal(t, "gpt-4o", resp.Model)
+	})
+
+	t.Run("skips fallbacks", func(t *testing.T) {
+		primary := provider.NewProviderMock(t)
+		secondary := provider.NewProviderMock(t)
+		proxy := &Proxy{
+			cfg:       cfg,
+			providers: map[string]provider.Provider{"override-primary": primary, "override-secondary": secondary},
+		}
+
+		primary.ChatCompletionMock.Return(nil, errors.New("primary provider failed"))
+
+		resp, err := proxy.ChatCompletionsHandler(WithProviderOverride(context.Background(), "override-primary"), req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, internalerrors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(internalerrors.ProviderErrors{
+			{Provider: "override-primary", Model: "gpt-4o", Err: errors.New("primary provider failed")},
+		}), err)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		proxy := &Proxy{cfg: cfg, providers: map[string]provider.Provider{}}
+
+		resp, err := proxy.ChatCompletionsHandler(WithProviderOverride(context.Background(), "missing"), req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, internalerrors.ErrBadRequest.WithMessage("unknown provider override: missing"), err)
+	})
+}
+
+func TestFindModel_Index(t *testing.T) {
+	cfg := &config.Config{
+		Providers: []*config.ProviderConfig{{ID: "provider1"}},
+		Models: []*config.ModelConfig{
+			{ID: "model-a", Name: "first", Provider: "provider1"},
+			{ID: "model-a", Name: "duplicate", Provider: "provider1"},
+			{ID: "model-b", Name: "second", Provider: "provider1"},
+		},
+	}
+	indexed := &Proxy{
+		cfg:           cfg,
+		modelsByID:    indexByID(cfg.Models, func(m *config.ModelConfig) string { return m.ID }),
+		providersByID: indexByID(cfg.Providers, func(p *config.ProviderConfig) string { return p.ID }),
+	}
+	scanned := &Proxy{cfg: cfg}
+
+	for _, p := range []*Proxy{indexed, scanned} {
+		assert.Equal(t, "first", p.findModel("model-a").Name)
+		assert.Equal(t, "second", p.findModel("model-b").Name)
+		assert.Nil(t, p.findModel("missing"))
+		assert.Equal(t, cfg.Providers[0], p.findProvider("provider1"))
+		assert.Nil(t, p.findProvider("missing"))
+	}
+}
+
+func BenchmarkChatCompletionsHandler_ModelLookup(b *testing.B) {
+	cfg := &config.Config{Providers: []*config.ProviderConfig{{ID: "test-provider"}}}
+	const models = 1000
+	for i := range models {
+		cfg.Models = append(cfg.Models, &config.ModelConfig{ID: fmt.Sprintf("model-%d", i), Name: "upstream-model", Provider: "test-provider"})
+	}
+	// The last model falls back on the one before it, through the whole list.
+	for i := 1; i < models; i++ {
+		cfg.Models[i].Fallback = []string{cfg.Models[i-1].ID}
+	}
+	mockProvider := provider.NewProviderMock(b)
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{Usage: &api.Usage{TotalTokens: 1}}, nil)
+	proxy := &Proxy{
+		cfg:           cfg,
+		providers:     map[string]provider.Provider{"test-provider": mockProvider},
+		modelsByID:    indexByID(cfg.Models, func(m *config.ModelConfig) string { return m.ID }),
+		providersByID: indexByID(cfg.Providers, func(p *config.ProviderConfig) string { return p.ID }),
+	}
+
+	for _, model := range []string{cfg.Models[models-1].ID, "missing-model"} {
+		b.Run(model, func(b *testing.B) {
+			req := api.ChatCompletionRequest{
+				Model:    model,
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+			}
+			for b.Loop() {
+				_, _ = proxy.ChatCompletionsHandler(context.Background(), req)
+			}
+		})
+	}
+}
@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	defaultRedactionReplacement = "[REDACTED]"
+	defaultMaxMatchLength       = 64
+)
+
+var contentRedactionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_gateway_content_redactions_total",
+		Help: "Total number of matches redacted from the chat completion content",
+	},
+	[]string{"pattern"},
+)
+
+func init() {
+	prometheus.MustRegister(contentRedactionsTotal)
+}
+
+// contentFilter redacts the content matching its patterns from the chat
+// completions returned to clients.
+type contentFilter struct {
+	patterns    []contentPattern
+	replacement string
+	// holdback is the length of the streamed content held back from the
+	// client, so that matches spanning chunks are found.
+	holdback int
+}
+
+type contentPattern struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// newContentFilter creates the content filter of cfg, or returns nil when no
+// pattern is configured.
+func newContentFilter(cfg config.ContentFilterConfig) (*contentFilter, error) {
+	if len(cfg.Patterns) == 0 {
+		return nil, nil
+	}
+	f := &contentFilter{
+		replacement: cmp.Or(cfg.Replacement, defaultRedactionReplacement),
+		holdback:    cmp.Or(cfg.MaxMatchLength, defaultMaxMatchLength) - 1,
+	}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content filter pattern %q: %w", pattern.Name, err)
+		}
+		f.patterns = append(f.patterns, contentPattern{name: pattern.Name, re: re})
+	}
+	return f, nil
+}
+
+// redact replaces the matches of the patterns in s.
+func (f *contentFilter) redact(s string) string {
+	for _, pattern := range f.patterns {
+		n := 0
+		s = pattern.re.ReplaceAllStringFunc(s, func(string) string {
+			n++
+			return f.replacement
+		})
+		if n > 0 {
+			contentRedactionsTotal.WithLabelValues(pattern.name).Add(float64(n))
+		}
+	}
+	return s
+}
+
+// redactResponse redacts the text content of the choices of resp.
+func (f *contentFilter) redactResponse(resp *api.ChatCompletionResponse) {
+	if f == nil || resp == nil {
+		return
+	}
+	for i := range resp.Choices {
+		content := resp.Choices[i].Message.Content
+		if content == nil {
+			continue
+		}
+		text, err := content.AsChatMessageContent0()
+		if err != nil {
+			continue
+		}
+		_ = content.FromChatMessageContent0(f.redact(text))
+	}
+}
+
+// split returns the length of the prefix of s that can be redacted and sent:
+// all of s but the last holdback bytes, and any match running into them, which
+// may go on in the next chunk.
+func (f *contentFilter) split(s string) int {
+	cut := len(s) - f.holdback
+	if cut <= 0 {
+		return 0
+	}
+	for _, pattern := range f.patterns {
+		for _, loc := range pattern.re.FindAllStringIndex(s, -1) {
+			if loc[0] < cut && loc[1] > cut {
+				cut = loc[0]
+			}
+		}
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return cut
+}
+
+// streamRedactor redacts the content of the chunks of a chat completion
+// stream, holding back the end of the content of every choice until the next
+// chunk shows whether it is part of a match.
+type streamRedactor struct {
+	filter *contentFilter
+	// pending is the content held back, by choice index.
+	pending map[int]string
+	// sent is the redacted content sent, by choice index.
+	sent map[int]*strings.Builder
+}
+
+func (f *contentFilter) newStreamRedactor() *streamRedactor {
+	if f == nil {
+		return nil
+	}
+	return &streamRedactor{filter: f, pending: map[int]string{}, sent: map[int]*strings.Builder{}}
+}
+
+// redactChunk redacts the content of chunk, all of it for the choices it
+// finishes.
+func (r *streamRedactor) redactChunk(chunk *api.ChatCompletionChunk) {
+	if r == nil {
+		return
+	}
+	for i := range chunk.Choices {
+		choice := &chunk.Choices[i]
+		text := r.pending[choice.Index]
+		if choice.Delta.Content != nil {
+			text += *choice.Delta.Content
+		}
+		cut := len(text)
+		if choice.FinishReason == nil {
+			cut = r.filter.split(text)
+		}
+		r.pending[choice.Index] = text[cut:]
+		if choice.Delta.Content == nil && cut == 0 {
+			continue
+		}
+		redacted := r.filter.redact(text[:cut])
+		choice.Delta.Content = &redacted
+		r.sentTo(choice.Index).WriteString(redacted)
+	}
+}
+
+// flush returns a chunk with the content still held back, or nil if there
+// is none, as when the stream ended without finish reasons.
+func (r *streamRedactor) flush(last api.ChatCompletionChunk) *api.ChatCompletionChunk {
+	if r == nil {
+		return nil
+	}
+	var choices []api.ChatCompletionChunkChoice
+	for _, index := range slices.Sorted(maps.Keys(r.pending)) {
+		text := r.pending[index]
+		if text == "" {
+			continue
+		}
+		redacted := r.filter.redact(text)
+		r.sentTo(index).WriteString(redacted)
+		choices = append(choices, api.ChatCompletionChunkChoice{Index: index, Delta: api.ChatCompletionDelta{Content: &redacted}})
+		delete(r.pending, index)
+	}
+	if len(choices) == 0 {
+		return nil
+	}
+	return &api.ChatCompletionChunk{
+		Id:      last.Id,
+		Object:  last.Object,
+		Created: last.Created,
+		Model:   last.Model,
+		Choices: choices,
+	}
+}
+
+// redactResponse replaces the text content of the choices of resp, the
+// aggregate of the stream, with the redacted content sent to the client.
+func (r *streamRedactor) redactResponse(resp *api.ChatCompletionResponse) {
+	if r == nil || resp == nil {
+		return
+	}
+	for i := range resp.Choices {
+		content := resp.Choices[i].Message.Content
+		if content == nil {
+			continue
+		}
+		if _, err := content.AsChatMessageContent0(); err != nil {
+			continue
+		}
+		sent := ""
+		if b, ok := r.sent[resp.Choices[i].Index]; ok {
+			sent = b.String()
+		}
+		_ = content.FromChatMessageContent0(sent)
+	}
+}
+
+func (r *streamRedactor) sentTo(index int) *strings.Builder {
+	b, ok := r.sent[index]
+	if !ok {
+		b = &strings.Builder{}
+		r.sent[index] = b
+	}
+	return b
+}
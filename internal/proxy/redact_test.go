@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRedactingProxy returns a proxy serving chat-model with mockProvider and
+// redacting card numbers and internal tokens.
+func newRedactingProxy(t *testing.T, mockProvider provider.Provider) *Proxy {
+	filter, err := newContentFilter(config.ContentFilterConfig{
+		Patterns: []config.ContentFilterPattern{
+			{Name: "test_card", Regex: `\b(?:\d{4}[ -]?){3}\d{4}\b`},
+			{Name: "test_token", Regex: `tok_[A-Za-z0-9]{16}`},
+		},
+		MaxMatchLength: 24,
+	})
+	require.NoError(t, err)
+
+	return &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "chat-model", Name: "upstream-model", Provider: "test-provider"}},
+		},
+		providers:     map[string]provider.Provider{"test-provider": mockProvider},
+		contentFilter: filter,
+	}
+}
+
+func TestNewContentFilter(t *testing.T) {
+	filter, err := newContentFilter(config.ContentFilterConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	_, err = newContentFilter(config.ContentFilterConfig{Patterns: []config.ContentFilterPattern{{Name: "broken", Regex: "("}}})
+	assert.ErrorContains(t, err, `invalid content filter pattern "broken"`)
+}
+
+func TestChatCompletionsHandler_RedactsContent(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := newRedactingProxy(t, mockProvider)
+
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "upstream-model",
+		Choices: []api.ChatCompletionChoice{{
+			Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Card 4111 1111 1111 1111 and tok_abcdefghijklmnop.")},
+		}},
+	}, nil)
+
+	cards := testutil.ToFloat64(contentRedactionsTotal.WithLabelValues("test_card"))
+	tokens := testutil.ToFloat64(contentRedactionsTotal.WithLabelValues("test_token"))
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "Card [REDACTED] and [REDACTED].", text)
+	assert.Equal(t, cards+1, testutil.ToFloat64(contentRedactionsTotal.WithLabelValues("test_card")))
+	assert.Equal(t, tokens+1, testutil.ToFloat64(contentRedactionsTotal.WithLabelValues("test_token")))
+}
+
+func TestChatCompletionsStreamHandler_RedactsContentAcrossChunks(t *testing.T) {
+	tests := []struct {
+		name   string
+		deltas []string
+		finish bool
+	}{
+		{name: "finished", deltas: []string{"Your card is 4111 1111", " 1111 1111, keep it safe."}, finish: true},
+		{name: "not finished", deltas: []string{"Your card is 4111 1111", " 1111 1111, keep it safe."}},
+		{name: "token", deltas: []string{"Use tok_abcdefgh", "ijklmnop to log in."}, finish: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockProvider := provider.NewProviderMock(t)
+			proxy := newRedactingProxy(t, mockProvider)
+
+			mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+				for _, delta := range tt.deltas {
+					if err := send(ctx, &api.ChatCompletionChunk{
+						Object:  "chat.completion.chunk",
+						Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &delta}}},
+					}); err != nil {
+						return nil, err
+					}
+				}
+				if tt.finish {
+					stop := api.ChatCompletionChunkChoiceFinishReasonStop
+					if err := send(ctx, &api.ChatCompletionChunk{
+						Object:  "chat.completion.chunk",
+						Choices: []api.ChatCompletionChunkChoice{{FinishReason: &stop}},
+					}); err != nil {
+						return nil, err
+					}
+				}
+				return &api.ChatCompletionResponse{
+					Model: req.Model,
+					Choices: []api.ChatCompletionChoice{{
+						Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent(strings.Join(tt.deltas, ""))},
+					}},
+				}, nil
+			})
+
+			var sent strings.Builder
+			err := proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+				Model:    "chat-model",
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+			}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+				for _, choice := range chunk.Choices {
+					if choice.Delta.Content != nil {
+						assert.NotRegexp(t, `\d{4}|tok_`, *choice.Delta.Content)
+						sent.WriteString(*choice.Delta.Content)
+					}
+				}
+				return nil
+			})
+			require.NoError(t, err)
+
+			redacted := proxy.contentFilter.redact(strings.Join(tt.deltas, ""))
+			assert.Equal(t, redacted, sent.String())
+			assert.Contains(t, sent.String(), "[REDACTED]")
+		})
+	}
+}
+
+func TestStreamRedactor_HoldsBackMatches(t *testing.T) {
+	filter, err := newContentFilter(config.ContentFilterConfig{
+		Patterns:       []config.ContentFilterPattern{{Name: "test_digits", Regex: `\d+`}},
+		Replacement:    "#",
+		MaxMatchLength: 4,
+	})
+	require.NoError(t, err)
+	redactor := filter.newStreamRedactor()
+
+	chunk := func(content string) *api.ChatCompletionChunk {
+		return &api.ChatCompletionChunk{Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}}}
+	}
+
+	first := chunk("hi 1234")
+	redactor.redactChunk(first)
+	// The last 3 bytes and the match running into them are held back.
+	assert.Equal(t, "hi ", *first.Choices[0].Delta.Content)
+
+	second := chunk("56 byeö!!")
+	redactor.redactChunk(second)
+	// The content is cut at a rune boundary.
+	assert.Equal(t, "# bye", *second.Choices[0].Delta.Content)
+
+	rest := redactor.flush(api.ChatCompletionChunk{Id: "chatcmpl-1"})
+	require.NotNil(t, rest)
+	assert.Equal(t, "chatcmpl-1", rest.Id)
+	assert.Equal(t, "ö!!", *rest.Choices[0].Delta.Content)
+	assert.Nil(t, redactor.flush(api.ChatCompletionChunk{}))
+
+	resp := &api.ChatCompletionResponse{Choices: []api.ChatCompletionChoice{{Message: api.ChatMessage{Content: createChatContent("hi 123456 byeö!!")}}}}
+	redactor.redactResponse(resp)
+	text, err := resp.Choices[0].Message.Content.AsChatMessageContent0()
+	require.NoError(t, err)
+	assert.Equal(t, "hi # byeö!!", text)
+}
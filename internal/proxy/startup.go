@@ -0,0 +1,82 @@
+package proxy
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+)
+
+// defaultProviderStartupTimeout is used when the startup config doesn't set a provider timeout.
+const defaultProviderStartupTimeout = 30 * time.Second
+
+// providerFactory creates the provider of a provider config. The I/O of the
+// constructors, such as the lookup of their credentials, is bound to ctx.
+type providerFactory func(ctx context.Context, pCfg *config.ProviderConfig) (provider.Provider, error)
+
+type providerResult struct {
+	provider provider.Provider
+	err      error
+}
+
+// initProviders creates the providers concurrently, each bounded by the startup
+// provider timeout. It returns the providers that were created along with the
+// errors of all the others joined together.
+func initProviders(ctx context.Context, pCfgs []*config.ProviderConfig, startup config.StartupConfig, newProvider providerFactory) (map[string]provider.Provider, error) {
+	timeout := startup.ProviderTimeout
+	if timeout <= 0 {
+		timeout = defaultProviderStartupTimeout
+	}
+
+	results := make([]providerResult, len(pCfgs))
+
+	var wg sync.WaitGroup
+	for i, pCfg := range pCfgs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := newProviderWithTimeout(ctx, pCfg, timeout, newProvider)
+			results[i] = providerResult{provider: p, err: err}
+		}()
+	}
+	wg.Wait()
+
+	providers := make(map[string]provider.Provider, len(pCfgs))
+	var errs []error
+	for i, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+		providers[pCfgs[i].ID] = r.provider
+	}
+	return providers, stderrors.Join(errs...)
+}
+
+// newProviderWithTimeout gives up on creating a provider after timeout. The
+// constructor gets a ctx cancelled then, but doesn't necessarily stop there, so
+// a hanging one is left behind.
+func newProviderWithTimeout(ctx context.Context, pCfg *config.ProviderConfig, timeout time.Duration, newProvider providerFactory) (provider.Provider, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan providerResult, 1)
+	go func() {
+		p, err := newProvider(ctx, pCfg)
+		done <- providerResult{provider: p, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.provider, r.err
+	case <-ctx.Done():
+		if stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out creating LLM model for provider %s after %s", pCfg.ID, timeout)
+		}
+		return nil, fmt.Errorf("creating LLM model for provider %s: %w", pCfg.ID, ctx.Err())
+	}
+}
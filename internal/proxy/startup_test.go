@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/dmitrii/llm-gateway/internal/provider/dummy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInitProviders(t *testing.T) {
+	hang := make(chan struct{})
+	t.Cleanup(func() { close(hang) })
+
+	newProvider := func(_ context.Context, pCfg *config.ProviderConfig) (provider.Provider, error) {
+		switch pCfg.ID {
+		case "broken":
+			return nil, errors.New("invalid credentials")
+		case "hanging":
+			<-hang
+		}
+		return dummy.NewDummyProvider(), nil
+	}
+
+	pCfgs := []*config.ProviderConfig{{ID: "ok-1"}, {ID: "broken"}, {ID: "hanging"}, {ID: "ok-2"}}
+	start := time.Now()
+	providers, err := initProviders(context.Background(), pCfgs, config.StartupConfig{ProviderTimeout: 50 * time.Millisecond}, newProvider)
+
+	assert.Less(t, time.Since(start), time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid credentials")
+	assert.Contains(t, err.Error(), "timed out creating LLM model for provider hanging")
+	assert.Len(t, providers, 2)
+	assert.Contains(t, providers, "ok-1")
+	assert.Contains(t, providers, "ok-2")
+}
+
+func TestInitProviders_Context(t *testing.T) {
+	// The constructors honoring their ctx return once initProviders gives up on them.
+	stopped := make(chan error, 2)
+	newProvider := func(ctx context.Context, pCfg *config.ProviderConfig) (provider.Provider, error) {
+		<-ctx.Done()
+		stopped <- ctx.Err()
+		return nil, ctx.Err()
+	}
+	pCfgs := []*config.ProviderConfig{{ID: "slow"}}
+
+	t.Run("timeout", func(t *testing.T) {
+		_, err := initProviders(context.Background(), pCfgs, config.StartupConfig{ProviderTimeout: 10 * time.Millisecond}, newProvider)
+		assert.ErrorContains(t, err, "timed out creating LLM model for provider slow")
+		assert.ErrorIs(t, <-stopped, context.DeadlineExceeded)
+	})
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := initProviders(ctx, pCfgs, config.StartupConfig{}, newProvider)
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.ErrorIs(t, <-stopped, context.Canceled)
+	})
+}
+
+func TestNewProxy_AllowPartial(t *testing.T) {
+	cfg := &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "dummy1", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+			{ID: "openai1", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIUrl: "https://api.openai.com"}},
+		},
+	}
+
+	_, err := NewProxy(context.Background(), cfg)
+	require.Error(t, err)
+
+	cfg.Startup.AllowPartial = true
+	proxy, err := NewProxy(context.Background(), cfg)
+	require.NoError(t, err)
+	assert.Contains(t, proxy.providers, "dummy1")
+	assert.NotContains(t, proxy.providers, "openai1")
+}
+
+func TestNewProxy_DisabledProvider(t *testing.T) {
+	proxy, err := NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "dummy1", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+			// A disabled provider isn't created, so its broken config doesn't fail the proxy.
+			{ID: "openai1", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIUrl: "https://api.openai.com"}, Enabled: ptr(false)},
+		},
+	})
+	require.NoError(t, err)
+	assert.Contains(t, proxy.providers, "dummy1")
+	assert.NotContains(t, proxy.providers, "openai1")
+}
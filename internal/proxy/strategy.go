@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// errorRateWeight is the weight of the latest attempt in the error rate of a model.
+const errorRateWeight = 0.1
+
+// errorRateHalfLife is the time it takes an error rate to halve without attempts,
+// so that a model left aside for its errors is eventually tried again.
+const errorRateHalfLife = time.Minute
+
+// errorRateFloor is the error rate below which a model counts as healthy, to be
+// tried in the configured order again.
+const errorRateFloor = 0.01
+
+// errorRates tracks the recent error rate of each model, as an exponentially
+// weighted moving average of its attempts, which decays over time. Only the
+// failures that would trigger a fallback count as errors. A nil errorRates
+// tracks nothing.
+type errorRates struct {
+	now func() time.Time
+
+	mu    sync.Mutex
+	rates map[string]errorRate
+}
+
+// errorRate is the error rate of a model as of its last attempt.
+type errorRate struct {
+	value   float64
+	updated time.Time
+}
+
+// at returns the error rate decayed until now.
+func (r errorRate) at(now time.Time) float64 {
+	elapsed := now.Sub(r.updated)
+	if elapsed <= 0 {
+		return r.value
+	}
+	return r.value * math.Exp2(-float64(elapsed)/float64(errorRateHalfLife))
+}
+
+func newErrorRates() *errorRates {
+	return &errorRates{now: time.Now, rates: make(map[string]errorRate)}
+}
+
+// record adds the outcome of an attempt of the model.
+func (r *errorRates) record(model string, failed bool) {
+	if r == nil {
+		return
+	}
+	outcome := 0.0
+	if failed {
+		outcome = 1
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	value := r.rates[model].at(now)
+	r.rates[model] = errorRate{value: value + errorRateWeight*(outcome-value), updated: now}
+}
+
+// rate returns the error rate of the model, 0 when it has no attempts yet or
+// it decayed below errorRateFloor.
+func (r *errorRates) rate(model string) float64 {
+	if r == nil {
+		return 0
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rate, ok := r.rates[model]
+	if !ok {
+		return 0
+	}
+	if value := rate.at(r.now()); value >= errorRateFloor {
+		return value
+	}
+	return 0
+}
+
+// orderModels returns the models to try for a request of model, models being
+// the model ID followed by its fallbacks, in the order of the fallback strategy
+// of the model. models isn't modified.
+func (p *Proxy) orderModels(model *config.ModelConfig, models []string) []string {
+	switch model.FallbackStrategy {
+	case config.FallbackStrategyRandom:
+		models = slices.Clone(models)
+		shuffle := p.shuffle
+		if shuffle == nil {
+			shuffle = rand.Shuffle
+		}
+		shuffle(len(models), func(i, j int) { models[i], models[j] = models[j], models[i] })
+	case config.FallbackStrategyLeastErrors:
+		rates := make(map[string]float64, len(models))
+		for _, id := range models {
+			rates[id] = p.errorRates.rate(id)
+		}
+		models = slices.Clone(models)
+		slices.SortStableFunc(models, func(a, b string) int { return cmp.Compare(rates[a], rates[b]) })
+	}
+	return models
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/gojuno/minimock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderModels(t *testing.T) {
+	models := []string{"cheap", "mid", "premium"}
+
+	rates := newErrorRates()
+	for range 5 {
+		rates.record("cheap", true)
+	}
+	rates.record("mid", false)
+	rates.record("premium", true)
+
+	tests := []struct {
+		name     string
+		strategy config.FallbackStrategy
+		expected []string
+	}{
+		{name: "default", expected: []string{"cheap", "mid", "premium"}},
+		{name: "ordered", strategy: config.FallbackStrategyOrdered, expected: []string{"cheap", "mid", "premium"}},
+		{name: "random", strategy: config.FallbackStrategyRandom, expected: []string{"mid", "cheap", "premium"}},
+		{name: "least errors", strategy: config.FallbackStrategyLeastErrors, expected: []string{"mid", "premium", "cheap"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Proxy{errorRates: rates, shuffle: rand.New(rand.NewPCG(1, 2)).Shuffle}
+			ordered := p.orderModels(&config.ModelConfig{FallbackStrategy: tt.strategy}, models)
+			assert.Equal(t, tt.expected, ordered)
+			assert.Equal(t, []string{"cheap", "mid", "premium"}, models)
+		})
+	}
+
+	t.Run("least errors keeps the order of equal rates", func(t *testing.T) {
+		p := &Proxy{errorRates: newErrorRates()}
+		ordered := p.orderModels(&config.ModelConfig{FallbackStrategy: config.FallbackStrategyLeastErrors}, models)
+		assert.Equal(t, models, ordered)
+	})
+}
+
+func TestErrorRates(t *testing.T) {
+	now := time.Now()
+	rates := newErrorRates()
+	rates.now = func() time.Time { return now }
+	assert.Zero(t, rates.rate("model"))
+
+	rates.record("model", true)
+	assert.InDelta(t, 0.1, rates.rate("model"), 1e-9)
+	rates.record("model", true)
+	assert.InDelta(t, 0.19, rates.rate("model"), 1e-9)
+	rates.record("model", false)
+	assert.InDelta(t, 0.171, rates.rate("model"), 1e-9)
+
+	// The rate halves every half-life without attempts, down to the floor.
+	now = now.Add(errorRateHalfLife)
+	assert.InDelta(t, 0.0855, rates.rate("model"), 1e-9)
+	rates.record("model", false)
+	assert.InDelta(t, 0.07695, rates.rate("model"), 1e-9)
+	now = now.Add(2 * errorRateHalfLife)
+	assert.InDelta(t, 0.07695/4, rates.rate("model"), 1e-9)
+	now = now.Add(errorRateHalfLife)
+	assert.Zero(t, rates.rate("model"))
+
+	var disabled *errorRates
+	disabled.record("model", true)
+	assert.Zero(t, disabled.rate("model"))
+}
+
+func TestChatCompletionsHandler_LeastErrorsStrategy(t *testing.T) {
+	mockProvider1 := provider.NewProviderMock(t)
+	mockProvider2 := provider.NewProviderMock(t)
+
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "chat-model", Name: "primary-model", Provider: "provider1", Fallback: []string{"fallback-model"}, FallbackStrategy: config.FallbackStrategyLeastErrors},
+				{ID: "fallback-model", Name: "backup-model", Provider: "provider2"},
+			},
+		},
+		providers: map[string]provider.Provider{
+			"provider1": mockProvider1,
+			"provider2": mockProvider2,
+		},
+		errorRates: newErrorRates(),
+	}
+
+	messages := []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}}
+	req := api.ChatCompletionRequest{Model: "chat-model", Messages: messages}
+
+	// The primary model fails once, which makes the fallback model the first
+	// one tried by the next request.
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{Model: "primary-model", Messages: messages}).
+		Return(nil, errors.New("primary provider failed"))
+	mockProvider2.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{Model: "backup-model", Messages: messages}).
+		Return(&api.ChatCompletionResponse{Model: "backup-model"}, nil)
+
+	for range 2 {
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "backup-model", resp.Model)
+	}
+	assert.Equal(t, uint64(1), mockProvider1.ChatCompletionAfterCounter())
+	assert.Equal(t, uint64(2), mockProvider2.ChatCompletionAfterCounter())
+
+	// Once its error rate decayed, the primary model is tried first again, and
+	// stays first as it recovered.
+	later := time.Now().Add(10 * errorRateHalfLife)
+	proxy.errorRates.now = func() time.Time { return later }
+	mockProvider1.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{Model: "primary-model", Messages: messages}).
+		Return(&api.ChatCompletionResponse{Model: "primary-model"}, nil)
+	for range 2 {
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "primary-model", resp.Model)
+	}
+	assert.Equal(t, uint64(3), mockProvider1.ChatCompletionAfterCounter())
+	assert.Equal(t, uint64(2), mockProvider2.ChatCompletionAfterCounter())
+}
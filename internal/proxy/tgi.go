@@ -0,0 +1,38 @@
+package proxy
+
+import (
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/tmc/langchaingo/llms"
+	llmsopenai "github.com/tmc/langchaingo/llms/openai"
+)
+
+// tgiModel is the model name sent to TGI servers, which serve a single model
+// and ignore the name.
+const tgiModel = "tgi"
+
+// tgiAnonymousToken is sent as the bearer token of TGI servers without auth,
+// the OpenAI client refusing to run without one.
+const tgiAnonymousToken = "-"
+
+// newTGILLM creates the client of a dedicated Text Generation Inference server.
+// TGI takes the chat messages and answers with the token usage in the shape of
+// the OpenAI chat completions API on /v1/chat/completions, applying the chat
+// template of the served model itself.
+func newTGILLM(hfCfg *config.HuggingFaceProviderConfig, httpClient *client.Client) (llms.Model, error) {
+	token := hfCfg.APIKey
+	if token == "" {
+		token = tgiAnonymousToken
+	}
+	llm, err := llmsopenai.New(
+		llmsopenai.WithToken(token),
+		llmsopenai.WithBaseURL(strings.TrimSuffix(hfCfg.EndpointURL, "/")+"/v1"),
+		llmsopenai.WithHTTPClient(httpClient),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &fixedModel{Model: llm, model: tgiModel}, nil
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"cmp"
+	"context"
+	stderrors "errors"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// defaultMaxThrottleRetries is the number of retries of a throttled model when
+// none is configured.
+const defaultMaxThrottleRetries = 1
+
+var providerThrottledTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_gateway_provider_throttled_total",
+		Help: "Total number of provider calls rate limited with a short Retry-After and retried after it",
+	},
+	[]string{"provider"},
+)
+
+func init() {
+	prometheus.MustRegister(providerThrottledTotal)
+}
+
+// throttleWait returns the Retry-After of err when it is an upstream 429 worth
+// waiting for: within the configured maximum and the deadline of ctx.
+func (p *Proxy) throttleWait(ctx context.Context, err error) (time.Duration, bool) {
+	maxWait := p.cfg.Fallback.MaxRetryAfter
+	if maxWait <= 0 {
+		return 0, false
+	}
+	var statusErr *client.StatusError
+	if !stderrors.As(err, &statusErr) || statusErr.StatusCode != http.StatusTooManyRequests || statusErr.RetryAfter > maxWait {
+		return 0, false
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= statusErr.RetryAfter {
+		// The retry would run out of time anyway.
+		return 0, false
+	}
+	return statusErr.RetryAfter, true
+}
+
+// callWithThrottleRetry calls attempt, calling it again after the Retry-After of
+// an upstream 429 within the configured maximum, unless canRetry says
+// otherwise, instead of leaving the request to the fallbacks. The slots are
+// given back during the wait, and the retries are taken from the retry budget
+// of endpoint. An attempt the upstream client already retried, having waited
+// for the Retry-After itself, isn't retried again.
+func callWithThrottleRetry[T any](ctx context.Context, p *Proxy, providerName, endpoint string, slots *callSlots, canRetry func() bool, attempt func(ctx context.Context) (T, error)) (T, error) {
+	maxRetries := cmp.Or(p.cfg.Fallback.MaxThrottleRetries, defaultMaxThrottleRetries)
+	for retries := 0; ; retries++ {
+		var clientRetries atomic.Int32
+		attemptCtx := client.WithRetryBudget(ctx, func() bool {
+			if !p.retryBudget.allow(endpoint) {
+				return false
+			}
+			clientRetries.Add(1)
+			return true
+		})
+		resp, err := attempt(attemptCtx)
+		if err == nil || retries >= maxRetries || clientRetries.Load() > 0 || !canRetry() {
+			return resp, err
+		}
+		wait, ok := p.throttleWait(ctx, err)
+		if !ok || !p.retryBudget.allow(endpoint) {
+			return resp, err
+		}
+
+		slog.WarnContext(ctx, "Provider rate limited the request, retrying after Retry-After", "provider", providerName, "retry_after", wait)
+		providerThrottledTotal.WithLabelValues(providerName).Inc()
+		slots.release()
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		case <-timer.C:
+		}
+		if !slots.reacquire(ctx) {
+			return resp, err
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package proxy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChatCompletionsHandler_ThrottleRetry(t *testing.T) {
+	tests := []struct {
+		name            string
+		maxRetryAfter   time.Duration
+		retryAfter      time.Duration
+		timeout         time.Duration
+		expectedCalls   uint64
+		expectedServed  string
+		expectedRetries float64
+	}{
+		{name: "short retry after", maxRetryAfter: time.Second, retryAfter: 20 * time.Millisecond, expectedCalls: 2, expectedServed: "primary", expectedRetries: 1},
+		{name: "long retry after", maxRetryAfter: time.Second, retryAfter: 2 * time.Second, expectedCalls: 1, expectedServed: "backup"},
+		{name: "past the deadline", maxRetryAfter: time.Second, retryAfter: 500 * time.Millisecond, timeout: 100 * time.Millisecond, expectedCalls: 1, expectedServed: "backup"},
+		{name: "disabled", retryAfter: 20 * time.Millisecond, expectedCalls: 1, expectedServed: "backup"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			primary := provider.NewProviderMock(t)
+			backup := provider.NewProviderMock(t)
+			proxy := &Proxy{
+				cfg: &config.Config{
+					Fallback: config.FallbackConfig{MaxRetryAfter: tt.maxRetryAfter},
+					Models: []*config.ModelConfig{
+						{ID: "primary", Name: "primary", Provider: "throttled-provider", Fallback: []string{"backup"}},
+						{ID: "backup", Name: "backup", Provider: "backup-provider"},
+					},
+				},
+				providers: map[string]provider.Provider{"throttled-provider": primary, "backup-provider": backup},
+			}
+
+			primary.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+				if primary.ChatCompletionBeforeCounter() == 1 {
+					return nil, &client.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: tt.retryAfter}
+				}
+				return &api.ChatCompletionResponse{Model: req.Model}, nil
+			})
+			backup.ChatCompletionMock.Optional().Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+				return &api.ChatCompletionResponse{Model: req.Model}, nil
+			})
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+			retries := testutil.ToFloat64(providerThrottledTotal.WithLabelValues("throttled-provider"))
+
+			resp, err := proxy.ChatCompletionsHandler(ctx, api.ChatCompletionRequest{
+				Model:    "primary",
+				Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+			})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.expectedServed, resp.Model)
+			assert.Equal(t, tt.expectedCalls, primary.ChatCompletionAfterCounter())
+			assert.Equal(t, retries+tt.expectedRetries, testutil.ToFloat64(providerThrottledTotal.WithLabelValues("throttled-provider")))
+		})
+	}
+}
+
+func TestChatCompletionsHandler_ThrottleRetryLimit(t *testing.T) {
+	primary := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Fallback: config.FallbackConfig{MaxRetryAfter: time.Second, MaxThrottleRetries: 2},
+			Models:   []*config.ModelConfig{{ID: "primary", Name: "primary", Provider: "throttled-provider"}},
+		},
+		providers: map[string]provider.Provider{"throttled-provider": primary},
+	}
+	primary.ChatCompletionMock.Return(nil, &client.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond})
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "primary",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, uint64(3), primary.ChatCompletionAfterCounter())
+}
+
+func TestChatCompletionsHandler_ThrottleRetryReleasesSlots(t *testing.T) {
+	primary := provider.NewProviderMock(t)
+	pCfg := &config.ProviderConfig{ID: "throttled-provider", MaxConcurrency: 1}
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Fallback: config.FallbackConfig{MaxRetryAfter: time.Second},
+			Models:   []*config.ModelConfig{{ID: "primary", Name: "primary", Provider: "throttled-provider"}},
+		},
+		providers:     map[string]provider.Provider{"throttled-provider": primary},
+		limiters:      map[string]*concurrencyLimiter{"throttled-provider": newConcurrencyLimiter(pCfg)},
+		globalLimiter: newGlobalLimiter(config.ServerConfig{MaxConcurrency: 1}),
+	}
+	limiter := proxy.limiters["throttled-provider"]
+
+	primary.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		if primary.ChatCompletionBeforeCounter() == 1 {
+			return nil, &client.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 50 * time.Millisecond}
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+
+	done := make(chan error)
+	go func() {
+		_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+			Model:    "primary",
+			Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+		})
+		done <- err
+	}()
+	require.Eventually(t, func() bool { return primary.ChatCompletionAfterCounter() == 1 }, time.Second, time.Millisecond)
+	// Both slots are free for other calls while the retry waits.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.True(t, limiter.acquire(ctx))
+	require.NoError(t, proxy.globalLimiter.acquire(ctx))
+	proxy.globalLimiter.release()
+	limiter.release()
+
+	require.NoError(t, <-done)
+	assert.Equal(t, uint64(2), primary.ChatCompletionAfterCounter())
+	assert.Empty(t, limiter.sem)
+}
+
+func TestChatCompletionsHandler_ThrottleRetryAfterClientRetries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "0")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+	upstream := client.New(srv.Client(), client.WithRetry(client.RetryConfig{MaxAttempts: 2}))
+
+	primary := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Fallback: config.FallbackConfig{MaxRetryAfter: time.Second},
+			Models:   []*config.ModelConfig{{ID: "primary", Name: "primary", Provider: "throttled-provider"}},
+		},
+		providers: map[string]provider.Provider{"throttled-provider": primary},
+	}
+	primary.ChatCompletionMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest) (*api.ChatCompletionResponse, error) {
+		httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+		require.NoError(t, err)
+		_, err = upstream.Do(httpReq)
+		return nil, err
+	})
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "primary",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+
+	// The client waited for the Retry-After and retried already.
+	require.Error(t, err)
+	assert.Equal(t, uint64(1), primary.ChatCompletionAfterCounter())
+}
+
+func TestChatCompletionsHandler_ThrottleRetryBudget(t *testing.T) {
+	primary := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Fallback: config.FallbackConfig{MaxRetryAfter: time.Second, MaxThrottleRetries: 3},
+			Models:   []*config.ModelConfig{{ID: "primary", Name: "primary", Provider: "throttled-provider"}},
+		},
+		providers:   map[string]provider.Provider{"throttled-provider": primary},
+		retryBudget: newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 0.001, Burst: 1}),
+	}
+	primary.ChatCompletionMock.Return(nil, &client.StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Millisecond})
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "primary",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, uint64(2), primary.ChatCompletionAfterCounter())
+}
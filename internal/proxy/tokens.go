@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/pkoukk/tiktoken-go"
+	tiktokenloader "github.com/pkoukk/tiktoken-go-loader"
+)
+
+func init() {
+	// Load the encodings from the embedded files instead of downloading them.
+	tiktoken.SetBpeLoader(tiktokenloader.NewOfflineLoader())
+}
+
+// TokenCounter counts the tokens of a text as the given model would. It is used
+// to estimate the usage of responses without one.
+type TokenCounter interface {
+	CountTokens(model, text string) int
+}
+
+// tiktokenCounter counts tokens with the tiktoken encoding of OpenAI models. Other
+// models are counted with cl100k_base, which approximates most modern tokenizers.
+type tiktokenCounter struct{}
+
+const (
+	defaultEncoding = "cl100k_base"
+	// charsPerToken approximates the count if no encoding can be loaded.
+	charsPerToken = 4
+)
+
+func (tiktokenCounter) CountTokens(model, text string) int {
+	enc, err := encoding(modelEncoding(model))
+	if err != nil {
+		return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+	}
+	return len(enc.Encode(text, nil, nil))
+}
+
+// tokenizerChars counts a token per charsPerToken characters, for model families
+// that no tiktoken encoding approximates well.
+const tokenizerChars = "chars"
+
+// encodingCounter counts tokens with a fixed tiktoken encoding, or by characters
+// with tokenizerChars, whatever the model.
+type encodingCounter string
+
+func (c encodingCounter) CountTokens(_, text string) int {
+	if c != tokenizerChars {
+		if enc, err := encoding(string(c)); err == nil {
+			return len(enc.Encode(text, nil, nil))
+		}
+	}
+	return (utf8.RuneCountInString(text) + charsPerToken - 1) / charsPerToken
+}
+
+// encodings are the tiktoken encodings by name. Building one takes tens of
+// milliseconds, so each is built once and shared, encoding being safe for
+// concurrent use.
+var encodings sync.Map
+
+// encoding returns the tiktoken encoding of the given name.
+func encoding(name string) (*tiktoken.Tiktoken, error) {
+	if enc, ok := encodings.Load(name); ok {
+		return enc.(*tiktoken.Tiktoken), nil
+	}
+	enc, err := tiktoken.GetEncoding(name)
+	if err != nil {
+		return nil, err
+	}
+	actual, _ := encodings.LoadOrStore(name, enc)
+	return actual.(*tiktoken.Tiktoken), nil
+}
+
+// modelEncoding returns the name of the tiktoken encoding of model, the
+// default one for models tiktoken doesn't know.
+func modelEncoding(model string) string {
+	if name, ok := tiktoken.MODEL_TO_ENCODING[model]; ok {
+		return name
+	}
+	for prefix, name := range tiktoken.MODEL_PREFIX_TO_ENCODING {
+		if strings.HasPrefix(model, prefix) {
+			return name
+		}
+	}
+	return defaultEncoding
+}
+
+// Tokens added by the chat format around every message and to prime the reply,
+// as counted for OpenAI chat models.
+const (
+	tokensPerMessage = 3
+	tokensPerReply   = 3
+)
+
+// estimateUsage completes the usage of a chat completion, counting the prompt
+// and completion tokens the provider didn't report, and reports whether any
+// were counted. The tokens the provider reported are kept.
+func estimateUsage(counter TokenCounter, req *api.ChatCompletionRequest, resp *api.ChatCompletionResponse) (*api.Usage, bool) {
+	var usage api.Usage
+	if resp.Usage != nil {
+		usage = *resp.Usage
+	}
+	estimated := false
+	if usage.PromptTokens == 0 {
+		usage.PromptTokens = estimatePromptTokens(counter, req)
+		estimated = true
+	}
+	if usage.CompletionTokens == 0 {
+		if completion := estimateCompletionTokens(counter, req.Model, resp); completion > 0 {
+			usage.CompletionTokens = completion
+			estimated = true
+		}
+	}
+	if estimated || usage.TotalTokens == 0 {
+		usage.TotalTokens = usage.PromptTokens + usage.CompletionTokens
+	}
+	return &usage, estimated
+}
+
+// needsUsageEstimate reports whether usage lacks tokens estimateUsage counts.
+func needsUsageEstimate(usage *api.Usage) bool {
+	return usage == nil || usage.PromptTokens == 0 || usage.CompletionTokens == 0 || usage.TotalTokens == 0
+}
+
+// estimateCompletionTokens counts the tokens of the choices of resp.
+func estimateCompletionTokens(counter TokenCounter, model string, resp *api.ChatCompletionResponse) int {
+	completion := 0
+	for _, choice := range resp.Choices {
+		completion += counter.CountTokens(model, messageText(choice.Message))
+		// Reasoning is billed as completion tokens.
+		if choice.Message.ReasoningContent != nil {
+			completion += counter.CountTokens(model, *choice.Message.ReasoningContent)
+		}
+	}
+	return completion
+}
+
+// recordPartialUsage records the estimated usage of a stream of model cut short
+// after completion was streamed.
+func (p *Proxy) recordPartialUsage(model *config.ModelConfig, req *api.ChatCompletionRequest, completion string) {
+	counter := p.tokenCounterFor(model)
+	if counter == nil {
+		return
+	}
+	prompt := estimatePromptTokens(counter, req)
+	completionTokens := counter.CountTokens(req.Model, completion)
+	recordUsage(req.Model, model.Provider, endpointChatCompletions, true, prompt, completionTokens, prompt+completionTokens)
+	if cost := usageCost(model, prompt, completionTokens); cost > 0 {
+		costTotal.WithLabelValues(req.Model, model.Provider).Add(cost)
+	}
+}
+
+// estimatePromptTokens counts the tokens of the messages of req.
+func estimatePromptTokens(counter TokenCounter, req *api.ChatCompletionRequest) int {
+	prompt := tokensPerReply
+	for _, msg := range req.Messages {
+		prompt += tokensPerMessage + counter.CountTokens(req.Model, messageText(msg))
+	}
+	return prompt
+}
+
+// messageText returns the text content of a message.
+func messageText(msg api.ChatMessage) string {
+	if msg.Content == nil {
+		return ""
+	}
+	if parts, err := msg.Content.AsChatMessageContent1(); err == nil {
+		texts := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part.Text != nil {
+				texts = append(texts, *part.Text)
+			}
+		}
+		return strings.Join(texts, "\n")
+	}
+	text, _ := msg.Content.AsChatMessageContent0()
+	return text
+}
@@ -0,0 +1,263 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wordCounter counts one token per word.
+type wordCounter struct{}
+
+func (wordCounter) CountTokens(_, text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestTiktokenCounter(t *testing.T) {
+	counter := tiktokenCounter{}
+
+	assert.Equal(t, 4, counter.CountTokens("gpt-4", "Hello, world!"))
+	// Unknown models are counted with the default encoding.
+	assert.Equal(t, 4, counter.CountTokens("llama3", "Hello, world!"))
+	assert.Zero(t, counter.CountTokens("gpt-4", ""))
+
+	// The encodings are built once.
+	first, err := encoding(defaultEncoding)
+	require.NoError(t, err)
+	second, err := encoding(defaultEncoding)
+	require.NoError(t, err)
+	assert.Same(t, first, second)
+}
+
+func TestEstimateUsage(t *testing.T) {
+	req := &api.ChatCompletionRequest{
+		Model: "llama3",
+		Messages: []api.ChatMessage{
+			{Role: api.ChatMessageRoleSystem, Content: createChatContent("Be brief.")},
+			{Role: api.ChatMessageRoleUser, Content: createChatContent("Tell me a joke")},
+		},
+	}
+	resp := &api.ChatCompletionResponse{
+		Choices: []api.ChatCompletionChoice{
+			{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Why did the chicken cross?")}},
+		},
+	}
+
+	usage, estimated := estimateUsage(wordCounter{}, req, resp)
+
+	// 2 + 4 words plus 3 tokens per message and 3 to prime the reply.
+	assert.Equal(t, &api.Usage{PromptTokens: 15, CompletionTokens: 5, TotalTokens: 20}, usage)
+	assert.True(t, estimated)
+
+	t.Run("partial usage", func(t *testing.T) {
+		partial := *resp
+		partial.Usage = &api.Usage{PromptTokens: 40}
+		usage, estimated := estimateUsage(wordCounter{}, req, &partial)
+		assert.Equal(t, &api.Usage{PromptTokens: 40, CompletionTokens: 5, TotalTokens: 45}, usage)
+		assert.True(t, estimated)
+	})
+
+	t.Run("missing total", func(t *testing.T) {
+		partial := *resp
+		partial.Usage = &api.Usage{PromptTokens: 40, CompletionTokens: 10}
+		usage, estimated := estimateUsage(wordCounter{}, req, &partial)
+		assert.Equal(t, &api.Usage{PromptTokens: 40, CompletionTokens: 10, TotalTokens: 50}, usage)
+		assert.False(t, estimated)
+	})
+}
+
+func TestEstimateUsage_Reasoning(t *testing.T) {
+	reasoning := "A classic setup"
+	resp := &api.ChatCompletionResponse{
+		Choices: []api.ChatCompletionChoice{
+			{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Why did the chicken cross?"), ReasoningContent: &reasoning}},
+		},
+	}
+
+	usage, _ := estimateUsage(wordCounter{}, &api.ChatCompletionRequest{Model: "llama3"}, resp)
+
+	assert.Equal(t, 8, usage.CompletionTokens)
+}
+
+func TestChatCompletionsHandler_EstimatedUsage(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "local", Name: "estimated-model", Provider: "ollama"}},
+		},
+		providers:    map[string]provider.Provider{"ollama": mockProvider},
+		tokenCounter: wordCounter{},
+	}
+
+	mockProvider.ChatCompletionMock.Return(&api.ChatCompletionResponse{
+		Model: "estimated-model",
+		Choices: []api.ChatCompletionChoice{
+			{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent("Hi there")}},
+		},
+	}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "local",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &api.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9}, resp.Usage)
+	assert.Equal(t, 7.0, testutil.ToFloat64(promptTokensTotal.WithLabelValues("estimated-model", "ollama", endpointChatCompletions, "true")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(completionTokensTotal.WithLabelValues("estimated-model", "ollama", endpointChatCompletions, "true")))
+	assert.Zero(t, testutil.ToFloat64(promptTokensTotal.WithLabelValues("estimated-model", "ollama", endpointChatCompletions, "false")))
+}
+
+func TestChatCompletionsStreamHandler_EstimatedUsage(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "local-stream", Name: "estimated-stream-model", Provider: "ollama"}},
+		},
+		providers:    map[string]provider.Provider{"ollama": mockProvider},
+		tokenCounter: wordCounter{},
+	}
+
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		content := "Hi there"
+		if err := send(ctx, &api.ChatCompletionChunk{
+			Object:  "chat.completion.chunk",
+			Model:   req.Model,
+			Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &content}}},
+		}); err != nil {
+			return nil, err
+		}
+		return &api.ChatCompletionResponse{
+			Model: req.Model,
+			Choices: []api.ChatCompletionChoice{
+				{Message: api.ChatMessage{Role: api.ChatMessageRoleAssistant, Content: createChatContent(content)}},
+			},
+		}, nil
+	})
+
+	var last *api.ChatCompletionChunk
+	err := proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+		Model:         "local-stream",
+		Messages:      []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+		StreamOptions: &api.StreamOptions{IncludeUsage: ptr(true)},
+	}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		last = chunk
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, &api.Usage{PromptTokens: 7, CompletionTokens: 2, TotalTokens: 9}, last.Usage)
+	assert.Equal(t, 7.0, testutil.ToFloat64(promptTokensTotal.WithLabelValues("estimated-stream-model", "ollama", endpointChatCompletions, "true")))
+}
+
+func TestChatCompletionsStreamHandler_ClientDisconnect(t *testing.T) {
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "local-disconnect", Name: "disconnect-model", Provider: "ollama"}},
+		},
+		providers:    map[string]provider.Provider{"ollama": mockProvider},
+		tokenCounter: wordCounter{},
+	}
+
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		for _, word := range []string{"Hi ", "there ", "again"} {
+			if err := send(ctx, &api.ChatCompletionChunk{
+				Choices: []api.ChatCompletionChunkChoice{{Delta: api.ChatCompletionDelta{Content: &word}}},
+			}); err != nil {
+				return nil, fmt.Errorf("stream aborted: %w", err)
+			}
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+
+	disconnects := testutil.ToFloat64(clientDisconnectsTotal.WithLabelValues("disconnect-model", "ollama"))
+
+	// The client goes away after reading two chunks.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	received := 0
+	err := proxy.ChatCompletionsStreamHandler(ctx, api.ChatCompletionRequest{
+		Model:    "local-disconnect",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		if received == 2 {
+			cancel()
+			return ctx.Err()
+		}
+		received++
+		return nil
+	})
+	require.Error(t, err)
+
+	assert.Equal(t, disconnects+1, testutil.ToFloat64(clientDisconnectsTotal.WithLabelValues("disconnect-model", "ollama")))
+	// The prompt is estimated as for complete responses, the completion counts the streamed words.
+	assert.Equal(t, 7.0, testutil.ToFloat64(promptTokensTotal.WithLabelValues("disconnect-model", "ollama", endpointChatCompletions, "true")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(completionTokensTotal.WithLabelValues("disconnect-model", "ollama", endpointChatCompletions, "true")))
+}
+
+func TestEncodingCounter(t *testing.T) {
+	assert.Equal(t, 3, encodingCounter(tokenizerChars).CountTokens("claude-3-5-sonnet", "Hello world"))
+	assert.Equal(t, 2, encodingCounter("cl100k_base").CountTokens("any-model", "Hello world"))
+}
+
+func TestChatCompletionsHandler_ContextWindow(t *testing.T) {
+	// The provider mock fails the test if the upstream is called.
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{{ID: "small", Name: "small-model", Provider: "ollama", ContextWindow: 16, Tokenizer: tokenizerChars}},
+		},
+		providers: map[string]provider.Provider{"ollama": mockProvider},
+	}
+
+	_, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:     "small",
+		MaxTokens: ptr(10),
+		Messages:  []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Summarize this long document")}},
+	})
+	var apiErr internalerrors.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+}
+
+func TestChatCompletionsHandler_ContextWindowFallback(t *testing.T) {
+	small, large := provider.NewProviderMock(t), provider.NewProviderMock(t)
+	large.ChatCompletionMock.Return(&api.ChatCompletionResponse{Model: "large-model"}, nil)
+	breaker := newCircuitBreaker("small-provider", config.CircuitBreakerConfig{FailureThreshold: 1})
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Models: []*config.ModelConfig{
+				{ID: "small", Name: "small-model", Provider: "small-provider", ContextWindow: 16, Tokenizer: tokenizerChars, Fallback: []string{"large"}},
+				{ID: "large", Name: "large-model", Provider: "large-provider", ContextWindow: 1000, Tokenizer: tokenizerChars},
+			},
+		},
+		providers:   map[string]provider.Provider{"small-provider": small, "large-provider": large},
+		breakers:    map[string]*circuitBreaker{"small-provider": breaker},
+		retryBudget: newRetryBudget(config.RetryBudgetConfig{RetriesPerSecond: 0.001, Burst: 1}),
+	}
+	req := api.ChatCompletionRequest{
+		Model:     "small",
+		MaxTokens: ptr(10),
+		Messages:  []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Summarize this long document")}},
+	}
+
+	for range 2 {
+		resp, err := proxy.ChatCompletionsHandler(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "large-model", resp.Model)
+	}
+	// The rejections are no failures of the provider, nor retries.
+	assert.True(t, breaker.allow())
+	assert.True(t, proxy.retryBudget.limiter.Allow())
+}
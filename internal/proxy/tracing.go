@@ -0,0 +1,44 @@
+package proxy
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dmitrii/llm-gateway/internal/proxy"
+
+// Span attributes of the proxy spans.
+const (
+	attrModel            = attribute.Key("llm.model")
+	attrProvider         = attribute.Key("llm.provider")
+	attrAttempt          = attribute.Key("llm.attempt")
+	attrPromptTokens     = attribute.Key("llm.usage.prompt_tokens")
+	attrCompletionTokens = attribute.Key("llm.usage.completion_tokens")
+	attrTotalTokens      = attribute.Key("llm.usage.total_tokens")
+)
+
+func startSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// endSpan marks the span as failed if err is set and ends it.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// setUsageAttributes adds the token usage of a response to the span of ctx.
+func setUsageAttributes(ctx context.Context, promptTokens, completionTokens, totalTokens int) {
+	trace.SpanFromContext(ctx).SetAttributes(
+		attrPromptTokens.Int(promptTokens),
+		attrCompletionTokens.Int(completionTokens),
+		attrTotalTokens.Int(totalTokens),
+	)
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// Transform adapts the chat completions exchanged with an upstream to its
+// quirks. Request mutates the request before it's sent, Response the response
+// once received and Chunk every chunk of a stream as it arrives; any of them
+// may be nil.
+type Transform struct {
+	Request  func(req *api.ChatCompletionRequest)
+	Response func(resp *api.ChatCompletionResponse)
+	Chunk    func(chunk *api.ChatCompletionChunk)
+}
+
+var (
+	transformsMu sync.RWMutex
+	transforms   = map[string]Transform{
+		"strip_unsupported_fields": {Request: stripUnsupportedFields},
+		"normalize_finish_reason":  {Response: normalizeFinishReason, Chunk: normalizeChunkFinishReason},
+	}
+)
+
+// RegisterTransform makes the transform t available to the provider and model
+// configs under name, replacing the transform registered under name, if any.
+// It must be called before the proxy is created.
+func RegisterTransform(name string, t Transform) {
+	transformsMu.Lock()
+	defer transformsMu.Unlock()
+	transforms[name] = t
+}
+
+func lookupTransform(name string) (Transform, bool) {
+	transformsMu.RLock()
+	defer transformsMu.RUnlock()
+	t, ok := transforms[name]
+	return t, ok
+}
+
+// validateTransforms checks that the transforms named by cfg are registered.
+func validateTransforms(cfg *config.Config) error {
+	var errs []string
+	for _, pCfg := range cfg.Providers {
+		for _, name := range pCfg.Transforms {
+			if _, ok := lookupTransform(name); !ok {
+				errs = append(errs, fmt.Sprintf("provider %q references unknown transform %q", pCfg.ID, name))
+			}
+		}
+	}
+	for _, model := range cfg.Models {
+		for _, name := range model.Transforms {
+			if _, ok := lookupTransform(name); !ok {
+				errs = append(errs, fmt.Sprintf("model %q references unknown transform %q", model.ID, name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "\n"))
+	}
+	return nil
+}
+
+// transformsFor returns the transforms of the chat completions of model: the
+// ones of its provider followed by its own.
+func (p *Proxy) transformsFor(model *config.ModelConfig) []Transform {
+	var names []string
+	if pCfg := p.findProvider(model.Provider); pCfg != nil {
+		names = append(names, pCfg.Transforms...)
+	}
+	names = append(names, model.Transforms...)
+
+	result := make([]Transform, 0, len(names))
+	for _, name := range names {
+		if t, ok := lookupTransform(name); ok {
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+func transformRequest(ts []Transform, req *api.ChatCompletionRequest) {
+	for _, t := range ts {
+		if t.Request != nil {
+			t.Request(req)
+		}
+	}
+}
+
+func transformResponse(ts []Transform, resp *api.ChatCompletionResponse) {
+	for _, t := range ts {
+		if t.Response != nil {
+			t.Response(resp)
+		}
+	}
+}
+
+func transformChunk(ts []Transform, chunk *api.ChatCompletionChunk) {
+	for _, t := range ts {
+		if t.Chunk != nil {
+			t.Chunk(chunk)
+		}
+	}
+}
+
+// stripUnsupportedFields drops the OpenAI parameters that OpenAI-compatible
+// servers commonly reject instead of ignoring.
+func stripUnsupportedFields(req *api.ChatCompletionRequest) {
+	req.LogitBias = nil
+	req.Seed = nil
+	req.User = nil
+	req.FrequencyPenalty = nil
+	req.PresencePenalty = nil
+}
+
+// upstreamFinishReasons maps the finish reasons of upstreams, lowercased, to
+// the OpenAI ones.
+var upstreamFinishReasons = map[string]api.ChatCompletionChoiceFinishReason{
+	"stop":           api.ChatCompletionChoiceFinishReasonStop,
+	"length":         api.ChatCompletionChoiceFinishReasonLength,
+	"tool_calls":     api.ChatCompletionChoiceFinishReasonToolCalls,
+	"function_call":  api.ChatCompletionChoiceFinishReasonFunctionCall,
+	"content_filter": api.ChatCompletionChoiceFinishReasonContentFilter,
+	"eos":            api.ChatCompletionChoiceFinishReasonStop,
+	"eos_token":      api.ChatCompletionChoiceFinishReasonStop,
+	"end_turn":       api.ChatCompletionChoiceFinishReasonStop,
+	"stop_sequence":  api.ChatCompletionChoiceFinishReasonStop,
+	"complete":       api.ChatCompletionChoiceFinishReasonStop,
+	"max_tokens":     api.ChatCompletionChoiceFinishReasonLength,
+	"model_length":   api.ChatCompletionChoiceFinishReasonLength,
+	"tool_use":       api.ChatCompletionChoiceFinishReasonToolCalls,
+	"safety":         api.ChatCompletionChoiceFinishReasonContentFilter,
+	"recitation":     api.ChatCompletionChoiceFinishReasonContentFilter,
+}
+
+// normalizeFinishReason replaces the finish reasons of the choices with their
+// OpenAI equivalent. Unknown finish reasons are left as is.
+func normalizeFinishReason(resp *api.ChatCompletionResponse) {
+	for i, choice := range resp.Choices {
+		reason := strings.ToLower(string(choice.FinishReason))
+		if normalized, ok := upstreamFinishReasons[reason]; ok {
+			resp.Choices[i].FinishReason = normalized
+		}
+	}
+}
+
+// normalizeChunkFinishReason does what normalizeFinishReason does for the
+// choices of a streamed chunk.
+func normalizeChunkFinishReason(chunk *api.ChatCompletionChunk) {
+	for i, choice := range chunk.Choices {
+		if choice.FinishReason == nil {
+			continue
+		}
+		reason := strings.ToLower(string(*choice.FinishReason))
+		if normalized, ok := upstreamFinishReasons[reason]; ok {
+			finishReason := api.ChatCompletionChunkChoiceFinishReason(normalized)
+			chunk.Choices[i].FinishReason = &finishReason
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+	"github.com/gojuno/minimock/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registerTestTransform registers t under name for the duration of the test.
+func registerTestTransform(tb testing.TB, name string, t Transform) {
+	RegisterTransform(name, t)
+	tb.Cleanup(func() {
+		transformsMu.Lock()
+		defer transformsMu.Unlock()
+		delete(transforms, name)
+	})
+}
+
+func TestStripUnsupportedFields(t *testing.T) {
+	user := "user-1"
+	req := api.ChatCompletionRequest{
+		Model:            "model",
+		Temperature:      ptr(float32(0.5)),
+		Seed:             ptr(42),
+		User:             &user,
+		LogitBias:        &map[string]int{"50256": -100},
+		FrequencyPenalty: ptr(float32(1)),
+		PresencePenalty:  ptr(float32(1)),
+	}
+
+	stripUnsupportedFields(&req)
+
+	assert.Equal(t, api.ChatCompletionRequest{Model: "model", Temperature: ptr(float32(0.5))}, req)
+}
+
+func TestNormalizeFinishReason(t *testing.T) {
+	tests := []struct {
+		reason   string
+		expected api.ChatCompletionChoiceFinishReason
+	}{
+		{reason: "stop", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{reason: "STOP", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{reason: "eos_token", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{reason: "end_turn", expected: api.ChatCompletionChoiceFinishReasonStop},
+		{reason: "MAX_TOKENS", expected: api.ChatCompletionChoiceFinishReasonLength},
+		{reason: "tool_use", expected: api.ChatCompletionChoiceFinishReasonToolCalls},
+		{reason: "SAFETY", expected: api.ChatCompletionChoiceFinishReasonContentFilter},
+		{reason: "unknown", expected: "unknown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reason, func(t *testing.T) {
+			resp := api.ChatCompletionResponse{Choices: []api.ChatCompletionChoice{{FinishReason: api.ChatCompletionChoiceFinishReason(tt.reason)}}}
+			normalizeFinishReason(&resp)
+			assert.Equal(t, tt.expected, resp.Choices[0].FinishReason)
+		})
+	}
+}
+
+func TestValidateTransforms(t *testing.T) {
+	err := validateTransforms(&config.Config{
+		Providers: []*config.ProviderConfig{{ID: "provider1", Transforms: []string{"strip_unsupported_fields", "missing"}}},
+		Models:    []*config.ModelConfig{{ID: "model1", Transforms: []string{"normalize_finish_reason", "unknown"}}},
+	})
+
+	require.Error(t, err)
+	assert.Equal(t, "provider \"provider1\" references unknown transform \"missing\"\nmodel \"model1\" references unknown transform \"unknown\"", err.Error())
+}
+
+func TestChatCompletionsHandler_Transforms(t *testing.T) {
+	var order []string
+	registerTestTransform(t, "test_provider", Transform{
+		Request:  func(req *api.ChatCompletionRequest) { order = append(order, "provider request") },
+		Response: func(resp *api.ChatCompletionResponse) { order = append(order, "provider response") },
+	})
+	registerTestTransform(t, "test_model", Transform{
+		Request:  func(req *api.ChatCompletionRequest) { order = append(order, "model request") },
+		Response: func(resp *api.ChatCompletionResponse) { order = append(order, "model response") },
+	})
+
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Providers: []*config.ProviderConfig{{ID: "provider1", Transforms: []string{"strip_unsupported_fields", "test_provider"}}},
+			Models: []*config.ModelConfig{
+				{ID: "chat-model", Name: "upstream-model", Provider: "provider1", Transforms: []string{"test_model", "normalize_finish_reason"}},
+			},
+		},
+		providers: map[string]provider.Provider{"provider1": mockProvider},
+	}
+
+	user := api.ChatMessage{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello, world!")}
+	mockProvider.ChatCompletionMock.Expect(minimock.AnyContext, &api.ChatCompletionRequest{
+		Model:    "upstream-model",
+		Messages: []api.ChatMessage{user},
+	}).Return(&api.ChatCompletionResponse{
+		Model:   "upstream-model",
+		Choices: []api.ChatCompletionChoice{{FinishReason: "eos_token"}},
+	}, nil)
+
+	resp, err := proxy.ChatCompletionsHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Messages: []api.ChatMessage{user},
+		Seed:     ptr(42),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, api.ChatCompletionChoiceFinishReasonStop, resp.Choices[0].FinishReason)
+	assert.Equal(t, []string{"provider request", "model request", "provider response", "model response"}, order)
+}
+
+func TestChatCompletionsStreamHandler_Transforms(t *testing.T) {
+	var order []string
+	registerTestTransform(t, "test_chunk", Transform{
+		Request: func(req *api.ChatCompletionRequest) { order = append(order, "request") },
+		Chunk:   func(chunk *api.ChatCompletionChunk) { order = append(order, "chunk") },
+	})
+
+	mockProvider := provider.NewProviderMock(t)
+	proxy := &Proxy{
+		cfg: &config.Config{
+			Providers: []*config.ProviderConfig{{ID: "provider1", Transforms: []string{"test_chunk"}}},
+			Models: []*config.ModelConfig{
+				{ID: "chat-model", Name: "upstream-model", Provider: "provider1", Transforms: []string{"normalize_finish_reason"}},
+			},
+		},
+		providers: map[string]provider.Provider{"provider1": mockProvider},
+	}
+
+	mockProvider.ChatCompletionStreamMock.Set(func(ctx context.Context, req *api.ChatCompletionRequest, send provider.StreamFunc) (*api.ChatCompletionResponse, error) {
+		content := "Hello"
+		finishReason := api.ChatCompletionChunkChoiceFinishReason("eos_token")
+		for _, choice := range []api.ChatCompletionChunkChoice{
+			{Delta: api.ChatCompletionDelta{Content: &content}},
+			{FinishReason: &finishReason},
+		} {
+			if err := send(ctx, &api.ChatCompletionChunk{Choices: []api.ChatCompletionChunkChoice{choice}}); err != nil {
+				return nil, err
+			}
+		}
+		return &api.ChatCompletionResponse{Model: req.Model}, nil
+	})
+
+	var chunks []*api.ChatCompletionChunk
+	err := proxy.ChatCompletionsStreamHandler(context.Background(), api.ChatCompletionRequest{
+		Model:    "chat-model",
+		Messages: []api.ChatMessage{{Role: api.ChatMessageRoleUser, Content: createChatContent("Hello")}},
+	}, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		chunks = append(chunks, chunk)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, chunks, 2)
+	require.NotNil(t, chunks[1].Choices[0].FinishReason)
+	assert.Equal(t, api.ChatCompletionChunkChoiceFinishReasonStop, *chunks[1].Choices[0].FinishReason)
+	assert.Equal(t, []string{"request", "chunk", "chunk"}, order)
+}
@@ -0,0 +1,33 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+)
+
+// ValidateConfig checks the settings of cfg that config.Load can't check, since
+// they need the proxy to make sense of: the transforms it references, the
+// content filter patterns and the proxy_url of the enabled providers. It makes
+// no call to the providers.
+func ValidateConfig(cfg *config.Config) error {
+	var errs []error
+	if err := validateTransforms(cfg); err != nil {
+		errs = append(errs, err)
+	}
+	if _, err := newContentFilter(cfg.ContentFilter); err != nil {
+		errs = append(errs, err)
+	}
+	for _, pCfg := range cfg.Providers {
+		if !pCfg.IsEnabled() || pCfg.ProxyURL == "" {
+			continue
+		}
+		if _, err := parseProxyURL(pCfg.ProxyURL); err != nil {
+			errs = append(errs, fmt.Errorf("provider %q: %w", pCfg.ID, err))
+		} else if ownsHTTPClient(pCfg) {
+			errs = append(errs, fmt.Errorf("proxy_url is not supported by the %s provider %s, which uses an HTTP client of its own", pCfg.Provider, pCfg.ID))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,29 @@
+// Package requestid carries the correlation ID of a gateway request through its context.
+package requestid
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header is the HTTP header the request ID is read from and forwarded in.
+const Header = "X-Request-ID"
+
+type contextKey struct{}
+
+// New generates a new request ID.
+func New() string {
+	return uuid.NewString()
+}
+
+// NewContext returns a copy of ctx carrying the request ID.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID carried by ctx, or an empty string.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
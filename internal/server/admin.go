@@ -0,0 +1,16 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// configHandler serves the effective configuration returned by current, env
+// overrides and defaults applied, with the secret fields masked.
+func configHandler(current func() *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, config.Redacted(current()))
+	}
+}
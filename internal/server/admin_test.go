@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{Port: "8080", APIKeys: []string{"admin-key"}},
+		Providers: []*config.ProviderConfig{
+			{ID: "anthropic", Provider: config.ProviderAnthropic, Config: &config.AnthropicProviderConfig{APIKey: "sk-ant-secret", APIUrl: "https://api.anthropic.com/v1"}},
+		},
+	}
+	r := gin.New()
+	r.GET("/admin/config", authMiddleware(cfg.Server.APIKeys), configHandler(func() *config.Config { return cfg }))
+
+	t.Run("requires an API key", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("masks secrets", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		req.Header.Set("Authorization", "Bearer admin-key")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotContains(t, w.Body.String(), "admin-key")
+		assert.NotContains(t, w.Body.String(), "sk-ant-secret")
+
+		var body map[string]any
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		provider := body["providers"].([]any)[0].(map[string]any)
+		assert.Equal(t, "anthropic", provider["id"])
+		assert.Equal(t, "https://api.anthropic.com/v1", provider["config"].(map[string]any)["api_url"])
+	})
+}
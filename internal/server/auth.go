@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+)
+
+// apiKeyContextKey is the gin context key holding the authenticated API key.
+const apiKeyContextKey = "api_key"
+
+// authMiddleware rejects requests that don't carry one of the configured API keys
+// in an `Authorization: Bearer <key>` header. Requests without credentials, or
+// with an unknown key, get a 401 with a `WWW-Authenticate: Bearer` challenge,
+// and malformed headers a 400. It is a no-op when no keys are configured.
+func authMiddleware(apiKeys []string) func(c *gin.Context) {
+	// Keys are compared as hashes so that the comparison time doesn't depend on their length.
+	hashes := make([][32]byte, len(apiKeys))
+	for i, key := range apiKeys {
+		hashes[i] = sha256.Sum256([]byte(key))
+	}
+
+	return func(c *gin.Context) {
+		if len(hashes) == 0 {
+			return
+		}
+
+		key, err := parseBearerToken(c.GetHeader("Authorization"))
+		if err != nil {
+			if err == errMissingAPIKey {
+				c.Header("WWW-Authenticate", "Bearer")
+			}
+			HandleError(c, err)
+			c.Abort()
+			return
+		}
+
+		hash := sha256.Sum256([]byte(key))
+		match := 0
+		for _, h := range hashes {
+			match |= subtle.ConstantTimeCompare(hash[:], h[:])
+		}
+		if match != 1 {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			HandleError(c, errors.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		c.Set(apiKeyContextKey, key)
+		c.Request = c.Request.WithContext(proxy.WithAPIKey(c.Request.Context(), key))
+	}
+}
+
+var (
+	errMissingAPIKey          = errors.ErrUnauthorized.WithMessage("Missing API key")
+	errMalformedAuthorization = errors.ErrBadRequest.WithMessage("Malformed Authorization header, expected Bearer <api-key>")
+)
+
+// parseBearerToken returns the token of the Authorization header value, in the
+// `Bearer <token>` format. The scheme is case-insensitive and the whitespace
+// around the token is ignored. It returns errMissingAPIKey when the header is
+// empty and errMalformedAuthorization when it isn't in that format.
+func parseBearerToken(header string) (string, error) {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return "", errMissingAPIKey
+	}
+
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok {
+		scheme, token, ok = strings.Cut(header, "\t")
+	}
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" || strings.ContainsAny(token, " \t") {
+		return "", errMalformedAuthorization
+	}
+	return token, nil
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name                    string
+		apiKeys                 []string
+		header                  string
+		expectedStatus          int
+		expectedWWWAuthenticate string
+	}{
+		{name: "auth disabled", apiKeys: nil, header: "", expectedStatus: http.StatusOK},
+		{name: "valid key", apiKeys: []string{"key-1", "key-2"}, header: "Bearer key-2", expectedStatus: http.StatusOK},
+		{name: "lowercase scheme", apiKeys: []string{"key-1"}, header: "bearer key-1", expectedStatus: http.StatusOK},
+		{name: "extra spaces", apiKeys: []string{"key-1"}, header: "  Bearer   key-1  ", expectedStatus: http.StatusOK},
+		{name: "invalid key", apiKeys: []string{"key-1"}, header: "Bearer key-2", expectedStatus: http.StatusUnauthorized, expectedWWWAuthenticate: `Bearer error="invalid_token"`},
+		{name: "missing header", apiKeys: []string{"key-1"}, header: "", expectedStatus: http.StatusUnauthorized, expectedWWWAuthenticate: "Bearer"},
+		{name: "blank header", apiKeys: []string{"key-1"}, header: "   ", expectedStatus: http.StatusUnauthorized, expectedWWWAuthenticate: "Bearer"},
+		{name: "missing scheme", apiKeys: []string{"key-1"}, header: "key-1", expectedStatus: http.StatusBadRequest},
+		{name: "missing token", apiKeys: []string{"key-1"}, header: "Bearer ", expectedStatus: http.StatusBadRequest},
+		{name: "other scheme", apiKeys: []string{"key-1"}, header: "Basic a2V5LTE=", expectedStatus: http.StatusBadRequest},
+		{name: "token with spaces", apiKeys: []string{"key-1"}, header: "Bearer key 1", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			auth := authMiddleware(tt.apiKeys)
+			r.GET("/v1/models", func(c *gin.Context) {
+				auth(c)
+				if c.IsAborted() {
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedWWWAuthenticate, w.Header().Get("WWW-Authenticate"))
+		})
+	}
+}
@@ -0,0 +1,173 @@
+package server
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCompressionMinLength is the size from which responses are compressed
+// when the configuration doesn't set one.
+const defaultCompressionMinLength = 1024
+
+var gzipWriters = sync.Pool{
+	New: func() any { return gzip.NewWriter(nil) },
+}
+
+// compressionMiddleware gzips the responses of the clients accepting it. Responses
+// are buffered until they reach minLength bytes, and sent uncompressed when they
+// end before. A flush, as done by the streamed chat completions, starts the
+// compression right away and flushes the compressed data written so far.
+func compressionMiddleware(cfg config.CompressionConfig) gin.HandlerFunc {
+	if !cfg.Enabled {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	minLength := cfg.MinLength
+	if minLength <= 0 {
+		minLength = defaultCompressionMinLength
+	}
+
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) {
+			c.Next()
+			return
+		}
+
+		w := &gzipResponseWriter{ResponseWriter: c.Writer, minLength: minLength}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if coding = strings.TrimSpace(coding); coding != "gzip" && coding != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the beginning of a response to decide whether to
+// compress it. The status is passed on as is, so that the metrics and the
+// access log record the response of the handler.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minLength int
+
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+	written bool
+}
+
+func (w *gzipResponseWriter) Write(data []byte) (int, error) {
+	w.written = true
+	if !w.decided {
+		w.buf = append(w.buf, data...)
+		if len(w.buf) < w.minLength {
+			return len(data), nil
+		}
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+		return len(data), nil
+	}
+	return w.write(data)
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow sends the headers of a response without body, which is never
+// compressed.
+func (w *gzipResponseWriter) WriteHeaderNow() {
+	if !w.decided && len(w.buf) == 0 {
+		w.decided = true
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *gzipResponseWriter) Written() bool {
+	return w.written || w.ResponseWriter.Written()
+}
+
+func (w *gzipResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.start(true); err != nil {
+			return
+		}
+	}
+	if w.gz != nil {
+		if err := w.gz.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// start sends the headers and the buffered data, compressed when compress is
+// set and the handler didn't encode the response itself.
+func (w *gzipResponseWriter) start(compress bool) error {
+	w.decided = true
+	h := w.Header()
+	if compress && h.Get("Content-Encoding") == "" && bodyAllowed(w.Status()) {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzipWriters.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.write(buf)
+	return err
+}
+
+func (w *gzipResponseWriter) write(data []byte) (int, error) {
+	if w.gz != nil {
+		return w.gz.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+// close writes what is left of the response, uncompressed if it never reached
+// the minimum length.
+func (w *gzipResponseWriter) close() {
+	if !w.decided {
+		_ = w.start(false)
+	}
+	if w.gz != nil {
+		_ = w.gz.Close()
+		w.gz.Reset(nil)
+		gzipWriters.Put(w.gz)
+		w.gz = nil
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
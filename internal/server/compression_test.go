@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	large := strings.Repeat("a", 2048)
+	tests := []struct {
+		name           string
+		cfg            config.CompressionConfig
+		acceptEncoding string
+		body           string
+		wantGzip       bool
+	}{
+		{
+			name:           "disabled",
+			acceptEncoding: "gzip",
+			body:           large,
+		},
+		{
+			name:           "large response",
+			cfg:            config.CompressionConfig{Enabled: true},
+			acceptEncoding: "gzip, deflate",
+			body:           large,
+			wantGzip:       true,
+		},
+		{
+			name:           "small response",
+			cfg:            config.CompressionConfig{Enabled: true},
+			acceptEncoding: "gzip",
+			body:           "small",
+		},
+		{
+			name:           "custom min length",
+			cfg:            config.CompressionConfig{Enabled: true, MinLength: 4},
+			acceptEncoding: "gzip",
+			body:           "small",
+			wantGzip:       true,
+		},
+		{
+			name: "gzip not accepted",
+			cfg:  config.CompressionConfig{Enabled: true},
+			body: large,
+		},
+		{
+			name:           "gzip refused",
+			cfg:            config.CompressionConfig{Enabled: true},
+			acceptEncoding: "gzip;q=0, identity",
+			body:           large,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var status int
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				c.Next()
+				status = c.Writer.Status()
+			})
+			r.Use(compressionMiddleware(tt.cfg))
+			r.GET("/test", func(c *gin.Context) {
+				c.Header("Content-Length", "1")
+				c.String(http.StatusCreated, tt.body)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			if tt.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.Equal(t, http.StatusCreated, status)
+
+			body := w.Body.String()
+			if tt.wantGzip {
+				assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+				assert.Empty(t, w.Header().Get("Content-Length"))
+				gz, err := gzip.NewReader(w.Body)
+				require.NoError(t, err)
+				data, err := io.ReadAll(gz)
+				require.NoError(t, err)
+				body = string(data)
+			} else {
+				assert.Empty(t, w.Header().Get("Content-Encoding"))
+			}
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestCompressionMiddleware_Stream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	next := make(chan struct{})
+	r := gin.New()
+	r.Use(compressionMiddleware(config.CompressionConfig{Enabled: true}))
+	r.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		c.Status(http.StatusOK)
+		for _, event := range []string{"first", "second"} {
+			_, _ = io.WriteString(c.Writer, "data: "+event+"\n\n")
+			c.Writer.Flush()
+			<-next
+		}
+	})
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/stream", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	// Each event is readable before the handler writes the next one.
+	gz, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err)
+	events := bufio.NewReader(gz)
+	for _, event := range []string{"first", "second"} {
+		line, err := events.ReadString('\n')
+		require.NoError(t, err)
+		assert.Equal(t, "data: "+event+"\n", line)
+		_, err = events.ReadString('\n')
+		require.NoError(t, err)
+		next <- struct{}{}
+	}
+	rest, err := io.ReadAll(events)
+	require.NoError(t, err)
+	assert.Empty(t, rest)
+}
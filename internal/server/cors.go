@@ -0,0 +1,88 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodOptions}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", requestid.Header}
+	// corsExposedHeaders are the response headers scripts may read.
+	corsExposedHeaders = []string{requestid.Header, "Retry-After", servedModelHeader}
+)
+
+// corsMiddleware applies the CORS policy to the requests under pathPrefix and
+// answers their preflight requests. It does nothing when no origin is allowed.
+// With credentials allowed, the request origin is echoed back instead of "*".
+func corsMiddleware(cfg config.CORSConfig, pathPrefix string) gin.HandlerFunc {
+	if len(cfg.AllowedOrigins) == 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+
+	allowAnyOrigin := false
+	origins := make(map[string]struct{}, len(cfg.AllowedOrigins))
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			allowAnyOrigin = true
+		}
+		origins[origin] = struct{}{}
+	}
+
+	methods := cfg.AllowedMethods
+	if len(methods) == 0 {
+		methods = defaultCORSMethods
+	}
+	headers := cfg.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+	exposeHeaders := strings.Join(corsExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(cfg.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" || !strings.HasPrefix(c.Request.URL.Path, pathPrefix) {
+			c.Next()
+			return
+		}
+
+		h := c.Writer.Header()
+		h.Add("Vary", "Origin")
+		if _, ok := origins[origin]; !ok && !allowAnyOrigin {
+			c.Next()
+			return
+		}
+
+		if allowAnyOrigin && !cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Allow-Methods", allowMethods)
+			h.Set("Access-Control-Allow-Headers", allowHeaders)
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		h.Set("Access-Control-Expose-Headers", exposeHeaders)
+		c.Next()
+	}
+}
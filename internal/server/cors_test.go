@@ -0,0 +1,121 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		cfg         config.CORSConfig
+		method      string
+		path        string
+		origin      string
+		preflight   bool
+		wantStatus  int
+		wantOrigin  string
+		wantMethods string
+		wantMaxAge  string
+		wantCreds   string
+	}{
+		{
+			name:       "disabled without allowed origins",
+			method:     http.MethodPost,
+			path:       "/v1/chat/completions",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "allowed origin",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}},
+			method:     http.MethodPost,
+			path:       "/v1/chat/completions",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com",
+		},
+		{
+			name:       "disallowed origin",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}},
+			method:     http.MethodPost,
+			path:       "/v1/chat/completions",
+			origin:     "https://evil.example.com",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "wildcard origin",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"*"}},
+			method:     http.MethodPost,
+			path:       "/v1/chat/completions",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:       "wildcard origin with credentials echoes the origin",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:     http.MethodPost,
+			path:       "/v1/chat/completions",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://app.example.com",
+			wantCreds:  "true",
+		},
+		{
+			name:        "preflight",
+			cfg:         config.CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, MaxAge: 10 * time.Minute},
+			method:      http.MethodOptions,
+			path:        "/v1/chat/completions",
+			origin:      "https://app.example.com",
+			preflight:   true,
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "https://app.example.com",
+			wantMethods: "GET, POST, OPTIONS",
+			wantMaxAge:  "600",
+		},
+		{
+			name:       "outside of the prefix",
+			cfg:        config.CORSConfig{AllowedOrigins: []string{"*"}},
+			method:     http.MethodGet,
+			path:       "/healthz",
+			origin:     "https://app.example.com",
+			wantStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(corsMiddleware(tt.cfg, "/v1/"))
+			r.POST("/v1/chat/completions", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+			r.GET("/healthz", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("Origin", tt.origin)
+			if tt.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, tt.wantOrigin, w.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, tt.wantMethods, w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, tt.wantMaxAge, w.Header().Get("Access-Control-Max-Age"))
+			assert.Equal(t, tt.wantCreds, w.Header().Get("Access-Control-Allow-Credentials"))
+		})
+	}
+}
@@ -1,32 +1,175 @@
 package server
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
 	"github.com/dmitrii/llm-gateway/internal/proxy"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+var responseBytesTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_gateway_response_bytes_total",
+		Help: "Total bytes of the chat completions sent to clients, before compression",
+	},
+	[]string{"model", "provider"},
+)
+
+func init() {
+	prometheus.MustRegister(responseBytesTotal)
+}
+
+// providerOverrideHeader pins a chat completion to a provider, see
+// config.ServerConfig.AllowProviderOverride.
+const providerOverrideHeader = "X-Provider-Override"
+
+// servedModelHeader reports the model that served a chat completion as
+// <provider ID>/<upstream model name>, see config.ServerConfig.ExposeServedModel.
+const servedModelHeader = "X-Served-Model"
+
 type ProxyHandler struct {
-	proxy *proxy.Proxy
+	// mu guards proxy, which is replaced by config reloads, see Reloader.
+	mu     sync.RWMutex
+	proxy  *servedProxy
+	limits messageLimits
+	// allowProviderOverride enables the provider override header.
+	allowProviderOverride bool
+	// exposeUpstreamModel keeps the upstream model name in chat completions.
+	exposeUpstreamModel bool
+	// exposeServedModel enables the served model header.
+	exposeServedModel bool
+	// streamKeepalive is the interval of the pings of idle streams, disabled when 0.
+	streamKeepalive time.Duration
 }
 
-func NewProxyHandler(proxy *proxy.Proxy) *ProxyHandler {
-	return &ProxyHandler{
-		proxy: proxy,
+func NewProxyHandler(proxy *proxy.Proxy, cfg config.ServerConfig) *ProxyHandler {
+	h := &ProxyHandler{
+		limits: messageLimits{
+			maxMessages: cfg.MaxMessages,
+			maxChars:    cfg.MaxMessageChars,
+		},
+		allowProviderOverride: cfg.AllowProviderOverride,
+		exposeUpstreamModel:   cfg.ExposeUpstreamModel,
+		exposeServedModel:     cfg.ExposeServedModel,
+		streamKeepalive:       cfg.StreamKeepaliveInterval,
 	}
+	h.setProxy(proxy)
+	return h
+}
+
+// servedProxy is a proxy of the handler with the requests it serves.
+type servedProxy struct {
+	proxy    *proxy.Proxy
+	requests sync.WaitGroup
+}
+
+// setProxy makes the handler send the requests it receives from now on to
+// proxy. It returns a function waiting for the requests sent to the previous
+// proxy to finish and closing it.
+func (p *ProxyHandler) setProxy(proxy *proxy.Proxy) (retire func()) {
+	p.mu.Lock()
+	previous := p.proxy
+	p.proxy = &servedProxy{proxy: proxy}
+	p.mu.Unlock()
+
+	return func() {
+		if previous == nil {
+			return
+		}
+		previous.requests.Wait()
+		if err := previous.proxy.Close(); err != nil {
+			slog.Error("Failed to close the replaced proxy", "error", err)
+		}
+	}
+}
+
+// currentProxy returns the proxy the requests are sent to.
+func (p *ProxyHandler) currentProxy() *proxy.Proxy {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.proxy.proxy
+}
+
+// useProxy returns the proxy to send a request to, and a function to call once
+// the request is done with it.
+func (p *ProxyHandler) useProxy() (*proxy.Proxy, func()) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	served := p.proxy
+	served.requests.Add(1)
+	return served.proxy, served.requests.Done
 }
 
 // FindPets implements all the handlers in the ServerInterface
 func (p *ProxyHandler) CreateChatCompletion(c *gin.Context) {
+	// Deferred so that a panic recovered by the recovery middleware doesn't leak the gauge.
+	inFlightRequests.Inc()
+	defer inFlightRequests.Dec()
+
 	var req api.ChatCompletionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		HandleError(c, bindError(err))
+		return
+	}
+	if err := validateChatCompletion(&req); err != nil {
+		HandleError(c, err)
+		return
+	}
+	if err := p.limits.check(req.Messages); err != nil {
+		HandleError(c, err)
+		return
+	}
+	if id := c.GetHeader(providerOverrideHeader); id != "" && p.allowProviderOverride {
+		c.Request = c.Request.WithContext(proxy.WithProviderOverride(c.Request.Context(), id))
+	}
+
+	trackOutcome(c)
+
+	if req.Stream != nil && *req.Stream {
+		streamRequestTimeout(c)
+		p.streamChatCompletion(c, req)
+		return
+	}
+
+	llmProxy, done := p.useProxy()
+	defer done()
+	resp, err := llmProxy.ChatCompletionsHandler(c, req)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+	resp.Model = p.responseModel(c, resp.Model)
+	p.setServedModelHeader(c)
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		HandleError(c, fmt.Errorf("failed to marshal chat completion: %w", err))
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	recordResponseBytes(c, len(data))
+}
+
+func (p *ProxyHandler) CreateEmbeddings(c *gin.Context) {
+	var req api.EmbeddingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, bindError(err))
 		return
 	}
 
-	resp, err := p.proxy.ChatCompletionsHandler(c, req)
+	trackOutcome(c)
+	llmProxy, done := p.useProxy()
+	defer done()
+	resp, err := llmProxy.EmbeddingsHandler(c, req)
 	if err != nil {
 		HandleError(c, err)
 		return
@@ -34,3 +177,154 @@ func (p *ProxyHandler) CreateChatCompletion(c *gin.Context) {
 
 	c.JSON(http.StatusOK, resp)
 }
+
+func (p *ProxyHandler) CreateModeration(c *gin.Context) {
+	var req api.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		HandleError(c, bindError(err))
+		return
+	}
+
+	trackOutcome(c)
+	llmProxy, done := p.useProxy()
+	defer done()
+	resp, err := llmProxy.ModerationsHandler(c, req)
+	if err != nil {
+		HandleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// outcomeKey is the gin context key of the proxy.Outcome of a request.
+const outcomeKey = "llm_gateway.outcome"
+
+// trackOutcome makes the proxy collect the outcome of the request for the access log.
+func trackOutcome(c *gin.Context) {
+	ctx, outcome := proxy.WithOutcome(c.Request.Context())
+	c.Request = c.Request.WithContext(ctx)
+	c.Set(outcomeKey, outcome)
+}
+
+// recordResponseBytes counts n bytes of the chat completion of c as sent by the
+// model and provider that served it, or by the requested model with no
+// provider when none did, as when a stream fails midway.
+func recordResponseBytes(c *gin.Context, n int) {
+	model, provider := "", ""
+	if v, ok := c.Get(outcomeKey); ok {
+		outcome := v.(*proxy.Outcome)
+		model, provider = outcome.Model, outcome.Provider
+		if provider != "" {
+			model = outcome.ServedModel
+		}
+	}
+	responseBytesTotal.WithLabelValues(model, provider).Add(float64(n))
+}
+
+// setServedModelHeader sets the served model header of the chat completion of
+// c, once the model serving it is known, if enabled.
+func (p *ProxyHandler) setServedModelHeader(c *gin.Context) {
+	if !p.exposeServedModel {
+		return
+	}
+	if v, ok := c.Get(outcomeKey); ok {
+		if outcome := v.(*proxy.Outcome); outcome.Provider != "" {
+			c.Header(servedModelHeader, outcome.Provider+"/"+outcome.UpstreamModel)
+		}
+	}
+}
+
+// responseModel returns the model name of the chat completion responses of
+// the request: the requested model ID, aliases resolved, unless the upstream
+// name is exposed.
+func (p *ProxyHandler) responseModel(c *gin.Context, upstream string) string {
+	if p.exposeUpstreamModel {
+		return upstream
+	}
+	if v, ok := c.Get(outcomeKey); ok {
+		if outcome := v.(*proxy.Outcome); outcome.Model != "" {
+			return outcome.Model
+		}
+	}
+	return upstream
+}
+
+func (p *ProxyHandler) ListModels(c *gin.Context) {
+	llmProxy, done := p.useProxy()
+	defer done()
+	c.JSON(http.StatusOK, llmProxy.ListModelsHandler())
+}
+
+// streamChatCompletion writes the completion as server-sent events, one
+// `data: {chunk}` frame per chunk, terminated by `data: [DONE]`. Once started,
+// an idle stream is pinged with SSE comments every streamKeepalive.
+func (p *ProxyHandler) streamChatCompletion(c *gin.Context, req api.ChatCompletionRequest) {
+	started := false
+	var pings *keepalive
+	defer func() { pings.close() }()
+	// written sums the bytes of the frames as they are flushed. They are
+	// counted once the stream ends, when the provider that served it is known.
+	written := 0
+	defer func() {
+		if written > 0 {
+			recordResponseBytes(c, written)
+		}
+	}()
+	llmProxy, done := p.useProxy()
+	defer done()
+	err := llmProxy.ChatCompletionsStreamHandler(c, req, func(ctx context.Context, chunk *api.ChatCompletionChunk) error {
+		// Stop the upstream as soon as the client is gone.
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !started {
+			c.Header("Content-Type", "text/event-stream")
+			c.Header("Cache-Control", "no-cache")
+			c.Header("Connection", "keep-alive")
+			p.setServedModelHeader(c)
+			c.Status(http.StatusOK)
+			started = true
+			pings = startKeepalive(c, p.streamKeepalive)
+		}
+		chunk.Model = p.responseModel(c, chunk.Model)
+		var err error
+		pings.write(func() {
+			var n int
+			n, err = writeSSEData(c, chunk)
+			written += n
+		})
+		return err
+	})
+	pings.close()
+	if err != nil {
+		if !started {
+			HandleError(c, err)
+			return
+		}
+		// Headers are already sent, so the error can only be reported in-stream.
+		slog.ErrorContext(c, "Streaming chat completion failed", "error", err)
+		n, _ := writeSSEData(c, newErrorResponse(asError(err)))
+		written += n
+		return
+	}
+
+	n, _ := fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+	written += n
+	c.Writer.Flush()
+}
+
+// writeSSEData writes v as a server-sent event and flushes it, returning the
+// number of bytes written.
+func writeSSEData(c *gin.Context, v any) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal stream chunk: %w", err)
+	}
+	n, err := fmt.Fprintf(c.Writer, "data: %s\n\n", data)
+	if err != nil {
+		return n, fmt.Errorf("failed to write stream chunk: %w", err)
+	}
+	c.Writer.Flush()
+	return n, nil
+}
@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateChatCompletion_InFlightGaugeOnPanic(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Without a proxy the handler panics once the request is bound.
+	handler := NewProxyHandler(nil, config.ServerConfig{})
+	r := gin.New()
+	r.Use(gin.Recovery())
+	r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+	before := testutil.ToFloat64(inFlightRequests)
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+		strings.NewReader(`{"model":"gpt-4","messages":[{"role":"user","content":"hi"}]}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, before, testutil.ToFloat64(inFlightRequests))
+}
+
+func TestCreateChatCompletion_ProviderOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "dummy", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+		},
+		Models: []*config.ModelConfig{{ID: "dummy-model", Name: "dummy", Provider: "dummy"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		allowOverride  bool
+		override       string
+		expectedStatus int
+	}{
+		{name: "configured provider", allowOverride: true, override: "dummy", expectedStatus: http.StatusOK},
+		{name: "unknown provider", allowOverride: true, override: "missing", expectedStatus: http.StatusBadRequest},
+		{name: "ignored when disabled", allowOverride: false, override: "missing", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyHandler(llmProxy, config.ServerConfig{AllowProviderOverride: tt.allowOverride})
+			r := gin.New()
+			r.ContextWithFallback = true
+			r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"dummy-model","messages":[{"role":"user","content":"hi"}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(providerOverrideHeader, tt.override)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestCreateChatCompletion_ResponseModel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "dummy", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+		},
+		Models:  []*config.ModelConfig{{ID: "dummy-model", Name: "dummy-upstream", Provider: "dummy"}},
+		Aliases: map[string]string{"smart": "dummy-model"},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		expose   bool
+		stream   bool
+		expected string
+	}{
+		{name: "requested model ID", expected: "dummy-model"},
+		{name: "requested model ID of the chunks", stream: true, expected: "dummy-model"},
+		{name: "upstream name exposed", expose: true, expected: "dummy-upstream"},
+		{name: "upstream name exposed in the chunks", expose: true, stream: true, expected: "dummy-upstream"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyHandler(llmProxy, config.ServerConfig{ExposeUpstreamModel: tt.expose})
+			r := gin.New()
+			r.ContextWithFallback = true
+			r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+			body := fmt.Sprintf(`{"model":"smart","stream":%t,"messages":[{"role":"user","content":"hi"}]}`, tt.stream)
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			if !tt.stream {
+				var resp api.ChatCompletionResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, tt.expected, resp.Model)
+				return
+			}
+			var ids []string
+			for _, line := range strings.Split(w.Body.String(), "\n") {
+				data, ok := strings.CutPrefix(line, "data: ")
+				if !ok || data == "[DONE]" {
+					continue
+				}
+				var chunk api.ChatCompletionChunk
+				require.NoError(t, json.Unmarshal([]byte(data), &chunk))
+				assert.Equal(t, tt.expected, chunk.Model)
+				ids = append(ids, chunk.Id)
+			}
+			require.NotEmpty(t, ids)
+			for _, id := range ids {
+				assert.Equal(t, ids[0], id)
+			}
+		})
+	}
+}
+
+func TestCreateChatCompletion_ResponseBytes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "bytes-provider", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+		},
+		Models: []*config.ModelConfig{{ID: "bytes-model", Name: "dummy", Provider: "bytes-provider"}},
+	})
+	require.NoError(t, err)
+	handler := NewProxyHandler(llmProxy, config.ServerConfig{})
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream %t", stream), func(t *testing.T) {
+			counter := responseBytesTotal.WithLabelValues("bytes-model", "bytes-provider")
+			before := testutil.ToFloat64(counter)
+
+			body := fmt.Sprintf(`{"model":"bytes-model","stream":%t,"messages":[{"role":"user","content":"hi"}]}`, stream)
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			assert.Equal(t, before+float64(w.Body.Len()), testutil.ToFloat64(counter))
+		})
+	}
+}
+
+func TestCreateChatCompletion_ServedModelHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "openai", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIKey: "test-key", APIUrl: failing.URL}},
+			{ID: "dummy", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+		},
+		Models: []*config.ModelConfig{
+			{ID: "primary", Name: "gpt-4o", Provider: "openai", Fallback: []string{"backup"}},
+			{ID: "backup", Name: "dummy-upstream", Provider: "dummy"},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		expose   bool
+		stream   bool
+		expected string
+	}{
+		{name: "fallback", expose: true, expected: "dummy/dummy-upstream"},
+		{name: "fallback streamed", expose: true, stream: true, expected: "dummy/dummy-upstream"},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyHandler(llmProxy, config.ServerConfig{ExposeServedModel: tt.expose})
+			r := gin.New()
+			r.ContextWithFallback = true
+			r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+			body := fmt.Sprintf(`{"model":"primary","stream":%t,"messages":[{"role":"user","content":"hi"}]}`, tt.stream)
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			assert.Equal(t, tt.expected, w.Header().Get(servedModelHeader))
+			if !tt.stream {
+				var resp api.ChatCompletionResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				// The response keeps the requested model ID.
+				assert.Equal(t, "primary", resp.Model)
+			}
+		})
+	}
+}
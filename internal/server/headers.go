@@ -0,0 +1,26 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/gin-gonic/gin"
+)
+
+// forwardHeadersMiddleware stores the client request headers named in names in
+// the request context, for the upstream clients to pass them on.
+func forwardHeadersMiddleware(names []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		forwarded := http.Header{}
+		for _, name := range names {
+			if values := c.Request.Header.Values(name); len(values) > 0 {
+				forwarded[http.CanonicalHeaderKey(name)] = values
+			}
+		}
+		if len(forwarded) > 0 {
+			c.Request = c.Request.WithContext(client.WithForwardedHeaders(c.Request.Context(), forwarded))
+		}
+
+		c.Next()
+	}
+}
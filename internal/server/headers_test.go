@@ -0,0 +1,47 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/client"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForwardHeadersMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var upstream http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstream = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(forwardHeadersMiddleware([]string{"x-tenant-id", "Authorization"}))
+	r.GET("/v1/models", func(c *gin.Context) {
+		req, err := http.NewRequestWithContext(c, http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer provider-key")
+		resp, err := client.New(srv.Client()).Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	req.Header.Set("X-Tenant-ID", "tenant-1")
+	req.Header.Set("X-Other", "not forwarded")
+	req.Header.Set("Authorization", "Bearer gateway-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "tenant-1", upstream.Get("X-Tenant-ID"))
+	assert.Empty(t, upstream.Get("X-Other"))
+	assert.Equal(t, "Bearer provider-key", upstream.Get("Authorization"))
+}
@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+)
+
+const probeTimeout = 2 * time.Second
+
+const (
+	providerStatusUp        = "up"
+	providerStatusDown      = "down"
+	providerStatusNotProbed = "not_probed"
+)
+
+// ProviderStatus is the last-known status of a provider.
+type ProviderStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// ReadinessResponse is the body returned by the /readyz endpoint.
+type ReadinessResponse struct {
+	Ready     bool             `json:"ready"`
+	Providers []ProviderStatus `json:"providers"`
+}
+
+// readinessChecker reports whether the gateway is ready to serve traffic once
+// it is created; StartupHandler answers /readyz until then.
+type readinessChecker struct {
+	cfg        config.ReadinessConfig
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	providers []*config.ProviderConfig
+	checkedAt time.Time
+	last      ReadinessResponse
+}
+
+func newReadinessChecker(cfg config.ReadinessConfig, providers []*config.ProviderConfig) *readinessChecker {
+	return &readinessChecker{
+		cfg:        cfg,
+		providers:  providers,
+		httpClient: &http.Client{Timeout: probeTimeout},
+	}
+}
+
+// setProviders replaces the providers checked, as on config reloads, dropping
+// the cached result.
+func (rc *readinessChecker) setProviders(providers []*config.ProviderConfig) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.providers = providers
+	rc.checkedAt = time.Time{}
+}
+
+// check returns the readiness result, probing the providers if the cached one expired.
+func (rc *readinessChecker) check(ctx context.Context) ReadinessResponse {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if !rc.checkedAt.IsZero() && time.Since(rc.checkedAt) < rc.cfg.CacheTTL {
+		return rc.last
+	}
+
+	res := ReadinessResponse{Ready: true, Providers: make([]ProviderStatus, len(rc.providers))}
+	for i, pCfg := range rc.providers {
+		status := ProviderStatus{ID: pCfg.ID, Status: providerStatusNotProbed}
+		if pCfg.Provider == config.ProviderDummy {
+			status.Status = providerStatusUp
+		} else if url := providerBaseURL(pCfg); rc.cfg.ProbeProviders && url != "" {
+			if err := rc.probe(ctx, url); err != nil {
+				status.Status = providerStatusDown
+				status.Error = probeErrorMessage(err)
+				slog.WarnContext(ctx, "Provider readiness probe failed", "provider", pCfg.ID, "error", err)
+				res.Ready = false
+			} else {
+				status.Status = providerStatusUp
+			}
+		}
+		res.Providers[i] = status
+	}
+
+	rc.checkedAt = time.Now()
+	rc.last = res
+	return res
+}
+
+// probe checks that the URL is reachable, see probeURL.
+func (rc *readinessChecker) probe(ctx context.Context, url string) error {
+	return probeURL(ctx, rc.httpClient, url)
+}
+
+// probeURL checks that the URL answers as an API does. The base URLs are probed
+// without credentials, so besides 2xx, the 401, 403, 404 and 405 of a healthy
+// API count as up; other statuses, 429 and 5xx included, as down.
+func probeURL(ctx context.Context, httpClient *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &probeStatusError{status: resp.StatusCode}
+	}
+	return nil
+}
+
+// probeStatusError is the error of a probe answered with an unexpected status.
+type probeStatusError struct {
+	status int
+}
+
+func (e *probeStatusError) Error() string {
+	return fmt.Sprintf("unexpected status %d", e.status)
+}
+
+// probeErrorMessage returns the message of a failed probe reported by the
+// unauthenticated /readyz and /status endpoints. The errors themselves name the
+// provider URLs and addresses, so they are only logged.
+func probeErrorMessage(err error) string {
+	var statusErr *probeStatusError
+	var netErr net.Error
+	switch {
+	case errors.As(err, &statusErr):
+		return statusErr.Error()
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "unreachable"
+	}
+}
+
+// providerBaseURL returns the base URL of the provider, or "" if it has none.
+func providerBaseURL(pCfg *config.ProviderConfig) string {
+	switch c := pCfg.Config.(type) {
+	case *config.OpenAIProviderConfig:
+		return c.APIUrl
+	case *config.AzureOpenAIProviderConfig:
+		return c.APIUrl
+	case *config.AnthropicProviderConfig:
+		return c.APIUrl
+	case *config.OllamaProviderConfig:
+		return c.APIUrl
+	case *config.HuggingFaceProviderConfig:
+		if c.Mode == config.HuggingFaceModeInferenceEndpoints {
+			return c.EndpointURL
+		}
+		return c.APIUrl
+	case *config.MistralProviderConfig:
+		return c.APIUrl
+	case *config.CohereProviderConfig:
+		return c.APIUrl
+	case *config.OpenRouterProviderConfig:
+		return c.APIUrl
+	default:
+		return ""
+	}
+}
+
+func healthzHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func (rc *readinessChecker) readyzHandler(c *gin.Context) {
+	res := rc.check(c.Request.Context())
+	status := http.StatusOK
+	if !res.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, res)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderBaseURL(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.ProviderConfigInterface
+		want string
+	}{
+		{
+			name: "huggingface serverless",
+			cfg:  &config.HuggingFaceProviderConfig{APIUrl: "https://router.huggingface.co", EndpointURL: "https://tgi.internal"},
+			want: "https://router.huggingface.co",
+		},
+		{
+			name: "huggingface inference endpoints",
+			cfg:  &config.HuggingFaceProviderConfig{Mode: config.HuggingFaceModeInferenceEndpoints, APIUrl: "https://router.huggingface.co", EndpointURL: "https://tgi.internal"},
+			want: "https://tgi.internal",
+		},
+		{
+			name: "gemini",
+			cfg:  &config.GeminiProviderConfig{},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, providerBaseURL(&config.ProviderConfig{ID: "hf", Config: tt.cfg}))
+		})
+	}
+}
+
+func TestReadinessChecker_ProbesTGIEndpoint(t *testing.T) {
+	var probed []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probed = append(probed, r.URL.Path)
+	}))
+	t.Cleanup(srv.Close)
+
+	rc := newReadinessChecker(config.ReadinessConfig{ProbeProviders: true}, []*config.ProviderConfig{{
+		ID:       "tgi",
+		Provider: config.ProviderHuggingFace,
+		Config: &config.HuggingFaceProviderConfig{
+			Mode:        config.HuggingFaceModeInferenceEndpoints,
+			APIUrl:      "http://127.0.0.1:1",
+			EndpointURL: srv.URL + "/tgi",
+		},
+	}})
+
+	res := rc.check(context.Background())
+	assert.True(t, res.Ready)
+	assert.Equal(t, []ProviderStatus{{ID: "tgi", Status: providerStatusUp}}, res.Providers)
+	assert.Equal(t, []string{"/tgi"}, probed)
+}
@@ -3,17 +3,83 @@ package server
 import (
 	errs "errors"
 	"log/slog"
+	"net/http"
 
+	"github.com/dmitrii/llm-gateway/api"
 	"github.com/dmitrii/llm-gateway/internal/errors"
 	"github.com/gin-gonic/gin"
 )
 
+// errorDetailsKey is set on the gin context of requests whose error responses
+// carry the provider errors, see errorDetailsMiddleware.
+const errorDetailsKey = "llm_gateway.error_details"
+
 func HandleError(c *gin.Context, err error) {
+	if timedOut(c) {
+		// Whatever failed, it was cut short by the request timeout.
+		err = errors.ErrGatewayTimeout.WithMessage("request timed out").WithDetails(err)
+	}
+	typedError := asError(err)
+	slog.ErrorContext(c, "Failed to execute request", "status", typedError.Status, "message", typedError.Message, "details", typedError.Details)
+	resp := newErrorResponse(typedError)
+	if c.GetBool(errorDetailsKey) {
+		resp.Error.Details = providerErrorDetails(typedError)
+	}
+	c.JSON(typedError.Status, resp)
+}
+
+// errorDetailsMiddleware makes HandleError add the provider errors to the
+// error responses.
+func errorDetailsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorDetailsKey, true)
+	}
+}
+
+// providerErrorDetails returns the provider errors wrapped by err, or nil if
+// there are none.
+func providerErrorDetails(err errors.Error) *[]api.ProviderError {
+	var providerErrs errors.ProviderErrors
+	if err.Details == nil || !errs.As(err.Details, &providerErrs) {
+		return nil
+	}
+	details := make([]api.ProviderError, len(providerErrs))
+	for i, pe := range providerErrs {
+		details[i] = api.ProviderError{Provider: pe.Provider, Model: pe.Model, Message: pe.Err.Error()}
+	}
+	return &details
+}
+
+// asError returns the errors.Error wrapped by err, or an internal error wrapping err.
+func asError(err error) errors.Error {
 	var typedError errors.Error
 	if errs.As(err, &typedError) {
-	} else {
-		typedError = errors.ErrInternal.WithDetails(err)
+		return typedError
+	}
+	return errors.ErrInternal.WithDetails(err)
+}
+
+// newErrorResponse converts err to the error format of the OpenAI API.
+// Details are only logged, never sent to the client.
+func newErrorResponse(err errors.Error) api.ErrorResponse {
+	var resp api.ErrorResponse
+	resp.Error.Message = err.Message
+	resp.Error.Type = errorType(err.Status)
+	return resp
+}
+
+// errorType maps an HTTP status to an OpenAI error type.
+func errorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status >= http.StatusInternalServerError:
+		return "internal_error"
+	default:
+		return "invalid_request_error"
 	}
-	slog.Error("Failed to execute request", "status", typedError.Status, "message", typedError.Message, "details", typedError.Details)
-	c.JSON(typedError.Status, typedError)
 }
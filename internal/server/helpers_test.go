@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandleError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "not found",
+			err:            errors.ErrNotFound.WithMessage("model not found in config"),
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"error":{"message":"model not found in config","type":"invalid_request_error","param":null,"code":null}}`,
+		},
+		{
+			name:           "unauthorized",
+			err:            errors.ErrUnauthorized,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"error":{"message":"Invalid API key","type":"authentication_error","param":null,"code":null}}`,
+		},
+		{
+			name:           "rate limited",
+			err:            errors.ErrRateLimited,
+			expectedStatus: http.StatusTooManyRequests,
+			expectedBody:   `{"error":{"message":"Rate limit exceeded","type":"rate_limit_error","param":null,"code":null}}`,
+		},
+		{
+			name:           "untyped error",
+			err:            fmt.Errorf("boom"),
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"error":{"message":"Internal server error","type":"internal_error","param":null,"code":null}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+			HandleError(c, tt.err)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestHandleError_ProviderErrorDetails(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	err := errors.ErrInternal.WithMessage("failed to get completion from any provider").WithDetails(errors.ProviderErrors{
+		{Provider: "provider1", Model: "gpt-4o", Err: errors.ErrUnavailable.WithMessage("overloaded")},
+		{Provider: "provider2", Model: "llama3", Err: fmt.Errorf("connection refused")},
+	})
+
+	t.Run("suppressed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+		HandleError(c, err)
+
+		assert.JSONEq(t, `{"error":{"message":"failed to get completion from any provider","type":"internal_error","param":null,"code":null}}`, w.Body.String())
+	})
+
+	t.Run("exposed", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		errorDetailsMiddleware()(c)
+
+		HandleError(c, err)
+
+		assert.JSONEq(t, `{"error":{"message":"failed to get completion from any provider","type":"internal_error","param":null,"code":null,"details":[
+			{"provider":"provider1","model":"gpt-4o","message":"overloaded"},
+			{"provider":"provider2","model":"llama3","message":"connection refused"}
+		]}}`, w.Body.String())
+	})
+}
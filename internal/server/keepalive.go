@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ssePing is the SSE comment written to idle streams, which clients ignore.
+const ssePing = ": ping\n\n"
+
+// keepalive pings a stream that nothing was written to for an interval, so
+// that intermediaries don't close its connection while the model generates
+// the next chunk. A nil keepalive writes no pings.
+type keepalive struct {
+	c        *gin.Context
+	interval time.Duration
+	// mu serializes the pings and the frames of the stream.
+	mu        sync.Mutex
+	lastWrite time.Time
+	stop      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// startKeepalive starts pinging the stream of c every interval it is idle,
+// until close. It returns nil when interval is 0.
+func startKeepalive(c *gin.Context, interval time.Duration) *keepalive {
+	if interval <= 0 {
+		return nil
+	}
+	k := &keepalive{
+		c:         c,
+		interval:  interval,
+		lastWrite: time.Now(),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	go k.run()
+	return k
+}
+
+func (k *keepalive) run() {
+	defer close(k.stopped)
+
+	timer := time.NewTimer(k.interval)
+	defer timer.Stop()
+	for {
+		select {
+		case <-k.stop:
+			return
+		case <-k.c.Done():
+			return
+		case <-timer.C:
+		}
+
+		k.mu.Lock()
+		if time.Since(k.lastWrite) >= k.interval {
+			if _, err := fmt.Fprint(k.c.Writer, ssePing); err != nil {
+				k.mu.Unlock()
+				return
+			}
+			k.c.Writer.Flush()
+			k.lastWrite = time.Now()
+		}
+		next := k.interval - time.Since(k.lastWrite)
+		k.mu.Unlock()
+		timer.Reset(next)
+	}
+}
+
+// write runs fn, which writes a frame to the stream, between two pings.
+func (k *keepalive) write(fn func()) {
+	if k == nil {
+		fn()
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	fn()
+	k.lastWrite = time.Now()
+}
+
+// close stops the pings and waits for the last one to be written, before the
+// stream ends.
+func (k *keepalive) close() {
+	if k == nil {
+		return
+	}
+	k.closeOnce.Do(func() { close(k.stop) })
+	<-k.stopped
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamChatCompletion_Keepalive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The upstream takes a while to generate the second chunk.
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"hi\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer upstream.Close()
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			{ID: "openai", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIKey: "test-key", APIUrl: upstream.URL}},
+		},
+		Models: []*config.ModelConfig{{ID: "chat", Name: "gpt-4o", Provider: "openai"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		interval      time.Duration
+		expectedPings bool
+	}{
+		{name: "enabled", interval: 10 * time.Millisecond, expectedPings: true},
+		{name: "disabled"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewProxyHandler(llmProxy, config.ServerConfig{StreamKeepaliveInterval: tt.interval})
+			r := gin.New()
+			r.ContextWithFallback = true
+			r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+				strings.NewReader(`{"model":"chat","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			require.Equal(t, http.StatusOK, w.Code)
+
+			body := w.Body.String()
+			require.True(t, strings.HasSuffix(body, "data: [DONE]\n\n"), body)
+			if !tt.expectedPings {
+				assert.NotContains(t, body, ssePing)
+				return
+			}
+			// The pings are sent between the two chunks, as whole frames.
+			first := strings.Index(body, `"content":"hi"`)
+			ping := strings.Index(body, "\n\n"+ssePing)
+			last := strings.Index(body, `"finish_reason":"stop"`)
+			assert.Less(t, first, ping)
+			assert.Less(t, ping, last)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	errs "errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// bodyLimitMiddleware rejects request bodies larger than maxBytes with a 413.
+// Bodies without a Content-Length are cut off while they are read, see bindError.
+// It is a no-op when maxBytes is 0.
+func bodyLimitMiddleware(maxBytes int64) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if maxBytes <= 0 {
+			return
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			HandleError(c, errors.ErrRequestTooLarge)
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+	}
+}
+
+// jsonContentTypeMiddleware rejects POST requests whose Content-Type isn't
+// application/json, parameters such as the charset aside, with a 415.
+func jsonContentTypeMiddleware() func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost {
+			return
+		}
+
+		contentType := c.GetHeader("Content-Type")
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil && mediaType == "application/json" {
+			return
+		}
+		message := "Content-Type must be application/json"
+		if contentType != "" {
+			message += fmt.Sprintf(", got %q", contentType)
+		}
+		HandleError(c, errors.ErrUnsupportedMediaType.WithMessage(message))
+		c.Abort()
+	}
+}
+
+// bindError converts an error decoding the request body to an API error.
+func bindError(err error) errors.Error {
+	var maxBytesErr *http.MaxBytesError
+	if errs.As(err, &maxBytesErr) {
+		return errors.ErrRequestTooLarge.WithDetails(err)
+	}
+	return errors.ErrBadRequest.WithMessage("Invalid request body").WithDetails(err)
+}
+
+// messageLimits caps the messages of a chat completion request. A zero value
+// disables the corresponding limit.
+type messageLimits struct {
+	maxMessages int
+	maxChars    int
+}
+
+// check returns a bad request error if messages exceed the limits.
+// Characters are counted over the text content of all messages.
+func (l messageLimits) check(messages []api.ChatMessage) error {
+	if l.maxMessages > 0 && len(messages) > l.maxMessages {
+		return errors.ErrBadRequest.WithMessage(fmt.Sprintf("Too many messages: %d, at most %d are allowed", len(messages), l.maxMessages))
+	}
+	if l.maxChars <= 0 {
+		return nil
+	}
+
+	chars := 0
+	for _, msg := range messages {
+		chars += messageChars(msg)
+	}
+	if chars > l.maxChars {
+		return errors.ErrBadRequest.WithMessage(fmt.Sprintf("Messages too long: %d characters, at most %d are allowed", chars, l.maxChars))
+	}
+	return nil
+}
+
+// messageChars returns the number of characters of the text content of msg.
+func messageChars(msg api.ChatMessage) int {
+	if msg.Content == nil {
+		return 0
+	}
+	if parts, err := msg.Content.AsChatMessageContent1(); err == nil {
+		chars := 0
+		for _, part := range parts {
+			if part.Text != nil {
+				chars += utf8.RuneCountInString(*part.Text)
+			}
+		}
+		return chars
+	}
+	text, _ := msg.Content.AsChatMessageContent0()
+	return utf8.RuneCountInString(text)
+}
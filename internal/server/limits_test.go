@@ -0,0 +1,166 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	internalerrors "github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	const limit = 64
+	// A JSON body of exactly n bytes.
+	body := func(n int) string {
+		return `{"model":"` + strings.Repeat("a", n-len(`{"model":""}`)) + `"}`
+	}
+
+	tests := []struct {
+		name           string
+		body           string
+		chunked        bool
+		expectedStatus int
+	}{
+		{name: "just under the limit", body: body(limit - 1), expectedStatus: http.StatusOK},
+		{name: "at the limit", body: body(limit), expectedStatus: http.StatusOK},
+		{name: "just over the limit", body: body(limit + 1), expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "just under the limit without content length", body: body(limit - 1), chunked: true, expectedStatus: http.StatusOK},
+		{name: "just over the limit without content length", body: body(limit + 1), chunked: true, expectedStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			bodyLimit := bodyLimitMiddleware(limit)
+			r.POST("/v1/chat/completions", func(c *gin.Context) {
+				bodyLimit(c)
+				if c.IsAborted() {
+					return
+				}
+				var req api.ChatCompletionRequest
+				if err := c.ShouldBindJSON(&req); err != nil {
+					HandleError(c, bindError(err))
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			var reqBody io.Reader = strings.NewReader(tt.body)
+			if tt.chunked {
+				// Hide the length so that the body is only cut off while it is read.
+				reqBody = io.MultiReader(reqBody)
+			}
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", reqBody)
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				var resp api.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Equal(t, internalerrors.ErrRequestTooLarge.Message, resp.Error.Message)
+				assert.Equal(t, "invalid_request_error", resp.Error.Type)
+			}
+		})
+	}
+}
+
+func TestJSONContentTypeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		contentType    string
+		body           string
+		expectedStatus int
+	}{
+		{name: "json", contentType: "application/json", body: `{"model":"m"}`, expectedStatus: http.StatusOK},
+		{name: "json with charset", contentType: "application/json; charset=utf-8", body: `{"model":"m"}`, expectedStatus: http.StatusOK},
+		{name: "form encoded", contentType: "application/x-www-form-urlencoded", body: "model=m", expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "missing", body: `{"model":"m"}`, expectedStatus: http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			contentType := jsonContentTypeMiddleware()
+			r.POST("/v1/chat/completions", func(c *gin.Context) {
+				contentType(c)
+				if c.IsAborted() {
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus != http.StatusOK {
+				var resp api.ErrorResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+				assert.Contains(t, resp.Error.Message, "Content-Type must be application/json")
+				assert.Equal(t, "invalid_request_error", resp.Error.Type)
+			}
+		})
+	}
+}
+
+func TestMessageLimits(t *testing.T) {
+	text := func(s string) api.ChatMessage {
+		content := &api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent0(s))
+		return api.ChatMessage{Role: api.ChatMessageRoleUser, Content: content}
+	}
+	parts := func(texts ...string) api.ChatMessage {
+		contentParts := make([]api.MessageContentPart, len(texts))
+		for i := range texts {
+			contentParts[i].Text = &texts[i]
+		}
+		content := &api.ChatMessage_Content{}
+		require.NoError(t, content.FromChatMessageContent1(contentParts))
+		return api.ChatMessage{Role: api.ChatMessageRoleUser, Content: content}
+	}
+
+	tests := []struct {
+		name     string
+		limits   messageLimits
+		messages []api.ChatMessage
+		wantErr  bool
+	}{
+		{name: "unlimited", messages: []api.ChatMessage{text("hello"), text("world")}},
+		{name: "message count within the limit", limits: messageLimits{maxMessages: 2}, messages: []api.ChatMessage{text("a"), text("b")}},
+		{name: "too many messages", limits: messageLimits{maxMessages: 1}, messages: []api.ChatMessage{text("a"), text("b")}, wantErr: true},
+		{name: "characters within the limit", limits: messageLimits{maxChars: 10}, messages: []api.ChatMessage{text("héllo"), parts("wor", "ld")}},
+		{name: "too many characters", limits: messageLimits{maxChars: 9}, messages: []api.ChatMessage{text("héllo"), parts("wor", "ld")}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.limits.check(tt.messages)
+			if !tt.wantErr {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr internalerrors.Error
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, http.StatusBadRequest, apiErr.Status)
+		})
+	}
+}
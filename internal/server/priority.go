@@ -0,0 +1,35 @@
+package server
+
+import (
+	"fmt"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+)
+
+// priorityHeader picks the priority tier of a request.
+const priorityHeader = "X-Priority"
+
+// priorityMiddleware sets the priority tier of the request in its context: the
+// tier of its API key, else the one of its X-Priority header, else the default
+// one, from the priority tiers of the active config. Requests naming an unknown
+// tier are rejected with a 400; without tiers, requests are left as they are.
+// It must run after authMiddleware, since the tier may come from the
+// authenticated API key.
+func priorityMiddleware(activeConfig func() *config.Config) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		cfg := activeConfig().Server.Priority
+		if len(cfg.Tiers) == 0 {
+			return
+		}
+		tier, ok := cfg.Tier(c.GetString(apiKeyContextKey), c.GetHeader(priorityHeader))
+		if !ok {
+			HandleError(c, errors.ErrBadRequest.WithMessage(fmt.Sprintf("Unknown priority tier %q", tier)))
+			c.Abort()
+			return
+		}
+		c.Request = c.Request.WithContext(proxy.WithPriority(c.Request.Context(), tier))
+	}
+}
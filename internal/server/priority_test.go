@@ -0,0 +1,84 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{Server: config.ServerConfig{Priority: config.PriorityConfig{
+		Tiers:   []string{"interactive", "batch"},
+		APIKeys: map[string][]string{"batch": {"batch-key"}},
+	}}}
+	tests := []struct {
+		name           string
+		cfg            *config.Config
+		apiKey         string
+		header         string
+		expectedStatus int
+	}{
+		{name: "default tier", expectedStatus: http.StatusOK},
+		{name: "header tier", header: "interactive", expectedStatus: http.StatusOK},
+		{name: "unknown header tier", header: "urgent", expectedStatus: http.StatusBadRequest},
+		{name: "API key tier takes precedence", apiKey: "batch-key", header: "urgent", expectedStatus: http.StatusOK},
+		{name: "no tiers", cfg: &config.Config{}, header: "urgent", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				if tt.apiKey != "" {
+					c.Set(apiKeyContextKey, tt.apiKey)
+				}
+			})
+			r.Use(priorityMiddleware(func() *config.Config { return cmp.Or(tt.cfg, cfg) }))
+			r.GET("/v1/models", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			if tt.header != "" {
+				req.Header.Set(priorityHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusBadRequest {
+				assert.Contains(t, w.Body.String(), `Unknown priority tier \"urgent\"`)
+			}
+		})
+	}
+}
+
+func TestPriorityMiddleware_Reload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	active := &config.Config{}
+	r := gin.New()
+	r.Use(priorityMiddleware(func() *config.Config { return active }))
+	r.GET("/v1/models", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+		req.Header.Set(priorityHeader, "urgent")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, serve())
+	// The tiers of a reloaded config apply to the next requests.
+	active = &config.Config{Server: config.ServerConfig{MaxConcurrency: 1, Priority: config.PriorityConfig{Tiers: []string{"interactive"}}}}
+	assert.Equal(t, http.StatusBadRequest, serve())
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+var rateLimitedTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "llm_gateway_rate_limited_total",
+		Help: "Total number of requests rejected by the rate limiter",
+	},
+	[]string{"scope"},
+)
+
+func init() {
+	prometheus.MustRegister(rateLimitedTotal)
+}
+
+// Rate limit scopes, used as metric labels.
+const (
+	rateLimitScopeGlobal    = "global"
+	rateLimitScopePerAPIKey = "api_key"
+)
+
+// RateLimiter decides whether a request may proceed.
+// Implementations must be safe for concurrent use.
+type RateLimiter interface {
+	// Allow consumes a token of the bucket identified by key. When the bucket is empty
+	// it returns false and how long to wait before the next token is available.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// memoryRateLimiter keeps one in-memory token bucket per key.
+type memoryRateLimiter struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+}
+
+// newMemoryRateLimiter creates an in-memory RateLimiter applying cfg to every key.
+func newMemoryRateLimiter(cfg config.RateLimit) *memoryRateLimiter {
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = max(int(math.Ceil(cfg.RequestsPerSecond)), 1)
+	}
+	return &memoryRateLimiter{
+		limit:    rate.Limit(cfg.RequestsPerSecond),
+		burst:    burst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+func (l *memoryRateLimiter) Allow(_ context.Context, key string) (bool, time.Duration, error) {
+	l.mu.Lock()
+	limiter, ok := l.limiters[key]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[key] = limiter
+	}
+	l.mu.Unlock()
+
+	now := time.Now()
+	reservation := limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay, nil
+	}
+	return true, 0, nil
+}
+
+// rateLimitMiddleware rejects requests over the global limit or over the limit
+// of their API key with a 429 and a Retry-After header. It must run after
+// authMiddleware, since the per-key limit uses the authenticated API key.
+func rateLimitMiddleware(global, perAPIKey RateLimiter) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		if global != nil && !allowRequest(c, global, rateLimitScopeGlobal, rateLimitScopeGlobal) {
+			return
+		}
+		if perAPIKey != nil {
+			key := c.GetString(apiKeyContextKey)
+			if key == "" {
+				return
+			}
+			// Limiter backends only ever see a digest of the key.
+			hash := sha256.Sum256([]byte(key))
+			if !allowRequest(c, perAPIKey, rateLimitScopePerAPIKey, hex.EncodeToString(hash[:])) {
+				return
+			}
+		}
+	}
+}
+
+// allowRequest checks the limiter and aborts the request when it is over the limit.
+func allowRequest(c *gin.Context, limiter RateLimiter, scope, key string) bool {
+	ok, retryAfter, err := limiter.Allow(c.Request.Context(), key)
+	if err != nil {
+		// A broken limiter backend shouldn't take the gateway down with it.
+		slog.WarnContext(c, "Rate limiter failed, letting the request through", "scope", scope, "error", err)
+		return true
+	}
+	if ok {
+		return true
+	}
+
+	rateLimitedTotal.WithLabelValues(scope).Inc()
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	HandleError(c, errors.ErrRateLimited)
+	c.Abort()
+	return false
+}
+
+// newRateLimiters creates the in-memory limiters of the enabled limits.
+func newRateLimiters(cfg config.RateLimitConfig) (global, perAPIKey RateLimiter) {
+	if cfg.Global.RequestsPerSecond > 0 {
+		global = newMemoryRateLimiter(cfg.Global)
+	}
+	if cfg.PerAPIKey.RequestsPerSecond > 0 {
+		perAPIKey = newMemoryRateLimiter(cfg.PerAPIKey)
+	}
+	return global, perAPIKey
+}
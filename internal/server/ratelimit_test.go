@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name             string
+		cfg              config.RateLimitConfig
+		apiKeys          []string
+		headers          []string
+		expectedStatuses []int
+	}{
+		{
+			name:             "disabled",
+			headers:          []string{"", "", ""},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusOK},
+		},
+		{
+			name:             "global limit",
+			cfg:              config.RateLimitConfig{Global: config.RateLimit{RequestsPerSecond: 0.01, Burst: 2}},
+			headers:          []string{"", "", ""},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+		{
+			name:             "per api key limit",
+			cfg:              config.RateLimitConfig{PerAPIKey: config.RateLimit{RequestsPerSecond: 0.01, Burst: 1}},
+			apiKeys:          []string{"key-1", "key-2"},
+			headers:          []string{"Bearer key-1", "Bearer key-2", "Bearer key-1"},
+			expectedStatuses: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			auth := authMiddleware(tt.apiKeys)
+			limit := rateLimitMiddleware(newRateLimiters(tt.cfg))
+			r.GET("/v1/models", func(c *gin.Context) {
+				for _, middleware := range []func(*gin.Context){auth, limit} {
+					middleware(c)
+					if c.IsAborted() {
+						return
+					}
+				}
+				c.Status(http.StatusOK)
+			})
+
+			for i, header := range tt.headers {
+				req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+				if header != "" {
+					req.Header.Set("Authorization", header)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+
+				assert.Equal(t, tt.expectedStatuses[i], w.Code, "request %d", i)
+				if w.Code == http.StatusTooManyRequests {
+					assert.Equal(t, "100", w.Header().Get("Retry-After"))
+				}
+			}
+		})
+	}
+}
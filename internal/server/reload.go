@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+)
+
+// Reloader replaces the proxy of the gateway with one created from a freshly
+// loaded config, on SIGHUP or POST /admin/reload. Providers, models, aliases and
+// fallbacks are reloaded; the server settings take a restart.
+type Reloader struct {
+	load     func() (*config.Config, error)
+	handler  *ProxyHandler
+	checkers []providerChecker
+
+	// mu serializes the reloads.
+	mu  sync.Mutex
+	cfg atomic.Pointer[config.Config]
+}
+
+// providerChecker checks the enabled providers, such as the health poller.
+type providerChecker interface {
+	setProviders(providers []*config.ProviderConfig)
+}
+
+// newReloader creates a Reloader of the proxy served by handler, currently
+// configured with cfg. Configs are loaded with load. The checkers are switched
+// over to the enabled providers of every reloaded config.
+func newReloader(cfg *config.Config, handler *ProxyHandler, load func() (*config.Config, error), checkers ...providerChecker) *Reloader {
+	r := &Reloader{load: load, handler: handler, checkers: checkers}
+	r.cfg.Store(cfg)
+	return r
+}
+
+// config returns the active config.
+func (r *Reloader) config() *config.Config {
+	return r.cfg.Load()
+}
+
+// ReloadResult is the outcome of a reload. The config is left as it was when
+// Errors isn't empty.
+type ReloadResult struct {
+	Reloaded  bool       `json:"reloaded"`
+	Errors    []string   `json:"errors,omitempty"`
+	Providers ConfigDiff `json:"providers"`
+	Models    ConfigDiff `json:"models"`
+}
+
+// ConfigDiff lists the IDs added and removed by a reload.
+type ConfigDiff struct {
+	Added   []string `json:"added"`
+	Removed []string `json:"removed"`
+}
+
+// Reload loads the config and switches the proxy over to it. The new proxy
+// keeps the state of the previous one, see proxy.Proxy.CarryOver. Requests in
+// flight finish with the proxy they started with, which is closed once they
+// are done. When the config can't be loaded or the proxy created, the active
+// config stays in place. The providers of the new config are initialized within ctx.
+func (r *Reloader) Reload(ctx context.Context) ReloadResult {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg, err := r.load()
+	if err != nil {
+		return ReloadResult{Errors: strings.Split(err.Error(), "\n")}
+	}
+	llmProxy, err := proxy.NewProxy(ctx, cfg)
+	if err != nil {
+		return ReloadResult{Errors: strings.Split(fmt.Sprintf("failed to create proxy: %v", err), "\n")}
+	}
+
+	llmProxy.CarryOver(r.handler.currentProxy())
+	old := r.cfg.Swap(cfg)
+	go r.handler.setProxy(llmProxy)()
+	for _, checker := range r.checkers {
+		checker.setProviders(cfg.EnabledProviders())
+	}
+	return ReloadResult{
+		Reloaded: true,
+		Providers: diffIDs(
+			ids(old.Providers, func(p *config.ProviderConfig) string { return p.ID }),
+			ids(cfg.Providers, func(p *config.ProviderConfig) string { return p.ID }),
+		),
+		Models: diffIDs(
+			ids(old.Models, func(m *config.ModelConfig) string { return m.ID }),
+			ids(cfg.Models, func(m *config.ModelConfig) string { return m.ID }),
+		),
+	}
+}
+
+func ids[T any](items []T, id func(T) string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		result = append(result, id(item))
+	}
+	return result
+}
+
+func diffIDs(before, after []string) ConfigDiff {
+	diff := ConfigDiff{Added: []string{}, Removed: []string{}}
+	for _, id := range after {
+		if !slices.Contains(before, id) {
+			diff.Added = append(diff.Added, id)
+		}
+	}
+	for _, id := range before {
+		if !slices.Contains(after, id) {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	return diff
+}
+
+// reloadOnSignal reloads the config on every SIGHUP until ctx is done.
+func (r *Reloader) reloadOnSignal(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+			r.logResult(ctx, r.Reload(ctx))
+		}
+	}
+}
+
+func (r *Reloader) logResult(ctx context.Context, result ReloadResult) {
+	if !result.Reloaded {
+		slog.ErrorContext(ctx, "Config reload failed, keeping the active config", "errors", result.Errors)
+		return
+	}
+	slog.InfoContext(ctx, "Config reloaded",
+		"providers_added", result.Providers.Added,
+		"providers_removed", result.Providers.Removed,
+		"models_added", result.Models.Added,
+		"models_removed", result.Models.Removed,
+	)
+}
+
+// reloadHandler reloads the config and answers with the result, with a 400
+// when the new config is rejected.
+func reloadHandler(r *Reloader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result := r.Reload(c.Request.Context())
+		r.logResult(c, result)
+		if !result.Reloaded {
+			c.JSON(http.StatusBadRequest, result)
+			return
+		}
+		c.JSON(http.StatusOK, result)
+	}
+}
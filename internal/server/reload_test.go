@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dummyConfig(providerIDs []string, modelIDs ...string) *config.Config {
+	cfg := &config.Config{Server: config.ServerConfig{APIKeys: []string{"admin-key"}}}
+	for _, id := range providerIDs {
+		cfg.Providers = append(cfg.Providers, &config.ProviderConfig{ID: id, Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}})
+	}
+	for _, id := range modelIDs {
+		cfg.Models = append(cfg.Models, &config.ModelConfig{ID: id, Name: id, Provider: providerIDs[0]})
+	}
+	return cfg
+}
+
+func TestReloadHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	initial := dummyConfig([]string{"dummy1", "dummy2"}, "model-a", "model-b")
+	llmProxy, err := proxy.NewProxy(context.Background(), initial)
+	require.NoError(t, err)
+	handler := NewProxyHandler(llmProxy, initial.Server)
+
+	var next *config.Config
+	var loadErr error
+	healthPoller := newHealthPoller(config.HealthCheckConfig{}, initial.EnabledProviders())
+	reloader := newReloader(initial, handler, func() (*config.Config, error) { return next, loadErr }, healthPoller)
+
+	r := gin.New()
+	admin := r.Group("/admin", authMiddleware(initial.Server.APIKeys))
+	admin.POST("/reload", reloadHandler(reloader))
+	reload := func(apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		if apiKey != "" {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("requires an API key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, reload("").Code)
+	})
+
+	t.Run("rejected config", func(t *testing.T) {
+		loadErr = errors.New("router config validation error: model \"model-c\" references unknown provider \"missing\"\nalias \"x\" references unknown model \"y\"")
+		defer func() { loadErr = nil }()
+
+		w := reload("admin-key")
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		var result ReloadResult
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+		assert.False(t, result.Reloaded)
+		assert.Len(t, result.Errors, 2)
+		assert.Same(t, initial, reloader.config())
+		assert.Same(t, llmProxy, handler.currentProxy())
+	})
+
+	t.Run("reloaded config", func(t *testing.T) {
+		next = dummyConfig([]string{"dummy1", "dummy3"}, "model-a", "model-c")
+
+		w := reload("admin-key")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{
+			"reloaded": true,
+			"providers": {"added": ["dummy3"], "removed": ["dummy2"]},
+			"models": {"added": ["model-c"], "removed": ["model-b"]}
+		}`, w.Body.String())
+		assert.Same(t, next, reloader.config())
+		assert.NotSame(t, llmProxy, handler.currentProxy())
+		assert.Equal(t, "model-c", handler.currentProxy().ListModelsHandler().Data[1].Id)
+
+		// The checkers follow the reloaded providers.
+		status := healthPoller.status()
+		require.Len(t, status.Providers, 2)
+		assert.Equal(t, "dummy1", status.Providers[0].ID)
+		assert.Equal(t, "dummy3", status.Providers[1].ID)
+	})
+}
+
+func TestProxyHandlerSetProxy(t *testing.T) {
+	initial := dummyConfig([]string{"dummy1"}, "model-a")
+	llmProxy, err := proxy.NewProxy(context.Background(), initial)
+	require.NoError(t, err)
+	handler := NewProxyHandler(llmProxy, initial.Server)
+
+	used, done := handler.useProxy()
+	assert.Same(t, llmProxy, used)
+
+	next, err := proxy.NewProxy(context.Background(), initial)
+	require.NoError(t, err)
+	retired := make(chan struct{})
+	go func() {
+		handler.setProxy(next)()
+		close(retired)
+	}()
+	require.Eventually(t, func() bool { return handler.currentProxy() == next }, time.Second, time.Millisecond)
+
+	// The previous proxy is only closed once its requests are done.
+	select {
+	case <-retired:
+		t.Fatal("the previous proxy was retired with a request in flight")
+	case <-time.After(20 * time.Millisecond):
+	}
+	done()
+	select {
+	case <-retired:
+	case <-time.After(time.Second):
+		t.Fatal("the previous proxy wasn't retired")
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// requestIDContextKey is the gin context key holding the request ID.
+const requestIDContextKey = "request_id"
+
+// maxRequestIDLength bounds client-provided request IDs, longer ones are replaced.
+const maxRequestIDLength = 128
+
+// requestIDMiddleware reads the X-Request-ID header, generating an ID when it is absent,
+// and stores it in the gin context and the request context. The ID is echoed back
+// in the response header.
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if id == "" || len(id) > maxRequestIDLength {
+			id = requestid.New()
+		}
+
+		c.Set(requestIDContextKey, id)
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+	}
+}
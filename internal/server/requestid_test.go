@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name   string
+		header string
+	}{
+		{name: "propagates the client request ID", header: "client-request-id"},
+		{name: "generates a request ID when absent", header: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromGin, fromContext string
+			r := gin.New()
+			r.Use(requestIDMiddleware())
+			r.GET("/v1/models", func(c *gin.Context) {
+				fromGin = c.GetString(requestIDContextKey)
+				fromContext = requestid.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			id := w.Header().Get(requestid.Header)
+			assert.NotEmpty(t, id)
+			if tt.header != "" {
+				assert.Equal(t, tt.header, id)
+			}
+			assert.Equal(t, id, fromGin)
+			assert.Equal(t, id, fromContext)
+		})
+	}
+}
@@ -2,14 +2,20 @@ package server
 
 import (
 	"bytes"
+	"context"
 	"fmt"
+	"hash/fnv"
 	"html/template"
 	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/dmitrii/llm-gateway/api"
 	"github.com/dmitrii/llm-gateway/internal/config"
 	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -21,32 +27,84 @@ var (
 			Name: "http_requests_total",
 			Help: "Total number of HTTP requests",
 		},
-		[]string{"method", "path"},
+		[]string{"method", "path", "status"},
+	)
+	inFlightRequests = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "llm_gateway_inflight_requests",
+			Help: "Number of chat completion requests being served",
+		},
 	)
 )
 
 func init() {
 	prometheus.MustRegister(httpRequestsTotal)
+	prometheus.MustRegister(inFlightRequests)
 }
 
-func New(cfg *config.Config, logger *slog.Logger) (*gin.Engine, error) {
+// unloggedPaths are the probe and scrape endpoints left out of the request logs and traces.
+var unloggedPaths = []string{"/metrics", "/metrics.json", "/healthz", "/readyz", "/status"}
+
+// New creates the gateway router. Background work, such as the provider health
+// checks and the config reloads on SIGHUP, runs until ctx is done.
+func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*gin.Engine, error) {
 	r := gin.New()
+	// Let handlers pass the gin context down as a context.Context carrying
+	// the request context values and cancellation.
+	r.ContextWithFallback = true
 
 	r.Use(gin.Recovery())
-	r.Use(loggingMiddleware(logger, []string{"/metrics"}))
+	r.Use(requestIDMiddleware())
+	if cfg.Server.ExposeErrorDetails {
+		r.Use(errorDetailsMiddleware())
+	}
+	if len(cfg.Server.ForwardHeaders) > 0 {
+		r.Use(forwardHeadersMiddleware(cfg.Server.ForwardHeaders))
+	}
+	r.Use(tracingMiddleware(unloggedPaths))
+	r.Use(loggingMiddleware(logger, unloggedPaths, cfg.Logging.SampleRate))
 	r.Use(metricsMiddleware())
+	r.Use(compressionMiddleware(cfg.Server.Compression))
+	r.Use(corsMiddleware(cfg.Server.CORS, "/v1/"))
+	r.Use(requestTimeoutMiddleware(cfg.Server.RequestTimeout, cfg.Server.StreamRequestTimeout, "/v1/"))
+
+	// Health probes
+	readiness := newReadinessChecker(cfg.Server.Readiness, cfg.EnabledProviders())
+	r.GET("/healthz", healthzHandler)
+	r.GET("/readyz", readiness.readyzHandler)
 
 	// Initialize proxy
-	llmProxy, err := proxy.NewProxy(cfg)
+	llmProxy, err := proxy.NewProxy(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create proxy: %w", err)
 	}
 
-	handler := NewProxyHandler(llmProxy)
+	healthPoller := newHealthPoller(cfg.Server.HealthCheck, cfg.EnabledProviders())
+	go healthPoller.run(ctx)
+	go newSummaryLogger(cfg.Metrics, prometheus.DefaultGatherer, logger).run(ctx)
+	r.GET("/status", healthPoller.statusHandler)
+
+	handler := NewProxyHandler(llmProxy, cfg.Server)
+	reloader := newReloader(cfg, handler, config.Load, readiness, healthPoller)
+	go reloader.reloadOnSignal(ctx)
+
+	globalLimiter, perAPIKeyLimiter := newRateLimiters(cfg.Server.RateLimit)
+	middlewares := []api.MiddlewareFunc{
+		authMiddleware(cfg.Server.APIKeys),
+		rateLimitMiddleware(globalLimiter, perAPIKeyLimiter),
+		jsonContentTypeMiddleware(),
+		bodyLimitMiddleware(cfg.Server.MaxRequestBytes),
+		priorityMiddleware(reloader.config),
+	}
 	api.RegisterHandlersWithOptions(r, handler, api.GinServerOptions{
-		BaseURL: "/v1",
+		BaseURL:     "/v1",
+		Middlewares: middlewares,
 	})
 
+	admin := r.Group("/admin", authMiddleware(cfg.Server.APIKeys))
+	admin.GET("/config", configHandler(reloader.config))
+	admin.POST("/reload", reloadHandler(reloader))
+
 	// Read and process OpenAPI spec
 	openAPITemplate, err := template.ParseFiles(cfg.OpenAPI.SpecPath)
 	if err != nil {
@@ -66,11 +124,15 @@ func New(cfg *config.Config, logger *slog.Logger) (*gin.Engine, error) {
 
 	// Metrics handler
 	r.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	r.GET("/metrics.json", metricsSnapshotHandler(prometheus.DefaultGatherer))
 
 	return r, nil
 }
 
-func loggingMiddleware(logger *slog.Logger, ignorePaths []string) gin.HandlerFunc {
+// loggingMiddleware logs every request outside ignorePaths, with the model,
+// provider and token usage of the proxied ones. Successful requests are sampled
+// at sampleRate, keyed by the request ID; other responses are always logged.
+func loggingMiddleware(logger *slog.Logger, ignorePaths []string, sampleRate float64) gin.HandlerFunc {
 	ignorePathsMap := make(map[string]struct{})
 	for _, path := range ignorePaths {
 		ignorePathsMap[path] = struct{}{}
@@ -80,21 +142,71 @@ func loggingMiddleware(logger *slog.Logger, ignorePaths []string) gin.HandlerFun
 
 		c.Next()
 
-		if _, ok := ignorePathsMap[c.Request.URL.Path]; !ok {
-			logger.Info("request",
-				"method", c.Request.Method,
-				"path", c.Request.URL.Path,
-				"status", c.Writer.Status(),
-				"time", fmt.Sprintf("%vms", time.Since(start).Milliseconds()),
-				"ip", c.ClientIP(),
-			)
+		if _, ok := ignorePathsMap[c.Request.URL.Path]; ok {
+			return
+		}
+		status := c.Writer.Status()
+		if status >= http.StatusOK && status < http.StatusMultipleChoices &&
+			!sampled(requestid.FromContext(c.Request.Context()), sampleRate) {
+			return
+		}
+		attrs := []any{
+			"method", c.Request.Method,
+			"path", c.Request.URL.Path,
+			"status", status,
+			"time", fmt.Sprintf("%vms", time.Since(start).Milliseconds()),
+			"ip", c.ClientIP(),
+		}
+		if v, ok := c.Get(outcomeKey); ok {
+			attrs = append(attrs, outcomeAttrs(v.(*proxy.Outcome))...)
 		}
+		logger.InfoContext(c.Request.Context(), "request", attrs...)
 	}
 }
 
+// outcomeAttrs are the log attributes of the outcome of a proxied request. The
+// served model, provider and usage are only logged once known.
+func outcomeAttrs(outcome *proxy.Outcome) []any {
+	attrs := []any{"model", outcome.Model}
+	if outcome.Provider == "" {
+		return attrs
+	}
+	return append(attrs,
+		"served_model", outcome.ServedModel,
+		"served_provider", outcome.Provider,
+		"fallback", outcome.Fallback,
+		"prompt_tokens", outcome.PromptTokens,
+		"completion_tokens", outcome.CompletionTokens,
+	)
+}
+
+// sampled reports whether the request with the given ID falls within the sample
+// rate. The decision is derived from a hash of the ID, so it is the same for every
+// log and trace of the request.
+func sampled(id string, rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New64a()
+	h.Write([]byte(id))
+	return float64(h.Sum64()) < rate*math.MaxUint64
+}
+
+// unknownRoute is the path label of requests that didn't match a route.
+const unknownRoute = "unknown"
+
+// metricsMiddleware counts the requests by route template rather than raw path,
+// so that the cardinality of the metric stays bounded.
 func metricsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Next()
-		httpRequestsTotal.WithLabelValues(c.Request.Method, c.Request.URL.Path).Inc()
+		path := c.FullPath()
+		if path == "" {
+			path = unknownRoute
+		}
+		httpRequestsTotal.WithLabelValues(c.Request.Method, path, strconv.Itoa(c.Writer.Status())).Inc()
 	}
 }
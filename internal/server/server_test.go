@@ -0,0 +1,143 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoggingMiddleware_Sampling(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.Use(requestIDMiddleware())
+	r.Use(loggingMiddleware(slog.New(slog.NewTextHandler(&buf, nil)), []string{"/healthz"}, 0.5))
+	r.GET("/v1/ok", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/v1/fail", func(c *gin.Context) { c.Status(http.StatusBadGateway) })
+	r.GET("/healthz", func(c *gin.Context) { c.Status(http.StatusInternalServerError) })
+
+	get := func(path, id string) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set(requestid.Header, id)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	const n = 200
+	logged := 0
+	for i := range n {
+		id := fmt.Sprintf("req-%d", i)
+		buf.Reset()
+		get("/v1/ok", id)
+		wasLogged := buf.Len() > 0
+		assert.Equal(t, sampled(id, 0.5), wasLogged)
+		if wasLogged {
+			logged++
+		}
+
+		// The decision is the same for every request with the ID.
+		buf.Reset()
+		get("/v1/ok", id)
+		assert.Equal(t, wasLogged, buf.Len() > 0)
+	}
+	assert.InDelta(t, n/2, logged, n/5)
+
+	buf.Reset()
+	for i := range 10 {
+		get("/v1/fail", fmt.Sprintf("req-%d", i))
+	}
+	assert.Equal(t, 10, strings.Count(buf.String(), "status=502"))
+
+	buf.Reset()
+	get("/healthz", "req-1")
+	assert.Empty(t, buf.String())
+}
+
+func TestSampled(t *testing.T) {
+	assert.True(t, sampled("req-1", 1))
+	assert.False(t, sampled("req-1", 0))
+	assert.Equal(t, sampled("req-1", 0.3), sampled("req-1", 0.3))
+}
+
+func TestMetricsMiddleware_RouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(metricsMiddleware())
+	r.GET("/v1/models/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	found := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/v1/models/:id", "200"))
+	unknown := testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, unknownRoute, "404"))
+
+	for _, path := range []string{"/v1/models/gpt-4o", "/v1/models/claude", "/v1/typo", "/v1/typo2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, found+2, testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, "/v1/models/:id", "200")))
+	assert.Equal(t, unknown+2, testutil.ToFloat64(httpRequestsTotal.WithLabelValues(http.MethodGet, unknownRoute, "404")))
+}
+
+func TestLoggingMiddleware_Outcome(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := &config.Config{
+		Providers: []*config.ProviderConfig{{ID: "dummy", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}}},
+		Models: []*config.ModelConfig{
+			{ID: "primary", Name: "primary", Provider: "gone", Fallback: []string{"backup"}},
+			{ID: "backup", Name: "backup", Provider: "dummy"},
+		},
+	}
+	llmProxy, err := proxy.NewProxy(context.Background(), cfg)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(loggingMiddleware(slog.New(slog.NewJSONHandler(&buf, nil)), nil, 1))
+	api.RegisterHandlersWithOptions(r, NewProxyHandler(llmProxy, cfg.Server), api.GinServerOptions{BaseURL: "/v1"})
+
+	post := func(model string) map[string]any {
+		buf.Reset()
+		body := `{"model": "` + model + `", "messages": [{"role": "user", "content": "Hello"}]}`
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body)))
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		return line
+	}
+
+	t.Run("served by a fallback", func(t *testing.T) {
+		line := post("primary")
+		assert.Equal(t, "primary", line["model"])
+		assert.Equal(t, "backup", line["served_model"])
+		assert.Equal(t, "dummy", line["served_provider"])
+		assert.Equal(t, true, line["fallback"])
+		assert.Positive(t, line["prompt_tokens"])
+		assert.Positive(t, line["completion_tokens"])
+	})
+
+	t.Run("served by the requested model", func(t *testing.T) {
+		line := post("backup")
+		assert.Equal(t, "backup", line["served_model"])
+		assert.Equal(t, false, line["fallback"])
+	})
+
+	t.Run("not served", func(t *testing.T) {
+		line := post("unknown")
+		assert.Equal(t, "unknown", line["model"])
+		assert.NotContains(t, line, "served_provider")
+	})
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// metricsSnapshot is the JSON snapshot of the gateway metrics served by
+// /metrics.json, for clients that don't parse the Prometheus text format.
+type metricsSnapshot struct {
+	// Totals are the current values of the summary counters, e.g. requests
+	// and prompt_tokens, and the number of requests in flight.
+	Totals map[string]float64 `json:"totals"`
+	// Metrics are the gateway counters and gauges by name. Histograms are
+	// only served by /metrics.
+	Metrics map[string]snapshotMetric `json:"metrics"`
+}
+
+type snapshotMetric struct {
+	Type   string           `json:"type"`
+	Help   string           `json:"help"`
+	Series []snapshotSeries `json:"series"`
+}
+
+type snapshotSeries struct {
+	Labels map[string]string `json:"labels,omitempty"`
+	Value  float64           `json:"value"`
+}
+
+// metricsSnapshotHandler serves the snapshot of the metrics of gatherer.
+func metricsSnapshotHandler(gatherer prometheus.Gatherer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		families, err := gatherer.Gather()
+		if err != nil {
+			slog.ErrorContext(c, "Failed to gather the metrics snapshot", "error", err)
+			HandleError(c, errors.ErrInternal)
+			return
+		}
+		c.JSON(http.StatusOK, newMetricsSnapshot(families))
+	}
+}
+
+func newMetricsSnapshot(families []*dto.MetricFamily) metricsSnapshot {
+	snapshot := metricsSnapshot{
+		Totals:  summaryTotals(families),
+		Metrics: make(map[string]snapshotMetric),
+	}
+	snapshot.Totals["inflight_requests"] = 0
+	for _, family := range families {
+		if !isGatewayMetric(family.GetName()) {
+			continue
+		}
+		var metric snapshotMetric
+		switch family.GetType() {
+		case dto.MetricType_COUNTER:
+			metric.Type = "counter"
+		case dto.MetricType_GAUGE:
+			metric.Type = "gauge"
+		default:
+			continue
+		}
+		metric.Help = family.GetHelp()
+		metric.Series = make([]snapshotSeries, 0, len(family.GetMetric()))
+		for _, m := range family.GetMetric() {
+			value := m.GetCounter().GetValue() + m.GetGauge().GetValue()
+			metric.Series = append(metric.Series, snapshotSeries{Labels: metricLabels(m), Value: value})
+			if family.GetName() == "llm_gateway_inflight_requests" {
+				snapshot.Totals["inflight_requests"] += value
+			}
+		}
+		snapshot.Metrics[family.GetName()] = metric
+	}
+	return snapshot
+}
+
+// isGatewayMetric reports whether the metric is one of the gateway's own,
+// rather than one of the Go runtime or the process.
+func isGatewayMetric(name string) bool {
+	return strings.HasPrefix(name, "llm_gateway_") || name == "http_requests_total"
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsSnapshotHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total", Help: "requests"}, []string{"method", "path", "status"})
+	promptTokens := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "llm_gateway_prompt_tokens_total", Help: "prompt tokens"}, []string{"model", "provider"})
+	inflight := prometheus.NewGauge(prometheus.GaugeOpts{Name: "llm_gateway_inflight_requests", Help: "inflight"})
+	duration := prometheus.NewHistogram(prometheus.HistogramOpts{Name: "llm_gateway_request_duration_seconds"})
+	other := prometheus.NewCounter(prometheus.CounterOpts{Name: "go_other_total"})
+	registry.MustRegister(requests, promptTokens, inflight, duration, other)
+
+	requests.WithLabelValues("POST", "/v1/chat/completions", "200").Add(3)
+	requests.WithLabelValues("POST", "/v1/chat/completions", "502").Inc()
+	promptTokens.WithLabelValues("gpt-4o", "openai").Add(100)
+	inflight.Set(2)
+	duration.Observe(1)
+	other.Inc()
+
+	r := gin.New()
+	r.GET("/metrics.json", metricsSnapshotHandler(registry))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics.json", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var snapshot metricsSnapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &snapshot))
+
+	assert.Equal(t, 4.0, snapshot.Totals["requests"])
+	assert.Equal(t, 1.0, snapshot.Totals["request_errors"])
+	assert.Equal(t, 100.0, snapshot.Totals["prompt_tokens"])
+	assert.Equal(t, 2.0, snapshot.Totals["inflight_requests"])
+
+	assert.Equal(t, snapshotMetric{
+		Type:   "counter",
+		Help:   "prompt tokens",
+		Series: []snapshotSeries{{Labels: map[string]string{"model": "gpt-4o", "provider": "openai"}, Value: 100}},
+	}, snapshot.Metrics["llm_gateway_prompt_tokens_total"])
+	assert.Equal(t, snapshotMetric{
+		Type:   "gauge",
+		Help:   "inflight",
+		Series: []snapshotSeries{{Value: 2}},
+	}, snapshot.Metrics["llm_gateway_inflight_requests"])
+	assert.Len(t, snapshot.Metrics["http_requests_total"].Series, 2)
+	assert.NotContains(t, snapshot.Metrics, "llm_gateway_request_duration_seconds")
+	assert.NotContains(t, snapshot.Metrics, "go_other_total")
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/gin-gonic/gin"
+)
+
+// StartupHandler answers the probes while New initializes the providers, which
+// may take a while, and serves the gateway once it is set. Until then /healthz
+// answers 200, and /readyz and every other route 503.
+type StartupHandler struct {
+	starting http.Handler
+	gateway  atomic.Pointer[http.Handler]
+}
+
+func NewStartupHandler() *StartupHandler {
+	r := gin.New()
+	r.GET("/healthz", healthzHandler)
+	r.GET("/readyz", func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, ReadinessResponse{Ready: false, Providers: []ProviderStatus{}})
+	})
+	r.NoRoute(func(c *gin.Context) {
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(errors.ErrUnavailable.WithMessage("the gateway is starting")))
+	})
+	return &StartupHandler{starting: r}
+}
+
+// SetGateway makes the handler serve the gateway router returned by New.
+func (h *StartupHandler) SetGateway(gateway http.Handler) {
+	h.gateway.Store(&gateway)
+}
+
+func (h *StartupHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if gateway := h.gateway.Load(); gateway != nil {
+		(*gateway).ServeHTTP(w, r)
+		return
+	}
+	h.starting.ServeHTTP(w, r)
+}
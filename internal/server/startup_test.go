@@ -0,0 +1,48 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartupHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewStartupHandler()
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("starting", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serve("/healthz").Code)
+
+		w := serve("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var readiness ReadinessResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &readiness))
+		assert.False(t, readiness.Ready)
+
+		w = serve("/v1/models")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var resp api.ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "the gateway is starting", resp.Error.Message)
+	})
+
+	t.Run("started", func(t *testing.T) {
+		gateway := gin.New()
+		gateway.GET("/readyz", func(c *gin.Context) { c.JSON(http.StatusOK, ReadinessResponse{Ready: true}) })
+		h.SetGateway(gateway)
+
+		assert.Equal(t, http.StatusOK, serve("/readyz").Code)
+		assert.Equal(t, http.StatusNotFound, serve("/v1/models").Code)
+	})
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var providerUp = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "llm_gateway_provider_up",
+		Help: "Whether the last health check of the provider succeeded (1) or failed (0)",
+	},
+	[]string{"provider"},
+)
+
+func init() {
+	prometheus.MustRegister(providerUp)
+}
+
+// ProviderHealth is the result of the last health check of a provider.
+type ProviderHealth struct {
+	ID        string     `json:"id"`
+	Status    string     `json:"status"`
+	LastCheck *time.Time `json:"last_check,omitempty"`
+	LastError string     `json:"last_error,omitempty"`
+}
+
+// StatusResponse is the body returned by the /status endpoint.
+type StatusResponse struct {
+	Providers []ProviderHealth `json:"providers"`
+}
+
+// healthPoller checks the providers in the background and keeps the last result
+// of each. Providers without a base URL are reported as not probed.
+type healthPoller struct {
+	interval time.Duration
+	probe    func(ctx context.Context, url string) error
+
+	mu        sync.RWMutex
+	providers []*config.ProviderConfig
+	health    map[string]ProviderHealth
+}
+
+func newHealthPoller(cfg config.HealthCheckConfig, providers []*config.ProviderConfig) *healthPoller {
+	httpClient := &http.Client{Timeout: probeTimeout}
+	hp := &healthPoller{
+		interval: cfg.Interval,
+		probe: func(ctx context.Context, url string) error {
+			return probeURL(ctx, httpClient, url)
+		},
+	}
+	hp.setProviders(providers)
+	return hp
+}
+
+// setProviders replaces the providers checked, as on config reloads. The last
+// health of the providers kept is preserved; the new ones are not probed yet.
+func (hp *healthPoller) setProviders(providers []*config.ProviderConfig) {
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+
+	health := make(map[string]ProviderHealth, len(providers))
+	for _, pCfg := range providers {
+		if h, ok := hp.health[pCfg.ID]; ok {
+			health[pCfg.ID] = h
+		} else {
+			health[pCfg.ID] = ProviderHealth{ID: pCfg.ID, Status: providerStatusNotProbed}
+		}
+	}
+	for id := range hp.health {
+		if _, ok := health[id]; !ok {
+			providerUp.DeleteLabelValues(id)
+		}
+	}
+	hp.providers = providers
+	hp.health = health
+}
+
+// run checks the providers every interval until ctx is done. It returns at once
+// if polling is disabled.
+func (hp *healthPoller) run(ctx context.Context) {
+	if hp.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(hp.interval)
+	defer ticker.Stop()
+	for {
+		hp.checkAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkAll checks the providers concurrently and records the results.
+func (hp *healthPoller) checkAll(ctx context.Context) {
+	hp.mu.RLock()
+	providers := hp.providers
+	hp.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, pCfg := range providers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hp.check(ctx, pCfg)
+		}()
+	}
+	wg.Wait()
+}
+
+func (hp *healthPoller) check(ctx context.Context, pCfg *config.ProviderConfig) {
+	var err error
+	if pCfg.Provider != config.ProviderDummy {
+		url := providerBaseURL(pCfg)
+		if url == "" {
+			return
+		}
+		err = hp.probe(ctx, url)
+	}
+	if ctx.Err() != nil {
+		// Shutting down, the result says nothing about the provider.
+		return
+	}
+
+	now := time.Now()
+	health := ProviderHealth{ID: pCfg.ID, Status: providerStatusUp, LastCheck: &now}
+	if err != nil {
+		health.Status = providerStatusDown
+		health.LastError = probeErrorMessage(err)
+		slog.WarnContext(ctx, "Provider health check failed", "provider", pCfg.ID, "error", err)
+	}
+
+	hp.mu.Lock()
+	defer hp.mu.Unlock()
+	if _, ok := hp.health[pCfg.ID]; !ok {
+		// Removed by a reload while it was checked.
+		return
+	}
+	hp.health[pCfg.ID] = health
+	if err != nil {
+		providerUp.WithLabelValues(pCfg.ID).Set(0)
+	} else {
+		providerUp.WithLabelValues(pCfg.ID).Set(1)
+	}
+}
+
+// status returns the last health of every provider, in config order.
+func (hp *healthPoller) status() StatusResponse {
+	hp.mu.RLock()
+	defer hp.mu.RUnlock()
+
+	res := StatusResponse{Providers: make([]ProviderHealth, len(hp.providers))}
+	for i, pCfg := range hp.providers {
+		res.Providers[i] = hp.health[pCfg.ID]
+	}
+	return res
+}
+
+func (hp *healthPoller) statusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, hp.status())
+}
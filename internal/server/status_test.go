@@ -0,0 +1,143 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthPoller(t *testing.T) {
+	providers := []*config.ProviderConfig{
+		{ID: "status-up", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIUrl: "http://up"}},
+		{ID: "status-down", Provider: config.ProviderOllama, Config: &config.OllamaProviderConfig{APIUrl: "http://down"}},
+		{ID: "status-gemini", Provider: config.ProviderGemini, Config: &config.GeminiProviderConfig{}},
+		{ID: "status-dummy", Provider: config.ProviderDummy, Config: &config.DummyProviderConfig{}},
+	}
+	hp := newHealthPoller(config.HealthCheckConfig{Interval: time.Hour}, providers)
+	hp.probe = func(_ context.Context, url string) error {
+		if url == "http://down" {
+			return errors.New("dial tcp 10.0.0.7:11434: connection refused")
+		}
+		return nil
+	}
+
+	hp.checkAll(context.Background())
+
+	res := hp.status()
+	require.Len(t, res.Providers, 4)
+	assert.Equal(t, providerStatusUp, res.Providers[0].Status)
+	assert.NotNil(t, res.Providers[0].LastCheck)
+	assert.Equal(t, providerStatusDown, res.Providers[1].Status)
+	assert.Equal(t, "unreachable", res.Providers[1].LastError)
+	assert.Equal(t, ProviderHealth{ID: "status-gemini", Status: providerStatusNotProbed}, res.Providers[2])
+	assert.Equal(t, providerStatusUp, res.Providers[3].Status)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(providerUp.WithLabelValues("status-up")))
+	assert.Equal(t, 0.0, testutil.ToFloat64(providerUp.WithLabelValues("status-down")))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/status", hp.statusHandler)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var body StatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "status-down", body.Providers[1].ID)
+	assert.Equal(t, providerStatusDown, body.Providers[1].Status)
+}
+
+func TestHealthPoller_StopsOnCancel(t *testing.T) {
+	providers := []*config.ProviderConfig{
+		{ID: "poll", Provider: config.ProviderOpenAI, Config: &config.OpenAIProviderConfig{APIUrl: "http://up"}},
+	}
+	hp := newHealthPoller(config.HealthCheckConfig{Interval: time.Millisecond}, providers)
+	var checks atomic.Int32
+	hp.probe = func(context.Context, string) error {
+		checks.Add(1)
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		hp.run(ctx)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return checks.Load() >= 3 }, time.Second, time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("poller did not stop")
+	}
+}
+
+func TestHealthPoller_Disabled(t *testing.T) {
+	hp := newHealthPoller(config.HealthCheckConfig{}, []*config.ProviderConfig{{ID: "idle", Provider: config.ProviderDummy}})
+
+	// run returns at once without checking.
+	hp.run(context.Background())
+
+	assert.Equal(t, providerStatusNotProbed, hp.status().Providers[0].Status)
+}
+
+func TestProbeURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/not-found":
+			w.WriteHeader(http.StatusNotFound)
+		case "/rate-limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "/unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "/ok"},
+		{path: "/unauthorized"},
+		{path: "/not-found"},
+		{path: "/rate-limited", wantErr: "unexpected status 429"},
+		{path: "/unavailable", wantErr: "unexpected status 503"},
+		{path: "/slow", wantErr: "timeout"},
+	}
+	httpClient := &http.Client{Timeout: 50 * time.Millisecond}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			err := probeURL(context.Background(), httpClient, srv.URL+tt.path)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantErr, probeErrorMessage(err))
+		})
+	}
+
+	err := probeURL(context.Background(), httpClient, "http://127.0.0.1:1")
+	require.Error(t, err)
+	assert.Equal(t, "unreachable", probeErrorMessage(err))
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// summaryCounter is a value of the metrics summary: the sum of the series of
+// a Prometheus counter that keep accepts, all of them when keep is nil.
+type summaryCounter struct {
+	key    string
+	metric string
+	keep   func(labels map[string]string) bool
+}
+
+// summaryCounters are the values of the metrics summary, in log order.
+var summaryCounters = []summaryCounter{
+	{key: "requests", metric: "http_requests_total", keep: isAPIRequest},
+	{key: "request_errors", metric: "http_requests_total", keep: func(labels map[string]string) bool {
+		status, _ := strconv.Atoi(labels["status"])
+		return isAPIRequest(labels) && status >= 500
+	}},
+	{key: "prompt_tokens", metric: "llm_gateway_prompt_tokens_total"},
+	{key: "completion_tokens", metric: "llm_gateway_completion_tokens_total"},
+	{key: "provider_errors", metric: "llm_gateway_provider_errors_total"},
+	{key: "fallbacks", metric: "llm_gateway_fallback_total"},
+}
+
+// isAPIRequest reports whether the labels of http_requests_total are the ones
+// of a /v1 request.
+func isAPIRequest(labels map[string]string) bool {
+	return strings.HasPrefix(labels["path"], "/v1/")
+}
+
+// summaryLogger periodically logs the metrics summary, read from the counters
+// of the Prometheus metrics. Every summary covers the increase of the counters
+// since the previous one.
+type summaryLogger struct {
+	interval time.Duration
+	gatherer prometheus.Gatherer
+	logger   *slog.Logger
+	// last are the totals of the previous summary, by key.
+	last map[string]float64
+}
+
+// newSummaryLogger creates the summary logger of cfg, the first summary
+// covering what the counters count from now on.
+func newSummaryLogger(cfg config.MetricsConfig, gatherer prometheus.Gatherer, logger *slog.Logger) *summaryLogger {
+	s := &summaryLogger{
+		interval: cfg.SummaryInterval,
+		gatherer: gatherer,
+		logger:   logger,
+	}
+	if s.interval > 0 {
+		s.last, _ = s.totals()
+	}
+	return s
+}
+
+// run logs the summary every interval until ctx is done, and a last one then.
+// It returns at once if the summary is disabled.
+func (s *summaryLogger) run(ctx context.Context) {
+	if s.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			s.flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			s.flush(ctx)
+		}
+	}
+}
+
+// flush logs the increase of the counters since the previous summary.
+func (s *summaryLogger) flush(ctx context.Context) {
+	totals, err := s.totals()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to gather the metrics summary", "error", err)
+		return
+	}
+	attrs := []any{"interval", s.interval.String()}
+	for _, c := range summaryCounters {
+		attrs = append(attrs, c.key, totals[c.key]-s.last[c.key])
+	}
+	s.last = totals
+	s.logger.InfoContext(ctx, "metrics summary", attrs...)
+}
+
+// totals returns the current values of the summary counters, by key.
+func (s *summaryLogger) totals() (map[string]float64, error) {
+	families, err := s.gatherer.Gather()
+	if err != nil {
+		return nil, err
+	}
+	return summaryTotals(families), nil
+}
+
+// summaryTotals returns the values of the summary counters in families, by key.
+func summaryTotals(families []*dto.MetricFamily) map[string]float64 {
+	byName := make(map[string]*dto.MetricFamily, len(families))
+	for _, family := range families {
+		byName[family.GetName()] = family
+	}
+
+	totals := make(map[string]float64, len(summaryCounters))
+	for _, c := range summaryCounters {
+		family, ok := byName[c.metric]
+		if !ok {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			if c.keep != nil && !c.keep(metricLabels(m)) {
+				continue
+			}
+			totals[c.key] += m.GetCounter().GetValue()
+		}
+	}
+	return totals
+}
+
+func metricLabels(m *dto.Metric) map[string]string {
+	labels := make(map[string]string, len(m.GetLabel()))
+	for _, pair := range m.GetLabel() {
+		labels[pair.GetName()] = pair.GetValue()
+	}
+	return labels
+}
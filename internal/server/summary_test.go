@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryLogger(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total"}, []string{"method", "path", "status"})
+	promptTokens := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "llm_gateway_prompt_tokens_total"}, []string{"model", "provider"})
+	fallbacks := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "llm_gateway_fallback_total"}, []string{"requested_model", "served_model", "reason"})
+	registry.MustRegister(requests, promptTokens, fallbacks)
+
+	var out bytes.Buffer
+	s := newSummaryLogger(config.MetricsConfig{SummaryInterval: time.Minute}, registry, slog.New(slog.NewJSONHandler(&out, nil)))
+	lastSummary := func() map[string]any {
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		var summary map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &summary))
+		return summary
+	}
+
+	requests.WithLabelValues("POST", "/v1/chat/completions", "200").Add(3)
+	requests.WithLabelValues("POST", "/v1/chat/completions", "502").Inc()
+	requests.WithLabelValues("GET", "/healthz", "200").Add(10)
+	promptTokens.WithLabelValues("a", "p1").Add(100)
+	promptTokens.WithLabelValues("b", "p2").Add(50)
+	s.flush(context.Background())
+
+	summary := lastSummary()
+	assert.Equal(t, "metrics summary", summary["msg"])
+	assert.Equal(t, "1m0s", summary["interval"])
+	assert.Equal(t, 4.0, summary["requests"])
+	assert.Equal(t, 1.0, summary["request_errors"])
+	assert.Equal(t, 150.0, summary["prompt_tokens"])
+	assert.Equal(t, 0.0, summary["fallbacks"])
+
+	// The next summary only covers what happened since.
+	requests.WithLabelValues("POST", "/v1/chat/completions", "200").Inc()
+	fallbacks.WithLabelValues("a", "b", "server_error").Inc()
+	s.flush(context.Background())
+
+	summary = lastSummary()
+	assert.Equal(t, 1.0, summary["requests"])
+	assert.Equal(t, 0.0, summary["request_errors"])
+	assert.Equal(t, 0.0, summary["prompt_tokens"])
+	assert.Equal(t, 1.0, summary["fallbacks"])
+}
+
+func TestSummaryLogger_Run(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "http_requests_total"}, []string{"method", "path", "status"})
+	registry.MustRegister(requests)
+	// Counted before the summaries started, so in none of them.
+	requests.WithLabelValues("POST", "/v1/chat/completions", "200").Add(5)
+
+	var out bytes.Buffer
+	s := newSummaryLogger(config.MetricsConfig{SummaryInterval: time.Hour}, registry, slog.New(slog.NewJSONHandler(&out, nil)))
+	requests.WithLabelValues("POST", "/v1/chat/completions", "200").Inc()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.run(ctx)
+
+	// Shutting down logs what the last interval counted.
+	var summary map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &summary))
+	assert.Equal(t, 1.0, summary["requests"])
+
+	t.Run("disabled", func(t *testing.T) {
+		newSummaryLogger(config.MetricsConfig{}, registry, slog.Default()).run(context.Background())
+	})
+}
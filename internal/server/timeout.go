@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// errRequestTimeout is the cancellation cause of requests that ran out of the
+// request timeout.
+var errRequestTimeout = stderrors.New("request timed out")
+
+type requestDeadlineKey struct{}
+
+// requestDeadline cancels a request once its timeout has passed.
+type requestDeadline struct {
+	cancel        context.CancelCauseFunc
+	timer         *time.Timer
+	streamTimeout time.Duration
+}
+
+// reset restarts the deadline with timeout, counted from now. A timeout of zero
+// lifts it.
+func (d *requestDeadline) reset(timeout time.Duration) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if timeout > 0 {
+		d.timer = time.AfterFunc(timeout, func() { d.cancel(errRequestTimeout) })
+	}
+}
+
+// requestTimeoutMiddleware cancels the context of the requests under pathPrefix
+// after timeout, or after streamTimeout once they turn out to be streamed, see
+// streamRequestTimeout. A zero timeout leaves the corresponding requests unbounded.
+// Handlers that give up on the cancelled context without answering get a 504.
+func requestTimeoutMiddleware(timeout, streamTimeout time.Duration, pathPrefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if timeout <= 0 && streamTimeout <= 0 || !strings.HasPrefix(c.Request.URL.Path, pathPrefix) {
+			c.Next()
+			return
+		}
+
+		ctx, cancel := context.WithCancelCause(c.Request.Context())
+		deadline := &requestDeadline{cancel: cancel, streamTimeout: streamTimeout}
+		deadline.reset(timeout)
+		defer func() {
+			deadline.reset(0)
+			cancel(context.Canceled)
+		}()
+		c.Request = c.Request.WithContext(context.WithValue(ctx, requestDeadlineKey{}, deadline))
+
+		c.Next()
+
+		if !c.Writer.Written() && timedOut(c) {
+			HandleError(c, errRequestTimeout)
+		}
+	}
+}
+
+// streamRequestTimeout switches the request over to the stream timeout of the
+// request timeout middleware, counted from now.
+func streamRequestTimeout(c *gin.Context) {
+	if deadline, ok := c.Request.Context().Value(requestDeadlineKey{}).(*requestDeadline); ok {
+		deadline.reset(deadline.streamTimeout)
+	}
+}
+
+// timedOut reports whether the request was cancelled by the request timeout.
+func timedOut(c *gin.Context) bool {
+	return context.Cause(c.Request.Context()) == errRequestTimeout
+}
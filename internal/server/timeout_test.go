@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/dmitrii/llm-gateway/internal/proxy"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestTimeoutMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	upstreamCancelled := make(chan struct{}, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Cancellations are only noticed once the body is read.
+		_, _ = io.Copy(io.Discard, r.Body)
+		if strings.Contains(r.URL.Path, "slow") {
+			<-r.Context().Done()
+			upstreamCancelled <- struct{}{}
+			return
+		}
+		// Streams outlive the request timeout.
+		time.Sleep(100 * time.Millisecond)
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{\"role\":\"assistant\",\"content\":\"hi\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"id\":\"1\",\"object\":\"chat.completion.chunk\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer upstream.Close()
+
+	llmProxy, err := proxy.NewProxy(context.Background(), &config.Config{
+		Providers: []*config.ProviderConfig{
+			// The model name ends up in the URL of the Azure OpenAI API.
+			{ID: "azure", Provider: config.ProviderAzureOpenAI, Config: &config.AzureOpenAIProviderConfig{
+				APIKey: "test-key", APIUrl: upstream.URL, ApiVersion: "2024-10-21", ApiType: "AZURE",
+			}},
+		},
+		Models: []*config.ModelConfig{
+			{ID: "slow", Name: "slow", Provider: "azure"},
+			{ID: "stream", Name: "stream", Provider: "azure"},
+		},
+	})
+	require.NoError(t, err)
+
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(requestTimeoutMiddleware(50*time.Millisecond, 0, "/v1/"))
+	handler := NewProxyHandler(llmProxy, config.ServerConfig{})
+	r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+	r.GET("/v1/stuck", func(c *gin.Context) {
+		<-c.Done()
+	})
+
+	t.Run("cancels the upstream call", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"slow","messages":[{"role":"user","content":"hi"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+		assert.JSONEq(t, `{"error":{"message":"request timed out","type":"internal_error","code":null,"param":null}}`, w.Body.String())
+		select {
+		case <-upstreamCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("upstream request was not cancelled")
+		}
+	})
+
+	t.Run("answers for stuck handlers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/stuck", nil))
+
+		assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	})
+
+	t.Run("exempts streams", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions",
+			strings.NewReader(`{"model":"stream","stream":true,"messages":[{"role":"user","content":"hi"}]}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"content":"hi"`)
+		assert.Contains(t, w.Body.String(), "data: [DONE]")
+	})
+}
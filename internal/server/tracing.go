@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/dmitrii/llm-gateway/internal/requestid"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dmitrii/llm-gateway/internal/server"
+
+// tracingMiddleware starts the root span of a request, continuing the trace of
+// the incoming trace context headers if there are any.
+func tracingMiddleware(ignorePaths []string) gin.HandlerFunc {
+	ignorePathsMap := make(map[string]struct{})
+	for _, path := range ignorePaths {
+		ignorePathsMap[path] = struct{}{}
+	}
+	return func(c *gin.Context) {
+		if _, ok := ignorePathsMap[c.Request.URL.Path]; ok {
+			c.Next()
+			return
+		}
+
+		route := c.FullPath()
+		if route == "" {
+			route = c.Request.URL.Path
+		}
+
+		ctx := otel.GetTextMapPropagator().Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+		ctx, span := otel.Tracer(tracerName).Start(ctx, c.Request.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", c.Request.Method),
+				attribute.String("http.route", route),
+				attribute.String("request_id", requestid.FromContext(ctx)),
+			),
+		)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
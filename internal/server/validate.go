@@ -0,0 +1,98 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/errors"
+	"github.com/dmitrii/llm-gateway/internal/provider"
+)
+
+// knownRoles are the chat message roles the providers can convert.
+var knownRoles = map[api.ChatMessageRole]struct{}{
+	api.ChatMessageRoleSystem:    {},
+	api.ChatMessageRoleUser:      {},
+	api.ChatMessageRoleAssistant: {},
+	api.ChatMessageRoleTool:      {},
+	api.ChatMessageRoleFunction:  {},
+}
+
+// validateChatCompletion checks a chat completion request before any provider
+// work. Every invalid field is reported in a single bad request error.
+func validateChatCompletion(req *api.ChatCompletionRequest) error {
+	var problems []string
+	add := func(field, format string, args ...any) {
+		problems = append(problems, field+": "+fmt.Sprintf(format, args...))
+	}
+
+	if len(req.Messages) == 0 {
+		add("messages", "must not be empty")
+	}
+	for i, msg := range req.Messages {
+		field := fmt.Sprintf("messages[%d]", i)
+		if _, ok := knownRoles[msg.Role]; !ok {
+			add(field+".role", "unknown role %q", msg.Role)
+			continue
+		}
+		if isEmptyContent(msg.Content) && !mayOmitContent(msg) {
+			add(field+".content", "must not be empty")
+		}
+	}
+
+	checkRange := func(field string, value *float32, lo, hi float32) {
+		if value != nil && (*value < lo || *value > hi) {
+			add(field, "must be between %g and %g", lo, hi)
+		}
+	}
+	checkRange("temperature", req.Temperature, 0, 2)
+	checkRange("top_p", req.TopP, 0, 1)
+	checkRange("presence_penalty", req.PresencePenalty, -2, 2)
+	checkRange("frequency_penalty", req.FrequencyPenalty, -2, 2)
+	if req.N != nil && *req.N < 1 {
+		add("n", "must be at least 1")
+	}
+	if req.MaxTokens != nil && *req.MaxTokens < 1 {
+		add("max_tokens", "must be at least 1")
+	}
+	if req.TopLogprobs != nil {
+		if *req.TopLogprobs < 0 || *req.TopLogprobs > 20 {
+			add("top_logprobs", "must be between 0 and 20")
+		}
+		if req.Logprobs == nil || !*req.Logprobs {
+			add("top_logprobs", "requires logprobs")
+		}
+	}
+	if _, err := provider.StopSequences(req); err != nil {
+		add("stop", "must be a string or an array of strings")
+	}
+
+	if len(problems) > 0 {
+		return errors.ErrBadRequest.WithMessage("Invalid request: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// mayOmitContent reports whether msg is valid without content: tool and function
+// results, and assistant messages calling tools.
+func mayOmitContent(msg api.ChatMessage) bool {
+	switch msg.Role {
+	case api.ChatMessageRoleTool, api.ChatMessageRoleFunction:
+		return true
+	case api.ChatMessageRoleAssistant:
+		return msg.FunctionCall != nil || (msg.ToolCalls != nil && len(*msg.ToolCalls) > 0)
+	}
+	return false
+}
+
+// isEmptyContent reports whether content holds neither text nor content parts.
+func isEmptyContent(content *api.ChatMessage_Content) bool {
+	if content == nil {
+		return true
+	}
+	if parts, err := content.AsChatMessageContent1(); err == nil {
+		return len(parts) == 0
+	}
+	text, err := content.AsChatMessageContent0()
+	return err == nil && text == ""
+}
@@ -0,0 +1,80 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dmitrii/llm-gateway/api"
+	"github.com/dmitrii/llm-gateway/internal/config"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateChatCompletion_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Without a proxy the handler panics if a request gets past the validation.
+	handler := NewProxyHandler(nil, config.ServerConfig{})
+	r := gin.New()
+	r.POST("/v1/chat/completions", handler.CreateChatCompletion)
+
+	tests := []struct {
+		name            string
+		body            string
+		expectedMessage string
+	}{
+		{
+			name:            "empty messages",
+			body:            `{"model":"gpt-4","messages":[]}`,
+			expectedMessage: "Invalid request: messages: must not be empty",
+		},
+		{
+			name:            "unknown role",
+			body:            `{"model":"gpt-4","messages":[{"role":"user","content":"hi"},{"role":"bot","content":"hello"}]}`,
+			expectedMessage: `Invalid request: messages[1].role: unknown role "bot"`,
+		},
+		{
+			name:            "every problem at once",
+			body:            `{"model":"gpt-4","messages":[{"role":"user","content":""}],"temperature":3,"top_p":-1,"n":0,"max_tokens":0,"presence_penalty":2.5,"stop":5}`,
+			expectedMessage: "Invalid request: messages[0].content: must not be empty; temperature: must be between 0 and 2; top_p: must be between 0 and 1; presence_penalty: must be between -2 and 2; n: must be at least 1; max_tokens: must be at least 1; stop: must be a string or an array of strings",
+		},
+		{
+			name:            "top logprobs",
+			body:            `{"model":"gpt-4","messages":[{"role":"user","content":"hi"}],"top_logprobs":21}`,
+			expectedMessage: "Invalid request: top_logprobs: must be between 0 and 20; top_logprobs: requires logprobs",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var resp api.ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			assert.Equal(t, tt.expectedMessage, resp.Error.Message)
+			assert.Equal(t, "invalid_request_error", resp.Error.Type)
+		})
+	}
+}
+
+func TestValidateChatCompletion_ContentOptional(t *testing.T) {
+	var req api.ChatCompletionRequest
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "user", "content": [{"type": "text", "text": "What's the weather?"}]},
+			{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "weather", "arguments": "{}"}}]},
+			{"role": "tool", "tool_call_id": "call_1"}
+		]
+	}`), &req))
+
+	assert.NoError(t, validateChatCompletion(&req))
+}
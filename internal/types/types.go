@@ -1,47 +0,0 @@
-package types
-
-// ChatCompletionRequest represents the request body for the /v1/chat/completions endpoint.
-type ChatCompletionRequest struct {
-	Model            string         `json:"model"`
-	Messages         []ChatMessage  `json:"messages"`
-	Temperature      float64        `json:"temperature,omitempty"`
-	TopP             float64        `json:"top_p,omitempty"`
-	N                int            `json:"n,omitempty"`
-	Stream           bool           `json:"stream,omitempty"`
-	Stop             []string       `json:"stop,omitempty"`
-	MaxTokens        int            `json:"max_tokens,omitempty"`
-	PresencePenalty  float64        `json:"presence_penalty,omitempty"`
-	FrequencyPenalty float64        `json:"frequency_penalty,omitempty"`
-	LogitBias        map[string]int `json:"logit_bias,omitempty"`
-	User             string         `json:"user,omitempty"`
-}
-
-// ChatMessage represents a message in a chat conversation.
-type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
-}
-
-// ChatCompletionResponse represents the response body for the /v1/chat/completions endpoint.
-type ChatCompletionResponse struct {
-	ID      string                 `json:"id"`
-	Object  string                 `json:"object"`
-	Created int64                  `json:"created"`
-	Model   string                 `json:"model"`
-	Choices []ChatCompletionChoice `json:"choices"`
-	Usage   Usage                  `json:"usage"`
-}
-
-// ChatCompletionChoice represents a choice in a chat completion response.
-type ChatCompletionChoice struct {
-	Index        int         `json:"index"`
-	Message      ChatMessage `json:"message"`
-	FinishReason string      `json:"finish_reason"`
-}
-
-// Usage represents the usage statistics for a chat completion request.
-type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
-}